@@ -0,0 +1,121 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/analyzer"
+)
+
+var (
+	program = flag.Int("program", 0, "program_number to analyze (default: first program in PAT)")
+	format  = flag.String("format", "csv", "output format: csv or json (one object per line)")
+)
+
+type jsonRecord struct {
+	Time       float64  `json:"time"`
+	PID        uint16   `json:"pid"`
+	StreamType uint8    `json:"stream_type"`
+	PTS        uint64   `json:"pts"`
+	DTS        uint64   `json:"dts"`
+	Delay      float64  `json:"delay"`
+	AVOffset   *float64 `json:"av_offset,omitempty"`
+	TB         int      `json:"tb"`
+	MB         int      `json:"mb"`
+	EB         int      `json:"eb"`
+	Violations string   `json:"violations,omitempty"`
+}
+
+var csvHeader = []string{"time", "pid", "stream_type", "pts", "dts", "delay", "av_offset", "tb", "mb", "eb", "violations"}
+
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	a := analyzer.NewAnalyzer(tsparser.NewPacketScanner(input, logger), uint16(*program), logger)
+	switch *format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			log.Fatal(err)
+		}
+		a.SetHandler(func(r *analyzer.Record) error {
+			offset := ""
+			if r.HasAVOffset {
+				offset = formatSeconds(r.AVOffset.Seconds())
+			}
+			cw.Write([]string{
+				formatSeconds(r.Time.Seconds()),
+				fmt.Sprintf("0x%04x", r.PID),
+				fmt.Sprintf("0x%02x", uint8(r.StreamType)),
+				strconv.FormatUint(r.PTS, 10),
+				strconv.FormatUint(r.DTS, 10),
+				formatSeconds(r.Delay.Seconds()),
+				offset,
+				strconv.Itoa(r.TB),
+				strconv.Itoa(r.MB),
+				strconv.Itoa(r.EB),
+				r.Violations.String(),
+			})
+			return cw.Error()
+		})
+		defer cw.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		a.SetHandler(func(r *analyzer.Record) error {
+			record := jsonRecord{
+				Time:       r.Time.Seconds(),
+				PID:        uint16(r.PID),
+				StreamType: uint8(r.StreamType),
+				PTS:        r.PTS,
+				DTS:        r.DTS,
+				Delay:      r.Delay.Seconds(),
+				TB:         r.TB,
+				MB:         r.MB,
+				EB:         r.EB,
+				Violations: r.Violations.String(),
+			}
+			if r.HasAVOffset {
+				offset := r.AVOffset.Seconds()
+				record.AVOffset = &offset
+			}
+			return encoder.Encode(&record)
+		})
+	default:
+		log.Fatalf("unknown format: %s", *format)
+	}
+
+	if err := a.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dsmcc"
+)
+
+var (
+	program = flag.Int("program", 0, "program_number to extract (default: first program in PAT)")
+	pid     = flag.Int("pid", -1, "PID of the carousel (default: all the DSM-CC streams of the program)")
+	raw     = flag.Bool("raw", false, "write modules as they are instead of unpacking the resources")
+)
+
+// fileName makes the name of a resource safe to be a file name.
+func fileName(name string, module *dsmcc.Module, i int) string {
+	if name = path.Base(path.Clean("/" + name)); name != "/" && name != "." {
+		return name
+	}
+
+	return fmt.Sprintf("%04x.%d", module.ModuleId, i)
+}
+
+func writeModule(dir string, module *dsmcc.Module) error {
+	dir = filepath.Join(dir, fmt.Sprintf("%08x", module.DownloadId), fmt.Sprintf("%04x.%02x", module.ModuleId, module.Version))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if *raw {
+		return ioutil.WriteFile(filepath.Join(dir, fileName(module.Name(), module, 0)), module.Data, 0644)
+	}
+
+	resources, err := module.Resources()
+	if err != nil {
+		return err
+	}
+	for i, resource := range resources {
+		if err := ioutil.WriteFile(filepath.Join(dir, fileName(resource.Name, module, i)), resource.Data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output-dir\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	carousels := make(map[tsparser.PID]*dsmcc.Carousel)
+	if *pid >= 0 {
+		carousels[tsparser.PID(*pid)] = dsmcc.NewCarousel()
+	} else {
+		pmt := tsparser.FindProgram(input, uint16(*program))
+		if pmt == nil {
+			log.Fatal("program not found")
+		}
+		for _, es := range pmt.Streams() {
+			switch es.StreamType() {
+			case tsparser.DSMCCTypeBStream, tsparser.DSMCCTypeDStream:
+				carousels[es.PID()] = dsmcc.NewCarousel()
+			}
+		}
+		if len(carousels) == 0 {
+			log.Fatal("DSM-CC stream not found")
+		}
+		if _, err := input.Seek(0, 0); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	scanner := tsparser.NewPacketScanner(input, logger)
+	collector := tsparser.NewTableCollector()
+	for scanner.Scan() {
+		packet := scanner.Packet()
+		carousel, ok := carousels[packet.PID()]
+		if !ok {
+			continue
+		}
+
+		tables, _ := collector.Feed(packet)
+		for _, table := range tables {
+			modules, err := carousel.Feed(table)
+			if err != nil {
+				logger.Printf("pid=0x%04x: %v", packet.PID(), err)
+			}
+
+			for _, module := range modules {
+				dir := filepath.Join(flag.Arg(1), fmt.Sprintf("%04x", packet.PID()))
+				if err := writeModule(dir, module); err != nil {
+					logger.Printf("pid=0x%04x: module 0x%04x: %v", packet.PID(), module.ModuleId, err)
+				}
+			}
+		}
+	}
+}
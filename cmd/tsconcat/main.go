@@ -0,0 +1,47 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/concat"
+)
+
+var discontinuity = flag.Bool("discontinuity", false, "keep the original timestamps and signal discontinuities at the joins")
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] output.ts input.ts...\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input := tsparser.NewMultiFileReader(flag.Args()[1:]...)
+	defer input.Close()
+
+	output, err := os.Create(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	concatenator := concat.NewConcatenator(tsparser.NewPacketScanner(input, logger), w, logger)
+	concatenator.SetDiscontinuity(*discontinuity)
+	if err := concatenator.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
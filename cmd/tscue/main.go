@@ -0,0 +1,117 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/scte35"
+)
+
+var (
+	program   = flag.Int("program", 0, "program_number to inject into (default: first program in PAT)")
+	pid       = flag.Int("pid", -1, "PID of the SCTE-35 stream (default: next to the highest PID of the program)")
+	preroll   = flag.Duration("preroll", 4*time.Second, "time to send the cues ahead of their splice points")
+	condition = flag.Bool("condition", false, "move the splice points onto the next random access points of the video")
+	signal    = flag.Bool("signal", false, "send time_signal with segmentation_descriptor instead of splice_insert")
+)
+
+// parseCue parses PTS[/duration], where PTS is in 90kHz units and duration
+// is the length of the break.
+func parseCue(value string, eventId uint32) *scte35.SpliceInfoSection {
+	var duration time.Duration
+	if i := strings.IndexByte(value, '/'); i >= 0 {
+		d, err := time.ParseDuration(value[i+1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		value, duration = value[:i], d
+	}
+
+	pts, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.Fatal(err)
+	}
+	spliceTime := scte35.SpliceTime{Specified: true, PTS: pts & tsparser.TimestampMask}
+	ticks := uint64(duration * tsparser.ClockRate / time.Second)
+
+	if *signal {
+		segmentation := &scte35.Segmentation{
+			SegmentationEventId:   eventId,
+			ProgramSegmentation:   true,
+			DeliveryNotRestricted: true,
+			TypeId:                scte35.SegmentationProviderPlacementOpportunityStart,
+			SegmentNum:            1,
+			SegmentsExpected:      1,
+		}
+		if duration > 0 {
+			segmentation.Duration, segmentation.HasDuration = ticks, true
+		}
+		return scte35.NewSpliceInfoSection(&scte35.TimeSignal{SpliceTime: spliceTime},
+			scte35.NewSegmentationDescriptor(segmentation))
+	}
+
+	insert := &scte35.SpliceInsert{
+		SpliceEventId:   eventId,
+		OutOfNetwork:    true,
+		ProgramSplice:   true,
+		SpliceTime:      spliceTime,
+		UniqueProgramId: uint16(*program),
+	}
+	if duration > 0 {
+		insert.BreakDuration = &scte35.BreakDuration{AutoReturn: true, Duration: ticks}
+	}
+	return scte35.NewSpliceInfoSection(insert)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] output.ts input.ts PTS[/duration]...\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	output, err := os.Create(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	injector := scte35.NewInjector(tsparser.NewPacketScanner(input, logger), w, uint16(*program), logger)
+	if *pid >= 0 {
+		injector.SetPID(tsparser.PID(*pid))
+	}
+	injector.SetConditioning(*condition)
+	for i, value := range flag.Args()[2:] {
+		injector.AddCue(parseCue(value, uint32(i+1)), *preroll)
+	}
+
+	if err := injector.Run(); err == scte35.ErrCuesLeft {
+		logger.Print(err)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	logger.Printf("%d cues injected", injector.Injected())
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/cut"
+)
+
+const timeLayout = "2006-01-02T15:04:05"
+
+var (
+	program  = flag.Int("program", 0, "program_number to cut (default: first program in PAT)")
+	startPTS = flag.Int64("start-pts", -1, "start PTS in 90kHz units")
+	endPTS   = flag.Int64("end-pts", -1, "end PTS in 90kHz units")
+	start    = flag.String("start", "", "start time in JST ("+timeLayout+")")
+	end      = flag.String("end", "", "end time in JST ("+timeLayout+")")
+	event    = flag.Int("event", -1, "event_id to cut")
+)
+
+func parseTime(value string) time.Time {
+	location, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		location = time.FixedZone("JST", 9*60*60)
+	}
+
+	t, err := time.ParseInLocation(timeLayout, value, location)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output.ts\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	output, err := os.Create(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	cutter := cut.NewCutter(tsparser.NewPacketScanner(input, logger), w, uint16(*program), logger)
+	switch {
+	case *startPTS >= 0 && *endPTS >= 0:
+		cutter.SetPTSRange(uint64(*startPTS), uint64(*endPTS))
+	case *start != "" && *end != "":
+		cutter.SetTimeRange(parseTime(*start), parseTime(*end))
+	case *event >= 0:
+		cutter.SetEvent(uint16(*event))
+	default:
+		log.Fatal("either -start-pts/-end-pts, -start/-end or -event is required")
+	}
+
+	if err := cutter.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/cea"
+	"github.com/yosida95/tsparser/tsparser/dvb"
+	"github.com/yosida95/tsparser/tsparser/teletext"
+	"github.com/yosida95/tsparser/tsparser/video"
+)
+
+var (
+	program = flag.Int("program", 0, "program_number to demux (default: first program in PAT)")
+	prefix  = flag.String("prefix", "", "prefix of output files (default: input file name)")
+	withPTS = flag.Bool("pts", false, "write PTS sidecar files")
+	cc      = flag.String("cc", "", "extract CEA-608/708 captions of the video stream (srt or vtt)")
+	txt     = flag.String("teletext", "", "extract teletext subtitle pages (srt or vtt)")
+)
+
+func extension(es *tsparser.ElementaryStream) string {
+	switch es.StreamType() {
+	case tsparser.H264VideoStream:
+		return "264"
+	case tsparser.HEVCVideoStream:
+		return "h265"
+	case tsparser.MPEG1VideoStream, tsparser.MPEG2VideoStream:
+		return "m2v"
+	case tsparser.AACADTSStream:
+		return "aac"
+	case tsparser.AACLATMStream:
+		return "latm"
+	case tsparser.MPEG1AudioStream, tsparser.MPEG2AudioStream:
+		return "mpa"
+	case tsparser.PrivatePESStream:
+		if arib.IsCaptionStream(es) {
+			return "cap"
+		}
+		// The other private streams are written as they are if they are
+		// of audio, and otherwise left to the dedicated extractors.
+		for _, d := range es.Descriptors() {
+			switch dvb.DescriptorTag(d.Tag()) {
+			case dvb.AC3Descriptor:
+				return "ac3"
+			case dvb.EnhancedAC3Descriptor:
+				return "eac3"
+			}
+		}
+	}
+
+	return ""
+}
+
+// closeFiles closes files and returns err, or the first error in closing
+// them if err is nil.
+func closeFiles(files []*os.File, err error) error {
+	for _, f := range files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+func extractCaptions(r io.Reader, es *tsparser.ElementaryStream, logger *log.Logger) error {
+	extractor := cea.NewExtractor(tsparser.NewPESScanner(tsparser.NewPacketScanner(r, logger), logger), es, logger)
+	writers := make(map[string]cea.CaptionWriter)
+	var outputs []*os.File
+
+	extractor.SetHandler(func(c *cea.Caption) error {
+		w, ok := writers[c.Track]
+		if !ok {
+			output, err := os.Create(fmt.Sprintf("%s.%04x.%s.%s", *prefix, es.PID(), c.Track, *cc))
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, output)
+
+			if *cc == "vtt" {
+				origin, _ := extractor.Origin()
+				w = cea.NewWebVTTWriter(output, origin)
+			} else {
+				w = cea.NewSRTWriter(output)
+			}
+			writers[c.Track] = w
+		}
+
+		return w.WriteCaption(c)
+	})
+
+	return closeFiles(outputs, extractor.Run())
+}
+
+// subtitlePages returns the teletext subtitle pages of a stream.
+func subtitlePages(es *tsparser.ElementaryStream) []uint16 {
+	var pages []uint16
+	for _, d := range es.Descriptors() {
+		for _, t := range dvb.ParseTeletextDescriptor(d) {
+			if t.Type == dvb.TeletextTypeSubtitlePage || t.Type == dvb.TeletextTypeHearingImpairedSubtitlePage {
+				pages = append(pages, t.PageNumber())
+			}
+		}
+	}
+
+	return pages
+}
+
+func extractTeletext(r io.Reader, es *tsparser.ElementaryStream, pages []uint16, logger *log.Logger) error {
+	extractor := teletext.NewExtractor(tsparser.NewPESScanner(tsparser.NewPacketScanner(r, logger), logger), es.PID(), logger)
+	for _, page := range pages {
+		extractor.AddPage(page)
+	}
+	writers := make(map[uint16]cea.CaptionWriter)
+	var outputs []*os.File
+
+	extractor.SetHandler(func(s *teletext.Subtitle) error {
+		origin, _ := extractor.Origin()
+		w, ok := writers[s.Page]
+		if !ok {
+			output, err := os.Create(fmt.Sprintf("%s.%04x.%03x.%s", *prefix, es.PID(), s.Page, *txt))
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, output)
+
+			if *txt == "vtt" {
+				w = cea.NewWebVTTWriter(output, origin)
+			} else {
+				w = cea.NewSRTWriter(output)
+			}
+			writers[s.Page] = w
+		}
+
+		return w.WriteCaption(&cea.Caption{
+			Track: fmt.Sprintf("%03x", s.Page),
+			Start: tsparser.TimestampDiff(s.Start, origin),
+			End:   tsparser.TimestampDiff(s.End, origin),
+			Text:  s.Text,
+		})
+	})
+
+	return closeFiles(outputs, extractor.Run())
+}
+
+func demux(input io.Reader, pmt *tsparser.ProgramMapSection, logger *log.Logger) error {
+	demuxer := tsparser.NewDemuxer(tsparser.NewPacketScanner(input, logger), logger)
+	var outputs []*os.File
+	for _, es := range pmt.Streams() {
+		ext := extension(es)
+		if ext == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("%s.%04x.%s", *prefix, es.PID(), ext)
+		output, err := os.Create(name)
+		if err != nil {
+			return closeFiles(outputs, err)
+		}
+		outputs = append(outputs, output)
+
+		var w io.Writer = output
+		if arib.IsCaptionStream(es) {
+			w = arib.NewCaptionWriter(output)
+		}
+		demuxer.AddStream(es.PID(), w)
+
+		if *withPTS {
+			sidecar, err := os.Create(name + ".pts")
+			if err != nil {
+				return closeFiles(outputs, err)
+			}
+			outputs = append(outputs, sidecar)
+			demuxer.SetTimestampWriter(es.PID(), sidecar)
+		}
+	}
+
+	return closeFiles(outputs, demuxer.Run())
+}
+
+func run(name string) error {
+	input, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	pmt := tsparser.FindProgram(input, uint16(*program))
+	if pmt == nil {
+		return errors.New("Program not found")
+	}
+	if _, err := input.Seek(0, 0); err != nil {
+		return err
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if err := demux(input, pmt, logger); err != nil {
+		return err
+	}
+
+	if *cc != "" {
+		es := video.FindStream(pmt)
+		if es == nil {
+			return errors.New("Video stream not found")
+		}
+		if _, err := input.Seek(0, 0); err != nil {
+			return err
+		}
+		if err := extractCaptions(input, es, logger); err != nil {
+			return err
+		}
+	}
+
+	if *txt != "" {
+		for _, es := range pmt.Streams() {
+			pages := subtitlePages(es)
+			if len(pages) == 0 {
+				continue
+			}
+			if _, err := input.Seek(0, 0); err != nil {
+				return err
+			}
+			if err := extractTeletext(input, es, pages, logger); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if *prefix == "" {
+		*prefix = flag.Arg(0)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		log.Fatal(err)
+	}
+}
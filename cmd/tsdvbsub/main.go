@@ -0,0 +1,121 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dvb"
+	"github.com/yosida95/tsparser/tsparser/dvbsub"
+)
+
+var (
+	program = flag.Int("program", 0, "program_number to extract (default: first program in PAT)")
+	pid     = flag.Int("pid", 0, "PID of the subtitle stream (default: first subtitle stream in PMT)")
+	lang    = flag.String("lang", "", "ISO 639 language code of the subtitles")
+)
+
+func findSubtitling(pmt *tsparser.ProgramMapSection) (tsparser.PID, *dvb.Subtitling) {
+	for _, es := range pmt.Streams() {
+		if *pid != 0 && es.PID() != tsparser.PID(*pid) {
+			continue
+		}
+
+		for _, d := range es.Descriptors() {
+			if dvb.DescriptorTag(d.Tag()) != dvb.SubtitlingDescriptor {
+				continue
+			}
+			for _, s := range dvb.ParseSubtitlingDescriptor(d) {
+				if *lang == "" || s.Language == *lang {
+					return es.PID(), &s
+				}
+			}
+		}
+	}
+
+	return tsparser.NullPID, nil
+}
+
+func timecode(pts uint64) string {
+	ms := pts / (tsparser.ClockRate / 1000)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output-dir\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	pmt := tsparser.FindProgram(input, uint16(*program))
+	if pmt == nil {
+		log.Fatal("program not found")
+	}
+	subPID, subtitling := findSubtitling(pmt)
+	if subtitling == nil {
+		log.Fatal("subtitle stream not found")
+	}
+	if _, err := input.Seek(0, 0); err != nil {
+		log.Fatal(err)
+	}
+
+	dir := flag.Arg(1)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	manifest, err := os.Create(filepath.Join(dir, "manifest.tsv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer manifest.Close()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	extractor := dvbsub.NewExtractor(tsparser.NewPESScanner(tsparser.NewPacketScanner(input, logger), logger), subPID, *subtitling, logger)
+
+	var origin uint64
+	index := 0
+	extractor.SetHandler(func(s *dvbsub.Subtitle) error {
+		if index == 0 {
+			origin = s.Start
+		}
+		index++
+
+		name := fmt.Sprintf("%04d.png", index)
+		output, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+		if err := png.Encode(output, s.Image); err != nil {
+			return err
+		}
+
+		start := uint64(tsparser.TimestampDiff(s.Start, origin))
+		end := uint64(tsparser.TimestampDiff(s.End, origin))
+		bounds := s.Image.Bounds()
+		_, err = fmt.Fprintf(manifest, "%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n",
+			index, s.Start, s.End, timecode(start), timecode(end),
+			bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), name)
+		return err
+	})
+
+	if err := extractor.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
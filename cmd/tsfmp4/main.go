@@ -0,0 +1,54 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/fmp4"
+)
+
+var (
+	program  = flag.Int("program", 0, "program_number to remux (default: first program in PAT)")
+	duration = flag.Duration("fragment", fmp4.DefaultFragmentDuration, "minimum fragment duration")
+)
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output.mp4\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	output, err := os.Create(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	remuxer := fmp4.NewRemuxer(tsparser.NewPacketScanner(input, logger), w, uint16(*program), logger)
+	remuxer.SetFragmentDuration(*duration)
+
+	if err := remuxer.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/hls"
+)
+
+var (
+	program  = flag.Int("program", 0, "program_number to segment (default: first program in PAT)")
+	duration = flag.Duration("duration", 6*time.Second, "target segment duration")
+	window   = flag.Int("window", 0, "number of segments in a live playlist (default: VOD)")
+	prefix   = flag.String("prefix", "segment", "prefix of segment file names")
+)
+
+type segmentFile struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func (s *segmentFile) Close() error {
+	if err := s.Flush(); err != nil {
+		s.f.Close()
+		return err
+	}
+
+	return s.f.Close()
+}
+
+type expiry struct {
+	uri string
+	at  time.Duration
+}
+
+func writePlaylist(name string, playlist *hls.Playlist) error {
+	temp := name + ".tmp"
+	f, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := playlist.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, name)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output.m3u8\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	name := flag.Arg(1)
+	dir := filepath.Dir(name)
+	create := func(sequence int) (io.WriteCloser, string, error) {
+		uri := fmt.Sprintf("%s%05d.ts", *prefix, sequence)
+		f, err := os.Create(filepath.Join(dir, uri))
+		if err != nil {
+			return nil, "", err
+		}
+
+		return &segmentFile{bufio.NewWriter(f), f}, uri, nil
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	playlist := hls.NewPlaylist(*duration, *window)
+	segmenter := hls.NewSegmenter(tsparser.NewPacketScanner(input, logger), uint16(*program), playlist, create, logger)
+
+	// The segments slid out of the live playlist are kept available for
+	// their duration plus the duration of the playlist as RFC 8216 requires,
+	// and some more for a client which is late by a target duration.
+	var (
+		elapsed  time.Duration
+		expiries []expiry
+		removed  int
+	)
+	segments := make(map[int]*hls.Segment)
+	segmenter.SetPlaylistHandler(func(p *hls.Playlist) error {
+		for _, s := range p.Segments() {
+			if _, ok := segments[s.Sequence]; !ok {
+				segments[s.Sequence] = s
+				elapsed += s.Duration
+			}
+		}
+
+		for ; removed < p.MediaSequence(); removed++ {
+			if s, ok := segments[removed]; ok {
+				delete(segments, removed)
+				expiries = append(expiries, expiry{s.URI, elapsed + s.Duration + p.Duration() + p.TargetDuration()})
+			}
+		}
+		for len(expiries) > 0 && expiries[0].at <= elapsed {
+			os.Remove(filepath.Join(dir, expiries[0].uri))
+			expiries = expiries[1:]
+		}
+
+		return writePlaylist(name, p)
+	})
+
+	if err := segmenter.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/dsmcc"
+)
+
+var carouselPID = flag.Int("carousel", -1, "PID of the data carousel of the common data carrying LOGO modules")
+
+type services []arib.LogoService
+
+func (s services) Len() int {
+	return len(s)
+}
+
+func (s services) Less(i, j int) bool {
+	if s[i].OriginalNetworkId != s[j].OriginalNetworkId {
+		return s[i].OriginalNetworkId < s[j].OriginalNetworkId
+	} else if s[i].TransportStreamId != s[j].TransportStreamId {
+		return s[i].TransportStreamId < s[j].TransportStreamId
+	}
+
+	return s[i].ServiceId < s[j].ServiceId
+}
+
+func (s services) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func logoFileName(logo *arib.Logo) string {
+	return fmt.Sprintf("%04x_%03x_%03x_%d.png", logo.OriginalNetworkId, logo.LogoId, logo.LogoVersion, logo.LogoType)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] input.ts output-dir\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	input, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	dir := flag.Arg(1)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	scanner := tsparser.NewPacketScanner(input, logger)
+	collector := tsparser.NewTableCollector()
+	logos := arib.NewLogoCollector()
+	carousel := dsmcc.NewCarousel()
+
+	// transmissions are the logo transmission descriptors of the services
+	// in SDT, and links are the services listed in the LOGO modules.
+	transmissions := make(map[arib.LogoService]*arib.LogoTransmission)
+	links := make(map[arib.LogoService][]*arib.Logo)
+
+	write := func(logo *arib.Logo) {
+		if err := ioutil.WriteFile(filepath.Join(dir, logoFileName(logo)), logo.PNG, 0644); err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range logo.Services {
+			links[s] = append(links[s], logo)
+		}
+	}
+
+	for scanner.Scan() {
+		packet := scanner.Packet()
+		pid := packet.PID()
+		if pid != arib.CommonDataPID && pid != arib.ServiceDescriptionPID && int(pid) != *carouselPID {
+			continue
+		}
+
+		tables, _ := collector.Feed(packet)
+		for _, table := range tables {
+			switch {
+			case int(pid) == *carouselPID:
+				modules, err := carousel.Feed(table)
+				if err != nil {
+					logger.Printf("pid=0x%04x: %v", pid, err)
+				}
+				for _, module := range modules {
+					for _, logo := range logos.FeedModule(module) {
+						write(logo)
+					}
+				}
+			case table.TableId() == arib.CommonDataTable:
+				if logo := logos.Feed(table); logo != nil {
+					write(logo)
+				}
+			case table.TableId() == arib.ServiceDescriptionActualTable || table.TableId() == arib.ServiceDescriptionOtherTable:
+				if !table.CheckCRC() {
+					break
+				}
+				sec := arib.ParseServiceDescriptionSection(table)
+				for _, service := range sec.Services() {
+					if t := arib.FindLogoTransmission(service); t != nil {
+						key := arib.LogoService{
+							OriginalNetworkId: sec.OriginalNetworkId(),
+							TransportStreamId: sec.TransportStreamId(),
+							ServiceId:         service.ServiceId(),
+						}
+						transmissions[key] = t
+					}
+				}
+			}
+		}
+	}
+
+	var keys services
+	for key := range transmissions {
+		keys = append(keys, key)
+	}
+	for key := range links {
+		if _, ok := transmissions[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Sort(keys)
+
+	manifest, err := os.Create(filepath.Join(dir, "services.tsv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer manifest.Close()
+
+	for _, key := range keys {
+		found := make(map[uint8]*arib.Logo)
+		for _, logo := range links[key] {
+			found[logo.LogoType] = logo
+		}
+		for logoType := arib.LogoTypeSD43Small; logoType <= arib.LogoTypeHDLarge; logoType++ {
+			if logo := logos.Find(key.OriginalNetworkId, transmissions[key], logoType); logo != nil {
+				found[logoType] = logo
+			}
+		}
+
+		for logoType := arib.LogoTypeSD43Small; logoType <= arib.LogoTypeHDLarge; logoType++ {
+			if logo, ok := found[logoType]; ok {
+				fmt.Fprintf(manifest, "%d\t%d\t%d\t%d\t%s\n",
+					key.OriginalNetworkId, key.TransportStreamId, key.ServiceId, logoType, logoFileName(logo))
+			}
+		}
+	}
+}
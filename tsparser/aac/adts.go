@@ -0,0 +1,60 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+type ADTSHeader struct {
+	MPEG2                  bool
+	ProtectionAbsent       bool
+	Profile                uint8
+	SamplingFrequencyIndex uint8
+	ChannelConfiguration   uint8
+	FrameLength            int
+	BufferFullness         uint16
+	RawDataBlocks          int
+}
+
+func ParseADTSHeader(data []byte) (*ADTSHeader, error) {
+	if len(data) < 7 || data[0] != 0xff || data[1]&0xf6 != 0xf0 {
+		return nil, ErrInvalidHeader
+	}
+
+	h := &ADTSHeader{
+		MPEG2:                  data[1]&0x08 > 0,
+		ProtectionAbsent:       data[1]&0x01 > 0,
+		Profile:                uint8(data[2]&0xc0) >> 6,
+		SamplingFrequencyIndex: uint8(data[2]&0x3c) >> 2,
+		ChannelConfiguration:   uint8(data[2]&0x01)<<2 | uint8(data[3]&0xc0)>>6,
+		FrameLength:            int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5]&0xe0)>>5,
+		BufferFullness:         uint16(data[5]&0x1f)<<6 | uint16(data[6]&0xfc)>>2,
+		RawDataBlocks:          int(data[6]&0x03) + 1,
+	}
+	if h.FrameLength < h.HeaderLength() || samplingFrequency(h.SamplingFrequencyIndex) == 0 {
+		return nil, ErrInvalidHeader
+	}
+
+	return h, nil
+}
+
+func (h *ADTSHeader) HeaderLength() int {
+	if h.ProtectionAbsent {
+		return 7
+	}
+
+	return 9
+}
+
+func (h *ADTSHeader) Config(frame []byte) Config {
+	c := Config{
+		ObjectType:           ObjectType(h.Profile + 1),
+		SamplingFrequency:    samplingFrequency(h.SamplingFrequencyIndex),
+		ChannelConfiguration: h.ChannelConfiguration,
+		Channels:             channelsOf(h.ChannelConfiguration),
+	}
+
+	if c.ChannelConfiguration == 0 && len(frame) > h.HeaderLength() {
+		parseRawDataBlockConfig(frame[h.HeaderLength():], &c)
+	}
+	return c
+}
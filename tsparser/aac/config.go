@@ -0,0 +1,230 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var (
+	ErrInvalidHeader = errors.New("Invalid header")
+	ErrUnsupported   = errors.New("Unsupported configuration")
+)
+
+type ObjectType uint8
+
+const (
+	AACMain ObjectType = 1
+	AACLC   ObjectType = 2
+	AACSSR  ObjectType = 3
+	AACLTP  ObjectType = 4
+	SBR     ObjectType = 5
+	PS      ObjectType = 29
+)
+
+var samplingFrequencies = []int{
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+const SamplesPerFrame = 1024
+
+const (
+	elementSCE = 0
+	elementCPE = 1
+	elementCCE = 2
+	elementLFE = 3
+	elementDSE = 4
+	elementPCE = 5
+	elementFIL = 6
+	elementEND = 7
+)
+
+type Config struct {
+	ObjectType           ObjectType
+	SamplingFrequency    int
+	ChannelConfiguration uint8
+	Channels             int
+	DualMono             bool
+	SBR                  bool
+}
+
+func (c Config) FrameSamples() int {
+	if c.SBR {
+		return 2 * SamplesPerFrame
+	}
+
+	return SamplesPerFrame
+}
+
+// AudioSpecificConfig returns the config in the form of the MP4
+// decoder specific info.  A program config element is not carried, so
+// channel_configuration 0 is replaced with the number of channels where
+// it is representable.
+func (c Config) AudioSpecificConfig() []byte {
+	channelConfiguration := c.ChannelConfiguration
+	if channelConfiguration == 0 && c.Channels <= 2 {
+		channelConfiguration = uint8(c.Channels)
+	}
+
+	var bits uint64
+	var n uint
+	put := func(v uint32, width uint) {
+		bits = bits<<width | uint64(v)&(1<<width-1)
+		n += width
+	}
+	putFrequency := func(frequency int) {
+		index := samplingFrequencyIndex(frequency)
+		put(uint32(index), 4)
+		if index == 0x0f {
+			put(uint32(frequency), 24)
+		}
+	}
+
+	if c.SBR {
+		put(uint32(SBR), 5)
+		putFrequency(c.SamplingFrequency / 2)
+		put(uint32(channelConfiguration), 4)
+		putFrequency(c.SamplingFrequency)
+		put(uint32(c.ObjectType), 5)
+	} else {
+		put(uint32(c.ObjectType), 5)
+		putFrequency(c.SamplingFrequency)
+		put(uint32(channelConfiguration), 4)
+	}
+	put(0, 3) // frameLengthFlag, dependsOnCoreCoder, extensionFlag
+
+	length := (n + 7) / 8
+	bits <<= length*8 - n
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = byte(bits >> ((length - 1 - uint(i)) * 8))
+	}
+	return data
+}
+
+func samplingFrequencyIndex(frequency int) uint8 {
+	for i, f := range samplingFrequencies {
+		if f == frequency {
+			return uint8(i)
+		}
+	}
+
+	return 0x0f
+}
+
+func samplingFrequency(index uint8) int {
+	if int(index) < len(samplingFrequencies) {
+		return samplingFrequencies[index]
+	}
+
+	return 0
+}
+
+func channelsOf(configuration uint8) int {
+	switch configuration {
+	case 1, 2, 3, 4, 5, 6:
+		return int(configuration)
+	case 7:
+		return 8
+	}
+
+	return 0
+}
+
+// program_config_element() after its element_instance_tag
+func parseProgramConfig(r *bitstream.Reader, c *Config) {
+	r.Skip(2 + 4) // object_type, sampling_frequency_index
+	front := int(r.Bits(4))
+	side := int(r.Bits(4))
+	back := int(r.Bits(4))
+	lfe := int(r.Bits(2))
+	assoc := int(r.Bits(3))
+	cc := int(r.Bits(4))
+	if r.Bit() {
+		r.Skip(4)
+	}
+	if r.Bit() {
+		r.Skip(4)
+	}
+	if r.Bit() {
+		r.Skip(3)
+	}
+
+	channels, frontSCE := 0, 0
+	for i, n := 0, front+side+back; i < n; i++ {
+		if r.Bit() {
+			channels += 2
+		} else {
+			channels++
+			if i < front {
+				frontSCE++
+			}
+		}
+		r.Skip(4)
+	}
+	r.Skip(lfe*4 + assoc*4 + cc*5)
+	channels += lfe
+
+	c.Channels = channels
+	c.DualMono = front == 2 && frontSCE == 2 && side == 0 && back == 0 && lfe == 0
+}
+
+func parseRawDataBlockConfig(data []byte, c *Config) {
+	r := bitstream.NewReader(data)
+	switch r.Uint8(3) {
+	case elementPCE:
+		r.Skip(4)
+		parseProgramConfig(r, c)
+	case elementSCE:
+		c.Channels = 2
+		c.DualMono = true
+	}
+}
+
+func ParseAudioSpecificConfig(r *bitstream.Reader) (Config, error) {
+	var c Config
+	objectType := r.Uint8(5)
+	if objectType == 31 {
+		objectType = 32 + r.Uint8(6)
+	}
+	c.ObjectType = ObjectType(objectType)
+
+	if index := r.Uint8(4); index == 0x0f {
+		c.SamplingFrequency = int(r.Bits(24))
+	} else {
+		c.SamplingFrequency = samplingFrequency(index)
+	}
+	c.ChannelConfiguration = r.Uint8(4)
+	c.Channels = channelsOf(c.ChannelConfiguration)
+
+	if c.ObjectType == SBR || c.ObjectType == PS {
+		c.SBR = true
+		if index := r.Uint8(4); index == 0x0f {
+			c.SamplingFrequency = int(r.Bits(24))
+		} else {
+			c.SamplingFrequency = samplingFrequency(index)
+		}
+		c.ObjectType = ObjectType(r.Uint8(5))
+	}
+
+	switch c.ObjectType {
+	case AACMain, AACLC, AACSSR, AACLTP:
+		r.Skip(1) // frameLengthFlag
+		if r.Bit() {
+			r.Skip(14) // coreCoderDelay
+		}
+		r.Skip(1) // extensionFlag
+		if c.ChannelConfiguration == 0 {
+			r.Skip(4)
+			parseProgramConfig(r, &c)
+		}
+	default:
+		return c, ErrUnsupported
+	}
+
+	return c, r.Err()
+}
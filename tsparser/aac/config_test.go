@@ -0,0 +1,140 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var (
+	// AAC LC 48 kHz stereo.
+	stereoFrame = []byte{0xff, 0xf1, 0x4c, 0x80, 0x01, 0x7f, 0xfc, 0x21, 0x10, 0x05, 0x00}
+	// AAC LC 44.1 kHz stereo with CRC.
+	protectedFrame = []byte{0xff, 0xf0, 0x50, 0x80, 0x01, 0x7f, 0xfc, 0x12, 0x34, 0x21, 0x10}
+	// channel_configuration 0 followed by a single channel element.
+	sceFrame = []byte{0xff, 0xf1, 0x4c, 0x00, 0x01, 0x5f, 0xfc, 0x00, 0x80, 0x07}
+	// channel_configuration 0 followed by a program config element of two
+	// front single channel elements.
+	dualMonoFrame = []byte{0xff, 0xf1, 0x4c, 0x00, 0x01, 0xbf, 0xfc, 0xa0, 0x99, 0x00, 0x00, 0x00, 0x02}
+	// channel_configuration 0 followed by a program config element of 5.1
+	// channels.
+	surroundFrame = []byte{0xff, 0xf1, 0x4c, 0x00, 0x01, 0xdf, 0xfc, 0xa0, 0x99, 0x00, 0xa0, 0x00, 0x21, 0x10}
+)
+
+func TestParseADTSHeader(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected ADTSHeader
+		length   int
+	}{
+		{stereoFrame, ADTSHeader{
+			ProtectionAbsent:       true,
+			Profile:                1,
+			SamplingFrequencyIndex: 3,
+			ChannelConfiguration:   2,
+			FrameLength:            11,
+			BufferFullness:         0x7ff,
+			RawDataBlocks:          1,
+		}, 7},
+		{protectedFrame, ADTSHeader{
+			Profile:                1,
+			SamplingFrequencyIndex: 4,
+			ChannelConfiguration:   2,
+			FrameLength:            11,
+			BufferFullness:         0x7ff,
+			RawDataBlocks:          1,
+		}, 9},
+	}
+
+	for i, test := range tests {
+		h, err := ParseADTSHeader(test.data)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if *h != test.expected || h.HeaderLength() != test.length {
+			t.Errorf("%d: header = %+v (%d bytes), want %+v", i, *h, h.HeaderLength(), test.expected)
+		}
+	}
+
+	invalid := [][]byte{
+		stereoFrame[:6],
+		{0xff, 0xf3, 0x4c, 0x80, 0x01, 0x7f, 0xfc},
+		{0xff, 0xf1, 0x4c, 0x80, 0x00, 0xbf, 0xfc},
+		{0xff, 0xf1, 0x74, 0x80, 0x01, 0x7f, 0xfc},
+	}
+	for i, data := range invalid {
+		if _, err := ParseADTSHeader(data); err != ErrInvalidHeader {
+			t.Errorf("%d: err = %v, want %v", i, err, ErrInvalidHeader)
+		}
+	}
+}
+
+func TestADTSHeaderConfig(t *testing.T) {
+	tests := []struct {
+		frame    []byte
+		expected Config
+	}{
+		{stereoFrame, Config{ObjectType: AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2}},
+		{protectedFrame, Config{ObjectType: AACLC, SamplingFrequency: 44100, ChannelConfiguration: 2, Channels: 2}},
+		{sceFrame, Config{ObjectType: AACLC, SamplingFrequency: 48000, Channels: 2, DualMono: true}},
+		{dualMonoFrame, Config{ObjectType: AACLC, SamplingFrequency: 48000, Channels: 2, DualMono: true}},
+		{surroundFrame, Config{ObjectType: AACLC, SamplingFrequency: 48000, Channels: 6}},
+	}
+
+	for i, test := range tests {
+		h, err := ParseADTSHeader(test.frame)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if c := h.Config(test.frame); c != test.expected {
+			t.Errorf("%d: config = %+v, want %+v", i, c, test.expected)
+		}
+	}
+}
+
+func TestAudioSpecificConfig(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected Config
+	}{
+		{[]byte{0x12, 0x10}, Config{ObjectType: AACLC, SamplingFrequency: 44100, ChannelConfiguration: 2, Channels: 2}},
+		{[]byte{0x11, 0x90}, Config{ObjectType: AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2}},
+		// HE-AAC with explicit hierarchical signalling of SBR.
+		{[]byte{0x2b, 0x11, 0x88, 0x00}, Config{ObjectType: AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2, SBR: true}},
+		// An escaped sampling frequency of 50 kHz.
+		{[]byte{0x17, 0x80, 0x61, 0xa8, 0x10}, Config{ObjectType: AACLC, SamplingFrequency: 50000, ChannelConfiguration: 2, Channels: 2}},
+	}
+
+	for i, test := range tests {
+		c, err := ParseAudioSpecificConfig(bitstream.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if c != test.expected {
+			t.Errorf("%d: config = %+v, want %+v", i, c, test.expected)
+		}
+		if data := c.AudioSpecificConfig(); !bytes.Equal(data, test.data) {
+			t.Errorf("%d: AudioSpecificConfig = % x, want % x", i, data, test.data)
+		}
+	}
+
+	if c := (Config{ObjectType: AACLC, SamplingFrequency: 48000, Channels: 2, DualMono: true}); !bytes.Equal(c.AudioSpecificConfig(), []byte{0x11, 0x90}) {
+		t.Errorf("AudioSpecificConfig of dual mono = % x", c.AudioSpecificConfig())
+	}
+	if c := (Config{ObjectType: AACLC, SamplingFrequency: 48000, SBR: true}); c.FrameSamples() != 2*SamplesPerFrame {
+		t.Errorf("FrameSamples of SBR = %d", c.FrameSamples())
+	}
+
+	// AAC Scalable
+	if _, err := ParseAudioSpecificConfig(bitstream.NewReader([]byte{0x31, 0x90})); err != ErrUnsupported {
+		t.Errorf("err = %v, want %v", err, ErrUnsupported)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+import (
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+const loasHeaderLength = 3
+
+func parseLOASLength(data []byte) (int, bool) {
+	if len(data) < loasHeaderLength || data[0] != 0x56 || data[1]&0xe0 != 0xe0 {
+		return 0, false
+	}
+
+	return loasHeaderLength + (int(data[1]&0x1f)<<8 | int(data[2])), true
+}
+
+func latmGetValue(r *bitstream.Reader) uint32 {
+	bytes := int(r.Bits(2)) + 1
+	return r.Uint32(bytes * 8)
+}
+
+type streamMux struct {
+	config     Config
+	configured bool
+}
+
+// AudioMuxElement(1) of a single program, single layer stream.  It returns
+// the payload of the element and whether the stream mux config is updated.
+func parseAudioMuxElement(data []byte, mux *streamMux) ([]byte, bool, error) {
+	r := bitstream.NewReader(data)
+	updated := false
+	if !r.Bit() { // useSameStreamMux
+		if err := parseStreamMuxConfig(r, mux); err != nil {
+			return nil, false, err
+		}
+		updated = true
+	} else if !mux.configured {
+		return nil, false, r.Err()
+	}
+
+	length := 0
+	for {
+		tmp := int(r.Bits(8))
+		length += tmp
+		if tmp != 255 || r.Err() != nil {
+			break
+		}
+	}
+
+	if r.Left() < length*8 {
+		return nil, updated, ErrInvalidHeader
+	}
+	payload := make([]byte, length)
+	for i := range payload {
+		payload[i] = r.Uint8(8)
+	}
+	return payload, updated, r.Err()
+}
+
+func parseStreamMuxConfig(r *bitstream.Reader, mux *streamMux) error {
+	audioMuxVersion := r.Bit()
+	if audioMuxVersion && r.Bit() { // audioMuxVersionA
+		return ErrUnsupported
+	}
+	if audioMuxVersion {
+		latmGetValue(r) // taraBufferFullness
+	}
+
+	r.Skip(1)                             // allStreamsSameTimeFraming
+	r.Skip(6)                             // numSubFrames
+	if r.Bits(4) != 0 || r.Bits(3) != 0 { // numProgram, numLayer
+		return ErrUnsupported
+	}
+
+	var config Config
+	var err error
+	if audioMuxVersion {
+		length := int(latmGetValue(r))
+		start := r.Pos()
+		config, err = ParseAudioSpecificConfig(r)
+		r.Skip(length*8 - (r.Pos() - start))
+	} else {
+		config, err = ParseAudioSpecificConfig(r)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Bits(3) != 0 { // frameLengthType
+		return ErrUnsupported
+	}
+	r.Skip(8)    // latmBufferFullness
+	if r.Bit() { // otherDataPresent
+		if audioMuxVersion {
+			latmGetValue(r) // otherDataLenBits
+		} else {
+			for escape := true; escape && r.Err() == nil; {
+				escape = r.Bit()
+				r.Skip(8)
+			}
+		}
+	}
+	if r.Bit() { // crcCheckPresent
+		r.Skip(8)
+	}
+
+	mux.config, mux.configured = config, true
+	return r.Err()
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+import (
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type Frame struct {
+	Data    []byte
+	Payload []byte
+
+	PTS    uint64
+	HasPTS bool
+	Offset int64
+
+	Config        Config
+	Samples       int
+	ConfigChanged bool
+}
+
+func (f *Frame) Duration() uint64 {
+	if f.Config.SamplingFrequency == 0 {
+		return 0
+	}
+
+	return uint64(f.Samples) * tsparser.ClockRate / uint64(f.Config.SamplingFrequency)
+}
+
+type origin struct {
+	start  int64
+	pts    uint64
+	hasPTS bool
+	offset int64
+	used   bool
+}
+
+type FrameParser struct {
+	latm   bool
+	logger *log.Logger
+
+	buffer   []byte
+	consumed int64
+	origins  []*origin
+
+	mux       streamMux
+	config    Config
+	hasConfig bool
+	dualMono  bool
+
+	basePTS uint64
+	hasBase bool
+	samples int64
+
+	queue []*Frame
+}
+
+func NewFrameParser(streamType tsparser.StreamType, logger *log.Logger) *FrameParser {
+	return &FrameParser{
+		latm:   streamType == tsparser.AACLATMStream,
+		logger: logger,
+	}
+}
+
+func (p *FrameParser) log(v ...interface{}) {
+	if p.logger != nil {
+		p.logger.Print(v...)
+	}
+}
+
+func (p *FrameParser) SetDualMono(dualMono bool) {
+	p.dualMono = dualMono
+}
+
+func (p *FrameParser) Config() Config {
+	return p.config
+}
+
+// Push feeds the payload of a PES packet found at offset.  Completed
+// frames are returned by Frames.
+func (p *FrameParser) Push(pes tsparser.PES, offset int64) {
+	p.origins = append(p.origins, &origin{
+		start:  p.consumed + int64(len(p.buffer)),
+		pts:    pes.PTS(),
+		hasPTS: pes.HasPTS(),
+		offset: offset,
+	})
+	p.buffer = append(p.buffer, pes.Payload()...)
+	p.split()
+}
+
+func (p *FrameParser) Frames() []*Frame {
+	queue := p.queue
+	p.queue = nil
+	return queue
+}
+
+type FrameScanner struct {
+	s      *tsparser.PESScanner
+	pid    tsparser.PID
+	parser *FrameParser
+
+	queue []*Frame
+	frame *Frame
+	eof   bool
+}
+
+func NewFrameScanner(s *tsparser.PESScanner, pid tsparser.PID, streamType tsparser.StreamType, logger *log.Logger) *FrameScanner {
+	return &FrameScanner{
+		s:      s,
+		pid:    pid,
+		parser: NewFrameParser(streamType, logger),
+	}
+}
+
+func (s *FrameScanner) SetDualMono(dualMono bool) {
+	s.parser.SetDualMono(dualMono)
+}
+
+func (s *FrameScanner) Config() Config {
+	return s.parser.Config()
+}
+
+func (s *FrameScanner) Scan() bool {
+	for len(s.queue) == 0 {
+		if s.eof || !s.s.Scan() {
+			s.eof = true
+			return false
+		}
+
+		if s.s.PID() == s.pid {
+			s.parser.Push(s.s.PES(), s.s.Offset())
+			s.queue = s.parser.Frames()
+		}
+	}
+
+	s.frame, s.queue = s.queue[0], s.queue[1:]
+	return true
+}
+
+func (s *FrameScanner) Frame() *Frame {
+	return s.frame
+}
+
+func (p *FrameParser) skip(n int) {
+	p.buffer = p.buffer[n:]
+	p.consumed += int64(n)
+}
+
+func (p *FrameParser) split() {
+	for len(p.buffer) > 0 {
+		var length, blocks int
+		var config Config
+		var payload []byte
+		var ok bool
+		if p.latm {
+			length, config, payload, ok = p.nextLOAS()
+			blocks = 1
+		} else {
+			length, config, payload, blocks, ok = p.nextADTS()
+		}
+
+		if !ok {
+			return
+		} else if length == 0 {
+			p.skip(1)
+			continue
+		}
+
+		p.emit(p.buffer[:length], payload, config, blocks)
+		p.skip(length)
+	}
+
+	p.buffer = append([]byte(nil), p.buffer...)
+}
+
+func (p *FrameParser) nextADTS() (int, Config, []byte, int, bool) {
+	if len(p.buffer) < 7 {
+		return 0, Config{}, nil, 0, false
+	}
+
+	header, err := ParseADTSHeader(p.buffer)
+	if err != nil {
+		return 0, Config{}, nil, 0, true
+	} else if len(p.buffer) < header.FrameLength {
+		return 0, Config{}, nil, 0, false
+	}
+
+	frame := p.buffer[:header.FrameLength]
+	return header.FrameLength, header.Config(frame), frame[header.HeaderLength():], header.RawDataBlocks, true
+}
+
+func (p *FrameParser) nextLOAS() (int, Config, []byte, bool) {
+	if len(p.buffer) < loasHeaderLength {
+		return 0, Config{}, nil, false
+	}
+
+	length, ok := parseLOASLength(p.buffer)
+	if !ok {
+		return 0, Config{}, nil, true
+	} else if len(p.buffer) < length {
+		return 0, Config{}, nil, false
+	}
+
+	payload, _, err := parseAudioMuxElement(p.buffer[loasHeaderLength:length], &p.mux)
+	if err != nil {
+		p.log(err)
+	}
+	if !p.mux.configured {
+		return length, Config{}, nil, true
+	}
+	return length, p.mux.config, payload, true
+}
+
+func (p *FrameParser) originOf(position int64) *origin {
+	var found *origin
+	for _, o := range p.origins {
+		if o.start > position {
+			break
+		}
+		found = o
+	}
+
+	for len(p.origins) > 1 && p.origins[1].start <= position {
+		p.origins = p.origins[1:]
+	}
+	return found
+}
+
+func (p *FrameParser) emit(data, payload []byte, config Config, blocks int) {
+	if config.SamplingFrequency == 0 {
+		return
+	}
+	if p.dualMono && config.ChannelConfiguration == 0 && config.Channels <= 2 {
+		config.Channels, config.DualMono = 2, true
+	}
+
+	frame := &Frame{
+		Data:          append([]byte(nil), data...),
+		Config:        config,
+		Samples:       blocks * config.FrameSamples(),
+		ConfigChanged: p.hasConfig && p.config != config,
+	}
+	if p.latm {
+		frame.Payload = payload
+	} else {
+		frame.Payload = frame.Data[len(data)-len(payload):]
+	}
+
+	if o := p.originOf(p.consumed); o != nil {
+		frame.Offset = o.offset
+		if o.hasPTS && !o.used {
+			o.used = true
+			p.basePTS, p.samples, p.hasBase = o.pts, 0, true
+		}
+	}
+
+	if p.hasBase {
+		frame.HasPTS = true
+		frame.PTS = (p.basePTS + uint64(p.samples*tsparser.ClockRate/int64(config.SamplingFrequency))) & tsparser.TimestampMask
+	}
+	p.samples += int64(frame.Samples)
+
+	p.config, p.hasConfig = config, true
+	p.queue = append(p.queue, frame)
+}
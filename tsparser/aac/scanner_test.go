@@ -0,0 +1,228 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package aac
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	// AudioMuxElement(1) of AAC LC 48 kHz stereo carrying StreamMuxConfig
+	loasFrame = []byte{0x56, 0xe0, 0x0b, 0x20, 0x00, 0x11, 0x90, 0x1f, 0xe0, 0x21, 0x08, 0x80, 0x28, 0x00}
+	// AudioMuxElement(1) of useSameStreamMux
+	loasSameMuxFrame = []byte{0x56, 0xe0, 0x04, 0x81, 0x10, 0x88, 0x00}
+)
+
+func scanFrames(streamType tsparser.StreamType, dualMono bool, units ...[]byte) ([]*Frame, *FrameScanner) {
+	ts := fixture.Packetize(0x0110, units...)
+	s := NewFrameScanner(tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(ts), nil), nil), 0x0110, streamType, nil)
+	s.SetDualMono(dualMono)
+
+	var frames []*Frame
+	for s.Scan() {
+		frames = append(frames, s.Frame())
+	}
+	return frames, s
+}
+
+func TestFrameParserADTS(t *testing.T) {
+	p := NewFrameParser(tsparser.AACADTSStream, nil)
+	p.Push(fixture.PES(0xc0, 90000, []byte{0x00}, stereoFrame, stereoFrame, stereoFrame[:5]), 0)
+	p.Push(fixture.PES(0xc0, 100000, stereoFrame[5:], protectedFrame), 188)
+
+	frames := p.Frames()
+	if len(frames) != 4 {
+		t.Fatalf("%d frames, want 4", len(frames))
+	}
+
+	tests := []struct {
+		pts           uint64
+		offset        int64
+		frequency     int
+		configChanged bool
+	}{
+		{90000, 0, 48000, false},
+		{91920, 0, 48000, false},
+		{93840, 0, 48000, false},
+		{100000, 188, 44100, true},
+	}
+	for i, test := range tests {
+		f := frames[i]
+		if !f.HasPTS || f.PTS != test.pts || f.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, f.PTS, f.HasPTS, f.Offset)
+		}
+		if f.Config.SamplingFrequency != test.frequency || f.ConfigChanged != test.configChanged {
+			t.Errorf("%d: config = %+v, changed = %v", i, f.Config, f.ConfigChanged)
+		}
+		if f.Samples != SamplesPerFrame || len(f.Data) != 11 || !bytes.Equal(f.Payload, f.Data[len(f.Data)-len(f.Payload):]) {
+			t.Errorf("%d: %d samples, data = % x, payload = % x", i, f.Samples, f.Data, f.Payload)
+		}
+	}
+
+	if !bytes.Equal(frames[0].Payload, []byte{0x21, 0x10, 0x05, 0x00}) || !bytes.Equal(frames[3].Payload, []byte{0x21, 0x10}) {
+		t.Errorf("payload = % x, % x", frames[0].Payload, frames[3].Payload)
+	}
+	if frames[0].Duration() != 1920 {
+		t.Errorf("duration = %d", frames[0].Duration())
+	}
+	if p.Config() != frames[3].Config {
+		t.Errorf("config = %+v", p.Config())
+	}
+}
+
+func TestFrameParserDualMono(t *testing.T) {
+	// A fill element is not enough to tell the number of channels.
+	frame := []byte{0xff, 0xf1, 0x4c, 0x00, 0x01, 0x3f, 0xfc, 0xc0, 0x00}
+
+	for _, dualMono := range []bool{false, true} {
+		p := NewFrameParser(tsparser.AACADTSStream, nil)
+		p.SetDualMono(dualMono)
+		p.Push(fixture.PES(0xc0, 90000, frame, dualMonoFrame), 0)
+
+		frames := p.Frames()
+		if len(frames) != 2 {
+			t.Fatalf("%d frames, want 2", len(frames))
+		}
+		if c := frames[0].Config; c.DualMono != dualMono || (c.Channels == 2) != dualMono {
+			t.Errorf("dual mono %v: config = %+v", dualMono, c)
+		}
+		if c := frames[1].Config; !c.DualMono || c.Channels != 2 {
+			t.Errorf("dual mono %v: config = %+v", dualMono, c)
+		}
+	}
+}
+
+func TestFrameParserLATM(t *testing.T) {
+	p := NewFrameParser(tsparser.AACLATMStream, nil)
+	// A frame before StreamMuxConfig is dropped.
+	p.Push(fixture.PES(0xc0, 88080, loasSameMuxFrame), 0)
+	p.Push(fixture.PES(0xc0, 90000, loasFrame, loasSameMuxFrame[:2]), 188)
+	p.Push(fixture.PES(0xc0, 91920, loasSameMuxFrame[2:]), 376)
+
+	frames := p.Frames()
+	if len(frames) != 2 {
+		t.Fatalf("%d frames, want 2", len(frames))
+	}
+
+	expected := Config{ObjectType: AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2}
+	tests := []struct {
+		pts     uint64
+		offset  int64
+		payload []byte
+	}{
+		{90000, 188, []byte{0x21, 0x10, 0x05, 0x00}},
+		{91920, 188, []byte{0x21, 0x10}},
+	}
+	for i, test := range tests {
+		f := frames[i]
+		if !f.HasPTS || f.PTS != test.pts || f.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, f.PTS, f.HasPTS, f.Offset)
+		}
+		if f.Config != expected || f.ConfigChanged {
+			t.Errorf("%d: config = %+v, changed = %v", i, f.Config, f.ConfigChanged)
+		}
+		if !bytes.Equal(f.Payload, test.payload) {
+			t.Errorf("%d: payload = % x, want % x", i, f.Payload, test.payload)
+		}
+	}
+}
+
+func TestFrameScannerADTS(t *testing.T) {
+	frames, s := scanFrames(tsparser.AACADTSStream, false,
+		fixture.PES(0xc0, 90000, []byte{0x00}, stereoFrame, stereoFrame, stereoFrame[:5]),
+		fixture.PES(0xc0, 100000, stereoFrame[5:], protectedFrame),
+	)
+	if len(frames) != 4 {
+		t.Fatalf("%d frames, want 4", len(frames))
+	}
+
+	tests := []struct {
+		pts           uint64
+		offset        int64
+		data          []byte
+		frequency     int
+		configChanged bool
+	}{
+		{90000, 0, stereoFrame, 48000, false},
+		{91920, 0, stereoFrame, 48000, false},
+		{93840, 0, stereoFrame, 48000, false},
+		{100000, 188, protectedFrame, 44100, true},
+	}
+	for i, test := range tests {
+		f := frames[i]
+		if !f.HasPTS || f.PTS != test.pts || f.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, f.PTS, f.HasPTS, f.Offset)
+		}
+		if f.Config.SamplingFrequency != test.frequency || f.ConfigChanged != test.configChanged {
+			t.Errorf("%d: config = %+v, changed = %v", i, f.Config, f.ConfigChanged)
+		}
+		if f.Samples != SamplesPerFrame || !bytes.Equal(f.Data, test.data) {
+			t.Errorf("%d: %d samples, data = % x", i, f.Samples, f.Data)
+		}
+	}
+
+	if frames[0].Duration() != 1920 {
+		t.Errorf("duration = %d", frames[0].Duration())
+	}
+	if s.Config() != frames[3].Config {
+		t.Errorf("config = %+v", s.Config())
+	}
+}
+
+func TestFrameScannerDualMono(t *testing.T) {
+	// A fill element is not enough to tell the number of channels.
+	frame := []byte{0xff, 0xf1, 0x4c, 0x00, 0x01, 0x3f, 0xfc, 0xc0, 0x00}
+
+	for _, dualMono := range []bool{false, true} {
+		frames, _ := scanFrames(tsparser.AACADTSStream, dualMono, fixture.PES(0xc0, 90000, frame, dualMonoFrame))
+		if len(frames) != 2 {
+			t.Fatalf("%d frames, want 2", len(frames))
+		}
+		if c := frames[0].Config; c.DualMono != dualMono || (c.Channels == 2) != dualMono {
+			t.Errorf("dual mono %v: config = %+v", dualMono, c)
+		}
+		if c := frames[1].Config; !c.DualMono || c.Channels != 2 {
+			t.Errorf("dual mono %v: config = %+v", dualMono, c)
+		}
+	}
+}
+
+func TestFrameScannerLATM(t *testing.T) {
+	frames, _ := scanFrames(tsparser.AACLATMStream, false,
+		// A frame before StreamMuxConfig is dropped.
+		fixture.PES(0xc0, 88080, loasSameMuxFrame),
+		fixture.PES(0xc0, 90000, loasFrame, loasSameMuxFrame[:2]),
+		fixture.PES(0xc0, 91920, loasSameMuxFrame[2:]),
+	)
+	if len(frames) != 2 {
+		t.Fatalf("%d frames, want 2", len(frames))
+	}
+
+	expected := Config{ObjectType: AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2}
+	tests := []struct {
+		pts    uint64
+		offset int64
+		data   []byte
+	}{
+		{90000, 188, loasFrame},
+		{91920, 188, loasSameMuxFrame},
+	}
+	for i, test := range tests {
+		f := frames[i]
+		if !f.HasPTS || f.PTS != test.pts || f.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, f.PTS, f.HasPTS, f.Offset)
+		}
+		if f.Config != expected || f.ConfigChanged {
+			t.Errorf("%d: config = %+v, changed = %v", i, f.Config, f.ConfigChanged)
+		}
+		if !bytes.Equal(f.Data, test.data) {
+			t.Errorf("%d: data = % x, want % x", i, f.Data, test.data)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+type AdaptationField []byte
+
+func NewAdaptationField(discontinuity, randomAccess bool) AdaptationField {
+	af := AdaptationField{1, 0x00}
+	if discontinuity {
+		af[1] |= 0x80
+	}
+	if randomAccess {
+		af[1] |= 0x40
+	}
+
+	return af
+}
+
+func NewPCRAdaptationField(pcr uint64, discontinuity, randomAccess bool) AdaptationField {
+	af := NewAdaptationField(discontinuity, randomAccess)
+	af[0] += 6
+	af[1] |= 0x10
+
+	base := pcr / 300 & 0x1ffffffff
+	ext := pcr % 300
+	return append(af,
+		byte(base>>25), byte(base>>17), byte(base>>9), byte(base>>1),
+		byte(base&0x01)<<7|0x7e|byte(ext>>8), byte(ext))
+}
+
+func (af AdaptationField) Length() int {
+	if len(af) == 0 {
+		return 0
+	}
+
+	return int(af[0])
+}
+
+func (af AdaptationField) flags() byte {
+	if af.Length() == 0 || len(af) < 2 {
+		return 0
+	}
+
+	return af[1]
+}
+
+func (af AdaptationField) DiscontinuityIndicator() bool {
+	return af.flags()&0x80 > 0
+}
+
+func (af AdaptationField) SetDiscontinuityIndicator(discontinuity bool) {
+	if af.Length() == 0 || len(af) < 2 {
+		return
+	}
+
+	if discontinuity {
+		af[1] |= 0x80
+	} else {
+		af[1] &^= 0x80
+	}
+}
+
+func (af AdaptationField) RandomAccessIndicator() bool {
+	return af.flags()&0x40 > 0
+}
+
+func (af AdaptationField) SetRandomAccessIndicator(randomAccess bool) {
+	if af.Length() == 0 || len(af) < 2 {
+		return
+	}
+
+	if randomAccess {
+		af[1] |= 0x40
+	} else {
+		af[1] &^= 0x40
+	}
+}
+
+func (af AdaptationField) ElementaryStreamPriorityIndicator() bool {
+	return af.flags()&0x20 > 0
+}
+
+func (af AdaptationField) HasPCR() bool {
+	return af.flags()&0x10 > 0 && af.Length() >= 7
+}
+
+func (af AdaptationField) HasOPCR() bool {
+	return af.flags()&0x08 > 0
+}
+
+func (af AdaptationField) SplicingPointFlag() bool {
+	return af.flags()&0x04 > 0
+}
+
+func (af AdaptationField) PCR() uint64 {
+	if !af.HasPCR() {
+		return 0
+	}
+
+	return parsePCR(af[2:8])
+}
+
+// SetPCR rewrites the PCR in place.  It does nothing if the field has no
+// PCR.
+func (af AdaptationField) SetPCR(pcr uint64) {
+	if !af.HasPCR() {
+		return
+	}
+
+	base := pcr / 300 & 0x1ffffffff
+	ext := pcr % 300
+	af[2], af[3], af[4], af[5] = byte(base>>25), byte(base>>17), byte(base>>9), byte(base>>1)
+	af[6] = byte(base&0x01)<<7 | 0x7e | byte(ext>>8)
+	af[7] = byte(ext)
+}
+
+func parsePCR(data []byte) uint64 {
+	base := uint64(data[0])<<25 | uint64(data[1])<<17 | uint64(data[2])<<9 |
+		uint64(data[3])<<1 | uint64(data[4])>>7
+	ext := uint64(data[4]&0x01)<<8 | uint64(data[5])
+	return base*300 + ext
+}
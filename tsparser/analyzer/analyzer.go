@@ -0,0 +1,287 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/video"
+)
+
+var ErrNoStream = errors.New("No elementary stream is analyzed")
+
+// Record describes a PES packet of an audio or video stream at the arrival
+// of its header.  Times are measured on the program clock from the first
+// PCR.
+type Record struct {
+	Time       time.Duration
+	PID        tsparser.PID
+	StreamType tsparser.StreamType
+	PTS        uint64
+	DTS        uint64
+
+	// Delay is DTS minus the arrival time, i.e. how long the access unit
+	// stays in the decoder buffer.
+	Delay time.Duration
+
+	// AVOffset is PTS of an audio stream minus PTS of the video stream
+	// last arrived.
+	AVOffset    time.Duration
+	HasAVOffset bool
+
+	// The peak occupancies of the T-STD buffers in bytes and the
+	// violations of their limits since the last record of the video
+	// stream.
+	TB, MB, EB int
+	Violations Violation
+}
+
+type pendingPacket struct {
+	packet tsparser.Packet
+	index  int64
+}
+
+type Analyzer struct {
+	s       tsparser.PacketStream
+	program *tsparser.ProgramTracker
+	handler func(*Record) error
+	logger  *log.Logger
+
+	collector *tsparser.TableCollector
+	video     *tsparser.ElementaryStream
+	streams   map[tsparser.PID]*tsparser.ElementaryStream
+	timeline  *tsparser.Timeline
+
+	packets    int64
+	hasOrigin  bool
+	origin     int64
+	pcrPacket  int64
+	pcrTime    float64
+	packetTime float64
+	pending    []pendingPacket
+
+	std          *transportSTD
+	lastVideoPTS float64
+	hasVideoPTS  bool
+	records      int
+}
+
+func NewAnalyzer(s tsparser.PacketStream, serviceId uint16, logger *log.Logger) *Analyzer {
+	return &Analyzer{
+		s:         s,
+		program:   tsparser.NewProgramTracker(serviceId),
+		logger:    logger,
+		collector: tsparser.NewTableCollector(),
+		streams:   make(map[tsparser.PID]*tsparser.ElementaryStream),
+		timeline:  tsparser.NewTimeline(tsparser.NullPID),
+	}
+}
+
+func (a *Analyzer) log(p tsparser.Packet, v ...interface{}) {
+	if a.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	a.logger.Print(values...)
+}
+
+// SetHandler sets the function which is called with every record.
+func (a *Analyzer) SetHandler(fn func(*Record) error) {
+	a.handler = fn
+}
+
+func (a *Analyzer) Run() error {
+	for a.s.Scan() {
+		if err := a.handle(a.s.Packet()); err != nil {
+			return err
+		}
+	}
+	if err := a.flush(); err != nil {
+		return err
+	}
+
+	if a.records == 0 {
+		return ErrNoStream
+	}
+	return nil
+}
+
+func (a *Analyzer) handle(packet tsparser.Packet) error {
+	a.packets++
+
+	pid := packet.PID()
+	if pid == tsparser.ProgramAssociationPID || pid == a.program.PMTPID() {
+		a.handleTables(packet)
+	}
+
+	_, ok := a.streams[pid]
+	isPCR := pid == a.timeline.PCRPID() && packet.HasAdaptationField() && packet.AdaptationField().HasPCR()
+	if !isPCR {
+		if ok && a.hasOrigin && packet.HasPayload() {
+			copied := make(tsparser.Packet, len(packet))
+			copy(copied, packet)
+			a.pending = append(a.pending, pendingPacket{copied, a.packets})
+		}
+		return nil
+	}
+
+	t := a.handlePCR(packet)
+	if a.pcrPacket > 0 && t > a.pcrTime {
+		a.packetTime = (t - a.pcrTime) / float64(a.packets-a.pcrPacket)
+	}
+	if err := a.flush(); err != nil {
+		return err
+	}
+	a.pcrPacket, a.pcrTime = a.packets, t
+	if ok && packet.HasPayload() {
+		return a.handleStream(packet, a.pcrTime)
+	}
+	return nil
+}
+
+// flush analyzes the packets waiting for the next PCR.  Their arrival
+// times are interpolated with the transport rate between the PCRs.
+func (a *Analyzer) flush() error {
+	for _, p := range a.pending {
+		t := a.pcrTime + float64(p.index-a.pcrPacket)*a.packetTime
+		if err := a.handleStream(p.packet, t); err != nil {
+			return err
+		}
+	}
+
+	a.pending = a.pending[:0]
+	return nil
+}
+
+func (a *Analyzer) handleStream(packet tsparser.Packet, t float64) error {
+	pid := packet.PID()
+	es, ok := a.streams[pid]
+	if !ok {
+		return nil
+	}
+
+	payload := packet.Payload()
+	isVideo := a.video != nil && pid == a.video.PID()
+	if !packet.PayloadUnitStartIndicator() {
+		if isVideo && a.std != nil {
+			a.std.arrive(t, len(payload))
+		}
+		return nil
+	}
+
+	pes := tsparser.PES(payload)
+	if len(pes) < 14 || !pes.HasPTS() {
+		return nil
+	}
+
+	record := &Record{
+		Time:       seconds(t),
+		PID:        pid,
+		StreamType: es.StreamType(),
+		PTS:        pes.PTS(),
+		DTS:        pes.DTS(),
+	}
+	pts := a.clock(pes.PTS())
+	dts := a.clock(pes.DTS())
+	record.Delay = seconds(dts - t)
+
+	if isVideo {
+		a.lastVideoPTS, a.hasVideoPTS = pts, true
+		if a.std == nil {
+			if params := findBufferParams(es.StreamType(), pes.Payload()); params != nil {
+				a.std = newTransportSTD(params)
+				a.std.time = t
+			}
+		}
+		if a.std != nil {
+			a.std.advance(t)
+			record.TB, record.MB, record.EB, record.Violations = a.std.reset()
+			if record.Violations != 0 {
+				a.log(packet, record.Violations)
+			}
+			a.std.begin(dts)
+			a.std.arrive(t, len(payload))
+		}
+	} else if a.hasVideoPTS {
+		record.AVOffset = seconds(pts - a.lastVideoPTS)
+		record.HasAVOffset = true
+	}
+
+	a.records++
+	if a.handler == nil {
+		return nil
+	}
+	return a.handler(record)
+}
+
+// handlePCR returns the time of a PCR in seconds from the first one.
+func (a *Analyzer) handlePCR(packet tsparser.Packet) float64 {
+	if a.timeline.Feed(packet) {
+		a.log(packet, "PCR discontinuity")
+	}
+
+	clock := a.timeline.Clock()
+	if !a.hasOrigin {
+		a.hasOrigin, a.origin = true, clock
+	}
+
+	return float64(clock-a.origin) / tsparser.ClockRate
+}
+
+// clock converts a timestamp into seconds from the first PCR.
+func (a *Analyzer) clock(ts uint64) float64 {
+	return float64(a.timeline.Timestamp(ts)-a.origin) / tsparser.ClockRate
+}
+
+func seconds(t float64) time.Duration {
+	return time.Duration(t * float64(time.Second))
+}
+
+func (a *Analyzer) handleTables(packet tsparser.Packet) {
+	tables, err := a.collector.Feed(packet)
+	if err != nil {
+		a.log(packet, err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			a.log(packet, "CRC mismatch")
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			a.program.Update(tsparser.ParseProgramAssociationSection(table), a.collector)
+		case tsparser.ProgramMapTable:
+			if table.TableIdExtension() == a.program.ProgramNumber() {
+				a.handleProgramMap(tsparser.ParseProgramMapSection(table))
+			}
+		}
+	}
+}
+
+func (a *Analyzer) handleProgramMap(pmt *tsparser.ProgramMapSection) {
+	a.timeline.SetPCRPID(pmt.PCRPID())
+
+	streams := make(map[tsparser.PID]*tsparser.ElementaryStream)
+	for _, es := range pmt.Streams() {
+		if es.StreamType().IsVideo() || es.StreamType().IsAudio() {
+			streams[es.PID()] = es
+		}
+	}
+
+	v := video.FindStream(pmt)
+	if v == nil || a.video == nil || v.PID() != a.video.PID() || v.StreamType() != a.video.StreamType() {
+		a.std = nil
+	}
+	a.video, a.streams = v, streams
+}
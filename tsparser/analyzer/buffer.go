@@ -0,0 +1,311 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+	"github.com/yosida95/tsparser/tsparser/h264"
+	"github.com/yosida95/tsparser/tsparser/hevc"
+	"github.com/yosida95/tsparser/tsparser/mpeg2video"
+)
+
+const transportBufferSize = 512
+
+type Violation uint8
+
+const (
+	TBOverflow Violation = 1 << iota
+	MBOverflow
+	EBOverflow
+	EBUnderflow
+)
+
+var violationNames = []string{"TB overflow", "MB overflow", "EB overflow", "EB underflow"}
+
+func (v Violation) String() string {
+	var names []string
+	for i, name := range violationNames {
+		if v&(1<<uint(i)) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// bufferParams holds the parameters of the T-STD for a video stream in
+// bytes and bytes per second.
+type bufferParams struct {
+	rx  float64
+	rbx float64
+	mbs float64
+	ebs float64
+}
+
+// newBufferParams derives the leak method parameters of ISO/IEC 13818-1
+// 2.4.2 and 2.14.3 from the maximum bit rate and the coded picture buffer
+// size in bits.
+func newBufferParams(maxBitRate, cpbSize float64) *bufferParams {
+	rate := maxBitRate
+	if rate < 2000000 {
+		rate = 2000000
+	}
+
+	return &bufferParams{
+		rx:  1.2 * maxBitRate / 8,
+		rbx: maxBitRate / 8,
+		mbs: (0.004*rate + rate/750) / 8,
+		ebs: cpbSize / 8,
+	}
+}
+
+// MaxBR and MaxCPB of Table A-1 of ITU-T H.264 in units of
+// cpbBrNalFactor bits/s and bits.
+var h264Levels = map[uint8][2]float64{
+	9: {128, 350}, 10: {64, 175}, 11: {192, 500}, 12: {384, 1000}, 13: {768, 2000},
+	20: {2000, 2000}, 21: {4000, 4000}, 22: {4000, 4000},
+	30: {10000, 10000}, 31: {14000, 14000}, 32: {20000, 20000},
+	40: {20000, 25000}, 41: {50000, 62500}, 42: {50000, 62500},
+	50: {135000, 135000}, 51: {240000, 240000}, 52: {240000, 240000},
+	60: {240000, 240000}, 61: {480000, 480000}, 62: {800000, 800000},
+}
+
+func h264NalFactor(profileIdc uint8) float64 {
+	switch profileIdc {
+	case 100:
+		return 1500
+	case 110:
+		return 3600
+	case 122, 244:
+		return 4800
+	}
+
+	return 1200
+}
+
+// MaxBR and MaxCPB of Table A.8 of ITU-T H.265 for the Main and High tiers
+// in units of 1100 bits/s and bits.
+var hevcLevels = map[uint8][2][2]float64{
+	30:  {{128, 350}, {128, 350}},
+	60:  {{1500, 1500}, {1500, 1500}},
+	63:  {{3000, 3000}, {3000, 3000}},
+	90:  {{6000, 6000}, {6000, 6000}},
+	93:  {{10000, 10000}, {10000, 10000}},
+	120: {{12000, 12000}, {30000, 30000}},
+	123: {{20000, 20000}, {50000, 50000}},
+	150: {{25000, 25000}, {100000, 100000}},
+	153: {{40000, 40000}, {160000, 160000}},
+	156: {{60000, 60000}, {240000, 240000}},
+	180: {{60000, 60000}, {240000, 240000}},
+	183: {{120000, 120000}, {480000, 480000}},
+	186: {{240000, 240000}, {800000, 800000}},
+}
+
+// findBufferParams looks for the sequence parameters in the payload of a
+// PES packet of a video stream.
+func findBufferParams(t tsparser.StreamType, payload []byte) *bufferParams {
+	units, rest := bitstream.SplitAnnexB(payload)
+	if len(rest) > 3 {
+		units = append(units, rest[3:])
+	}
+
+	for _, unit := range units {
+		if len(unit) < 2 {
+			continue
+		}
+
+		switch t {
+		case tsparser.MPEG1VideoStream, tsparser.MPEG2VideoStream:
+			if unit[0] != mpeg2video.SequenceHeaderCode {
+				continue
+			}
+			if h, err := mpeg2video.ParseSequenceHeader(unit); err == nil && h.BitRate() > 0 {
+				return newBufferParams(float64(h.BitRate()), float64(h.VBVBufferSize()))
+			}
+		case tsparser.H264VideoStream:
+			if h264.NALUnit(unit).Type() != h264.SPSNAL {
+				continue
+			}
+			sps, err := h264.ParseSPS(h264.NALUnit(unit))
+			if err != nil {
+				continue
+			}
+			level := sps.LevelIdc
+			if level == 11 && sps.ConstraintFlags&0x10 > 0 {
+				level = 9
+			}
+			if limits, ok := h264Levels[level]; ok {
+				factor := h264NalFactor(sps.ProfileIdc)
+				return newBufferParams(factor*limits[0], factor*limits[1])
+			}
+		case tsparser.HEVCVideoStream:
+			if hevc.NALUnit(unit).Type() != hevc.SPSNUT {
+				continue
+			}
+			sps, err := hevc.ParseSPS(hevc.NALUnit(unit))
+			if err != nil {
+				continue
+			}
+			if limits, ok := hevcLevels[sps.ProfileTierLevel.LevelIdc]; ok {
+				tier := 0
+				if sps.ProfileTierLevel.Tier {
+					tier = 1
+				}
+				return newBufferParams(1100*limits[tier][0], 1100*limits[tier][1])
+			}
+		}
+	}
+
+	return nil
+}
+
+type accessUnit struct {
+	dts      float64
+	size     int
+	complete bool
+	late     bool
+}
+
+// transportSTD simulates the TB, MB and EB buffers of a video stream.
+// Access units are removed from EB at their DTS.
+type transportSTD struct {
+	params *bufferParams
+	time   float64
+
+	tb, mb, eb float64
+	debt       float64
+	units      []*accessUnit
+
+	peakTB, peakMB, peakEB float64
+	violations             Violation
+}
+
+func newTransportSTD(params *bufferParams) *transportSTD {
+	return &transportSTD{
+		params: params,
+	}
+}
+
+func (b *transportSTD) drain(until float64) {
+	if until <= b.time {
+		return
+	}
+
+	dt := until - b.time
+	b.time = until
+
+	x := b.tb
+	if max := b.params.rx * dt; x > max {
+		x = max
+	}
+	b.tb -= x
+	b.mb += x
+
+	y := b.mb
+	if max := b.params.rbx * dt; y > max {
+		y = max
+	}
+	b.mb -= y
+	if b.debt > 0 {
+		paid := b.debt
+		if paid > y {
+			paid = y
+		}
+		b.debt -= paid
+		y -= paid
+	}
+	b.eb += y
+
+	if b.mb > b.params.mbs {
+		b.violations |= MBOverflow
+	}
+	if b.eb > b.params.ebs {
+		b.violations |= EBOverflow
+	}
+	b.peak()
+}
+
+// advance runs the model until t, removing the access units whose DTS is
+// reached on the way.
+func (b *transportSTD) advance(t float64) {
+	for len(b.units) > 0 && b.units[0].dts <= t {
+		au := b.units[0]
+		if !au.complete {
+			if !au.late {
+				au.late = true
+				b.violations |= EBUnderflow
+			}
+			break
+		}
+
+		b.drain(au.dts)
+		b.remove(au)
+		b.units = b.units[1:]
+	}
+
+	b.drain(t)
+}
+
+func (b *transportSTD) remove(au *accessUnit) {
+	size := float64(au.size)
+	if b.eb < size {
+		if !au.late {
+			b.violations |= EBUnderflow
+		}
+		b.debt += size - b.eb
+		b.eb = 0
+		return
+	}
+
+	b.eb -= size
+}
+
+// arrive adds the payload of a transport packet arriving at t.
+func (b *transportSTD) arrive(t float64, n int) {
+	b.advance(t)
+	b.tb += float64(n)
+	if b.tb > transportBufferSize {
+		b.violations |= TBOverflow
+	}
+	if len(b.units) > 0 {
+		b.units[len(b.units)-1].size += n
+	}
+	b.peak()
+}
+
+// begin starts an access unit decoded at dts, completing the last one.
+func (b *transportSTD) begin(dts float64) {
+	b.complete()
+	b.units = append(b.units, &accessUnit{dts: dts})
+}
+
+func (b *transportSTD) complete() {
+	if n := len(b.units); n > 0 {
+		b.units[n-1].complete = true
+	}
+}
+
+func (b *transportSTD) peak() {
+	if b.tb > b.peakTB {
+		b.peakTB = b.tb
+	}
+	if b.mb > b.peakMB {
+		b.peakMB = b.mb
+	}
+	if b.eb > b.peakEB {
+		b.peakEB = b.eb
+	}
+}
+
+// reset returns the peak occupancies and the violations since the last
+// call.
+func (b *transportSTD) reset() (tb, mb, eb int, violations Violation) {
+	tb, mb, eb, violations = int(b.peakTB), int(b.peakMB), int(b.peakEB), b.violations
+	b.peakTB, b.peakMB, b.peakEB, b.violations = b.tb, b.mb, b.eb, 0
+	return
+}
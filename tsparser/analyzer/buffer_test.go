@@ -0,0 +1,161 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package analyzer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+func approximately(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func TestViolationString(t *testing.T) {
+	tests := []struct {
+		v        Violation
+		expected string
+	}{
+		{0, ""},
+		{TBOverflow, "TB overflow"},
+		{MBOverflow | EBUnderflow, "MB overflow, EB underflow"},
+	}
+
+	for i, test := range tests {
+		if s := test.v.String(); s != test.expected {
+			t.Errorf("%d: %q, want %q", i, s, test.expected)
+		}
+	}
+}
+
+func TestFindBufferParams(t *testing.T) {
+	tests := []struct {
+		streamType tsparser.StreamType
+		payload    []byte
+		expected   bufferParams
+	}{
+		// MPEG-2 20 Mbps with vbv_buffer_size 597.
+		{tsparser.MPEG2VideoStream, fixture.StartCodes(
+			[]byte{0xb3, 0x5a, 0x04, 0x38, 0x34, 0x30, 0xd4, 0x32, 0xa8},
+			[]byte{0xb5, 0x14, 0x42, 0x00, 0x01, 0x00, 0x00},
+		), bufferParams{
+			rx:  3000000,
+			rbx: 2500000,
+			mbs: (0.004*20000000 + 20000000/750.0) / 8,
+			ebs: 597 * 16 * 1024 / 8,
+		}},
+		// H.264 High profile level 4.0.
+		{tsparser.H264VideoStream, fixture.StartCodes(
+			[]byte{0x09, 0xf0},
+			[]byte{
+				0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78, 0x02, 0x27, 0xe5, 0xc0, 0x5a,
+				0x80, 0x80, 0x80, 0xa0, 0x00, 0x00, 0x7d, 0x20, 0x00, 0x1d, 0x4c, 0x10, 0x80,
+			},
+		), bufferParams{
+			rx:  1.2 * 30000000 / 8,
+			rbx: 30000000 / 8,
+			mbs: (0.004*30000000 + 30000000/750.0) / 8,
+			ebs: 37500000 / 8,
+		}},
+		// HEVC Main 10 profile, Main tier, level 5.1.
+		{tsparser.HEVCVideoStream, fixture.StartCodes(
+			[]byte{
+				0x42, 0x01, 0x01, 0x02, 0x20, 0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x03, 0x00, 0x99, 0xa0, 0x01, 0xe0, 0x20, 0x02, 0x1c,
+				0x4d, 0x96, 0x67, 0x92, 0x46, 0xd9, 0xaf, 0x77, 0x80, 0xb5, 0x09, 0x10,
+				0x09, 0x04, 0x00, 0x00, 0x0f, 0xa4, 0x00, 0x03, 0xa9, 0x80, 0x20,
+			},
+		), bufferParams{
+			rx:  1.2 * 44000000 / 8,
+			rbx: 44000000 / 8,
+			mbs: (0.004*44000000 + 44000000/750.0) / 8,
+			ebs: 44000000 / 8,
+		}},
+	}
+
+	for i, test := range tests {
+		p := findBufferParams(test.streamType, test.payload)
+		if p == nil {
+			t.Errorf("%d: parameters are not found", i)
+			continue
+		}
+		if !approximately(p.rx, test.expected.rx) || !approximately(p.rbx, test.expected.rbx) ||
+			!approximately(p.mbs, test.expected.mbs) || !approximately(p.ebs, test.expected.ebs) {
+			t.Errorf("%d: parameters = %+v, want %+v", i, *p, test.expected)
+		}
+	}
+
+	if p := findBufferParams(tsparser.H264VideoStream, fixture.StartCodes([]byte{0x09, 0xf0})); p != nil {
+		t.Errorf("parameters = %+v without SPS", *p)
+	}
+	// MB size of a low bit rate stream is based on 2 Mbps.
+	if p := newBufferParams(1000000, 1000000); !approximately(p.mbs, (0.004*2000000+2000000/750.0)/8) {
+		t.Errorf("mbs = %f", p.mbs)
+	}
+}
+
+func TestTransportSTD(t *testing.T) {
+	b := newTransportSTD(&bufferParams{rx: 2000, rbx: 1000, mbs: 1000, ebs: 1000})
+	b.begin(1)
+	b.arrive(0, 184)
+	b.arrive(0, 184)
+	b.begin(2)
+	b.advance(1)
+	if tb, mb, eb, violations := b.reset(); tb != 368 || mb != 0 || eb != 368 || violations != 0 {
+		t.Errorf("TB = %d, MB = %d, EB = %d, %v", tb, mb, eb, violations)
+	}
+	if b.eb != 0 || len(b.units) != 1 {
+		t.Errorf("EB = %f, %d access units", b.eb, len(b.units))
+	}
+
+	// An access unit which is not complete at its DTS underflows once.
+	b.arrive(1.5, 184)
+	b.advance(2.5)
+	if _, _, _, violations := b.reset(); violations != EBUnderflow {
+		t.Errorf("violations = %v", violations)
+	}
+	b.complete()
+	b.advance(3)
+	if _, _, _, violations := b.reset(); violations != 0 || len(b.units) != 0 || b.eb != 0 {
+		t.Errorf("violations = %v, %d access units, EB = %f", violations, len(b.units), b.eb)
+	}
+
+	b.arrive(4, 184)
+	b.arrive(4, 184)
+	b.arrive(4, 184)
+	if _, _, _, violations := b.reset(); violations != TBOverflow {
+		t.Errorf("violations = %v", violations)
+	}
+}
+
+func TestTransportSTDOverflow(t *testing.T) {
+	b := newTransportSTD(&bufferParams{rx: 2000, rbx: 300, mbs: 50, ebs: 200})
+	b.arrive(0, 184)
+	b.arrive(0, 184)
+	b.advance(1)
+	if tb, mb, eb, violations := b.reset(); tb != 368 || mb != 68 || eb != 300 || violations != MBOverflow|EBOverflow {
+		t.Errorf("TB = %d, MB = %d, EB = %d, %v", tb, mb, eb, violations)
+	}
+}
+
+func TestTransportSTDUnderflow(t *testing.T) {
+	b := newTransportSTD(&bufferParams{rx: 2000, rbx: 100, mbs: 1000, ebs: 1000})
+	b.begin(1)
+	b.arrive(0, 184)
+	b.complete()
+	b.advance(1)
+	if _, _, _, violations := b.reset(); violations != EBUnderflow || b.debt != 84 {
+		t.Errorf("violations = %v, debt = %f", violations, b.debt)
+	}
+
+	// The rest of the access unit removed early does not reach EB.
+	b.advance(2)
+	if b.mb != 0 || b.eb != 0 || b.debt != 0 {
+		t.Errorf("MB = %f, EB = %f, debt = %f", b.mb, b.eb, b.debt)
+	}
+}
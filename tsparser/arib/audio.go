@@ -0,0 +1,111 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	AudioSingleMono = 0x01
+	AudioDualMono   = 0x02
+	AudioStereo     = 0x03
+	Audio2_1        = 0x04
+	Audio3_0        = 0x05
+	Audio2_2        = 0x06
+	Audio3_1        = 0x07
+	Audio3_2        = 0x08
+	Audio3_2LFE     = 0x09
+	Audio22_2       = 0x11
+)
+
+var audioSamplingRates = map[uint8]int{
+	1: 16000, 2: 22050, 3: 24000, 5: 32000, 6: 44100, 7: 48000,
+}
+
+var audioChannels = map[uint8]int{
+	AudioSingleMono: 1, AudioDualMono: 2, AudioStereo: 2, Audio2_1: 3, Audio3_0: 3,
+	Audio2_2: 4, Audio3_1: 4, Audio3_2: 5, Audio3_2LFE: 6, Audio22_2: 24,
+}
+
+type AudioComponent struct {
+	StreamContent     uint8
+	ComponentType     uint8
+	ComponentTag      uint8
+	StreamType        tsparser.StreamType
+	SimulcastGroupTag uint8
+	MultiLingual      bool
+	MainComponent     bool
+	QualityIndicator  uint8
+	SamplingRateCode  uint8
+	Language          string
+	Language2         string
+	Text              []byte
+}
+
+func ParseAudioComponentDescriptor(d tsparser.Descriptor) *AudioComponent {
+	if DescriptorTag(d.Tag()) != AudioComponentDescriptor || d.Length() < 9 {
+		return nil
+	}
+
+	payload := d.Payload()
+	c := &AudioComponent{
+		StreamContent:     payload[0] & 0x0f,
+		ComponentType:     payload[1],
+		ComponentTag:      payload[2],
+		StreamType:        tsparser.StreamType(payload[3]),
+		SimulcastGroupTag: payload[4],
+		MultiLingual:      payload[5]&0x80 > 0,
+		MainComponent:     payload[5]&0x40 > 0,
+		QualityIndicator:  uint8(payload[5]&0x30) >> 4,
+		SamplingRateCode:  uint8(payload[5]&0x0e) >> 1,
+		Language:          string(payload[6:9]),
+	}
+
+	rest := payload[9:]
+	if c.MultiLingual && len(rest) >= 3 {
+		c.Language2 = string(rest[0:3])
+		rest = rest[3:]
+	}
+	c.Text = rest
+
+	return c
+}
+
+// ComponentTag returns component_tag of a stream given by the stream
+// identifier descriptor in PMT.
+func ComponentTag(es *tsparser.ElementaryStream) (uint8, bool) {
+	for _, d := range es.Descriptors() {
+		if DescriptorTag(d.Tag()) == StreamIdentifierDescriptor && d.Length() >= 1 && len(d) >= 3 {
+			return d.Payload()[0], true
+		}
+	}
+
+	return 0, false
+}
+
+// FindAudioComponent returns the audio component descriptor of an event
+// in EIT, which describes the stream of the component tag.
+func FindAudioComponent(event *Event, componentTag uint8) *AudioComponent {
+	for _, d := range event.Descriptors() {
+		if c := ParseAudioComponentDescriptor(d); c != nil && c.ComponentTag == componentTag {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (c *AudioComponent) DualMono() bool {
+	return c.ComponentType == AudioDualMono
+}
+
+func (c *AudioComponent) Channels() int {
+	return audioChannels[c.ComponentType]
+}
+
+func (c *AudioComponent) SamplingRate() int {
+	return audioSamplingRates[c.SamplingRateCode]
+}
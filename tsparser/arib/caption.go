@@ -0,0 +1,77 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"io"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+// DataComponentCaption is data_component_id of the captions and the
+// superimposes.
+const DataComponentCaption uint16 = 0x0008
+
+const (
+	SynchronizedPESDataIdentifier  = 0x80
+	AsynchronousPESDataIdentifier  = 0x81
+	captionPrivateStreamIdentifier = 0xff
+)
+
+func ParseCaptionDataGroup(payload []byte) ([]byte, bool) {
+	if len(payload) < 3 {
+		return nil, false
+	} else if payload[0] != SynchronizedPESDataIdentifier && payload[0] != AsynchronousPESDataIdentifier {
+		return nil, false
+	} else if payload[1] != captionPrivateStreamIdentifier {
+		return nil, false
+	}
+
+	start := 3 + int(payload[2]&0x0f)
+	if start > len(payload) {
+		return nil, false
+	}
+	return payload[start:], true
+}
+
+// IsCaptionStream reports whether a stream is of the captions, which is
+// identified by the data component descriptor in PMT.
+func IsCaptionStream(es *tsparser.ElementaryStream) bool {
+	if es.StreamType() != tsparser.PrivatePESStream {
+		return false
+	}
+
+	for _, d := range es.Descriptors() {
+		if DescriptorTag(d.Tag()) != DataComponentDescriptor || d.Length() < 2 || len(d) < int(d.Length())+2 {
+			continue
+		}
+		payload := d.Payload()
+		if uint16(payload[0])<<8|uint16(payload[1]) == DataComponentCaption {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CaptionWriter struct {
+	w io.Writer
+}
+
+func NewCaptionWriter(w io.Writer) *CaptionWriter {
+	return &CaptionWriter{w: w}
+}
+
+func (w *CaptionWriter) Write(payload []byte) (int, error) {
+	dataGroup, ok := ParseCaptionDataGroup(payload)
+	if !ok {
+		return len(payload), nil
+	}
+
+	if _, err := w.w.Write(dataGroup); err != nil {
+		return 0, err
+	}
+	return len(payload), nil
+}
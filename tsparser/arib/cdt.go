@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	DataTypeLogo uint8 = 0x01
+)
+
+type CommonDataSection struct {
+	downloadDataId    uint16
+	originalNetworkId uint16
+	dataType          uint8
+	version           uint8
+	sectionNumber     uint8
+	lastSectionNumber uint8
+	descriptors       []tsparser.Descriptor
+	dataModule        []byte
+}
+
+func ParseCommonDataSection(table tsparser.Table) *CommonDataSection {
+	sec := &CommonDataSection{
+		downloadDataId:    table.TableIdExtension(),
+		version:           table.VersionNumber(),
+		sectionNumber:     table.SectionNumber(),
+		lastSectionNumber: table.LastSectionNumber(),
+	}
+
+	payload := table.Data()
+	if len(payload) < 5 {
+		return sec
+	}
+	sec.originalNetworkId = uint16(payload[0])<<8 | uint16(payload[1])
+	sec.dataType = payload[2]
+
+	length := int(payload[3]&0x0f)<<8 | int(payload[4])
+	if 5+length > len(payload) {
+		return sec
+	}
+	sec.descriptors = tsparser.ParseDescriptors(payload[5 : 5+length])
+	sec.dataModule = payload[5+length:]
+
+	return sec
+}
+
+func (s *CommonDataSection) DownloadDataId() uint16 {
+	return s.downloadDataId
+}
+
+func (s *CommonDataSection) OriginalNetworkId() uint16 {
+	return s.originalNetworkId
+}
+
+func (s *CommonDataSection) DataType() uint8 {
+	return s.dataType
+}
+
+func (s *CommonDataSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *CommonDataSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *CommonDataSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *CommonDataSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
+
+// DataModule returns data_module_byte, whose syntax is defined by the
+// data_type.
+func (s *CommonDataSection) DataModule() []byte {
+	return s.dataModule
+}
@@ -81,5 +81,6 @@ const (
 	ConditionalPlaybackDescriptor         DescriptorTag = 0xF8
 	PartialReceptionDescriptor            DescriptorTag = 0xFB
 	EmergencyInformationDescriptor        DescriptorTag = 0xFC
+	DataComponentDescriptor               DescriptorTag = 0xFD
 	SystemManagementDescriptor            DescriptorTag = 0xFE
 )
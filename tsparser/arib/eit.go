@@ -0,0 +1,30 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/si"
+)
+
+type (
+	Event                   = si.Event
+	EventInformationSection = si.EventInformationSection
+)
+
+func NewEvent(eventId uint16, startTime time.Time, duration time.Duration, descriptors ...tsparser.Descriptor) *Event {
+	return si.NewEvent(eventId, startTime, duration, descriptors...)
+}
+
+// NewEventInformationSection returns an EIT whose start_time is in JST.
+func NewEventInformationSection(tableId tsparser.TableId, serviceId, transportStreamId, originalNetworkId uint16) *EventInformationSection {
+	return si.NewEventInformationSection(tableId, serviceId, transportStreamId, originalNetworkId, jst)
+}
+
+func ParseEventInformationSection(table tsparser.Table) *EventInformationSection {
+	return si.ParseEventInformationSection(table, jst)
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"image/color"
+	"strings"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dsmcc"
+)
+
+var ErrInvalidPNG = errors.New("Invalid PNG")
+
+const (
+	LogoTypeSD43Small  uint8 = 0x00 // 48x24
+	LogoTypeSD169Small uint8 = 0x01 // 36x24
+	LogoTypeHDSmall    uint8 = 0x02 // 48x27
+	LogoTypeSD43Large  uint8 = 0x03 // 72x36
+	LogoTypeSD169Large uint8 = 0x04 // 54x36
+	LogoTypeHDLarge    uint8 = 0x05 // 64x36
+)
+
+const (
+	LogoTransmissionCDT        uint8 = 0x01
+	LogoTransmissionLogoId     uint8 = 0x02
+	LogoTransmissionSimpleLogo uint8 = 0x03
+)
+
+// CommonCLUT is the common fixed colour table of ARIB STD-B24, which the
+// logos are drawn with.
+var CommonCLUT = make(color.Palette, 128)
+
+func init() {
+	levels := []uint8{0, 85, 170, 255}
+	colors := []color.NRGBA{
+		{0, 0, 0, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+		{0, 0, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+		{0, 0, 0, 0},
+		{170, 0, 0, 255}, {0, 170, 0, 255}, {170, 170, 0, 255},
+		{0, 0, 170, 255}, {170, 0, 170, 255}, {0, 170, 170, 255}, {170, 170, 170, 255},
+	}
+
+	// Entries 16 to 64 are the other combinations of the four levels.
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				if r%255 == 0 && g%255 == 0 && b%255 == 0 || r%170 == 0 && g%170 == 0 && b%170 == 0 {
+					continue
+				}
+				colors = append(colors, color.NRGBA{r, g, b, 255})
+			}
+		}
+	}
+
+	// Entries 65 to 127 are the colours other than black and transparent
+	// at half transparency.
+	for i, c := range colors[:65] {
+		if i != 0 && i != 8 {
+			colors = append(colors, color.NRGBA{c.R, c.G, c.B, 128})
+		}
+	}
+
+	for i, c := range colors {
+		CommonCLUT[i] = c
+	}
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func appendPNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	n := len(data)
+	buf.Write([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+	buf.WriteString(typ)
+	buf.Write(data)
+
+	crc := crc32.Update(crc32.ChecksumIEEE([]byte(typ)), crc32.IEEETable, data)
+	buf.Write([]byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)})
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ApplyCommonCLUT inserts CommonCLUT into a PNG of indexed colours without
+// PLTE, which the logos are transmitted as.  Other PNGs are returned as
+// they are.
+func ApplyCommonCLUT(png []byte) ([]byte, error) {
+	if !bytes.HasPrefix(png, pngSignature) {
+		return nil, ErrInvalidPNG
+	}
+
+	var chunks []pngChunk
+	for i := len(pngSignature); i < len(png); {
+		if i+12 > len(png) {
+			return nil, ErrInvalidPNG
+		}
+		n := int(tsparser.AsUint32(png[i : i+4]))
+		if n < 0 || i+12+n > len(png) {
+			return nil, ErrInvalidPNG
+		}
+		chunks = append(chunks, pngChunk{string(png[i+4 : i+8]), png[i+8 : i+8+n]})
+		i += 12 + n
+	}
+
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) < 13 {
+		return nil, ErrInvalidPNG
+	}
+	bitDepth, colorType := chunks[0].data[8], chunks[0].data[9]
+	if colorType != 3 {
+		return png, nil
+	}
+	for _, chunk := range chunks {
+		if chunk.typ == "PLTE" {
+			return png, nil
+		}
+	}
+
+	n := len(CommonCLUT)
+	if bitDepth < 8 && 1<<bitDepth < n {
+		n = 1 << bitDepth
+	}
+	palette := make([]byte, 0, 3*n)
+	alpha := make([]byte, 0, n)
+	for _, c := range CommonCLUT[:n] {
+		c := c.(color.NRGBA)
+		palette = append(palette, c.R, c.G, c.B)
+		alpha = append(alpha, c.A)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	for i, chunk := range chunks {
+		appendPNGChunk(&buf, chunk.typ, chunk.data)
+		if i == 0 {
+			appendPNGChunk(&buf, "PLTE", palette)
+			appendPNGChunk(&buf, "tRNS", alpha)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+type LogoTransmission struct {
+	Type           uint8
+	LogoId         uint16
+	LogoVersion    uint16
+	DownloadDataId uint16
+	LogoChar       []byte
+}
+
+func ParseLogoTransmissionDescriptor(d tsparser.Descriptor) *LogoTransmission {
+	if DescriptorTag(d.Tag()) != LogoTransmissionDescriptor || d.Length() < 1 || len(d) < int(d.Length())+2 {
+		return nil
+	}
+
+	payload := d.Payload()
+	t := &LogoTransmission{Type: payload[0]}
+	switch t.Type {
+	case LogoTransmissionCDT:
+		if len(payload) < 7 {
+			return nil
+		}
+		t.LogoId = uint16(payload[1]&0x01)<<8 | uint16(payload[2])
+		t.LogoVersion = uint16(payload[3]&0x0f)<<8 | uint16(payload[4])
+		t.DownloadDataId = uint16(payload[5])<<8 | uint16(payload[6])
+	case LogoTransmissionLogoId:
+		if len(payload) < 3 {
+			return nil
+		}
+		t.LogoId = uint16(payload[1]&0x01)<<8 | uint16(payload[2])
+	case LogoTransmissionSimpleLogo:
+		t.LogoChar = payload[1:]
+	}
+
+	return t
+}
+
+func FindLogoTransmission(service *Service) *LogoTransmission {
+	for _, d := range service.Descriptors() {
+		if t := ParseLogoTransmissionDescriptor(d); t != nil {
+			return t
+		}
+	}
+
+	return nil
+}
+
+type LogoKey struct {
+	OriginalNetworkId uint16
+	LogoId            uint16
+	LogoVersion       uint16
+	LogoType          uint8
+}
+
+type LogoService struct {
+	OriginalNetworkId uint16
+	TransportStreamId uint16
+	ServiceId         uint16
+}
+
+// Logo is a logo whose PNG has CommonCLUT applied.  The services are given
+// only for the logos of the data carousel.
+type Logo struct {
+	LogoKey
+	Services []LogoService
+	PNG      []byte
+}
+
+// servesNetwork reports whether the logo is of a network, which a logo of
+// the data carousel may be shared among.
+func (l *Logo) servesNetwork(originalNetworkId uint16) bool {
+	if l.OriginalNetworkId == originalNetworkId {
+		return true
+	}
+
+	for _, s := range l.Services {
+		if s.OriginalNetworkId == originalNetworkId {
+			return true
+		}
+	}
+	return false
+}
+
+// LogoCollector collects the logos transmitted by CDT or the modules of
+// the data carousel of the common data.
+type LogoCollector struct {
+	logos map[LogoKey]*Logo
+}
+
+func NewLogoCollector() *LogoCollector {
+	return &LogoCollector{
+		logos: make(map[LogoKey]*Logo),
+	}
+}
+
+func (c *LogoCollector) add(logo *Logo) bool {
+	if _, ok := c.logos[logo.LogoKey]; ok {
+		return false
+	}
+
+	png, err := ApplyCommonCLUT(logo.PNG)
+	if err != nil {
+		return false
+	}
+	logo.PNG = png
+	c.logos[logo.LogoKey] = logo
+
+	return true
+}
+
+// Feed feeds a CDT section, and returns the logo if it is new.
+func (c *LogoCollector) Feed(table tsparser.Table) *Logo {
+	if table.TableId() != CommonDataTable || !table.CheckCRC() {
+		return nil
+	}
+
+	sec := ParseCommonDataSection(table)
+	data := sec.DataModule()
+	if sec.DataType() != DataTypeLogo || len(data) < 7 {
+		return nil
+	}
+
+	size := int(data[5])<<8 | int(data[6])
+	if 7+size > len(data) {
+		return nil
+	}
+	logo := &Logo{
+		LogoKey: LogoKey{
+			OriginalNetworkId: sec.OriginalNetworkId(),
+			LogoId:            uint16(data[1]&0x01)<<8 | uint16(data[2]),
+			LogoVersion:       uint16(data[3]&0x0f)<<8 | uint16(data[4]),
+			LogoType:          data[0],
+		},
+		PNG: data[7 : 7+size],
+	}
+	if !c.add(logo) {
+		return nil
+	}
+
+	return logo
+}
+
+// FeedModule feeds a module of the data carousel, and returns the new
+// logos if it is a module named LOGO-0n or CS_LOGO-0n.  The logos are
+// versioned by the module as they have no logo_version.
+func (c *LogoCollector) FeedModule(module *dsmcc.Module) []*Logo {
+	name := module.Name()
+	if !strings.HasPrefix(name, "LOGO-0") && !strings.HasPrefix(name, "CS_LOGO-0") {
+		return nil
+	}
+
+	data, err := module.Content()
+	if err != nil || len(data) < 3 {
+		return nil
+	}
+	logoType := data[0]
+	n := int(data[1])<<8 | int(data[2])
+
+	var logos []*Logo
+	for i, j := 0, 3; i < n && j+3 <= len(data); i++ {
+		logo := &Logo{
+			LogoKey: LogoKey{
+				LogoId:      uint16(data[j]&0x01)<<8 | uint16(data[j+1]),
+				LogoVersion: uint16(module.Version),
+				LogoType:    logoType,
+			},
+		}
+		services := int(data[j+2])
+		j += 3
+		if j+6*services+2 > len(data) {
+			break
+		}
+		for k := 0; k < services; k++ {
+			logo.Services = append(logo.Services, LogoService{
+				OriginalNetworkId: uint16(data[j])<<8 | uint16(data[j+1]),
+				TransportStreamId: uint16(data[j+2])<<8 | uint16(data[j+3]),
+				ServiceId:         uint16(data[j+4])<<8 | uint16(data[j+5]),
+			})
+			j += 6
+		}
+		size := int(data[j])<<8 | int(data[j+1])
+		j += 2
+		if j+size > len(data) {
+			break
+		}
+		logo.PNG = data[j : j+size]
+		j += size
+
+		if len(logo.Services) > 0 {
+			logo.OriginalNetworkId = logo.Services[0].OriginalNetworkId
+		}
+		if c.add(logo) {
+			logos = append(logos, logo)
+		}
+	}
+
+	return logos
+}
+
+// Find returns the logo of a type which a service of a network refers to
+// with a logo transmission descriptor.  The latest version is returned if
+// the version is unknown or not collected.
+func (c *LogoCollector) Find(originalNetworkId uint16, t *LogoTransmission, logoType uint8) *Logo {
+	if t == nil || t.Type == LogoTransmissionSimpleLogo {
+		return nil
+	}
+
+	key := LogoKey{originalNetworkId, t.LogoId, t.LogoVersion, logoType}
+	if logo, ok := c.logos[key]; ok && t.Type == LogoTransmissionCDT {
+		return logo
+	}
+
+	var latest *Logo
+	for k, logo := range c.logos {
+		if logo.servesNetwork(originalNetworkId) && k.LogoId == t.LogoId && k.LogoType == logoType {
+			if latest == nil || k.LogoVersion > latest.LogoVersion {
+				latest = logo
+			}
+		}
+	}
+
+	return latest
+}
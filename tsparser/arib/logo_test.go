@@ -0,0 +1,186 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dsmcc"
+)
+
+// logoPNG makes a 4-bit PNG of indexed colours without PLTE, as the logos
+// are transmitted.
+func logoPNG(t *testing.T) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, 4, 2), CommonCLUT[:16])
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	stripped := append([]byte(nil), pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		n := int(tsparser.AsUint32(data[i:i+4])) + 12
+		if typ := string(data[i+4 : i+8]); typ != "PLTE" && typ != "tRNS" {
+			stripped = append(stripped, data[i:i+n]...)
+		}
+		i += n
+	}
+	return stripped
+}
+
+func TestCommonCLUT(t *testing.T) {
+	expected := map[int]color.NRGBA{
+		0:   {0, 0, 0, 255},
+		7:   {255, 255, 255, 255},
+		8:   {0, 0, 0, 0},
+		15:  {170, 170, 170, 255},
+		16:  {0, 0, 85, 255},
+		64:  {255, 255, 170, 255},
+		65:  {255, 0, 0, 128},
+		127: {255, 255, 170, 128},
+	}
+	for i, c := range expected {
+		if CommonCLUT[i] != c {
+			t.Errorf("%d: %v, want %v", i, CommonCLUT[i], c)
+		}
+	}
+}
+
+func TestApplyCommonCLUT(t *testing.T) {
+	data, err := ApplyCommonCLUT(logoPNG(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	palette := img.ColorModel().(color.Palette)
+	if len(palette) != 16 {
+		t.Fatalf("%d colours, want 16", len(palette))
+	}
+	for i, c := range palette {
+		if c != CommonCLUT[i] {
+			t.Errorf("%d: %v, want %v", i, c, CommonCLUT[i])
+		}
+	}
+
+	// A PNG with PLTE is left as it is.
+	if again, err := ApplyCommonCLUT(data); err != nil || !bytes.Equal(again, data) {
+		t.Errorf("PNG with PLTE is changed: %v", err)
+	}
+	if _, err := ApplyCommonCLUT([]byte("GIF89a")); err != ErrInvalidPNG {
+		t.Errorf("err = %v, want %v", err, ErrInvalidPNG)
+	}
+}
+
+func commonDataSection(t *testing.T, originalNetworkId uint16, logoType uint8, logoId, logoVersion uint16, data []byte) tsparser.Table {
+	payload := []byte{
+		byte(originalNetworkId >> 8), byte(originalNetworkId), DataTypeLogo, 0xf0, 0x00,
+		logoType, 0xfe | byte(logoId>>8), byte(logoId), 0xf0 | byte(logoVersion>>8), byte(logoVersion),
+		byte(len(data) >> 8), byte(len(data)),
+	}
+	payload = append(payload, data...)
+
+	header := &tsparser.TableHeader{
+		TableId:              CommonDataTable,
+		TableIdExtension:     0x0001,
+		VersionNumber:        3,
+		CurrentNextIndicator: true,
+	}
+	table, err := header.Build(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestCommonDataSection(t *testing.T) {
+	sec := ParseCommonDataSection(commonDataSection(t, 0x0004, LogoTypeHDLarge, 0x0123, 0x0456, []byte("PNG")))
+	if sec.DownloadDataId() != 1 || sec.OriginalNetworkId() != 4 || sec.DataType() != DataTypeLogo || sec.VersionNumber() != 3 {
+		t.Errorf("CDT = %+v", sec)
+	}
+	if len(sec.Descriptors()) != 0 || !bytes.Equal(sec.DataModule(), []byte{0x05, 0xff, 0x23, 0xf4, 0x56, 0x00, 0x03, 'P', 'N', 'G'}) {
+		t.Errorf("data_module = % x", sec.DataModule())
+	}
+}
+
+func TestLogoCollectorCDT(t *testing.T) {
+	c := NewLogoCollector()
+	table := commonDataSection(t, 0x0004, LogoTypeHDLarge, 0x0123, 0x0456, logoPNG(t))
+	logo := c.Feed(table)
+	if logo == nil {
+		t.Fatal("logo is not collected")
+	}
+	if logo.LogoKey != (LogoKey{0x0004, 0x0123, 0x0456, LogoTypeHDLarge}) {
+		t.Errorf("logo = %+v", logo.LogoKey)
+	}
+	if _, err := png.Decode(bytes.NewReader(logo.PNG)); err != nil {
+		t.Error(err)
+	}
+	if c.Feed(table) != nil {
+		t.Error("logo is collected twice")
+	}
+
+	service := NewService(0x0400, tsparser.NewDescriptor(tsparser.DescriptorTag(LogoTransmissionDescriptor),
+		[]byte{LogoTransmissionCDT, 0xff, 0x23, 0xf4, 0x56, 0x00, 0x01}))
+	transmission := FindLogoTransmission(service)
+	if transmission == nil || transmission.LogoId != 0x0123 || transmission.LogoVersion != 0x0456 || transmission.DownloadDataId != 1 {
+		t.Fatalf("logo transmission = %+v", transmission)
+	}
+	if found := c.Find(0x0004, transmission, LogoTypeHDLarge); found != logo {
+		t.Errorf("found = %+v", found)
+	}
+	if found := c.Find(0x0007, transmission, LogoTypeHDLarge); found != nil {
+		t.Errorf("logo of another network = %+v", found)
+	}
+}
+
+func TestLogoCollectorModule(t *testing.T) {
+	logoData := logoPNG(t)
+	data := []byte{LogoTypeSD43Small, 0x00, 0x01, 0x00, 0x12, 0x01, 0x00, 0x07, 0x40, 0x01, 0x04, 0x00}
+	data = append(data, byte(len(logoData)>>8), byte(len(logoData)))
+	data = append(data, logoData...)
+
+	module := &dsmcc.Module{
+		Version:     2,
+		Descriptors: []tsparser.Descriptor{tsparser.NewDescriptor(tsparser.DescriptorTag(dsmcc.NameDescriptor), []byte("CS_LOGO-00"))},
+		Data:        data,
+	}
+	c := NewLogoCollector()
+	logos := c.FeedModule(module)
+	if len(logos) != 1 {
+		t.Fatalf("%d logos, want 1", len(logos))
+	}
+	logo := logos[0]
+	if logo.LogoKey != (LogoKey{0x0007, 0x0012, 2, LogoTypeSD43Small}) {
+		t.Errorf("logo = %+v", logo.LogoKey)
+	}
+	if len(logo.Services) != 1 || logo.Services[0] != (LogoService{0x0007, 0x4001, 0x0400}) {
+		t.Errorf("services = %+v", logo.Services)
+	}
+
+	// The logos of the data carousel are found without logo_version.
+	transmission := &LogoTransmission{Type: LogoTransmissionLogoId, LogoId: 0x0012}
+	if found := c.Find(0x0007, transmission, LogoTypeSD43Small); found != logo {
+		t.Errorf("found = %+v", found)
+	}
+
+	module.Descriptors = []tsparser.Descriptor{tsparser.NewDescriptor(tsparser.DescriptorTag(dsmcc.NameDescriptor), []byte("BIT"))}
+	if logos := NewLogoCollector().FeedModule(module); len(logos) != 0 {
+		t.Errorf("logos of another module = %+v", logos)
+	}
+}
@@ -10,7 +10,38 @@ import (
 	"github.com/yosida95/tsparser/tsparser"
 )
 
+const (
+	NetworkInformationActualTable  tsparser.TableId = 0x40
+	NetworkInformationOtherTable   tsparser.TableId = 0x41
+	ServiceDescriptionActualTable  tsparser.TableId = 0x42
+	ServiceDescriptionOtherTable   tsparser.TableId = 0x46
+	BouquetAssociationTable        tsparser.TableId = 0x4A
+	EventInformationActualPFTable  tsparser.TableId = 0x4E
+	EventInformationOtherPFTable   tsparser.TableId = 0x4F
+	EventInformationActualSchTable tsparser.TableId = 0x50
+	EventInformationOtherSchTable  tsparser.TableId = 0x60
+	TimeDateTable                  tsparser.TableId = 0x70
+	RunningStatusTable             tsparser.TableId = 0x71
+	StuffingTable                  tsparser.TableId = 0x72
+	TimeOffsetTable                tsparser.TableId = 0x73
+	CommonDataTable                tsparser.TableId = 0xC8
+)
+
+const (
+	NetworkInformationPID tsparser.PID = 0x0010
+	ServiceDescriptionPID tsparser.PID = 0x0011
+	EventInformationPID   tsparser.PID = 0x0012
+	TimeDatePID           tsparser.PID = 0x0014
+	H_EventInformationPID tsparser.PID = 0x0026
+	M_EventInformationPID tsparser.PID = 0x0027
+	CommonDataPID         tsparser.PID = 0x0029
+)
+
 func ParseTimeDateSection(table tsparser.Table) time.Time {
 	JSTTime := table.Data()
 	return parseJSTTime(JSTTime)
 }
+
+func MarshalTimeDateSection(t time.Time) (tsparser.Table, error) {
+	return tsparser.NewShortTable(TimeDateTable, true, encodeJSTTime(t))
+}
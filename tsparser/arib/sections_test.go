@@ -0,0 +1,27 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package arib
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimeDateSectionMarshal(t *testing.T) {
+	now := time.Date(2014, time.October, 19, 21, 54, 30, 0, jstLocation())
+	table, err := MarshalTimeDateSection(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2014-10-19 is MJD 56949.
+	if !bytes.Equal(table, []byte{0x70, 0x70, 0x05, 0xde, 0x75, 0x21, 0x54, 0x30}) {
+		t.Errorf("TDT = %x", []byte(table))
+	}
+	if got := ParseTimeDateSection(table); !got.Equal(now) {
+		t.Errorf("time = %v, want %v", got, now)
+	}
+}
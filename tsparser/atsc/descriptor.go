@@ -0,0 +1,41 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	AC3AudioStreamDescriptor         tsparser.DescriptorTag = 0x81
+	CaptionServiceDescriptor         tsparser.DescriptorTag = 0x86
+	ContentAdvisoryDescriptor        tsparser.DescriptorTag = 0x87
+	ExtendedChannelNameDescriptor    tsparser.DescriptorTag = 0xA0
+	ServiceLocationDescriptor        tsparser.DescriptorTag = 0xA1
+	TimeShiftedServiceDescriptor     tsparser.DescriptorTag = 0xA2
+	ComponentNameDescriptor          tsparser.DescriptorTag = 0xA3
+	DCCDepartingRequestDescriptor    tsparser.DescriptorTag = 0xA8
+	DCCArrivingRequestDescriptor     tsparser.DescriptorTag = 0xA9
+	RedistributionControlDescriptor  tsparser.DescriptorTag = 0xAA
+	GenreDescriptor                  tsparser.DescriptorTag = 0xAB
+	EnhancedAC3AudioStreamDescriptor tsparser.DescriptorTag = 0xCC
+)
+
+func ParseExtendedChannelNameDescriptor(d tsparser.Descriptor) MultipleString {
+	if len(d) < 2 || d.Tag() != ExtendedChannelNameDescriptor || len(d) < int(d.Length())+2 {
+		return nil
+	}
+
+	return ParseMultipleString(d.Payload())
+}
+
+// ParseComponentNameDescriptor returns component_name_string.
+func ParseComponentNameDescriptor(d tsparser.Descriptor) MultipleString {
+	if len(d) < 2 || d.Tag() != ComponentNameDescriptor || len(d) < int(d.Length())+2 {
+		return nil
+	}
+
+	return ParseMultipleString(d.Payload())
+}
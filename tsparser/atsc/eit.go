@@ -0,0 +1,173 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type Event struct {
+	eventId     uint16
+	startTime   uint32
+	etmLocation uint8
+	duration    time.Duration
+	title       MultipleString
+	descriptors []tsparser.Descriptor
+}
+
+func (e *Event) EventId() uint16 {
+	return e.eventId
+}
+
+// StartTime returns start_time in seconds since the GPS epoch.
+func (e *Event) StartTime() uint32 {
+	return e.startTime
+}
+
+func (e *Event) ETMLocation() uint8 {
+	return e.etmLocation
+}
+
+func (e *Event) Duration() time.Duration {
+	return e.duration
+}
+
+func (e *Event) Title() MultipleString {
+	return e.title
+}
+
+func (e *Event) Descriptors() []tsparser.Descriptor {
+	return e.descriptors
+}
+
+type EventInformationSection struct {
+	sourceId          uint16
+	version           uint8
+	sectionNumber     uint8
+	lastSectionNumber uint8
+	events            []*Event
+}
+
+func ParseEventInformationSection(table tsparser.Table) *EventInformationSection {
+	sec := &EventInformationSection{
+		sourceId:          table.TableIdExtension(),
+		version:           table.VersionNumber(),
+		sectionNumber:     table.SectionNumber(),
+		lastSectionNumber: table.LastSectionNumber(),
+	}
+
+	payload := table.Data()
+	if len(payload) < 2 {
+		return sec
+	}
+
+	count := int(payload[1])
+	i := 2
+	for n := 0; n < count && i+10 <= len(payload); n++ {
+		e := &Event{
+			eventId: uint16(payload[i]&0x3f)<<8 | uint16(payload[i+1]),
+			startTime: uint32(payload[i+2])<<24 | uint32(payload[i+3])<<16 |
+				uint32(payload[i+4])<<8 | uint32(payload[i+5]),
+			etmLocation: (payload[i+6] >> 4) & 0x03,
+			duration: time.Duration(int(payload[i+6]&0x0f)<<16|
+				int(payload[i+7])<<8|int(payload[i+8])) * time.Second,
+		}
+
+		length := int(payload[i+9])
+		i += 10
+		if i+length+2 > len(payload) {
+			return sec
+		}
+		e.title = ParseMultipleString(payload[i : i+length])
+		i += length
+
+		length = int(payload[i]&0x0f)<<8 | int(payload[i+1])
+		i += 2
+		if i+length > len(payload) {
+			return sec
+		}
+		e.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.events = append(sec.events, e)
+	}
+
+	return sec
+}
+
+func (s *EventInformationSection) SourceId() uint16 {
+	return s.sourceId
+}
+
+func (s *EventInformationSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *EventInformationSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *EventInformationSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *EventInformationSection) Events() []*Event {
+	return s.events
+}
+
+type ExtendedTextSection struct {
+	tableIdExtension uint16
+	version          uint8
+	etmId            uint32
+	text             MultipleString
+}
+
+func ParseExtendedTextSection(table tsparser.Table) *ExtendedTextSection {
+	sec := &ExtendedTextSection{
+		tableIdExtension: table.TableIdExtension(),
+		version:          table.VersionNumber(),
+	}
+
+	payload := table.Data()
+	if len(payload) < 5 {
+		return sec
+	}
+	sec.etmId = uint32(payload[1])<<24 | uint32(payload[2])<<16 | uint32(payload[3])<<8 | uint32(payload[4])
+	sec.text = ParseMultipleString(payload[5:])
+
+	return sec
+}
+
+func (s *ExtendedTextSection) TableIdExtension() uint16 {
+	return s.tableIdExtension
+}
+
+func (s *ExtendedTextSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *ExtendedTextSection) ETMId() uint32 {
+	return s.etmId
+}
+
+func (s *ExtendedTextSection) SourceId() uint16 {
+	return uint16(s.etmId >> 16)
+}
+
+// EventId returns event_id of the event ETM.  It reports false for the
+// channel ETM.
+func (s *ExtendedTextSection) EventId() (uint16, bool) {
+	if s.etmId&0x03 != 0x02 {
+		return 0, false
+	}
+
+	return uint16(s.etmId>>2) & 0x3fff, true
+}
+
+func (s *ExtendedTextSection) Text() MultipleString {
+	return s.text
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"errors"
+)
+
+var (
+	ErrNoDecodeTree       = errors.New("Huffman decode tree is not loaded")
+	ErrInvalidDecodeTree  = errors.New("Invalid Huffman decode tree")
+	ErrInvalidHuffmanCode = errors.New("Invalid Huffman code")
+)
+
+// Decode trees of ATSC A/65 Annex C in the layout of Table C.5 and Table C.7.
+// A tree begins with 128 big-endian byte offsets of the subtrees for each
+// prior symbol, followed by the subtrees whose nodes are pairs of the
+// children for bit 0 and bit 1.  A child with the most significant bit set
+// is a leaf of the character in the rest bits, otherwise it is the index of
+// the next node in the subtree.
+//
+// The tables are not bundled with this package.  Load them with
+// LoadDecodeTrees before decoding strings of compression_type 0x01 and
+// 0x02, which otherwise fail with ErrNoDecodeTree.
+var (
+	TitleDecodeTree   []byte
+	ProgramDecodeTree []byte
+)
+
+// LoadDecodeTrees checks the layout of Table C.5 and Table C.7 and sets
+// them to TitleDecodeTree and ProgramDecodeTree.
+func LoadDecodeTrees(title, program []byte) error {
+	for _, tree := range [][]byte{title, program} {
+		if len(tree) < 256 {
+			return ErrInvalidDecodeTree
+		}
+		for i := 0; i < 128; i++ {
+			if offset := int(tree[2*i])<<8 | int(tree[2*i+1]); offset < 256 || offset+2 > len(tree) {
+				return ErrInvalidDecodeTree
+			}
+		}
+	}
+
+	TitleDecodeTree, ProgramDecodeTree = title, program
+	return nil
+}
+
+const (
+	huffmanEnd    byte = 0x00
+	huffmanEscape byte = 0x1b
+)
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) bit() byte {
+	b := r.data[r.pos/8] >> (7 - uint(r.pos%8)) & 0x01
+	r.pos++
+	return b
+}
+
+func (r *bitReader) byte() byte {
+	var b byte
+	for i := 0; i < 8; i++ {
+		b = b<<1 | r.bit()
+	}
+	return b
+}
+
+// decodeHuffman decodes a string compressed by the order-1 Huffman coding
+// of ATSC A/65 Annex C.  The subtree is chosen by the previous character,
+// starting at the subtree of the character 0x00.
+func decodeHuffman(compressionType uint8, data []byte) ([]byte, error) {
+	tree := TitleDecodeTree
+	if compressionType == CompressionHuffmanProgram {
+		tree = ProgramDecodeTree
+	}
+	if len(tree) < 256 {
+		return nil, ErrNoDecodeTree
+	}
+
+	var result []byte
+	r := &bitReader{data: data}
+	prior := byte(0)
+	for {
+		root := int(tree[2*int(prior)])<<8 | int(tree[2*int(prior)+1])
+
+		node := 0
+		var c byte
+		for {
+			if r.remaining() == 0 {
+				return result, nil
+			}
+
+			i := root + 2*node + int(r.bit())
+			if i >= len(tree) {
+				return nil, ErrInvalidDecodeTree
+			}
+			if tree[i]&0x80 > 0 {
+				c = tree[i] & 0x7f
+				break
+			}
+			node = int(tree[i])
+		}
+
+		switch c {
+		case huffmanEnd:
+			return result, nil
+		case huffmanEscape:
+			if r.remaining() < 8 {
+				return nil, ErrInvalidHuffmanCode
+			}
+			c = r.byte()
+		}
+
+		result = append(result, c)
+		prior = c & 0x7f
+	}
+}
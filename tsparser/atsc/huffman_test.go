@@ -0,0 +1,95 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"testing"
+)
+
+// testDecodeTree returns a tree in the layout of Table C.5 whose subtrees
+// are all the same: 00 for the end, 01 for the escape, 10 for 'H', 110 for
+// 'i' and 111 for ' '.
+func testDecodeTree() []byte {
+	tree := make([]byte, 256)
+	for i := 0; i < 128; i++ {
+		tree[2*i], tree[2*i+1] = 0x01, 0x00
+	}
+	return append(tree,
+		0x01, 0x02,
+		0x80|huffmanEnd, 0x80|huffmanEscape,
+		0x80|'H', 0x03,
+		0x80|'i', 0x80|' ',
+	)
+}
+
+func withDecodeTrees(title, program []byte, fn func()) {
+	savedTitle, savedProgram := TitleDecodeTree, ProgramDecodeTree
+	defer func() {
+		TitleDecodeTree, ProgramDecodeTree = savedTitle, savedProgram
+	}()
+
+	TitleDecodeTree, ProgramDecodeTree = title, program
+	fn()
+}
+
+func TestDecodeHuffman(t *testing.T) {
+	tree := testDecodeTree()
+	withDecodeTrees(nil, nil, func() {
+		if err := LoadDecodeTrees(tree, tree); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			data     []byte
+			expected string
+		}{
+			// "Hi H", the escaped '!' and the end.
+			{[]byte{0xb7, 0x92, 0x10}, "Hi H!"},
+			// The string ends with the data without the end.
+			{[]byte{0xb7}, "Hi "},
+			{nil, ""},
+		}
+		for i, test := range tests {
+			for _, compressionType := range []uint8{CompressionHuffmanTitle, CompressionHuffmanProgram} {
+				s := &StringSegment{CompressionType: compressionType, Mode: 0x00, Data: test.data}
+				if text, err := s.Text(); err != nil || text != test.expected {
+					t.Errorf("%d: text = %q (%v), want %q", i, text, err, test.expected)
+				}
+			}
+		}
+
+		// The escape is not followed by a character.
+		s := &StringSegment{CompressionType: CompressionHuffmanTitle, Mode: 0x00, Data: []byte{0x40}}
+		if _, err := s.Text(); err != ErrInvalidHuffmanCode {
+			t.Errorf("err = %v, want %v", err, ErrInvalidHuffmanCode)
+		}
+	})
+}
+
+func TestDecodeHuffmanWithoutTree(t *testing.T) {
+	withDecodeTrees(nil, nil, func() {
+		s := &StringSegment{CompressionType: CompressionHuffmanTitle, Mode: 0x00, Data: []byte{0xb7}}
+		if _, err := s.Text(); err != ErrNoDecodeTree {
+			t.Errorf("err = %v, want %v", err, ErrNoDecodeTree)
+		}
+	})
+}
+
+func TestLoadDecodeTrees(t *testing.T) {
+	tree := testDecodeTree()
+	broken := append([]byte(nil), tree...)
+	broken[10] = 0x02
+
+	withDecodeTrees(nil, nil, func() {
+		for i, trees := range [][2][]byte{{tree[:200], tree}, {tree, broken}} {
+			if err := LoadDecodeTrees(trees[0], trees[1]); err != ErrInvalidDecodeTree {
+				t.Errorf("%d: err = %v, want %v", i, err, ErrInvalidDecodeTree)
+			}
+		}
+		if TitleDecodeTree != nil || ProgramDecodeTree != nil {
+			t.Error("invalid trees are loaded")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	TableTypeTVCTCurrent uint16 = 0x0000
+	TableTypeTVCTNext    uint16 = 0x0001
+	TableTypeCVCTCurrent uint16 = 0x0002
+	TableTypeCVCTNext    uint16 = 0x0003
+	TableTypeChannelETT  uint16 = 0x0004
+	TableTypeDCCSCT      uint16 = 0x0005
+	TableTypeEIT         uint16 = 0x0100 // EIT-0 to EIT-127
+	TableTypeEventETT    uint16 = 0x0200 // event ETT-0 to event ETT-127
+	TableTypeRRT         uint16 = 0x0300 // RRT of rating_region 1 to 255
+	TableTypeDCCT        uint16 = 0x1400
+)
+
+type TableDefinition struct {
+	tableType   uint16
+	pid         tsparser.PID
+	version     uint8
+	numberBytes uint32
+	descriptors []tsparser.Descriptor
+}
+
+func (t *TableDefinition) TableType() uint16 {
+	return t.tableType
+}
+
+func (t *TableDefinition) PID() tsparser.PID {
+	return t.pid
+}
+
+func (t *TableDefinition) VersionNumber() uint8 {
+	return t.version
+}
+
+func (t *TableDefinition) NumberBytes() uint32 {
+	return t.numberBytes
+}
+
+func (t *TableDefinition) Descriptors() []tsparser.Descriptor {
+	return t.descriptors
+}
+
+// EIT returns n of EIT-n.
+func (t *TableDefinition) EIT() (int, bool) {
+	if TableTypeEIT <= t.tableType && t.tableType < TableTypeEIT+0x80 {
+		return int(t.tableType - TableTypeEIT), true
+	}
+
+	return 0, false
+}
+
+// EventETT returns n of event ETT-n.
+func (t *TableDefinition) EventETT() (int, bool) {
+	if TableTypeEventETT <= t.tableType && t.tableType < TableTypeEventETT+0x80 {
+		return int(t.tableType - TableTypeEventETT), true
+	}
+
+	return 0, false
+}
+
+type MasterGuideSection struct {
+	version     uint8
+	tables      []*TableDefinition
+	descriptors []tsparser.Descriptor
+}
+
+func ParseMasterGuideSection(table tsparser.Table) *MasterGuideSection {
+	sec := new(MasterGuideSection)
+	sec.version = table.VersionNumber()
+
+	payload := table.Data()
+	if len(payload) < 3 {
+		return sec
+	}
+
+	count := int(payload[1])<<8 | int(payload[2])
+	i := 3
+	for n := 0; n < count && i+11 <= len(payload); n++ {
+		t := &TableDefinition{
+			tableType: uint16(payload[i])<<8 | uint16(payload[i+1]),
+			pid:       tsparser.PID(payload[i+2]&0x1f)<<8 | tsparser.PID(payload[i+3]),
+			version:   payload[i+4] & 0x1f,
+			numberBytes: uint32(payload[i+5])<<24 | uint32(payload[i+6])<<16 |
+				uint32(payload[i+7])<<8 | uint32(payload[i+8]),
+		}
+
+		length := int(payload[i+9]&0x0f)<<8 | int(payload[i+10])
+		i += 11
+		if i+length > len(payload) {
+			return sec
+		}
+		t.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.tables = append(sec.tables, t)
+	}
+
+	if i+2 <= len(payload) {
+		length := int(payload[i]&0x0f)<<8 | int(payload[i+1])
+		if i+2+length <= len(payload) {
+			sec.descriptors = tsparser.ParseDescriptors(payload[i+2 : i+2+length])
+		}
+	}
+
+	return sec
+}
+
+func (s *MasterGuideSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *MasterGuideSection) Tables() []*TableDefinition {
+	return s.tables
+}
+
+func (s *MasterGuideSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
+
+// PIDs returns PIDs carrying EITs and ETTs other than the base PID.
+func (s *MasterGuideSection) PIDs() []tsparser.PID {
+	var pids []tsparser.PID
+	seen := make(map[tsparser.PID]bool)
+	for _, t := range s.tables {
+		_, eit := t.EIT()
+		_, ett := t.EventETT()
+		if !eit && !ett && t.tableType != TableTypeChannelETT || t.pid == BasePID || seen[t.pid] {
+			continue
+		}
+
+		seen[t.pid] = true
+		pids = append(pids, t.pid)
+	}
+
+	return pids
+}
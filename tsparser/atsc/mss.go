@@ -0,0 +1,165 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf16"
+)
+
+var (
+	ErrUnsupportedCompression = errors.New("Unsupported compression_type")
+	ErrUnsupportedMode        = errors.New("Unsupported mode")
+)
+
+const (
+	CompressionNone           uint8 = 0x00
+	CompressionHuffmanTitle   uint8 = 0x01
+	CompressionHuffmanProgram uint8 = 0x02
+	ModeSCSU                  uint8 = 0x3E
+	ModeUTF16                 uint8 = 0x3F
+	ModeNotApplicable         uint8 = 0xFF
+)
+
+type StringSegment struct {
+	CompressionType uint8
+	Mode            uint8
+	Data            []byte
+}
+
+func isUnicodePage(mode uint8) bool {
+	return mode <= 0x06 || 0x09 <= mode && mode <= 0x10 ||
+		0x20 <= mode && mode <= 0x27 || 0x30 <= mode && mode <= 0x33
+}
+
+// Text decodes the segment.  A mode selecting a page of Unicode is
+// combined with each byte to make a character.
+func (s *StringSegment) Text() (string, error) {
+	data := s.Data
+	switch s.CompressionType {
+	case CompressionNone:
+	case CompressionHuffmanTitle, CompressionHuffmanProgram:
+		if s.Mode != 0x00 {
+			return "", ErrUnsupportedMode
+		}
+
+		var err error
+		if data, err = decodeHuffman(s.CompressionType, data); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupportedCompression
+	}
+
+	switch {
+	case isUnicodePage(s.Mode):
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(s.Mode)<<8 | rune(b)
+		}
+		return string(runes), nil
+	case s.Mode == ModeUTF16:
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		}
+		return string(utf16.Decode(units)), nil
+	default:
+		return "", ErrUnsupportedMode
+	}
+}
+
+type LocalizedString struct {
+	Language string
+	Segments []*StringSegment
+}
+
+// Text concatenates the segments, and returns the error of the first
+// segment which can not be decoded along with the others.
+func (s *LocalizedString) Text() (string, error) {
+	var buf bytes.Buffer
+	var first error
+	for _, segment := range s.Segments {
+		text, err := segment.Text()
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		buf.WriteString(text)
+	}
+
+	return buf.String(), first
+}
+
+// String concatenates segments skipping those which can not be decoded.
+func (s *LocalizedString) String() string {
+	text, _ := s.Text()
+	return text
+}
+
+// MultipleString is a multiple_string_structure of ATSC A/65.
+type MultipleString []*LocalizedString
+
+func ParseMultipleString(data []byte) MultipleString {
+	if len(data) < 1 {
+		return nil
+	}
+
+	count := int(data[0])
+	strings := make(MultipleString, 0, count)
+	i := 1
+	for n := 0; n < count && i+4 <= len(data); n++ {
+		s := &LocalizedString{
+			Language: string(data[i : i+3]),
+		}
+
+		segments := int(data[i+3])
+		i += 4
+		for m := 0; m < segments && i+3 <= len(data); m++ {
+			length := int(data[i+2])
+			if i+3+length > len(data) {
+				return append(strings, s)
+			}
+
+			s.Segments = append(s.Segments, &StringSegment{
+				CompressionType: data[i],
+				Mode:            data[i+1],
+				Data:            data[i+3 : i+3+length],
+			})
+			i += 3 + length
+		}
+
+		strings = append(strings, s)
+	}
+
+	return strings
+}
+
+// Lookup returns the string in language.  The first string is returned if
+// no string matches.
+func (ms MultipleString) Lookup(language string) string {
+	if len(ms) == 0 {
+		return ""
+	}
+
+	for _, s := range ms {
+		if s.Language == language {
+			return s.String()
+		}
+	}
+
+	return ms[0].String()
+}
+
+func (ms MultipleString) String() string {
+	if len(ms) == 0 {
+		return ""
+	}
+
+	return ms[0].String()
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"testing"
+)
+
+func TestParseMultipleString(t *testing.T) {
+	data := []byte{
+		0x02,
+		'e', 'n', 'g', 0x02,
+		0x00, 0x00, 0x05, 'N', 'e', 'w', 's', ' ',
+		0x00, 0x3f, 0x04, 0x00, 'a', 0x00, 't',
+		's', 'p', 'a', 0x01,
+		0x00, 0x00, 0x04, 'N', 'o', 't', 0xed,
+	}
+
+	ms := ParseMultipleString(data)
+	if len(ms) != 2 || ms[0].Language != "eng" || len(ms[0].Segments) != 2 || ms[1].Language != "spa" {
+		t.Fatalf("multiple string = %+v", ms)
+	}
+	if s := ms.Lookup("spa"); s != "Notí" {
+		t.Errorf("spa = %q", s)
+	}
+	if s := ms.Lookup("fra"); s != "News at" {
+		t.Errorf("fra = %q", s)
+	}
+
+	// A segment which can not be decoded is skipped.
+	ms[0].Segments[1].CompressionType = 0x03
+	if text, err := ms[0].Text(); err != ErrUnsupportedCompression || text != "News " {
+		t.Errorf("text = %q (%v)", text, err)
+	}
+
+	// A truncated segment is dropped.
+	if ms := ParseMultipleString(data[:16]); len(ms) != 1 || len(ms[0].Segments) != 1 {
+		t.Errorf("truncated multiple string = %+v", ms)
+	}
+}
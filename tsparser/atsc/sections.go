@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	MasterGuideTable                 tsparser.TableId = 0xC7
+	TerrestrialVirtualChannelTable   tsparser.TableId = 0xC8
+	CableVirtualChannelTable         tsparser.TableId = 0xC9
+	RatingRegionTable                tsparser.TableId = 0xCA
+	EventInformationTable            tsparser.TableId = 0xCB
+	ExtendedTextTable                tsparser.TableId = 0xCC
+	SystemTimeTable                  tsparser.TableId = 0xCD
+	DirectedChannelChangeTable       tsparser.TableId = 0xD3
+	DirectedChannelChangeSelectTable tsparser.TableId = 0xD4
+)
+
+const (
+	BasePID tsparser.PID = 0x1FFB
+)
+
+var gpsEpoch = time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC)
+
+// GPSTime converts seconds since the GPS epoch to UTC.  gpsUTCOffset is the
+// number of leap seconds given by the STT.
+func GPSTime(seconds uint32, gpsUTCOffset uint8) time.Time {
+	return gpsEpoch.Add(time.Duration(int64(seconds)-int64(gpsUTCOffset)) * time.Second)
+}
+
+type SystemTimeSection struct {
+	systemTime   uint32
+	gpsUTCOffset uint8
+	dsStatus     bool
+	dsDayOfMonth uint8
+	dsHour       uint8
+	descriptors  []tsparser.Descriptor
+}
+
+func ParseSystemTimeSection(table tsparser.Table) *SystemTimeSection {
+	sec := new(SystemTimeSection)
+
+	payload := table.Data()
+	if len(payload) < 8 {
+		return sec
+	}
+	sec.systemTime = uint32(payload[1])<<24 | uint32(payload[2])<<16 | uint32(payload[3])<<8 | uint32(payload[4])
+	sec.gpsUTCOffset = payload[5]
+	sec.dsStatus = payload[6]&0x80 > 0
+	sec.dsDayOfMonth = payload[6] & 0x1f
+	sec.dsHour = payload[7]
+	sec.descriptors = tsparser.ParseDescriptors(payload[8:])
+
+	return sec
+}
+
+// SystemTime returns system_time in seconds since the GPS epoch.
+func (s *SystemTimeSection) SystemTime() uint32 {
+	return s.systemTime
+}
+
+func (s *SystemTimeSection) GPSUTCOffset() uint8 {
+	return s.gpsUTCOffset
+}
+
+func (s *SystemTimeSection) UTCTime() time.Time {
+	return GPSTime(s.systemTime, s.gpsUTCOffset)
+}
+
+// DaylightSaving returns DS_status, DS_day_of_month and DS_hour.
+func (s *SystemTimeSection) DaylightSaving() (status bool, dayOfMonth, hour uint8) {
+	return s.dsStatus, s.dsDayOfMonth, s.dsHour
+}
+
+func (s *SystemTimeSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
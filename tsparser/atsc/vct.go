@@ -0,0 +1,230 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package atsc
+
+import (
+	"unicode/utf16"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	ETMLocationNone uint8 = iota
+	ETMLocationPTC
+	ETMLocationChannelTSID
+)
+
+const (
+	ServiceTypeAnalogTelevision  uint8 = 0x01
+	ServiceTypeDigitalTelevision uint8 = 0x02
+	ServiceTypeAudio             uint8 = 0x03
+	ServiceTypeDataOnly          uint8 = 0x04
+	ServiceTypeSoftwareDownload  uint8 = 0x05
+)
+
+type VirtualChannel struct {
+	shortName          string
+	majorChannelNumber uint16
+	minorChannelNumber uint16
+	modulationMode     uint8
+	carrierFrequency   uint32
+	channelTSID        uint16
+	programNumber      uint16
+	etmLocation        uint8
+	accessControlled   bool
+	hidden             bool
+	pathSelect         bool
+	outOfBand          bool
+	hideGuide          bool
+	serviceType        uint8
+	sourceId           uint16
+	descriptors        []tsparser.Descriptor
+}
+
+func (c *VirtualChannel) ShortName() string {
+	return c.shortName
+}
+
+func (c *VirtualChannel) MajorChannelNumber() uint16 {
+	return c.majorChannelNumber
+}
+
+func (c *VirtualChannel) MinorChannelNumber() uint16 {
+	return c.minorChannelNumber
+}
+
+// IsOnePart reports whether the channel is numbered by a one-part number
+// which is carried in major and minor channel numbers of a CVCT.
+func (c *VirtualChannel) IsOnePart() bool {
+	return c.majorChannelNumber&0x3f0 == 0x3f0
+}
+
+// OnePartNumber returns the one-part channel number.
+func (c *VirtualChannel) OnePartNumber() uint16 {
+	return (c.majorChannelNumber&0x00f)<<10 | c.minorChannelNumber
+}
+
+func (c *VirtualChannel) ModulationMode() uint8 {
+	return c.modulationMode
+}
+
+func (c *VirtualChannel) CarrierFrequency() uint32 {
+	return c.carrierFrequency
+}
+
+func (c *VirtualChannel) ChannelTSID() uint16 {
+	return c.channelTSID
+}
+
+func (c *VirtualChannel) ProgramNumber() uint16 {
+	return c.programNumber
+}
+
+func (c *VirtualChannel) ETMLocation() uint8 {
+	return c.etmLocation
+}
+
+func (c *VirtualChannel) AccessControlled() bool {
+	return c.accessControlled
+}
+
+func (c *VirtualChannel) Hidden() bool {
+	return c.hidden
+}
+
+// PathSelect is defined only in CVCTs.
+func (c *VirtualChannel) PathSelect() bool {
+	return c.pathSelect
+}
+
+// OutOfBand is defined only in CVCTs.
+func (c *VirtualChannel) OutOfBand() bool {
+	return c.outOfBand
+}
+
+func (c *VirtualChannel) HideGuide() bool {
+	return c.hideGuide
+}
+
+func (c *VirtualChannel) ServiceType() uint8 {
+	return c.serviceType
+}
+
+func (c *VirtualChannel) SourceId() uint16 {
+	return c.sourceId
+}
+
+func (c *VirtualChannel) Descriptors() []tsparser.Descriptor {
+	return c.descriptors
+}
+
+type VirtualChannelSection struct {
+	tableId           tsparser.TableId
+	transportStreamId uint16
+	version           uint8
+	sectionNumber     uint8
+	lastSectionNumber uint8
+	channels          []*VirtualChannel
+	descriptors       []tsparser.Descriptor
+}
+
+// ParseVirtualChannelSection parses a TVCT or a CVCT.
+func ParseVirtualChannelSection(table tsparser.Table) *VirtualChannelSection {
+	sec := &VirtualChannelSection{
+		tableId:           table.TableId(),
+		transportStreamId: table.TableIdExtension(),
+		version:           table.VersionNumber(),
+		sectionNumber:     table.SectionNumber(),
+		lastSectionNumber: table.LastSectionNumber(),
+	}
+
+	payload := table.Data()
+	if len(payload) < 2 {
+		return sec
+	}
+
+	count := int(payload[1])
+	i := 2
+	for n := 0; n < count && i+32 <= len(payload); n++ {
+		name := make([]uint16, 0, 7)
+		for j := i; j < i+14; j += 2 {
+			if r := uint16(payload[j])<<8 | uint16(payload[j+1]); r != 0 {
+				name = append(name, r)
+			}
+		}
+
+		c := &VirtualChannel{
+			shortName:          string(utf16.Decode(name)),
+			majorChannelNumber: uint16(payload[i+14]&0x0f)<<6 | uint16(payload[i+15])>>2,
+			minorChannelNumber: uint16(payload[i+15]&0x03)<<8 | uint16(payload[i+16]),
+			modulationMode:     payload[i+17],
+			carrierFrequency: uint32(payload[i+18])<<24 | uint32(payload[i+19])<<16 |
+				uint32(payload[i+20])<<8 | uint32(payload[i+21]),
+			channelTSID:      uint16(payload[i+22])<<8 | uint16(payload[i+23]),
+			programNumber:    uint16(payload[i+24])<<8 | uint16(payload[i+25]),
+			etmLocation:      payload[i+26] >> 6,
+			accessControlled: payload[i+26]&0x20 > 0,
+			hidden:           payload[i+26]&0x10 > 0,
+			hideGuide:        payload[i+26]&0x02 > 0,
+			serviceType:      payload[i+27] & 0x3f,
+			sourceId:         uint16(payload[i+28])<<8 | uint16(payload[i+29]),
+		}
+		if sec.tableId == CableVirtualChannelTable {
+			c.pathSelect = payload[i+26]&0x08 > 0
+			c.outOfBand = payload[i+26]&0x04 > 0
+		}
+
+		length := int(payload[i+30]&0x03)<<8 | int(payload[i+31])
+		i += 32
+		if i+length > len(payload) {
+			return sec
+		}
+		c.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.channels = append(sec.channels, c)
+	}
+
+	if i+2 <= len(payload) {
+		length := int(payload[i]&0x03)<<8 | int(payload[i+1])
+		if i+2+length <= len(payload) {
+			sec.descriptors = tsparser.ParseDescriptors(payload[i+2 : i+2+length])
+		}
+	}
+
+	return sec
+}
+
+func (s *VirtualChannelSection) TableId() tsparser.TableId {
+	return s.tableId
+}
+
+func (s *VirtualChannelSection) IsCable() bool {
+	return s.tableId == CableVirtualChannelTable
+}
+
+func (s *VirtualChannelSection) TransportStreamId() uint16 {
+	return s.transportStreamId
+}
+
+func (s *VirtualChannelSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *VirtualChannelSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *VirtualChannelSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *VirtualChannelSection) Channels() []*VirtualChannel {
+	return s.channels
+}
+
+func (s *VirtualChannelSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package bitstream
+
+import (
+	"errors"
+)
+
+var (
+	ErrShortData     = errors.New("Insufficient data")
+	ErrInvalidGolomb = errors.New("Invalid Exp-Golomb code")
+)
+
+type Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+func (r *Reader) Err() error {
+	return r.err
+}
+
+func (r *Reader) Pos() int {
+	return r.pos
+}
+
+func (r *Reader) Left() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *Reader) ByteAligned() bool {
+	return r.pos%8 == 0
+}
+
+func (r *Reader) Align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+func (r *Reader) Bit() bool {
+	return r.Bits(1) == 1
+}
+
+func (r *Reader) Bits(n int) uint64 {
+	if r.err != nil {
+		return 0
+	} else if n > r.Left() {
+		r.err = ErrShortData
+		r.pos = len(r.data) * 8
+		return 0
+	}
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		b := r.data[r.pos/8] >> uint(7-r.pos%8) & 0x01
+		v = v<<1 | uint64(b)
+		r.pos++
+	}
+	return v
+}
+
+func (r *Reader) Uint8(n int) uint8 {
+	return uint8(r.Bits(n))
+}
+
+func (r *Reader) Uint16(n int) uint16 {
+	return uint16(r.Bits(n))
+}
+
+func (r *Reader) Uint32(n int) uint32 {
+	return uint32(r.Bits(n))
+}
+
+func (r *Reader) Skip(n int) {
+	if r.err != nil {
+		return
+	} else if n > r.Left() {
+		r.err = ErrShortData
+		r.pos = len(r.data) * 8
+		return
+	}
+
+	r.pos += n
+}
+
+func (r *Reader) UE() uint32 {
+	zeros := 0
+	for !r.Bit() {
+		if r.err != nil {
+			return 0
+		} else if zeros++; zeros > 31 {
+			r.err = ErrInvalidGolomb
+			return 0
+		}
+	}
+
+	return uint32(1<<uint(zeros)-1) + r.Uint32(zeros)
+}
+
+func (r *Reader) SE() int32 {
+	v := r.UE()
+	if v&0x01 > 0 {
+		return int32((v + 1) / 2)
+	}
+
+	return -int32(v / 2)
+}
+
+func (r *Reader) MoreRBSPData() bool {
+	if r.err != nil || r.Left() <= 0 {
+		return false
+	}
+
+	last := len(r.data) - 1
+	for last >= 0 && r.data[last] == 0 {
+		last--
+	}
+	if last < 0 {
+		return false
+	}
+
+	trailing := 0
+	for r.data[last]>>uint(trailing)&0x01 == 0 {
+		trailing++
+	}
+	return r.pos < last*8+7-trailing
+}
+
+func UnescapeRBSP(data []byte) []byte {
+	result := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+
+		if b == 0x00 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		result = append(result, b)
+	}
+
+	return result
+}
+
+func SplitAnnexB(data []byte) (units [][]byte, rest []byte) {
+	start := -1
+	for i := 0; i+2 < len(data); i++ {
+		if data[i] != 0x00 || data[i+1] != 0x00 || data[i+2] != 0x01 {
+			continue
+		}
+
+		if start >= 0 {
+			end := i
+			for end > start && data[end-1] == 0x00 {
+				end--
+			}
+			units = append(units, data[start:end])
+		}
+		start = i + 3
+		i += 2
+	}
+
+	if start < 0 {
+		return nil, data
+	}
+	return units, data[start-3:]
+}
+
+type AnnexBSplitter struct {
+	rest    []byte
+	restTag interface{}
+}
+
+func (s *AnnexBSplitter) Push(data []byte, tag interface{}, fn func(unit []byte, tag interface{})) {
+	hadRest := len(s.rest) > 0
+	units, rest := SplitAnnexB(append(s.rest, data...))
+	for i, unit := range units {
+		if i == 0 && hadRest {
+			fn(unit, s.restTag)
+		} else {
+			fn(unit, tag)
+		}
+	}
+
+	if len(units) > 0 || !hadRest {
+		s.restTag = tag
+	}
+	if len(units) == 0 && !hasStartCode(rest) && len(rest) > 2 {
+		rest = rest[len(rest)-2:]
+	}
+	s.rest = append([]byte(nil), rest...)
+}
+
+func (s *AnnexBSplitter) Flush(fn func(unit []byte, tag interface{})) {
+	if hasStartCode(s.rest) && len(s.rest) > 3 {
+		fn(s.rest[3:], s.restTag)
+	}
+	s.rest = nil
+}
+
+func hasStartCode(data []byte) bool {
+	return len(data) >= 3 && data[0] == 0x00 && data[1] == 0x00 && data[2] == 0x01
+}
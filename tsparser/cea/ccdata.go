@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+const (
+	NTSCField1 uint8 = 0
+	NTSCField2 uint8 = 1
+	DTVCCData  uint8 = 2
+	DTVCCStart uint8 = 3
+
+	// ATSCIdentifier is the user_identifier "GA94" of ATSC A/53.
+	ATSCIdentifier uint32 = 0x47413934
+
+	ccDataTypeCode     = 0x03
+	t35CountryUSA      = 0xB5
+	t35ProviderATSC    = 0x0031
+	t35ProviderDirecTV = 0x002F
+)
+
+// CCData is a construct of cc_data() carrying two bytes of CEA-608 or
+// CEA-708.
+type CCData struct {
+	Valid bool
+	Type  uint8
+	Data  [2]byte
+}
+
+// ParseCCData parses cc_data() of ATSC A/53, which begins with
+// process_cc_data_flag and cc_count.
+func ParseCCData(data []byte) []CCData {
+	if len(data) < 2 || data[0]&0x40 == 0 {
+		return nil
+	}
+
+	count := int(data[0] & 0x1f)
+	data = data[2:]
+
+	var constructs []CCData
+	for i := 0; i < count && 3*i+3 <= len(data); i++ {
+		b := data[3*i : 3*i+3]
+		constructs = append(constructs, CCData{
+			Valid: b[0]&0x04 > 0,
+			Type:  b[0] & 0x03,
+			Data:  [2]byte{b[1], b[2]},
+		})
+	}
+
+	return constructs
+}
+
+// ParseUserData parses ATSC_user_data() found in MPEG-2 video user data,
+// which begins with the user_identifier.
+func ParseUserData(data []byte) []CCData {
+	if len(data) < 5 || uint32(data[0])<<24|uint32(data[1])<<16|uint32(data[2])<<8|uint32(data[3]) != ATSCIdentifier {
+		return nil
+	} else if data[4] != ccDataTypeCode {
+		return nil
+	}
+
+	return ParseCCData(data[5:])
+}
+
+// ParseRegisteredUserData parses the payload of a
+// user_data_registered_itu_t_t35 SEI message of H.264 or HEVC.
+func ParseRegisteredUserData(payload []byte) []CCData {
+	if len(payload) < 3 || payload[0] != t35CountryUSA {
+		return nil
+	}
+
+	switch uint16(payload[1])<<8 | uint16(payload[2]) {
+	case t35ProviderATSC:
+		return ParseUserData(payload[3:])
+	case t35ProviderDirecTV:
+		if len(payload) < 4 || payload[3] != ccDataTypeCode {
+			return nil
+		}
+		return ParseCCData(payload[4:])
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRegisteredUserData(t *testing.T) {
+	want := []CCData{
+		{true, NTSCField1, [2]byte{0x94, 0x20}},
+		{false, NTSCField2, [2]byte{0x80, 0x80}},
+		{true, DTVCCStart, [2]byte{0x02, 0x21}},
+	}
+
+	tests := [][]byte{
+		{
+			0xb5, 0x00, 0x31, 'G', 'A', '9', '4', 0x03, 0x43, 0xff,
+			0xfc, 0x94, 0x20, 0xf9, 0x80, 0x80, 0xff, 0x02, 0x21, 0xff,
+		},
+		{
+			0xb5, 0x00, 0x2f, 0x03, 0x43, 0xff,
+			0xfc, 0x94, 0x20, 0xf9, 0x80, 0x80, 0xff, 0x02, 0x21,
+		},
+	}
+	for i, payload := range tests {
+		if data := ParseRegisteredUserData(payload); !reflect.DeepEqual(data, want) {
+			t.Errorf("%d: cc_data = %+v, want %+v", i, data, want)
+		}
+	}
+
+	invalid := [][]byte{
+		{0xb5, 0x00, 0x31, 'D', 'T', 'G', '1', 0x03, 0x41, 0xff, 0xfc, 0x94, 0x20},
+		{0xb5, 0x00, 0x31, 'G', 'A', '9', '4', 0x06, 0x41, 0xff, 0xfc, 0x94, 0x20},
+		{0xb5, 0x00, 0x31, 'G', 'A', '9', '4', 0x03, 0x01, 0xff, 0xfc, 0x94, 0x20},
+		{0x26, 0x00, 0x31, 'G', 'A', '9', '4', 0x03, 0x41, 0xff, 0xfc, 0x94, 0x20},
+	}
+	for i, payload := range invalid {
+		if data := ParseRegisteredUserData(payload); data != nil {
+			t.Errorf("%d: cc_data = %+v", i, data)
+		}
+	}
+}
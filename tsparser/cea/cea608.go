@@ -0,0 +1,293 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DisplayFunc is called with the text shown on the screen of a caption
+// track whenever it changes.  An empty text means the screen is cleared.
+type DisplayFunc func(track string, text string)
+
+const (
+	rows608    = 15
+	columns608 = 32
+)
+
+const (
+	popOn = iota
+	rollUp
+	paintOn
+)
+
+var (
+	pacRows = [8]int{11, 1, 3, 12, 14, 5, 7, 9}
+
+	basicChars = map[byte]rune{
+		0x2A: 'á', 0x5C: 'é', 0x5E: 'í', 0x5F: 'ó', 0x60: 'ú',
+		0x7B: 'ç', 0x7C: '÷', 0x7D: 'Ñ', 0x7E: 'ñ', 0x7F: '█',
+	}
+	specialChars  = []rune("®°½¿™¢£♪à èâêîôû")
+	extendedChars = [2][]rune{
+		[]rune("ÁÉÓÚÜü‘¡*’—©℠•“”ÀÂÇÈÊËëÎÏïÔÙùÛ«»"),
+		[]rune("ÃãÍÌìÒòÕõ{}\\^_|~ÄäÖöß¥¤│ÅåØø┌┐└┘"),
+	}
+)
+
+type screen [rows608][columns608]rune
+
+func (s *screen) text() string {
+	var lines []string
+	for _, row := range s {
+		line := make([]rune, 0, columns608)
+		for _, r := range row {
+			if r == 0 {
+				r = ' '
+			}
+			line = append(line, r)
+		}
+
+		if text := strings.TrimSpace(string(line)); text != "" {
+			lines = append(lines, text)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// channel608 is the caption memories of a data channel.
+type channel608 struct {
+	track   string
+	display DisplayFunc
+
+	mode      int
+	rollUp    int
+	textMode  bool
+	displayed screen
+	buffer    screen
+	row       int
+	col       int
+	dirty     bool
+}
+
+func (c *channel608) memory() *screen {
+	if c.mode == popOn {
+		return &c.buffer
+	}
+
+	return &c.displayed
+}
+
+func (c *channel608) commit() {
+	c.dirty = false
+	c.display(c.track, c.displayed.text())
+}
+
+func (c *channel608) put(r rune) {
+	if c.textMode {
+		return
+	}
+
+	if c.col >= columns608 {
+		c.col = columns608 - 1
+	}
+	c.memory()[c.row][c.col] = r
+	c.col++
+	c.dirty = c.dirty || c.mode != popOn
+}
+
+func (c *channel608) backspace() {
+	if c.textMode || c.col == 0 {
+		return
+	}
+
+	c.col--
+	c.memory()[c.row][c.col] = 0
+	c.dirty = c.dirty || c.mode != popOn
+}
+
+func (c *channel608) char(b byte) {
+	if r, ok := basicChars[b]; ok {
+		c.put(r)
+	} else {
+		c.put(rune(b))
+	}
+}
+
+func (c *channel608) control(c1, b2 byte) {
+	switch {
+	case (c1 == 0x14 || c1 == 0x15) && 0x20 <= b2 && b2 <= 0x2f:
+		c.command(b2)
+	case c1 == 0x17 && 0x21 <= b2 && b2 <= 0x23:
+		if c.col += int(b2 & 0x03); c.col >= columns608 {
+			c.col = columns608 - 1
+		}
+	case c1 == 0x11 && 0x20 <= b2 && b2 <= 0x2f:
+		// A mid-row code occupies a space.
+		c.put(' ')
+	case c1 == 0x11 && 0x30 <= b2 && b2 <= 0x3f:
+		c.put(specialChars[b2-0x30])
+	case (c1 == 0x12 || c1 == 0x13) && 0x20 <= b2 && b2 <= 0x3f:
+		// An extended character replaces the standard one sent before
+		// for the decoders which do not support it.
+		c.backspace()
+		c.put(extendedChars[c1-0x12][b2-0x20])
+	case b2 >= 0x40:
+		c.preamble(c1, b2)
+	}
+}
+
+func (c *channel608) command(b2 byte) {
+	switch b2 {
+	case 0x20: // RCL
+		c.mode, c.textMode = popOn, false
+	case 0x21: // BS
+		c.backspace()
+	case 0x24: // DER
+		m := c.memory()
+		for i := c.col; i < columns608; i++ {
+			m[c.row][i] = 0
+		}
+		c.dirty = c.dirty || c.mode != popOn
+	case 0x25, 0x26, 0x27: // RU2, RU3, RU4
+		if c.mode != rollUp {
+			c.displayed, c.buffer = screen{}, screen{}
+			c.row, c.col = rows608-1, 0
+			c.commit()
+		}
+		c.mode, c.rollUp, c.textMode = rollUp, int(b2-0x23), false
+	case 0x29: // RDC
+		c.mode, c.textMode = paintOn, false
+	case 0x2a, 0x2b: // TR, RTD
+		c.textMode = true
+	case 0x2c: // EDM
+		c.displayed = screen{}
+		c.commit()
+	case 0x2d: // CR
+		if c.textMode || c.mode != rollUp {
+			break
+		}
+		for i := c.row - c.rollUp + 1; i < c.row; i++ {
+			if i >= 0 {
+				c.displayed[i] = c.displayed[i+1]
+			}
+		}
+		c.displayed[c.row] = [columns608]rune{}
+		c.col = 0
+		c.commit()
+	case 0x2e: // ENM
+		c.buffer = screen{}
+	case 0x2f: // EOC
+		c.displayed, c.buffer = c.buffer, c.displayed
+		c.mode, c.textMode = popOn, false
+		c.commit()
+	}
+}
+
+func (c *channel608) preamble(c1, b2 byte) {
+	row := pacRows[c1&0x07] - 1
+	if b2&0x20 > 0 && c1 != 0x10 {
+		row++
+	}
+
+	if c.mode == rollUp && row != c.row {
+		// The roll-up window moves with its base row.
+		if row < c.rollUp-1 {
+			row = c.rollUp - 1
+		}
+
+		var moved screen
+		for i := 0; i < c.rollUp; i++ {
+			if from := c.row - i; from >= 0 {
+				moved[row-i] = c.displayed[from]
+			}
+		}
+		c.displayed = moved
+		c.dirty = true
+	}
+
+	c.row, c.col = row, 0
+	if b2&0x10 > 0 {
+		c.col = int(b2&0x0e) * 2
+	}
+}
+
+// Decoder608 decodes the CEA-608 byte pairs of a field into the caption
+// tracks CC1 and CC2 for field 1, or CC3 and CC4 for field 2.
+type Decoder608 struct {
+	channels [2]*channel608
+	current  int
+	last     [2]byte
+	xds      bool
+}
+
+func NewDecoder608(field uint8, fn DisplayFunc) *Decoder608 {
+	d := &Decoder608{current: -1}
+	for i := range d.channels {
+		d.channels[i] = &channel608{
+			track:   fmt.Sprintf("CC%d", 2*int(field&0x01)+i+1),
+			display: fn,
+		}
+	}
+
+	return d
+}
+
+func oddParity(b byte) bool {
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	return b&0x01 > 0
+}
+
+func (d *Decoder608) Decode(b1, b2 byte) {
+	if !oddParity(b1) || !oddParity(b2) {
+		return
+	}
+
+	b1, b2 = b1&0x7f, b2&0x7f
+	switch {
+	case b1 == 0 && b2 == 0:
+	case 0x10 <= b1 && b1 <= 0x1f:
+		// Control codes are sent twice for redundancy.
+		if d.last == [2]byte{b1, b2} {
+			d.last = [2]byte{}
+			return
+		}
+		d.last = [2]byte{b1, b2}
+		d.xds = false
+
+		d.current = int(b1>>3) & 0x01
+		d.channels[d.current].control(b1&^0x08, b2)
+		d.Flush()
+	case b1 < 0x10:
+		// XDS packets are not decoded.
+		d.last = [2]byte{}
+		d.xds = b1 != 0x0f
+	default:
+		d.last = [2]byte{}
+		if d.xds || d.current < 0 {
+			return
+		}
+
+		c := d.channels[d.current]
+		c.char(b1)
+		if b2 >= 0x20 {
+			c.char(b2)
+		}
+		d.Flush()
+	}
+}
+
+// Flush shows the changes of the displayed memories.
+func (d *Decoder608) Flush() {
+	for _, c := range d.channels {
+		if c.dirty {
+			c.commit()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"reflect"
+	"testing"
+)
+
+// withParity sets the parity bit of each byte of a caption.
+func withParity(data ...byte) []byte {
+	for i, b := range data {
+		if !oddParity(b) {
+			data[i] = b | 0x80
+		}
+	}
+	return data
+}
+
+type displayed struct {
+	track string
+	text  string
+}
+
+func decode608(field uint8, data []byte) []displayed {
+	var got []displayed
+	d := NewDecoder608(field, func(track string, text string) {
+		got = append(got, displayed{track, text})
+	})
+	for i := 0; i+1 < len(data); i += 2 {
+		d.Decode(data[i], data[i+1])
+	}
+	d.Flush()
+
+	return got
+}
+
+func TestDecoder608PopOn(t *testing.T) {
+	data := withParity(
+		0x14, 0x20, 0x14, 0x20, // RCL, repeated
+		0x14, 0x70, 0x14, 0x70, // PAC row 15
+		'H', 'e', 'l', 'l', 'o', 0x00,
+		0x14, 0x2f, 0x14, 0x2f, // EOC
+		0x14, 0x2c, // EDM
+	)
+	got := decode608(NTSCField1, data)
+	want := []displayed{{"CC1", "Hello"}, {"CC1", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("displayed %q, want %q", got, want)
+	}
+}
+
+func TestDecoder608RollUp(t *testing.T) {
+	data := withParity(
+		0x1c, 0x25, 0x1c, 0x25, // RU2 of CC4
+		'A', 'B',
+		0x1c, 0x2d, // CR
+		'C', 0x00,
+		0x19, 0x37, // ♪ of CC4
+	)
+	got := decode608(NTSCField2, data)
+	want := []displayed{
+		{"CC4", ""},
+		{"CC4", "AB"},
+		{"CC4", "AB"},
+		{"CC4", "AB\nC"},
+		{"CC4", "AB\nC♪"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("displayed %q, want %q", got, want)
+	}
+
+	// Bytes of even parity are dropped.
+	if got := decode608(NTSCField1, []byte{0x14, 0x25, 'A', 'B'}); got != nil {
+		t.Errorf("displayed %q", got)
+	}
+}
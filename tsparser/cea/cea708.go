@@ -0,0 +1,394 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	windows708    = 8
+	maxRows708    = 15
+	maxColumns708 = 42
+)
+
+// c1Params is the number of parameter bytes of the C1 commands.
+var c1Params = [32]int{
+	0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 0, 0,
+	2, 3, 2, 0, 0, 0, 0, 4, 6, 6, 6, 6, 6, 6, 6, 6,
+}
+
+var g2Chars = map[byte]rune{
+	0x20: ' ', 0x21: ' ', 0x25: '…', 0x2A: 'Š', 0x2C: 'Œ', 0x30: '█', 0x31: '‘', 0x32: '’', 0x33: '“',
+	0x34: '”', 0x35: '•', 0x39: '™', 0x3A: 'š', 0x3C: 'œ', 0x3D: '℠', 0x3F: 'Ÿ', 0x76: '⅛', 0x77: '⅜',
+	0x78: '⅝', 0x79: '⅞', 0x7A: '│', 0x7B: '┐', 0x7C: '└', 0x7D: '─', 0x7E: '┘', 0x7F: '┌',
+}
+
+type window708 struct {
+	defined  bool
+	visible  bool
+	priority int
+	rows     [][]rune
+	penRow   int
+	penCol   int
+}
+
+func (w *window708) clear() {
+	for i := range w.rows {
+		w.rows[i] = nil
+	}
+	w.penRow, w.penCol = 0, 0
+}
+
+func (w *window708) resize(rowCount int) {
+	for len(w.rows) > rowCount {
+		w.rows = w.rows[1:]
+	}
+	for len(w.rows) < rowCount {
+		w.rows = append(w.rows, nil)
+	}
+	if w.penRow >= rowCount {
+		w.penRow = rowCount - 1
+	}
+}
+
+func (w *window708) put(r rune) {
+	if w.penCol >= maxColumns708 {
+		return
+	}
+
+	row := w.rows[w.penRow]
+	for len(row) <= w.penCol {
+		row = append(row, ' ')
+	}
+	row[w.penCol] = r
+	w.rows[w.penRow] = row
+	w.penCol++
+}
+
+func (w *window708) backspace() {
+	if w.penCol == 0 {
+		return
+	}
+
+	w.penCol--
+	if row := w.rows[w.penRow]; w.penCol < len(row) {
+		w.rows[w.penRow] = append(row[:w.penCol], row[w.penCol+1:]...)
+	}
+}
+
+func (w *window708) carriageReturn() {
+	if w.penRow++; w.penRow >= len(w.rows) {
+		copy(w.rows, w.rows[1:])
+		w.rows[len(w.rows)-1] = nil
+		w.penRow = len(w.rows) - 1
+	}
+	w.penCol = 0
+}
+
+// service708 is the windows of a caption service.
+type service708 struct {
+	track   string
+	display DisplayFunc
+	windows [windows708]window708
+	current int
+	dirty   bool
+}
+
+func (s *service708) text() string {
+	var visible []*window708
+	for priority := 0; priority < 8; priority++ {
+		for i := range s.windows {
+			if w := &s.windows[i]; w.defined && w.visible && w.priority == priority {
+				visible = append(visible, w)
+			}
+		}
+	}
+
+	var lines []string
+	for _, w := range visible {
+		for _, row := range w.rows {
+			if text := strings.TrimSpace(string(row)); text != "" {
+				lines = append(lines, text)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (s *service708) commit() {
+	s.dirty = false
+	s.display(s.track, s.text())
+}
+
+func (s *service708) window() *window708 {
+	if w := &s.windows[s.current]; w.defined {
+		return w
+	}
+
+	return nil
+}
+
+func (s *service708) put(r rune) {
+	if w := s.window(); w != nil {
+		w.put(r)
+		s.dirty = s.dirty || w.visible
+	}
+}
+
+func (s *service708) decode(data []byte) {
+	for i := 0; i < len(data); {
+		b := data[i]
+		i++
+
+		switch {
+		case b == 0x10:
+			if i >= len(data) {
+				return
+			}
+			i = s.extended(data, i)
+		case b < 0x20:
+			i = s.c0(b, data, i)
+		case b < 0x80:
+			if b == 0x7f {
+				s.put('♪')
+			} else {
+				s.put(rune(b))
+			}
+		case b < 0xa0:
+			n := c1Params[b-0x80]
+			if i+n > len(data) {
+				return
+			}
+			s.c1(b, data[i:i+n])
+			i += n
+		default:
+			s.put(rune(b))
+		}
+	}
+
+	if s.dirty {
+		s.commit()
+	}
+}
+
+// extended handles a code following EXT1 and returns the position of the
+// next code.
+func (s *service708) extended(data []byte, i int) int {
+	b := data[i]
+	i++
+
+	switch {
+	case b < 0x20:
+		return i + int(b>>3)
+	case b < 0x80:
+		if r, ok := g2Chars[b]; ok {
+			s.put(r)
+		}
+	case b < 0x88:
+		return i + 4
+	case b < 0x90:
+		return i + 5
+	case b < 0xa0:
+		if i < len(data) {
+			return i + 1 + int(data[i]&0x3f)
+		}
+	}
+
+	return i
+}
+
+func (s *service708) c0(b byte, data []byte, i int) int {
+	w := s.window()
+	switch {
+	case b == 0x03: // ETX
+		if s.dirty {
+			s.commit()
+		}
+	case b == 0x08: // BS
+		if w != nil {
+			w.backspace()
+			s.dirty = s.dirty || w.visible
+		}
+	case b == 0x0c: // FF
+		if w != nil {
+			w.clear()
+			s.commit()
+		}
+	case b == 0x0d: // CR
+		if w != nil {
+			w.carriageReturn()
+			s.commit()
+		}
+	case b == 0x0e: // HCR
+		if w != nil {
+			w.rows[w.penRow], w.penCol = nil, 0
+			s.dirty = s.dirty || w.visible
+		}
+	case b == 0x18: // P16
+		if i+2 <= len(data) {
+			s.put(rune(data[i])<<8 | rune(data[i+1]))
+		}
+		return i + 2
+	case 0x11 <= b && b <= 0x17:
+		return i + 1
+	case b > 0x18:
+		return i + 2
+	}
+
+	return i
+}
+
+func (s *service708) c1(b byte, params []byte) {
+	if s.dirty {
+		s.commit()
+	}
+
+	switch {
+	case b <= 0x87: // CW0-CW7
+		s.current = int(b & 0x07)
+		return
+	case b == 0x88: // CLW
+		s.eachWindow(params[0], (*window708).clear)
+	case b == 0x89: // DSW
+		s.eachWindow(params[0], func(w *window708) { w.visible = true })
+	case b == 0x8a: // HDW
+		s.eachWindow(params[0], func(w *window708) { w.visible = false })
+	case b == 0x8b: // TGW
+		s.eachWindow(params[0], func(w *window708) { w.visible = !w.visible })
+	case b == 0x8c: // DLW
+		s.eachWindow(params[0], func(w *window708) { *w = window708{} })
+	case b == 0x8f: // RST
+		s.windows = [windows708]window708{}
+	case b == 0x92: // SPL
+		if w := s.window(); w != nil {
+			w.penRow, w.penCol = int(params[0]&0x0f), int(params[1]&0x3f)
+			if w.penRow >= len(w.rows) {
+				w.penRow = len(w.rows) - 1
+			}
+		}
+		return
+	case b >= 0x98: // DF0-DF7
+		s.current = int(b & 0x07)
+		w := &s.windows[s.current]
+		w.defined = true
+		w.visible = params[0]&0x20 > 0
+		w.priority = int(params[0] & 0x07)
+
+		rowCount := int(params[3]&0x0f) + 1
+		if rowCount > maxRows708 {
+			rowCount = maxRows708
+		}
+		w.resize(rowCount)
+	default:
+		return
+	}
+
+	s.commit()
+}
+
+func (s *service708) eachWindow(bitmap byte, fn func(*window708)) {
+	for i := range s.windows {
+		if bitmap&(1<<uint(i)) > 0 && s.windows[i].defined {
+			fn(&s.windows[i])
+		}
+	}
+}
+
+// Decoder708 assembles DTVCC packets from cc_data and decodes the service
+// blocks into the caption tracks SERVICE1 to SERVICE63.
+type Decoder708 struct {
+	display  DisplayFunc
+	packet   []byte
+	services map[int]*service708
+}
+
+func NewDecoder708(fn DisplayFunc) *Decoder708 {
+	return &Decoder708{
+		display:  fn,
+		services: make(map[int]*service708),
+	}
+}
+
+func (d *Decoder708) Push(cc CCData) {
+	if !cc.Valid {
+		return
+	}
+
+	switch cc.Type {
+	case DTVCCStart:
+		d.finish()
+		d.packet = append(d.packet[:0], cc.Data[:]...)
+	case DTVCCData:
+		if len(d.packet) == 0 {
+			return
+		}
+		d.packet = append(d.packet, cc.Data[:]...)
+	default:
+		return
+	}
+
+	if len(d.packet) >= packetSize(d.packet[0]) {
+		d.finish()
+	}
+}
+
+func packetSize(header byte) int {
+	if code := int(header & 0x3f); code > 0 {
+		return 2 * code
+	}
+
+	return 128
+}
+
+func (d *Decoder708) finish() {
+	if len(d.packet) == 0 {
+		return
+	}
+
+	packet := d.packet[1:]
+	if size := packetSize(d.packet[0]); size <= len(d.packet) {
+		packet = d.packet[1:size]
+	}
+	d.packet = d.packet[:0]
+
+	for i := 0; i < len(packet); {
+		number, size := int(packet[i]>>5), int(packet[i]&0x1f)
+		i++
+		if number == 7 && size > 0 {
+			if i >= len(packet) {
+				return
+			}
+			number = int(packet[i] & 0x3f)
+			i++
+		}
+
+		if number == 0 || i+size > len(packet) {
+			return
+		}
+		d.service(number).decode(packet[i : i+size])
+		i += size
+	}
+}
+
+func (d *Decoder708) service(number int) *service708 {
+	s, ok := d.services[number]
+	if !ok {
+		s = &service708{
+			track:   fmt.Sprintf("SERVICE%d", number),
+			display: d.display,
+		}
+		d.services[number] = s
+	}
+
+	return s
+}
+
+// Flush decodes the packet in progress.
+func (d *Decoder708) Flush() {
+	d.finish()
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecoder708(t *testing.T) {
+	var got []displayed
+	d := NewDecoder708(func(track string, text string) {
+		got = append(got, displayed{track, text})
+	})
+
+	packets := [][]byte{
+		// DF0 of a visible window of a row, and "Hi" on service 1.
+		{0x06, 0x29, 0x98, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x48, 0x69, 0x00},
+		// CR and "there" on service 1, and "Hola" on service 2 which has
+		// no window defined.
+		{0x47, 0x26, 0x0d, 't', 'h', 'e', 'r', 'e', 0x44, 'H', 'o', 'l', 'a', 0x00},
+		// CLW of window 0 on service 1.
+		{0x82, 0x22, 0x88, 0x01},
+	}
+	for _, packet := range packets {
+		for i := 0; i < len(packet); i += 2 {
+			cc := CCData{Valid: true, Type: DTVCCData, Data: [2]byte{packet[i], packet[i+1]}}
+			if i == 0 {
+				cc.Type = DTVCCStart
+			}
+			d.Push(cc)
+		}
+	}
+	d.Flush()
+
+	want := []displayed{
+		{"SERVICE1", ""},
+		{"SERVICE1", "Hi"},
+		{"SERVICE1", ""},
+		{"SERVICE1", "there"},
+		{"SERVICE1", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("displayed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/h264"
+	"github.com/yosida95/tsparser/tsparser/hevc"
+	"github.com/yosida95/tsparser/tsparser/mpeg2video"
+)
+
+var ErrUnsupportedStream = errors.New("Unsupported stream type")
+
+// maxReorderDepth bounds the pictures waiting to be put into presentation
+// order.
+const maxReorderDepth = 32
+
+// Caption is a text shown on a caption track from Start until End, which
+// are 90 kHz clocks from the PTS of the first picture.
+type Caption struct {
+	Track string
+	Start int64
+	End   int64
+	Text  string
+}
+
+type picture struct {
+	pts  uint64
+	data []CCData
+}
+
+// Extractor decodes the captions carried in the user data of a video
+// stream.  The cc_data of the pictures are fed to the decoders in
+// presentation order.
+type Extractor struct {
+	s          *tsparser.PESScanner
+	pid        tsparser.PID
+	streamType tsparser.StreamType
+	logger     *log.Logger
+	handler    func(*Caption) error
+
+	field1 *Decoder608
+	field2 *Decoder608
+	dtvcc  *Decoder708
+
+	pictures  []picture
+	lastPTS   uint64
+	hasPTS    bool
+	origin    uint64
+	presented uint64
+	clock     int64
+	started   bool
+	captions  map[string]*Caption
+	err       error
+}
+
+func NewExtractor(s *tsparser.PESScanner, es *tsparser.ElementaryStream, logger *log.Logger) *Extractor {
+	e := &Extractor{
+		s:          s,
+		pid:        es.PID(),
+		streamType: es.StreamType(),
+		logger:     logger,
+		captions:   make(map[string]*Caption),
+	}
+	e.field1 = NewDecoder608(NTSCField1, e.display)
+	e.field2 = NewDecoder608(NTSCField2, e.display)
+	e.dtvcc = NewDecoder708(e.display)
+
+	return e
+}
+
+// SetHandler sets the function which is called with every caption when it
+// is cleared from the screen.
+func (e *Extractor) SetHandler(fn func(*Caption) error) {
+	e.handler = fn
+}
+
+// Origin returns the PTS of the first picture, which the clocks of the
+// captions are measured from.
+func (e *Extractor) Origin() (uint64, bool) {
+	return e.origin, e.started
+}
+
+func (e *Extractor) Run() error {
+	switch e.streamType {
+	case tsparser.H264VideoStream:
+		scanner := h264.NewAccessUnitScanner(e.s, e.pid, e.logger)
+		for scanner.Scan() && e.err == nil {
+			au := scanner.AccessUnit()
+
+			var data []CCData
+			for _, nal := range au.NALUnits {
+				for _, message := range h264.ParseSEI(nal) {
+					if message.PayloadType == h264.UserDataRegisteredITUTT35SEI {
+						data = append(data, ParseRegisteredUserData(message.Payload)...)
+					}
+				}
+			}
+			e.push(au.PTS, au.DTS, au.HasPTS, data)
+		}
+	case tsparser.HEVCVideoStream:
+		scanner := hevc.NewAccessUnitScanner(e.s, e.pid, e.logger)
+		for scanner.Scan() && e.err == nil {
+			au := scanner.AccessUnit()
+
+			var data []CCData
+			for _, nal := range au.NALUnits {
+				for _, message := range hevc.ParseSEI(nal) {
+					if message.PayloadType == h264.UserDataRegisteredITUTT35SEI {
+						data = append(data, ParseRegisteredUserData(message.Payload)...)
+					}
+				}
+			}
+			e.push(au.PTS, au.DTS, au.HasPTS, data)
+		}
+	case tsparser.MPEG1VideoStream, tsparser.MPEG2VideoStream:
+		scanner := mpeg2video.NewPictureScanner(e.s, e.pid, e.logger)
+		for scanner.Scan() && e.err == nil {
+			p := scanner.Picture()
+
+			var data []CCData
+			for _, userData := range p.UserData {
+				data = append(data, ParseUserData(userData)...)
+			}
+			e.push(p.PTS, p.DTS, p.HasPTS, data)
+		}
+	default:
+		return ErrUnsupportedStream
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.flush()
+}
+
+// push adds a picture in decoding order.  No picture decoded later is
+// presented before the DTS of this picture, so the pictures presented by
+// then are released.
+func (e *Extractor) push(pts, dts uint64, hasPTS bool, data []CCData) {
+	if !hasPTS {
+		if !e.hasPTS {
+			return
+		}
+		pts, dts = e.lastPTS, e.lastPTS
+	}
+	e.lastPTS, e.hasPTS = pts, true
+
+	i := len(e.pictures)
+	for i > 0 && tsparser.TimestampDiff(e.pictures[i-1].pts, pts) > 0 {
+		i--
+	}
+	e.pictures = append(e.pictures, picture{})
+	copy(e.pictures[i+1:], e.pictures[i:])
+	e.pictures[i] = picture{pts, data}
+
+	n := 0
+	for n < len(e.pictures) && (tsparser.TimestampDiff(e.pictures[n].pts, dts) <= 0 || len(e.pictures)-n > maxReorderDepth) {
+		e.decode(e.pictures[n])
+		n++
+	}
+	e.pictures = e.pictures[n:]
+}
+
+func (e *Extractor) decode(p picture) {
+	if !e.started {
+		e.origin, e.presented, e.started = p.pts, p.pts, true
+	}
+	e.clock += tsparser.TimestampDiff(p.pts, e.presented)
+	e.presented = p.pts
+
+	for _, cc := range p.data {
+		switch {
+		case !cc.Valid:
+		case cc.Type == NTSCField1:
+			e.field1.Decode(cc.Data[0], cc.Data[1])
+		case cc.Type == NTSCField2:
+			e.field2.Decode(cc.Data[0], cc.Data[1])
+		default:
+			e.dtvcc.Push(cc)
+		}
+	}
+}
+
+// display follows the screen of a track.  Characters painted onto the
+// last line extend the caption on the screen, so that roll-up and paint-on
+// captions are not split into every character.
+func (e *Extractor) display(track string, text string) {
+	if c, ok := e.captions[track]; ok {
+		if c.Text == text {
+			return
+		} else if strings.HasPrefix(text, c.Text) && !strings.Contains(text[len(c.Text):], "\n") {
+			c.Text = text
+			return
+		}
+
+		delete(e.captions, track)
+		c.End = e.clock
+		e.emit(c)
+	}
+
+	if text != "" {
+		e.captions[track] = &Caption{
+			Track: track,
+			Start: e.clock,
+			Text:  text,
+		}
+	}
+}
+
+func (e *Extractor) emit(c *Caption) {
+	if e.err != nil || e.handler == nil || c.End <= c.Start {
+		return
+	}
+
+	e.err = e.handler(c)
+}
+
+func (e *Extractor) flush() error {
+	for _, p := range e.pictures {
+		e.decode(p)
+	}
+	e.pictures = nil
+
+	e.field1.Flush()
+	e.field2.Flush()
+	e.dtvcc.Flush()
+
+	tracks := make([]string, 0, len(e.captions))
+	for track := range e.captions {
+		tracks = append(tracks, track)
+	}
+	sort.Strings(tracks)
+
+	for _, track := range tracks {
+		c := e.captions[track]
+		c.End = e.clock
+		e.emit(c)
+	}
+	e.captions = make(map[string]*Caption)
+
+	return e.err
+}
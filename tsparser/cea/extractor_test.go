@@ -0,0 +1,82 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	accessUnitDelimiter = []byte{0x09, 0xf0}
+	// High profile 1920x1080 progressive at 29.97 fps.
+	testSPS = []byte{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78, 0x02, 0x27, 0xe5, 0xc0, 0x5a,
+		0x80, 0x80, 0x80, 0xa0, 0x00, 0x00, 0x7d, 0x20, 0x00, 0x1d, 0x4c, 0x10, 0x80,
+	}
+	testPPS  = []byte{0x68, 0xee, 0x3c, 0x80}
+	idrSlice = []byte{
+		0x65, 0x88, 0x80, 0x00, 0x04, 0x00, 0x00, 0x03, 0x03, 0x00, 0x00, 0x03, 0x01, 0x55,
+	}
+	pSlice = []byte{0x41, 0x9a, 0x22, 0x04}
+)
+
+// captionSEI returns a user_data_registered_itu_t_t35 SEI carrying two
+// byte pairs of CEA-608 field 1.
+func captionSEI(b1, b2, b3, b4 byte) []byte {
+	data := withParity(b1, b2, b3, b4)
+	return []byte{
+		0x06, 0x04, 0x10, 0xb5, 0x00, 0x31, 'G', 'A', '9', '4', 0x03, 0x42, 0xff,
+		0xfc, data[0], data[1], 0xfc, data[2], data[3], 0x80,
+	}
+}
+
+func TestExtractor(t *testing.T) {
+	ts := fixture.Packetize(0x0100,
+		fixture.PES(0xe0, 90000, fixture.AnnexB(accessUnitDelimiter, testSPS, testPPS, captionSEI(0x14, 0x20, 'H', 'I'), idrSlice)),
+		fixture.PES(0xe0, 93003, fixture.AnnexB(accessUnitDelimiter, captionSEI(0x14, 0x2f, 0x00, 0x00), pSlice)),
+		fixture.PES(0xe0, 96006, fixture.AnnexB(accessUnitDelimiter, pSlice)),
+		fixture.PES(0xe0, 99009, fixture.AnnexB(accessUnitDelimiter, captionSEI(0x14, 0x2c, 0x00, 0x00), pSlice)),
+		fixture.PES(0xe0, 102012, fixture.AnnexB(accessUnitDelimiter, captionSEI(0x14, 0x20, 'O', 'K'), pSlice)),
+		fixture.PES(0xe0, 105015, fixture.AnnexB(accessUnitDelimiter, captionSEI(0x14, 0x2f, 0x00, 0x00), pSlice)),
+		fixture.PES(0xe0, 108018, fixture.AnnexB(accessUnitDelimiter, pSlice)),
+	)
+
+	s := tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(ts), nil), nil)
+	e := NewExtractor(s, tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100), nil)
+
+	var captions []Caption
+	e.SetHandler(func(c *Caption) error {
+		captions = append(captions, *c)
+		return nil
+	})
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The caption left on the screen ends at the last picture.
+	want := []Caption{
+		{"CC1", 3003, 9009, "HI"},
+		{"CC1", 15015, 18018, "OK"},
+	}
+	if !reflect.DeepEqual(captions, want) {
+		t.Errorf("captions = %+v, want %+v", captions, want)
+	}
+	if origin, ok := e.Origin(); !ok || origin != 90000 {
+		t.Errorf("origin = %d (%v)", origin, ok)
+	}
+}
+
+func TestExtractorUnsupportedStream(t *testing.T) {
+	s := tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(nil), nil), nil)
+	e := NewExtractor(s, tsparser.NewElementaryStream(tsparser.AACADTSStream, 0x0110), nil)
+	if err := e.Run(); err != ErrUnsupportedStream {
+		t.Errorf("err = %v", err)
+	}
+}
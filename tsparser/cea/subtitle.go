@@ -0,0 +1,70 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type CaptionWriter interface {
+	WriteCaption(c *Caption) error
+}
+
+func formatClock(clock int64, separator string) string {
+	if clock < 0 {
+		clock = 0
+	}
+
+	ms := clock * 1000 / tsparser.ClockRate
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
+
+type SRTWriter struct {
+	w     io.Writer
+	index int
+}
+
+func NewSRTWriter(w io.Writer) *SRTWriter {
+	return &SRTWriter{w: w}
+}
+
+func (w *SRTWriter) WriteCaption(c *Caption) error {
+	w.index++
+	_, err := fmt.Fprintf(w.w, "%d\n%s --> %s\n%s\n\n",
+		w.index, formatClock(c.Start, ","), formatClock(c.End, ","), c.Text)
+	return err
+}
+
+var webVTTEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WebVTTWriter writes captions in WebVTT with X-TIMESTAMP-MAP, which maps
+// the clocks of the captions onto the PTS of the stream as HLS expects.
+type WebVTTWriter struct {
+	w      io.Writer
+	origin uint64
+	header bool
+}
+
+func NewWebVTTWriter(w io.Writer, origin uint64) *WebVTTWriter {
+	return &WebVTTWriter{w: w, origin: origin}
+}
+
+func (w *WebVTTWriter) WriteCaption(c *Caption) error {
+	if !w.header {
+		w.header = true
+		_, err := fmt.Fprintf(w.w, "WEBVTT\nX-TIMESTAMP-MAP=MPEGTS:%d,LOCAL:00:00:00.000\n\n", w.origin)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w.w, "%s --> %s\n%s\n\n",
+		formatClock(c.Start, "."), formatClock(c.End, "."), webVTTEscaper.Replace(c.Text))
+	return err
+}
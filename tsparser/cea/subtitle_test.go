@@ -0,0 +1,48 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cea
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testCaptions = []*Caption{
+	{"CC1", 3003, 9009, "HI"},
+	{"CC1", 5405400, 5508900, "<Tom & Jerry>\nmusic"},
+}
+
+func TestSRTWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSRTWriter(&buf)
+	for _, c := range testCaptions {
+		if err := w.WriteCaption(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "1\n00:00:00,033 --> 00:00:00,100\nHI\n\n" +
+		"2\n00:01:00,060 --> 00:01:01,210\n<Tom & Jerry>\nmusic\n\n"
+	if buf.String() != want {
+		t.Errorf("SRT = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWebVTTWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWebVTTWriter(&buf, 90000)
+	for _, c := range testCaptions {
+		if err := w.WriteCaption(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "WEBVTT\nX-TIMESTAMP-MAP=MPEGTS:90000,LOCAL:00:00:00.000\n\n" +
+		"00:00:00.033 --> 00:00:00.100\nHI\n\n" +
+		"00:01:00.060 --> 00:01:01.210\n&lt;Tom &amp; Jerry&gt;\nmusic\n\n"
+	if buf.String() != want {
+		t.Errorf("WebVTT = %q, want %q", buf.String(), want)
+	}
+}
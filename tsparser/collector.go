@@ -0,0 +1,116 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+type sectionBuffer struct {
+	data   []byte
+	lastCC uint8
+	seen   bool
+}
+
+func (b *sectionBuffer) checkContinuity(cc uint8) (duplicated bool, err error) {
+	lastCC, seen := b.lastCC, b.seen
+	b.lastCC, b.seen = cc, true
+	if !seen {
+		return
+	}
+
+	if cc == lastCC {
+		return true, nil
+	} else if cc != (lastCC+1)&0x0f {
+		b.data = b.data[:0]
+		return false, ErrPacketDropped
+	}
+
+	return
+}
+
+func (b *sectionBuffer) collect() (tables []Table) {
+	for len(b.data) >= 3 {
+		if b.data[0] == 0xff {
+			b.data = b.data[:0]
+			break
+		}
+
+		length := Table(b.data).SectionLength() + 3
+		if len(b.data) < length {
+			break
+		}
+
+		table := make(Table, length)
+		copy(table, b.data)
+		b.data = b.data[length:]
+		if table.validate() == nil {
+			tables = append(tables, table)
+		}
+	}
+
+	if len(b.data) == 0 {
+		b.data = nil
+	}
+	return
+}
+
+type TableCollector struct {
+	buffers map[PID]*sectionBuffer
+}
+
+func NewTableCollector() *TableCollector {
+	return &TableCollector{
+		buffers: make(map[PID]*sectionBuffer),
+	}
+}
+
+func (c *TableCollector) Reset(pid PID) {
+	delete(c.buffers, pid)
+}
+
+func (c *TableCollector) Feed(p Packet) (tables []Table, err error) {
+	if !p.HasPayload() {
+		return
+	} else if p.TransportScramblingControl() > 0 {
+		return nil, ErrPacketScrambled
+	}
+
+	buffer, ok := c.buffers[p.PID()]
+	if !ok {
+		buffer = new(sectionBuffer)
+		c.buffers[p.PID()] = buffer
+	}
+
+	duplicated, err := buffer.checkContinuity(p.ContinuityCounter())
+	if duplicated {
+		return
+	}
+
+	payload := p.Payload()
+	if !p.PayloadUnitStartIndicator() {
+		if len(buffer.data) > 0 {
+			buffer.data = append(buffer.data, payload...)
+			tables = buffer.collect()
+		}
+		return
+	}
+
+	if len(payload) == 0 {
+		buffer.data = nil
+		return nil, ErrInvalidPointer
+	}
+
+	pointerField := int(payload[0])
+	if pointerField >= len(payload) {
+		buffer.data = nil
+		return nil, ErrInvalidPointer
+	}
+
+	if len(buffer.data) > 0 {
+		buffer.data = append(buffer.data, payload[1:1+pointerField]...)
+		tables = buffer.collect()
+	}
+
+	buffer.data = append(buffer.data[:0], payload[1+pointerField:]...)
+	tables = append(tables, buffer.collect()...)
+	return
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTableCollectorEmptyPayload(t *testing.T) {
+	// A packet starting a section whose adaptation field takes up all
+	// the bytes, leaving no room for pointer_field.
+	p := make(Packet, PacketSize)
+	copy(p, []byte{0x47, 0x40, 0x00, 0x30, 0xb7, 0x00})
+	for i := 6; i < PacketSize; i++ {
+		p[i] = 0xff
+	}
+
+	collector := NewTableCollector()
+	if tables, err := collector.Feed(p); err != ErrInvalidPointer || tables != nil {
+		t.Errorf("tables = %v, err = %v", tables, err)
+	}
+
+	// The collector recovers at the next section.
+	pat := NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	table, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewPacketWriter(&buf).WriteTables(0x0000, table); err != nil {
+		t.Fatal(err)
+	}
+	next := Packet(buf.Bytes())
+	next[3] = next[3]&0xf0 | 0x01
+	if tables, err := collector.Feed(next); err != nil || len(tables) != 1 || !bytes.Equal(tables[0], table) {
+		t.Errorf("tables = %x, err = %v", tables, err)
+	}
+}
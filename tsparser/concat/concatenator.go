@@ -0,0 +1,402 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package concat
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	pcrWrap     = (tsparser.TimestampMask + 1) * 300
+	maxInterval = tsparser.ClockRate
+)
+
+type tableKey struct {
+	pid     tsparser.PID
+	tableId tsparser.TableId
+	number  uint16
+	section uint8
+}
+
+type tableState struct {
+	last    tsparser.Table
+	version uint8
+}
+
+// item is a buffered packet, or a rewritten PSI table in place of the
+// packets which carried it.
+type item struct {
+	packet tsparser.Packet
+	pid    tsparser.PID
+	table  tsparser.Table
+}
+
+// Concatenator joins transport streams read one after another, e.g. from
+// a MultiFileReader, into a single stream.  A join is detected as a time
+// base discontinuity of the PCR of the first program.  PCR, PTS and DTS
+// after a join are shifted so that they continue from the preceding
+// stream, unless SetDiscontinuity is set, in which case the original time
+// base is kept and the discontinuity_indicator is set instead.
+type Concatenator struct {
+	s             tsparser.PacketStream
+	w             *tsparser.PacketWriter
+	logger        *log.Logger
+	discontinuity bool
+
+	collector *tsparser.TableCollector
+	tables    map[tableKey]*tableState
+	current   map[tsparser.PID][]tsparser.Table
+	pmtPIDs   map[tsparser.PID]bool
+	esPIDs    map[tsparser.PID]bool
+	firstPMT  tsparser.PID
+	timeline  *tsparser.Timeline
+
+	hasPCR   bool
+	lastPCR  uint64
+	interval uint64
+	offset   uint64
+
+	hasEnd    bool
+	end       uint64
+	lastDTS   map[tsparser.PID]uint64
+	intervals map[tsparser.PID]uint64
+
+	buffer  []item
+	started map[tsparser.PID]bool
+	joins   int
+}
+
+func NewConcatenator(s tsparser.PacketStream, w io.Writer, logger *log.Logger) *Concatenator {
+	return &Concatenator{
+		s:         s,
+		w:         tsparser.NewPacketWriter(w),
+		logger:    logger,
+		collector: tsparser.NewTableCollector(),
+		tables:    make(map[tableKey]*tableState),
+		current:   make(map[tsparser.PID][]tsparser.Table),
+		pmtPIDs:   make(map[tsparser.PID]bool),
+		esPIDs:    make(map[tsparser.PID]bool),
+		firstPMT:  tsparser.NullPID,
+		timeline:  tsparser.NewTimeline(tsparser.NullPID),
+		lastDTS:   make(map[tsparser.PID]uint64),
+		intervals: make(map[tsparser.PID]uint64),
+		started:   make(map[tsparser.PID]bool),
+	}
+}
+
+func (c *Concatenator) log(p tsparser.Packet, v ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	c.logger.Print(values...)
+}
+
+// SetDiscontinuity makes joins signalled by the discontinuity_indicator
+// instead of rewriting the timestamps.
+func (c *Concatenator) SetDiscontinuity(discontinuity bool) {
+	c.discontinuity = discontinuity
+}
+
+// Joins returns the number of joins found so far.
+func (c *Concatenator) Joins() int {
+	return c.joins
+}
+
+func (c *Concatenator) Run() error {
+	for c.s.Scan() {
+		if err := c.handle(c.s.Packet()); err != nil {
+			return err
+		}
+	}
+
+	return c.flush(c.buffer)
+}
+
+func (c *Concatenator) handle(packet tsparser.Packet) error {
+	pid := packet.PID()
+	if pid == tsparser.ProgramAssociationPID || c.pmtPIDs[pid] {
+		c.handleTables(packet)
+		return nil
+	}
+
+	copied := make(tsparser.Packet, len(packet))
+	copy(copied, packet)
+
+	if pid != c.timeline.PCRPID() || !packet.HasAdaptationField() || !packet.AdaptationField().HasPCR() {
+		c.buffer = append(c.buffer, item{packet: copied, pid: pid})
+		return nil
+	}
+
+	pcr := packet.AdaptationField().PCR()
+	if c.timeline.Feed(packet) {
+		return c.join(copied, pcr)
+	}
+
+	if c.hasPCR {
+		c.interval = (pcr + pcrWrap - c.lastPCR) % pcrWrap
+	}
+	c.hasPCR, c.lastPCR = true, pcr
+
+	if err := c.flush(c.buffer); err != nil {
+		return err
+	}
+	c.buffer = append(c.buffer[:0], item{packet: copied, pid: pid})
+	return nil
+}
+
+// join writes out the packets buffered since the last PCR that precede the
+// next stream, which is assumed to start with its PAT, and shifts the time
+// base of the rest.  The last PAT and PMT are repeated at the join if the
+// next stream has not sent its own yet.
+func (c *Concatenator) join(packet tsparser.Packet, pcr uint64) error {
+	split := len(c.buffer)
+	for i, it := range c.buffer {
+		if it.table != nil && it.pid == tsparser.ProgramAssociationPID {
+			split = i
+			break
+		}
+	}
+
+	head := make([]item, 0, len(c.buffer)-split+1)
+	head = append(head, c.buffer[split:]...)
+	if err := c.flush(c.buffer[:split]); err != nil {
+		return err
+	}
+
+	c.joins++
+	c.started = make(map[tsparser.PID]bool)
+	if c.discontinuity {
+		packet.AdaptationField().SetDiscontinuityIndicator(true)
+		c.log(packet, "Join ", c.joins)
+	} else {
+		c.offset = c.rebase(append(head, item{packet: packet, pid: packet.PID()}), pcr)
+		c.log(packet, "Join ", c.joins, ", offset=", c.offset)
+	}
+	c.hasPCR, c.lastPCR = true, pcr
+
+	c.buffer = c.buffer[:0]
+	if len(head) == 0 || head[0].pid != tsparser.ProgramAssociationPID {
+		for _, pid := range c.tablePIDs() {
+			for _, table := range c.current[pid] {
+				c.buffer = append(c.buffer, item{pid: pid, table: table})
+			}
+		}
+	}
+	c.buffer = append(c.buffer, head...)
+	c.buffer = append(c.buffer, item{packet: packet, pid: packet.PID()})
+	return nil
+}
+
+// rebase returns the offset which makes the stream starting with items
+// follow the end of the previous one in both of PCR and DTS.
+func (c *Concatenator) rebase(items []item, pcr uint64) uint64 {
+	next := c.lastPCR/300 + c.offset + (c.interval+299)/300
+	need := tsparser.TimestampDiff(next, pcr/300)
+
+	hasFirst, first := false, uint64(0)
+	for _, it := range items {
+		pes, ok := c.pes(it)
+		if !ok {
+			continue
+		}
+
+		if dts := pes.DTS(); !hasFirst || tsparser.TimestampDiff(dts, first) < 0 {
+			hasFirst, first = true, dts
+		}
+	}
+	if hasFirst && c.hasEnd {
+		if d := tsparser.TimestampDiff(c.end, first); d > need {
+			need = d
+		}
+	}
+
+	return uint64(need) & tsparser.TimestampMask
+}
+
+func (c *Concatenator) tablePIDs() []tsparser.PID {
+	pids := []tsparser.PID{tsparser.ProgramAssociationPID}
+	for pid := range c.pmtPIDs {
+		pids = append(pids, pid)
+	}
+
+	return pids
+}
+
+// pes returns the PES header at the start of the payload of a packet of an
+// elementary stream.
+func (c *Concatenator) pes(it item) (tsparser.PES, bool) {
+	if it.packet == nil || !c.esPIDs[it.pid] || !it.packet.PayloadUnitStartIndicator() {
+		return nil, false
+	}
+
+	pes := tsparser.PES(it.packet.Payload())
+	if len(pes) < 14 || pes[0] != 0x00 || pes[1] != 0x00 || pes[2] != 0x01 || !pes.HasPTS() {
+		return nil, false
+	}
+	return pes, true
+}
+
+func (c *Concatenator) flush(items []item) error {
+	for _, it := range items {
+		if it.table != nil {
+			if err := c.w.WriteTables(it.pid, it.table); err != nil {
+				return err
+			}
+			continue
+		}
+
+		packet := it.packet
+		if !c.started[it.pid] {
+			if packet.HasPayload() && !packet.PayloadUnitStartIndicator() {
+				continue
+			}
+			c.started[it.pid] = packet.HasPayload()
+		}
+
+		if c.offset != 0 && packet.HasAdaptationField() {
+			if af := packet.AdaptationField(); af.HasPCR() {
+				af.SetPCR(af.PCR() + c.offset*300)
+			}
+		}
+		if pes, ok := c.pes(it); ok {
+			pts, dts := pes.PTS()+c.offset, pes.DTS()+c.offset
+			pes.SetPTS(pts)
+			pes.SetDTS(dts)
+			c.updateEnd(it.pid, dts&tsparser.TimestampMask)
+		}
+
+		if err := c.w.WritePacket(packet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateEnd tracks the DTS next to the last access unit of all streams.
+func (c *Concatenator) updateEnd(pid tsparser.PID, dts uint64) {
+	if last, ok := c.lastDTS[pid]; ok {
+		if d := tsparser.TimestampDiff(dts, last); d > 0 && d < maxInterval {
+			c.intervals[pid] = uint64(d)
+		}
+	}
+	c.lastDTS[pid] = dts
+
+	end := (dts + c.intervals[pid]) & tsparser.TimestampMask
+	if !c.hasEnd || tsparser.TimestampDiff(end, c.end) > 0 {
+		c.hasEnd, c.end = true, end
+	}
+}
+
+func (c *Concatenator) handleTables(packet tsparser.Packet) {
+	tables, err := c.collector.Feed(packet)
+	if err != nil {
+		c.log(packet, err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			c.log(packet, "CRC mismatch")
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			c.handleProgramAssociation(table)
+		case tsparser.ProgramMapTable:
+			c.handleProgramMap(packet.PID(), table)
+		default:
+			continue
+		}
+
+		rewritten := c.rewrite(packet, table)
+		c.current[packet.PID()] = []tsparser.Table{rewritten}
+		c.buffer = append(c.buffer, item{pid: packet.PID(), table: rewritten})
+	}
+}
+
+// rewrite renumbers the version of a table so that a table which differs
+// from the last one of the same program, possibly in another stream, has a
+// new version.
+func (c *Concatenator) rewrite(packet tsparser.Packet, table tsparser.Table) tsparser.Table {
+	key := tableKey{packet.PID(), table.TableId(), table.TableIdExtension(), table.SectionNumber()}
+	state, ok := c.tables[key]
+	switch {
+	case !ok:
+		state = &tableState{version: table.VersionNumber()}
+		c.tables[key] = state
+	case !sameContent(state.last, table):
+		state.version = (state.version + 1) & 0x1f
+		c.log(packet, "Table 0x", fmt.Sprintf("%02x", uint8(table.TableId())), " changed, version=", state.version)
+	}
+	state.last = table
+
+	if state.version == table.VersionNumber() {
+		return table
+	}
+
+	rewritten := make(tsparser.Table, len(table))
+	copy(rewritten, table)
+	rewritten.SetVersionNumber(state.version)
+	return rewritten
+}
+
+// sameContent compares two sections except for version_number and CRC.
+func sameContent(a, b tsparser.Table) bool {
+	if len(a) != len(b) || len(a) < 12 {
+		return false
+	}
+
+	for i := 0; i < len(a)-4; i++ {
+		if i == 5 {
+			if a[i]&0xc1 != b[i]&0xc1 {
+				return false
+			}
+		} else if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Concatenator) handleProgramAssociation(table tsparser.Table) {
+	programs := tsparser.ParseProgramAssociationSection(table).ProgramMap()
+
+	var first uint16
+	pmtPIDs := make(map[tsparser.PID]bool)
+	for number, pid := range programs {
+		pmtPIDs[pid] = true
+		if first == 0 || number < first {
+			first, c.firstPMT = number, pid
+		}
+	}
+
+	for pid := range c.pmtPIDs {
+		if !pmtPIDs[pid] {
+			c.collector.Reset(pid)
+			delete(c.current, pid)
+		}
+	}
+	c.pmtPIDs = pmtPIDs
+}
+
+func (c *Concatenator) handleProgramMap(pid tsparser.PID, table tsparser.Table) {
+	pmt := tsparser.ParseProgramMapSection(table)
+	for _, es := range pmt.Streams() {
+		c.esPIDs[es.PID()] = true
+	}
+
+	if pid == c.firstPMT && pmt.PCRPID() != c.timeline.PCRPID() {
+		c.timeline.SetPCRPID(pmt.PCRPID())
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cut
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/video"
+)
+
+var (
+	ErrNoRange       = errors.New("Range is not specified")
+	ErrNoStartPoint  = errors.New("Random access point is not found in the range")
+	ErrEventNotFound = errors.New("Event is not found")
+)
+
+const maxTrailingPackets = 20000
+
+type state uint8
+
+const (
+	stateWaiting state = iota
+	stateCutting
+	stateEnding
+	stateDone
+)
+
+type rangeKind uint8
+
+const (
+	rangeNone rangeKind = iota
+	rangePTS
+	rangeTime
+	rangeEvent
+)
+
+type Cutter struct {
+	s       tsparser.PacketStream
+	w       *tsparser.PacketWriter
+	program *tsparser.ProgramTracker
+	logger  *log.Logger
+
+	kind      rangeKind
+	startPTS  uint64
+	endPTS    uint64
+	hasPTS    bool
+	startTime time.Time
+	endTime   time.Time
+	eventId   uint16
+
+	collector  *tsparser.TableCollector
+	pat        tsparser.Table
+	patPending bool
+	pmt        tsparser.Table
+	pids       map[tsparser.PID]bool
+	video      *tsparser.ElementaryStream
+
+	lastPTS    uint64
+	hasLastPTS bool
+
+	state    state
+	buffer   []tsparser.Packet
+	started  map[tsparser.PID]bool
+	finished map[tsparser.PID]bool
+	trailing int
+}
+
+func NewCutter(s tsparser.PacketStream, w io.Writer, serviceId uint16, logger *log.Logger) *Cutter {
+	return &Cutter{
+		s:         s,
+		w:         tsparser.NewPacketWriter(w),
+		program:   tsparser.NewProgramTracker(serviceId),
+		logger:    logger,
+		collector: tsparser.NewTableCollector(),
+		pids:      make(map[tsparser.PID]bool),
+		started:   make(map[tsparser.PID]bool),
+		finished:  make(map[tsparser.PID]bool),
+	}
+}
+
+func (c *Cutter) log(p tsparser.Packet, v ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	c.logger.Print(values...)
+}
+
+func (c *Cutter) SetPTSRange(start, end uint64) {
+	c.kind = rangePTS
+	c.startPTS = start & tsparser.TimestampMask
+	c.endPTS = end & tsparser.TimestampMask
+	c.hasPTS = true
+}
+
+func (c *Cutter) SetTimeRange(start, end time.Time) {
+	c.kind = rangeTime
+	c.startTime, c.endTime = start, end
+	c.hasPTS = false
+}
+
+func (c *Cutter) SetEvent(eventId uint16) {
+	c.kind = rangeEvent
+	c.eventId = eventId
+	c.hasPTS = false
+}
+
+func isAfter(a, b uint64) bool {
+	return (a-b)&tsparser.TimestampMask < 1<<(tsparser.TimestampBits-1)
+}
+
+func (c *Cutter) Run() error {
+	if c.kind == rangeNone {
+		return ErrNoRange
+	}
+
+	for c.state != stateDone && c.s.Scan() {
+		packet := c.s.Packet()
+		if err := c.handle(packet); err != nil {
+			return err
+		}
+	}
+
+	switch c.state {
+	case stateWaiting:
+		if c.kind == rangeEvent && !c.hasPTS {
+			return ErrEventNotFound
+		}
+		return ErrNoStartPoint
+	}
+	return nil
+}
+
+func (c *Cutter) handle(packet tsparser.Packet) error {
+	pid := packet.PID()
+	switch {
+	case pid == tsparser.ProgramAssociationPID, pid == c.program.PMTPID(),
+		pid == arib.TimeDatePID && c.kind != rangePTS,
+		pid == arib.EventInformationPID && c.kind == rangeEvent:
+		c.handleTables(packet)
+	}
+
+	// The program is cut out alone with PAT rewritten to list only it.
+	if pid == tsparser.ProgramAssociationPID {
+		if c.patPending && c.state == stateCutting {
+			c.patPending = false
+			return c.w.WriteTables(pid, c.pat)
+		}
+		return nil
+	} else if !c.pids[pid] {
+		return nil
+	}
+
+	isVideoStart := c.video != nil && pid == c.video.PID() && packet.PayloadUnitStartIndicator()
+	if isVideoStart {
+		if pes := tsparser.PES(packet.Payload()); len(pes) >= 14 && pes.HasPTS() {
+			c.lastPTS, c.hasLastPTS = pes.PTS(), true
+		}
+	}
+
+	switch c.state {
+	case stateWaiting:
+		return c.wait(packet, isVideoStart)
+	case stateCutting:
+		if isVideoStart && c.hasLastPTS && isAfter(c.lastPTS, c.endPTS) {
+			c.state = stateEnding
+			c.finished[pid] = true
+			return nil
+		}
+		return c.emit(packet)
+	case stateEnding:
+		// The streams other than the video are cut at their next PES,
+		// where the streams never started have nothing to complete.
+		if !c.started[pid] || c.finished[pid] {
+			return nil
+		} else if packet.PayloadUnitStartIndicator() {
+			c.finished[pid] = true
+			if c.isFinished() {
+				c.state = stateDone
+			}
+			return nil
+		}
+
+		if c.trailing++; c.trailing > maxTrailingPackets {
+			c.state = stateDone
+			return nil
+		}
+		return c.emit(packet)
+	}
+
+	return nil
+}
+
+func (c *Cutter) isFinished() bool {
+	for pid, started := range c.started {
+		if started && !c.finished[pid] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Cutter) wait(packet tsparser.Packet, isVideoStart bool) error {
+	if isVideoStart {
+		if len(c.buffer) > 0 && c.isStartPoint() {
+			return c.start(packet)
+		}
+		c.buffer = c.buffer[:0]
+	}
+
+	if len(c.buffer) > 0 || isVideoStart {
+		copied := make(tsparser.Packet, len(packet))
+		copy(copied, packet)
+		c.buffer = append(c.buffer, copied)
+	}
+	return nil
+}
+
+func (c *Cutter) isStartPoint() bool {
+	first := c.buffer[0]
+	pes := tsparser.PES(first.Payload())
+	if !c.hasPTS || len(pes) < 14 || !pes.HasPTS() || !isAfter(pes.PTS(), c.startPTS) {
+		return false
+	} else if isAfter(pes.PTS(), c.endPTS) {
+		return false
+	}
+
+	data := make([]byte, 0, len(c.buffer)*tsparser.PacketPayloadSize)
+	for _, packet := range c.buffer {
+		if packet.PID() == c.video.PID() {
+			data = append(data, packet.Payload()...)
+		}
+	}
+	return video.IsRandomAccess(c.video.StreamType(), tsparser.PES(data).Payload())
+}
+
+func (c *Cutter) start(next tsparser.Packet) error {
+	c.state = stateCutting
+
+	if err := c.w.WriteTables(tsparser.ProgramAssociationPID, c.pat); err != nil {
+		return err
+	}
+	if err := c.w.WriteTables(c.program.PMTPID(), c.pmt); err != nil {
+		return err
+	}
+	c.patPending = false
+	c.started[c.program.PMTPID()] = true
+
+	for _, packet := range c.buffer {
+		if err := c.emit(packet); err != nil {
+			return err
+		}
+	}
+	c.buffer = nil
+
+	return c.emit(next)
+}
+
+func (c *Cutter) emit(packet tsparser.Packet) error {
+	pid := packet.PID()
+	if !c.started[pid] {
+		if packet.HasPayload() && !packet.PayloadUnitStartIndicator() {
+			return nil
+		}
+		c.started[pid] = packet.HasPayload()
+	}
+
+	return c.w.WritePacket(packet)
+}
+
+func (c *Cutter) handleTables(packet tsparser.Packet) {
+	tables, err := c.collector.Feed(packet)
+	if err != nil {
+		c.log(packet, err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			c.handleProgramAssociation(table)
+		case tsparser.ProgramMapTable:
+			if table.TableIdExtension() == c.program.ProgramNumber() {
+				c.handleProgramMap(table)
+			}
+		case arib.TimeDateTable, arib.TimeOffsetTable:
+			c.handleTime(arib.ParseTimeDateSection(table))
+		case arib.EventInformationActualPFTable:
+			c.handleEvent(arib.ParseEventInformationSection(table))
+		}
+	}
+}
+
+func (c *Cutter) handleProgramAssociation(table tsparser.Table) {
+	pat := tsparser.ParseProgramAssociationSection(table)
+	found, changed := c.program.Update(pat, c.collector)
+	if !found {
+		return
+	}
+
+	pid := c.program.PMTPID()
+	rewritten := tsparser.NewProgramAssociationSection(pat.TransportStreamId())
+	rewritten.SetVersionNumber(pat.VersionNumber())
+	rewritten.SetProgram(c.program.ProgramNumber(), pid)
+	if table, err := rewritten.Marshal(); err == nil {
+		c.pat, c.patPending = table, true
+	}
+
+	if changed {
+		c.pmt = nil
+		c.pids = map[tsparser.PID]bool{pid: true}
+		c.video = nil
+	}
+}
+
+func (c *Cutter) handleProgramMap(table tsparser.Table) {
+	pmt := tsparser.ParseProgramMapSection(table)
+	c.pmt = table
+	c.video = video.FindStream(pmt)
+
+	c.pids = map[tsparser.PID]bool{c.program.PMTPID(): true}
+	if pmt.PCRPID() != tsparser.NullPID {
+		c.pids[pmt.PCRPID()] = true
+	}
+	for _, es := range pmt.Streams() {
+		c.pids[es.PID()] = true
+	}
+}
+
+func (c *Cutter) handleTime(now time.Time) {
+	if c.hasPTS || !c.hasLastPTS || c.startTime.IsZero() {
+		return
+	}
+
+	c.startPTS = offsetPTS(c.lastPTS, c.startTime.Sub(now))
+	c.endPTS = offsetPTS(c.lastPTS, c.endTime.Sub(now))
+	c.hasPTS = true
+}
+
+func offsetPTS(pts uint64, d time.Duration) uint64 {
+	delta := int64(d) * tsparser.ClockRate / int64(time.Second)
+	return (pts + uint64(delta)) & tsparser.TimestampMask
+}
+
+func (c *Cutter) handleEvent(eit *arib.EventInformationSection) {
+	if c.startTime.IsZero() && eit.ServiceId() == c.program.ProgramNumber() {
+		for _, event := range eit.Events() {
+			if event.EventId() == c.eventId && !event.StartTime().IsZero() {
+				c.startTime = event.StartTime()
+				c.endTime = event.StartTime().Add(event.Duration())
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package cut
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	idrPicture = fixture.AnnexB([]byte{0x09, 0xf0}, []byte{0x65, 0x88, 0x80})
+	pPicture   = fixture.AnnexB([]byte{0x09, 0xf0}, []byte{0x41, 0x9a})
+)
+
+func testStream(t *testing.T) []byte {
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.AACADTSStream, 0x0110))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := tsparser.NewPacketWriter(&buf)
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTable)
+	for i, picture := range [][]byte{idrPicture, pPicture, idrPicture, pPicture, idrPicture, pPicture} {
+		pts := uint64(90000 + i*3003)
+		w.WriteUnit(0x0100, nil, fixture.PES(0xe0, pts, picture))
+		w.WriteUnit(0x0110, nil, fixture.PES(0xc0, pts, []byte{0xff, 0xf1}))
+	}
+	return buf.Bytes()
+}
+
+func TestCutterPTSRange(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCutter(tsparser.NewPacketScanner(bytes.NewReader(testStream(t)), nil), &buf, 0, nil)
+	c.SetPTSRange(95000, 100000)
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := tsparser.NewPacketScanner(&buf, nil)
+	var pids []tsparser.PID
+	var pts []uint64
+	for s.Scan() {
+		packet := s.Packet()
+		pids = append(pids, packet.PID())
+		if packet.PID() == 0x0100 {
+			pts = append(pts, tsparser.PES(packet.Payload()).PTS())
+		}
+	}
+
+	expected := []tsparser.PID{tsparser.ProgramAssociationPID, 0x1000, 0x0100, 0x0110, 0x0100, 0x0110}
+	if len(pids) != len(expected) {
+		t.Fatalf("PIDs = %#x, want %#x", pids, expected)
+	}
+	for i, pid := range expected {
+		if pids[i] != pid {
+			t.Fatalf("PIDs = %#x, want %#x", pids, expected)
+		}
+	}
+	if pts[0] != 96006 || pts[1] != 99009 {
+		t.Errorf("PTS = %v", pts)
+	}
+}
+
+func TestCutterNoStartPoint(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCutter(tsparser.NewPacketScanner(bytes.NewReader(testStream(t)), nil), &buf, 0, nil)
+	c.SetPTSRange(200000, 300000)
+	if err := c.Run(); err != ErrNoStartPoint {
+		t.Errorf("err = %v, want %v", err, ErrNoStartPoint)
+	}
+
+	c = NewCutter(tsparser.NewPacketScanner(bytes.NewReader(testStream(t)), nil), &buf, 0, nil)
+	if err := c.Run(); err != ErrNoRange {
+		t.Errorf("err = %v, want %v", err, ErrNoRange)
+	}
+}
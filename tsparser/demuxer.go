@@ -0,0 +1,69 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+type demuxerStream struct {
+	w       io.Writer
+	ts      io.Writer
+	written int64
+}
+
+type Demuxer struct {
+	s       *PESScanner
+	streams map[PID]*demuxerStream
+}
+
+func NewDemuxer(s PacketStream, logger *log.Logger) *Demuxer {
+	return &Demuxer{
+		s:       NewPESScanner(s, logger),
+		streams: make(map[PID]*demuxerStream),
+	}
+}
+
+func (d *Demuxer) AddStream(pid PID, w io.Writer) {
+	d.streams[pid] = &demuxerStream{w: w}
+}
+
+func (d *Demuxer) SetTimestampWriter(pid PID, w io.Writer) {
+	if stream, ok := d.streams[pid]; ok {
+		stream.ts = w
+	}
+}
+
+func (d *Demuxer) Run() error {
+	for d.s.Scan() {
+		stream, ok := d.streams[d.s.PID()]
+		if !ok {
+			continue
+		}
+
+		pes := d.s.PES()
+		if pes.ScramblingControl() > 0 {
+			continue
+		}
+
+		if stream.ts != nil && pes.HasPTS() {
+			_, err := fmt.Fprintf(stream.ts, "%d\t%d\t%d\t%d\n",
+				stream.written, pes.PTS(), pes.DTS(), d.s.Offset())
+			if err != nil {
+				return err
+			}
+		}
+
+		n, err := stream.w.Write(pes.Payload())
+		stream.written += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
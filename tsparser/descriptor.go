@@ -39,3 +39,24 @@ func (d Descriptor) Length() uint8 {
 func (d Descriptor) Payload() []byte {
 	return d[2 : d.Length()+2]
 }
+
+func NewDescriptor(tag DescriptorTag, payload []byte) Descriptor {
+	d := make(Descriptor, 2+len(payload))
+	d[0] = byte(tag)
+	d[1] = byte(len(payload))
+	copy(d[2:], payload)
+	return d
+}
+
+func MarshalDescriptors(descriptors []Descriptor) []byte {
+	length := 0
+	for _, d := range descriptors {
+		length += len(d)
+	}
+
+	data := make([]byte, 0, length)
+	for _, d := range descriptors {
+		data = append(data, d...)
+	}
+	return data
+}
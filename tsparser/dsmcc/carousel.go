@@ -0,0 +1,156 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type moduleKey struct {
+	downloadId uint32
+	moduleId   uint16
+}
+
+// moduleBuffer collects the blocks of a module announced by a DII.
+type moduleBuffer struct {
+	version     uint8
+	size        uint32
+	blockSize   int
+	descriptors []tsparser.Descriptor
+	data        []byte
+	received    []bool
+	left        int
+	done        bool
+}
+
+func newModuleBuffer(info *ModuleInfo, blockSize int) *moduleBuffer {
+	blocks := (int(info.moduleSize) + blockSize - 1) / blockSize
+	return &moduleBuffer{
+		version:     info.moduleVersion,
+		size:        info.moduleSize,
+		blockSize:   blockSize,
+		descriptors: info.descriptors,
+		data:        make([]byte, info.moduleSize),
+		received:    make([]bool, blocks),
+		left:        blocks,
+	}
+}
+
+// Carousel reassembles the modules of the data carousels carried on a PID
+// from DII and DDB sections.  A module is completed once for each version.
+type Carousel struct {
+	modules map[moduleKey]*moduleBuffer
+}
+
+func NewCarousel() *Carousel {
+	return &Carousel{
+		modules: make(map[moduleKey]*moduleBuffer),
+	}
+}
+
+// Feed feeds a DSM-CC section, and returns the modules completed by it.
+// The sections other than DII and DDB are ignored.
+func (c *Carousel) Feed(table tsparser.Table) ([]*Module, error) {
+	if table.TableId() != UNMessageTable && table.TableId() != DownloadDataTable {
+		return nil, nil
+	}
+
+	id, ok := MessageId(table)
+	if !ok {
+		return nil, ErrInvalidMessage
+	}
+
+	switch {
+	case table.TableId() == UNMessageTable && id == DownloadInfoIndicationMessage:
+		dii, err := ParseDownloadInfoIndication(table)
+		if err != nil {
+			return nil, err
+		}
+		return c.update(dii), nil
+	case table.TableId() == DownloadDataTable && id == DownloadDataBlockMessage:
+		ddb, err := ParseDownloadDataBlock(table)
+		if err != nil {
+			return nil, err
+		}
+		if module := c.collect(ddb); module != nil {
+			return []*Module{module}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// update follows the module list of a DII.  The blocks collected are
+// discarded when the version or the size of a module changes, and the
+// modules no longer listed are forgotten.
+func (c *Carousel) update(dii *DownloadInfoIndication) []*Module {
+	listed := make(map[uint16]bool)
+	var modules []*Module
+	for _, info := range dii.modules {
+		listed[info.moduleId] = true
+		key := moduleKey{dii.downloadId, info.moduleId}
+
+		buf, ok := c.modules[key]
+		if ok && buf.version == info.moduleVersion && buf.size == info.moduleSize && buf.blockSize == int(dii.blockSize) {
+			buf.descriptors = info.descriptors
+			continue
+		}
+
+		buf = newModuleBuffer(info, int(dii.blockSize))
+		c.modules[key] = buf
+		if buf.left == 0 {
+			buf.done = true
+			modules = append(modules, buf.module(key))
+		}
+	}
+
+	for key := range c.modules {
+		if key.downloadId == dii.downloadId && !listed[key.moduleId] {
+			delete(c.modules, key)
+		}
+	}
+
+	return modules
+}
+
+func (c *Carousel) collect(ddb *DownloadDataBlock) *Module {
+	key := moduleKey{ddb.downloadId, ddb.moduleId}
+	buf, ok := c.modules[key]
+	if !ok || buf.done || buf.version != ddb.moduleVersion {
+		return nil
+	}
+
+	n := int(ddb.blockNumber)
+	if n >= len(buf.received) || buf.received[n] {
+		return nil
+	}
+
+	offset := n * buf.blockSize
+	length := buf.blockSize
+	if offset+length > len(buf.data) {
+		length = len(buf.data) - offset
+	}
+	if len(ddb.blockData) < length {
+		return nil
+	}
+	copy(buf.data[offset:], ddb.blockData[:length])
+	buf.received[n] = true
+
+	if buf.left--; buf.left > 0 {
+		return nil
+	}
+	buf.done = true
+	return buf.module(key)
+}
+
+func (b *moduleBuffer) module(key moduleKey) *Module {
+	return &Module{
+		DownloadId:  key.downloadId,
+		ModuleId:    key.moduleId,
+		Version:     b.version,
+		Descriptors: b.descriptors,
+		Data:        b.data,
+	}
+}
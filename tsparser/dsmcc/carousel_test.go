@@ -0,0 +1,72 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func feed(t *testing.T, c *Carousel, table tsparser.Table) []*Module {
+	modules, err := c.Feed(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return modules
+}
+
+func TestCarousel(t *testing.T) {
+	c := NewCarousel()
+	if modules := feed(t, c, ddb(t, 1, 1, 0, 0, []byte("0123"))); len(modules) != 0 {
+		t.Errorf("DDB before DII: %d modules", len(modules))
+	}
+
+	// An empty module is completed by the DII.
+	modules := feed(t, c, dii(t, 1, 4, testModule{id: 1, size: 10}, testModule{id: 2, size: 0}))
+	if len(modules) != 1 || modules[0].ModuleId != 2 || len(modules[0].Data) != 0 {
+		t.Fatalf("modules = %+v", modules)
+	}
+
+	blocks := []struct {
+		number uint16
+		data   string
+	}{
+		{2, "89"},
+		{0, "0123"},
+		{0, "xxxx"},
+		{1, "4567"},
+	}
+	for i, block := range blocks {
+		modules = feed(t, c, ddb(t, 1, 1, 0, block.number, []byte(block.data)))
+		if i < len(blocks)-1 && len(modules) != 0 {
+			t.Errorf("%d: %d modules", i, len(modules))
+		}
+	}
+	if len(modules) != 1 {
+		t.Fatalf("%d modules, want 1", len(modules))
+	}
+	if m := modules[0]; m.DownloadId != 1 || m.ModuleId != 1 || m.Version != 0 || string(m.Data) != "0123456789" {
+		t.Errorf("module = %+v", m)
+	}
+
+	// A module is completed once for each version.
+	if modules := feed(t, c, ddb(t, 1, 1, 0, 1, []byte("4567"))); len(modules) != 0 {
+		t.Errorf("%d modules after completion", len(modules))
+	}
+	feed(t, c, dii(t, 1, 4, testModule{id: 1, size: 2, version: 1}))
+	if modules := feed(t, c, ddb(t, 1, 1, 0, 0, []byte("ab"))); len(modules) != 0 {
+		t.Errorf("%d modules of the former version", len(modules))
+	}
+	modules = feed(t, c, ddb(t, 1, 1, 1, 0, []byte("ab")))
+	if len(modules) != 1 || modules[0].Version != 1 || string(modules[0].Data) != "ab" {
+		t.Errorf("modules = %+v", modules)
+	}
+
+	// Sections other than DII and DDB are ignored.
+	if modules, err := c.Feed(message(t, StreamDescriptorTable, 0, 0, 0, nil)); len(modules) != 0 || err != nil {
+		t.Errorf("stream descriptors: modules = %+v, err = %v", modules, err)
+	}
+}
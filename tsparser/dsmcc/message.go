@@ -0,0 +1,272 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+var (
+	ErrInvalidMessage = errors.New("Invalid DSM-CC message")
+	ErrCRCMismatch    = errors.New("CRC mismatch")
+)
+
+const (
+	UNMessageTable        tsparser.TableId = 0x3B
+	DownloadDataTable     tsparser.TableId = 0x3C
+	StreamDescriptorTable tsparser.TableId = 0x3D
+)
+
+const (
+	DownloadInfoIndicationMessage uint16 = 0x1002
+	DownloadDataBlockMessage      uint16 = 0x1003
+	DownloadServerInitiateMessage uint16 = 0x1006
+)
+
+const (
+	protocolDiscriminator = 0x11
+	dsmccTypeDownload     = 0x03
+)
+
+// cursor reads fields of a DSM-CC message.  Reading beyond the data marks
+// it as failed instead of panicking.
+type cursor struct {
+	data   []byte
+	pos    int
+	failed bool
+}
+
+func (c *cursor) bytes(n int) []byte {
+	if c.failed || c.pos+n > len(c.data) {
+		c.failed = true
+		return make([]byte, n)
+	}
+
+	b := c.data[c.pos : c.pos+n]
+	c.pos += n
+	return b
+}
+
+func (c *cursor) uint8() uint8 {
+	return c.bytes(1)[0]
+}
+
+func (c *cursor) uint16() uint16 {
+	b := c.bytes(2)
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func (c *cursor) uint32() uint32 {
+	b := c.bytes(4)
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// MessageId returns messageId of the message carried in a DSM-CC section.
+func MessageId(table tsparser.Table) (uint16, bool) {
+	data := table.Data()
+	if len(data) < 4 || data[0] != protocolDiscriminator || data[1] != dsmccTypeDownload {
+		return 0, false
+	}
+
+	return uint16(data[2])<<8 | uint16(data[3]), true
+}
+
+// parseHeader checks a DSM-CC section and returns the cursor on the
+// message following dsmccMessageHeader or dsmccDownloadDataHeader, whose
+// transactionId or downloadId is returned.
+func parseHeader(table tsparser.Table, tableId tsparser.TableId, messageId uint16) (*cursor, uint32, error) {
+	if len(table) < 3 || table.TableId() != tableId || !table.SectionSyntaxIndicator() {
+		return nil, 0, ErrInvalidMessage
+	} else if !table.CheckCRC() {
+		return nil, 0, ErrCRCMismatch
+	}
+
+	c := &cursor{data: table.Data()}
+	if c.uint8() != protocolDiscriminator || c.uint8() != dsmccTypeDownload || c.uint16() != messageId {
+		return nil, 0, ErrInvalidMessage
+	}
+	id := c.uint32()
+	c.bytes(1)
+	adaptationLength := int(c.uint8())
+	messageLength := int(c.uint16())
+	c.bytes(adaptationLength)
+	if c.failed || messageLength < adaptationLength {
+		return nil, 0, ErrInvalidMessage
+	}
+
+	c = &cursor{data: c.bytes(messageLength - adaptationLength)}
+	if c.failed {
+		return nil, 0, ErrInvalidMessage
+	}
+	return c, id, nil
+}
+
+type DownloadServerInitiate struct {
+	transactionId uint32
+	serverId      []byte
+	privateData   []byte
+}
+
+func ParseDownloadServerInitiate(table tsparser.Table) (*DownloadServerInitiate, error) {
+	c, transactionId, err := parseHeader(table, UNMessageTable, DownloadServerInitiateMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &DownloadServerInitiate{transactionId: transactionId}
+	msg.serverId = c.bytes(20)
+	c.bytes(int(c.uint16()))
+	msg.privateData = c.bytes(int(c.uint16()))
+	if c.failed {
+		return nil, ErrInvalidMessage
+	}
+
+	return msg, nil
+}
+
+func (m *DownloadServerInitiate) TransactionId() uint32 {
+	return m.transactionId
+}
+
+func (m *DownloadServerInitiate) ServerId() []byte {
+	return m.serverId
+}
+
+func (m *DownloadServerInitiate) PrivateData() []byte {
+	return m.privateData
+}
+
+type ModuleInfo struct {
+	moduleId      uint16
+	moduleSize    uint32
+	moduleVersion uint8
+	descriptors   []tsparser.Descriptor
+}
+
+func (m *ModuleInfo) ModuleId() uint16 {
+	return m.moduleId
+}
+
+func (m *ModuleInfo) ModuleSize() uint32 {
+	return m.moduleSize
+}
+
+func (m *ModuleInfo) ModuleVersion() uint8 {
+	return m.moduleVersion
+}
+
+// Descriptors returns the descriptors of moduleInfoByte defined in ARIB
+// STD-B24.
+func (m *ModuleInfo) Descriptors() []tsparser.Descriptor {
+	return m.descriptors
+}
+
+type DownloadInfoIndication struct {
+	transactionId uint32
+	downloadId    uint32
+	blockSize     uint16
+	modules       []*ModuleInfo
+	privateData   []byte
+}
+
+func ParseDownloadInfoIndication(table tsparser.Table) (*DownloadInfoIndication, error) {
+	c, transactionId, err := parseHeader(table, UNMessageTable, DownloadInfoIndicationMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &DownloadInfoIndication{transactionId: transactionId}
+	msg.downloadId = c.uint32()
+	msg.blockSize = c.uint16()
+	c.bytes(10)
+	c.bytes(int(c.uint16()))
+
+	n := int(c.uint16())
+	for i := 0; i < n && !c.failed; i++ {
+		module := new(ModuleInfo)
+		module.moduleId = c.uint16()
+		module.moduleSize = c.uint32()
+		module.moduleVersion = c.uint8()
+		module.descriptors = tsparser.ParseDescriptors(c.bytes(int(c.uint8())))
+		msg.modules = append(msg.modules, module)
+	}
+	msg.privateData = c.bytes(int(c.uint16()))
+	if c.failed || msg.blockSize == 0 {
+		return nil, ErrInvalidMessage
+	}
+
+	return msg, nil
+}
+
+// TransactionId returns transactionId, whose update flag in the LSB is
+// toggled whenever the message is updated.
+func (m *DownloadInfoIndication) TransactionId() uint32 {
+	return m.transactionId
+}
+
+func (m *DownloadInfoIndication) DownloadId() uint32 {
+	return m.downloadId
+}
+
+func (m *DownloadInfoIndication) BlockSize() uint16 {
+	return m.blockSize
+}
+
+func (m *DownloadInfoIndication) Modules() []*ModuleInfo {
+	return m.modules
+}
+
+func (m *DownloadInfoIndication) PrivateData() []byte {
+	return m.privateData
+}
+
+type DownloadDataBlock struct {
+	downloadId    uint32
+	moduleId      uint16
+	moduleVersion uint8
+	blockNumber   uint16
+	blockData     []byte
+}
+
+func ParseDownloadDataBlock(table tsparser.Table) (*DownloadDataBlock, error) {
+	c, downloadId, err := parseHeader(table, DownloadDataTable, DownloadDataBlockMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &DownloadDataBlock{downloadId: downloadId}
+	msg.moduleId = c.uint16()
+	msg.moduleVersion = c.uint8()
+	c.bytes(1)
+	msg.blockNumber = c.uint16()
+	msg.blockData = c.data[c.pos:]
+	if c.failed {
+		return nil, ErrInvalidMessage
+	}
+
+	return msg, nil
+}
+
+func (m *DownloadDataBlock) DownloadId() uint32 {
+	return m.downloadId
+}
+
+func (m *DownloadDataBlock) ModuleId() uint16 {
+	return m.moduleId
+}
+
+func (m *DownloadDataBlock) ModuleVersion() uint8 {
+	return m.moduleVersion
+}
+
+func (m *DownloadDataBlock) BlockNumber() uint16 {
+	return m.blockNumber
+}
+
+func (m *DownloadDataBlock) BlockData() []byte {
+	return m.blockData
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type testModule struct {
+	id      uint16
+	size    uint32
+	version uint8
+	info    []byte
+}
+
+func message(t *testing.T, tableId tsparser.TableId, extension, messageId uint16, id uint32, body []byte) tsparser.Table {
+	data := []byte{
+		protocolDiscriminator, dsmccTypeDownload, byte(messageId >> 8), byte(messageId),
+		byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id),
+		0xff, 0x00, byte(len(body) >> 8), byte(len(body)),
+	}
+	data = append(data, body...)
+
+	header := &tsparser.TableHeader{
+		TableId:              tableId,
+		TableIdExtension:     extension,
+		CurrentNextIndicator: true,
+	}
+	table, err := header.Build(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func dii(t *testing.T, downloadId uint32, blockSize uint16, modules ...testModule) tsparser.Table {
+	body := []byte{
+		byte(downloadId >> 24), byte(downloadId >> 16), byte(downloadId >> 8), byte(downloadId),
+		byte(blockSize >> 8), byte(blockSize),
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0x00, 0x00,
+		0x00, byte(len(modules)),
+	}
+	for _, m := range modules {
+		body = append(body, byte(m.id>>8), byte(m.id),
+			byte(m.size>>24), byte(m.size>>16), byte(m.size>>8), byte(m.size),
+			m.version, byte(len(m.info)))
+		body = append(body, m.info...)
+	}
+	body = append(body, 0x00, 0x00)
+
+	return message(t, UNMessageTable, 0x0002, DownloadInfoIndicationMessage, 0x80000002, body)
+}
+
+func ddb(t *testing.T, downloadId uint32, moduleId uint16, version uint8, block uint16, data []byte) tsparser.Table {
+	body := append([]byte{byte(moduleId >> 8), byte(moduleId), version, 0xff, byte(block >> 8), byte(block)}, data...)
+	return message(t, DownloadDataTable, moduleId, DownloadDataBlockMessage, downloadId, body)
+}
+
+func TestParseDownloadInfoIndication(t *testing.T) {
+	info := tsparser.NewDescriptor(tsparser.DescriptorTag(NameDescriptor), []byte("index.bml"))
+	msg, err := ParseDownloadInfoIndication(dii(t, 0x01020304, 4066,
+		testModule{id: 0x0000, size: 10000, version: 1, info: info},
+		testModule{id: 0x0001, size: 0, version: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.TransactionId() != 0x80000002 || msg.DownloadId() != 0x01020304 || msg.BlockSize() != 4066 {
+		t.Errorf("DII = %+v", msg)
+	}
+	modules := msg.Modules()
+	if len(modules) != 2 {
+		t.Fatalf("%d modules, want 2", len(modules))
+	}
+	if m := modules[0]; m.ModuleId() != 0 || m.ModuleSize() != 10000 || m.ModuleVersion() != 1 ||
+		len(m.Descriptors()) != 1 || !bytes.Equal(m.Descriptors()[0], info) {
+		t.Errorf("module 0 = %+v", m)
+	}
+	if m := modules[1]; m.ModuleId() != 1 || m.ModuleSize() != 0 || m.ModuleVersion() != 2 || len(m.Descriptors()) != 0 {
+		t.Errorf("module 1 = %+v", m)
+	}
+}
+
+func TestParseDownloadDataBlock(t *testing.T) {
+	msg, err := ParseDownloadDataBlock(ddb(t, 0x01020304, 0x0005, 3, 7, []byte("block")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.DownloadId() != 0x01020304 || msg.ModuleId() != 5 || msg.ModuleVersion() != 3 || msg.BlockNumber() != 7 {
+		t.Errorf("DDB = %+v", msg)
+	}
+	if string(msg.BlockData()) != "block" {
+		t.Errorf("block data = %q", msg.BlockData())
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	broken := ddb(t, 1, 1, 0, 0, []byte("block"))
+	broken[len(broken)-1] ^= 0xff
+	if _, err := ParseDownloadDataBlock(broken); err != ErrCRCMismatch {
+		t.Errorf("CRC mismatch: err = %v", err)
+	}
+
+	if _, err := ParseDownloadInfoIndication(ddb(t, 1, 1, 0, 0, nil)); err != ErrInvalidMessage {
+		t.Errorf("DDB as DII: err = %v", err)
+	}
+	if _, err := ParseDownloadInfoIndication(dii(t, 1, 0)); err != ErrInvalidMessage {
+		t.Errorf("zero blockSize: err = %v", err)
+	}
+
+	truncated := message(t, UNMessageTable, 2, DownloadInfoIndicationMessage, 2, []byte{0, 0, 0, 1, 0x10})
+	if _, err := ParseDownloadInfoIndication(truncated); err != ErrInvalidMessage {
+		t.Errorf("truncated: err = %v", err)
+	}
+}
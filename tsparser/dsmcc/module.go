@@ -0,0 +1,159 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+var (
+	ErrUnsupportedCompression = errors.New("Unsupported compression_type")
+	ErrSizeMismatch           = errors.New("Decompressed size mismatch")
+)
+
+type DescriptorTag uint8
+
+// The descriptors of moduleInfoByte defined in ARIB STD-B24.
+const (
+	TypeDescriptor                  DescriptorTag = 0x01
+	NameDescriptor                  DescriptorTag = 0x02
+	InfoDescriptor                  DescriptorTag = 0x03
+	ModuleLinkDescriptor            DescriptorTag = 0x04
+	CRC32Descriptor                 DescriptorTag = 0x05
+	LocationDescriptor              DescriptorTag = 0x06
+	EstimatedDownloadTimeDescriptor DescriptorTag = 0x07
+	ExpireDescriptor                DescriptorTag = 0xC0
+	ActivationTimeDescriptor        DescriptorTag = 0xC1
+	CompressionTypeDescriptor       DescriptorTag = 0xC2
+	ControlDescriptor               DescriptorTag = 0xC3
+	ProviderPrivateDescriptor       DescriptorTag = 0xC4
+)
+
+const CompressionZlib uint8 = 0x00
+
+// Module is a module of a data carousel completed by the DDB blocks.
+type Module struct {
+	DownloadId  uint32
+	ModuleId    uint16
+	Version     uint8
+	Descriptors []tsparser.Descriptor
+	Data        []byte
+}
+
+// Resource is a file carried in a module.
+type Resource struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+func (m *Module) descriptor(tag DescriptorTag) []byte {
+	for _, d := range m.Descriptors {
+		if len(d) >= 2 && DescriptorTag(d.Tag()) == tag && len(d) >= int(d.Length())+2 {
+			return d.Payload()
+		}
+	}
+
+	return nil
+}
+
+// ContentType returns the media type given by the Type descriptor.
+func (m *Module) ContentType() string {
+	return string(m.descriptor(TypeDescriptor))
+}
+
+// Name returns the name given by the Name descriptor.
+func (m *Module) Name() string {
+	return string(m.descriptor(NameDescriptor))
+}
+
+// Content returns the data of the module decompressed as the Compression
+// Type descriptor tells.
+func (m *Module) Content() ([]byte, error) {
+	payload := m.descriptor(CompressionTypeDescriptor)
+	if len(payload) < 5 {
+		return m.Data, nil
+	} else if payload[0] != CompressionZlib {
+		return nil, ErrUnsupportedCompression
+	}
+	size := int(tsparser.AsUint32(payload[1:5]))
+
+	r, err := zlib.NewReader(bytes.NewReader(m.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	} else if len(data) != size {
+		return nil, ErrSizeMismatch
+	}
+
+	return data, nil
+}
+
+// Resources returns the files of the module.  The entities of a module in
+// multipart/mixed are named after their Content-Location.
+func (m *Module) Resources() ([]*Resource, error) {
+	data, err := m.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := m.ContentType()
+	if contentType != "" && !strings.HasPrefix(contentType, "multipart/") {
+		return []*Resource{{m.Name(), contentType, data}}, nil
+	}
+
+	// A module without the Type descriptor is an entity with the header.
+	br := bufio.NewReader(bytes.NewReader(data))
+	header, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil || header.Get("Content-Type") == "" {
+		return []*Resource{{m.Name(), contentType, data}}, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	} else if !strings.HasPrefix(mediaType, "multipart/") {
+		body, _ := ioutil.ReadAll(br)
+		return []*Resource{{m.Name(), mediaType, body}}, nil
+	}
+
+	var resources []*Resource
+	mr := multipart.NewReader(br, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return resources, err
+		}
+
+		body, err := ioutil.ReadAll(part)
+		if err != nil {
+			return resources, err
+		}
+		resources = append(resources, &Resource{
+			Name:        part.Header.Get("Content-Location"),
+			ContentType: part.Header.Get("Content-Type"),
+			Data:        body,
+		})
+	}
+
+	return resources, nil
+}
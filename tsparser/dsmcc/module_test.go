@@ -0,0 +1,115 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dsmcc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func moduleDescriptor(tag DescriptorTag, payload string) tsparser.Descriptor {
+	return tsparser.NewDescriptor(tsparser.DescriptorTag(tag), []byte(payload))
+}
+
+func compressionType(size int) tsparser.Descriptor {
+	return tsparser.NewDescriptor(tsparser.DescriptorTag(CompressionTypeDescriptor),
+		[]byte{CompressionZlib, byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)})
+}
+
+func TestModuleContent(t *testing.T) {
+	content := []byte(strings.Repeat("<bml/>", 100))
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(content)
+	zw.Close()
+
+	m := &Module{Descriptors: []tsparser.Descriptor{compressionType(len(content))}, Data: buf.Bytes()}
+	data, err := m.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("content = %q", data)
+	}
+
+	m.Descriptors = []tsparser.Descriptor{compressionType(len(content) + 1)}
+	if _, err := m.Content(); err != ErrSizeMismatch {
+		t.Errorf("err = %v, want %v", err, ErrSizeMismatch)
+	}
+
+	unsupported := compressionType(len(content))
+	unsupported[2] = 0x01
+	m.Descriptors = []tsparser.Descriptor{unsupported}
+	if _, err := m.Content(); err != ErrUnsupportedCompression {
+		t.Errorf("err = %v, want %v", err, ErrUnsupportedCompression)
+	}
+
+	plain := &Module{Data: content}
+	if data, err := plain.Content(); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("uncompressed content = %q, %v", data, err)
+	}
+}
+
+func TestModuleResources(t *testing.T) {
+	m := &Module{
+		Descriptors: []tsparser.Descriptor{
+			moduleDescriptor(TypeDescriptor, "image/png"),
+			moduleDescriptor(NameDescriptor, "logo.png"),
+		},
+		Data: []byte("PNG"),
+	}
+	resources, err := m.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].Name != "logo.png" || resources[0].ContentType != "image/png" || string(resources[0].Data) != "PNG" {
+		t.Errorf("resources = %+v", resources)
+	}
+}
+
+func TestModuleMultipartResources(t *testing.T) {
+	entity := strings.Replace(`Content-Type: multipart/mixed; boundary="boundary"
+
+--boundary
+Content-Location: startup.bml
+Content-Type: text/X-arib-bml; charset=EUC-JP
+
+<bml/>
+--boundary
+Content-Location: logo.png
+Content-Type: image/png
+
+PNG
+--boundary--
+`, "\n", "\r\n", -1)
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(entity))
+	zw.Close()
+
+	m := &Module{Descriptors: []tsparser.Descriptor{compressionType(len(entity))}, Data: buf.Bytes()}
+	resources, err := m.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Resource{
+		{"startup.bml", "text/X-arib-bml; charset=EUC-JP", []byte("<bml/>")},
+		{"logo.png", "image/png", []byte("PNG")},
+	}
+	if len(resources) != len(expected) {
+		t.Fatalf("%d resources, want %d", len(resources), len(expected))
+	}
+	for i, r := range resources {
+		if r.Name != expected[i].Name || r.ContentType != expected[i].ContentType || !bytes.Equal(r.Data, expected[i].Data) {
+			t.Errorf("%d: resource = %+v, want %+v", i, r, expected[i])
+		}
+	}
+}
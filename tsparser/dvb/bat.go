@@ -0,0 +1,157 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type TransportStream struct {
+	transportStreamId uint16
+	originalNetworkId uint16
+	descriptors       []tsparser.Descriptor
+}
+
+func NewTransportStream(transportStreamId, originalNetworkId uint16, descriptors ...tsparser.Descriptor) *TransportStream {
+	return &TransportStream{
+		transportStreamId: transportStreamId,
+		originalNetworkId: originalNetworkId,
+		descriptors:       descriptors,
+	}
+}
+
+func (ts *TransportStream) TransportStreamId() uint16 {
+	return ts.transportStreamId
+}
+
+func (ts *TransportStream) OriginalNetworkId() uint16 {
+	return ts.originalNetworkId
+}
+
+func (ts *TransportStream) Descriptors() []tsparser.Descriptor {
+	return ts.descriptors
+}
+
+type BouquetAssociationSection struct {
+	bouquetId         uint16
+	version           uint8
+	sectionNumber     uint8
+	lastSectionNumber uint8
+	descriptors       []tsparser.Descriptor
+	transportStreams  []*TransportStream
+}
+
+func NewBouquetAssociationSection(bouquetId uint16, descriptors ...tsparser.Descriptor) *BouquetAssociationSection {
+	return &BouquetAssociationSection{
+		bouquetId:   bouquetId,
+		descriptors: descriptors,
+	}
+}
+
+func ParseBouquetAssociationSection(table tsparser.Table) *BouquetAssociationSection {
+	sec := NewBouquetAssociationSection(table.TableIdExtension())
+	sec.version = table.VersionNumber()
+	sec.sectionNumber = table.SectionNumber()
+	sec.lastSectionNumber = table.LastSectionNumber()
+
+	payload := table.Data()
+	if len(payload) < 2 {
+		return sec
+	}
+
+	i := 2 + (int(payload[0]&0x0f)<<8 | int(payload[1]))
+	if i+2 > len(payload) {
+		return sec
+	}
+	sec.descriptors = tsparser.ParseDescriptors(payload[2:i])
+
+	end := i + 2 + (int(payload[i]&0x0f)<<8 | int(payload[i+1]))
+	if end > len(payload) {
+		end = len(payload)
+	}
+	for i += 2; i+6 <= end; {
+		ts := NewTransportStream(
+			uint16(payload[i])<<8|uint16(payload[i+1]),
+			uint16(payload[i+2])<<8|uint16(payload[i+3]))
+
+		length := int(payload[i+4]&0x0f)<<8 | int(payload[i+5])
+		i += 6
+		if i+length > end {
+			break
+		}
+		ts.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.transportStreams = append(sec.transportStreams, ts)
+	}
+
+	return sec
+}
+
+func (s *BouquetAssociationSection) BouquetId() uint16 {
+	return s.bouquetId
+}
+
+func (s *BouquetAssociationSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *BouquetAssociationSection) SetVersionNumber(version uint8) {
+	s.version = version & 0x1f
+}
+
+func (s *BouquetAssociationSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *BouquetAssociationSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *BouquetAssociationSection) SetSectionNumber(number, last uint8) {
+	s.sectionNumber = number
+	s.lastSectionNumber = last
+}
+
+func (s *BouquetAssociationSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
+
+func (s *BouquetAssociationSection) TransportStreams() []*TransportStream {
+	return s.transportStreams
+}
+
+func (s *BouquetAssociationSection) AddTransportStream(ts *TransportStream) {
+	s.transportStreams = append(s.transportStreams, ts)
+}
+
+func (s *BouquetAssociationSection) Marshal() (tsparser.Table, error) {
+	info := tsparser.MarshalDescriptors(s.descriptors)
+	data := []byte{0xf0 | byte(len(info)>>8)&0x0f, byte(len(info))}
+	data = append(data, info...)
+
+	var loop []byte
+	for _, ts := range s.transportStreams {
+		info := tsparser.MarshalDescriptors(ts.descriptors)
+		loop = append(loop,
+			byte(ts.transportStreamId>>8), byte(ts.transportStreamId),
+			byte(ts.originalNetworkId>>8), byte(ts.originalNetworkId),
+			0xf0|byte(len(info)>>8)&0x0f, byte(len(info)))
+		loop = append(loop, info...)
+	}
+	data = append(data, 0xf0|byte(len(loop)>>8)&0x0f, byte(len(loop)))
+	data = append(data, loop...)
+
+	header := &tsparser.TableHeader{
+		TableId:              BouquetAssociationTable,
+		PrivateIndicator:     true,
+		TableIdExtension:     s.bouquetId,
+		VersionNumber:        s.version,
+		CurrentNextIndicator: true,
+		SectionNumber:        s.sectionNumber,
+		LastSectionNumber:    s.lastSectionNumber,
+	}
+	return header.Build(data)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+// Genres of content_nibble_level_1 and content_nibble_level_2 in Table 28 of
+// ETSI EN 300 468.
+var genres = map[uint8][]string{
+	0x1: {
+		"movie/drama", "detective/thriller", "adventure/western/war", "science fiction/fantasy/horror",
+		"comedy", "soap/melodrama/folklore", "romance", "serious/classical/religious/historical movie/drama",
+		"adult movie/drama",
+	},
+	0x2: {
+		"news/current affairs", "news/weather report", "news magazine", "documentary",
+		"discussion/interview/debate",
+	},
+	0x3: {
+		"show/game show", "game show/quiz/contest", "variety show", "talk show",
+	},
+	0x4: {
+		"sports", "special events", "sports magazines", "football/soccer", "tennis/squash",
+		"team sports", "athletics", "motor sport", "water sport", "winter sports", "equestrian",
+		"martial sports",
+	},
+	0x5: {
+		"children's/youth programmes", "pre-school children's programmes", "entertainment programmes for 6 to 14",
+		"entertainment programmes for 10 to 16", "informational/educational/school programmes",
+		"cartoons/puppets",
+	},
+	0x6: {
+		"music/ballet/dance", "rock/pop", "serious music/classical music", "folk/traditional music",
+		"jazz", "musical/opera", "ballet",
+	},
+	0x7: {
+		"arts/culture", "performing arts", "fine arts", "religion", "popular culture/traditional arts",
+		"literature", "film/cinema", "experimental film/video", "broadcasting/press", "new media",
+		"arts/culture magazines", "fashion",
+	},
+	0x8: {
+		"social/political issues/economics", "magazines/reports/documentary", "economics/social advisory",
+		"remarkable people",
+	},
+	0x9: {
+		"education/science/factual topics", "nature/animals/environment", "technology/natural sciences",
+		"medicine/physiology/psychology", "foreign countries/expeditions", "social/spiritual sciences",
+		"further education", "languages",
+	},
+	0xA: {
+		"leisure hobbies", "tourism/travel", "handicraft", "motoring", "fitness and health", "cooking",
+		"advertisement/shopping", "gardening",
+	},
+	0xB: {
+		"original language", "black and white", "unpublished", "live broadcast", "plano-stereoscopic",
+		"local or regional",
+	},
+}
+
+// Genre returns the name of the genre.  A level 2 nibble which is not
+// defined falls back to the general genre of level 1.
+func (c ContentItem) Genre() string {
+	if c.Level1 == 0xf {
+		return "user defined"
+	}
+
+	names, ok := genres[c.Level1]
+	if !ok {
+		return ""
+	} else if int(c.Level2) < len(names) {
+		return names[c.Level2]
+	}
+
+	return names[0]
+}
@@ -0,0 +1,396 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/si"
+)
+
+type DescriptorTag uint8
+
+const (
+	NetworkNameDescriptor               DescriptorTag = 0x40
+	ServiceListDescriptor               DescriptorTag = 0x41
+	StuffingDescriptor                  DescriptorTag = 0x42
+	SatelliteDeliverySystemDescriptor   DescriptorTag = 0x43
+	CableDeliverySystemDescriptor       DescriptorTag = 0x44
+	VBIDataDescriptor                   DescriptorTag = 0x45
+	VBITeletextDescriptor               DescriptorTag = 0x46
+	BouquetNameDescriptor               DescriptorTag = 0x47
+	ServiceDescriptor                   DescriptorTag = 0x48
+	CountryAvailabilityDescriptor       DescriptorTag = 0x49
+	LinkageDescriptor                   DescriptorTag = 0x4A
+	NVODReferenceDescriptor             DescriptorTag = 0x4B
+	TimeShiftedServiceDescriptor        DescriptorTag = 0x4C
+	ShortEventDescriptor                DescriptorTag = 0x4D
+	ExtendedEventDescriptor             DescriptorTag = 0x4E
+	TimeShiftedEventDescriptor          DescriptorTag = 0x4F
+	ComponentDescriptor                 DescriptorTag = 0x50
+	MosaicDescriptor                    DescriptorTag = 0x51
+	StreamIdentifierDescriptor          DescriptorTag = 0x52
+	CAIdentifierDescriptor              DescriptorTag = 0x53
+	ContentDescriptor                   DescriptorTag = 0x54
+	ParentalRatingDescriptor            DescriptorTag = 0x55
+	TeletextDescriptor                  DescriptorTag = 0x56
+	TelephoneDescriptor                 DescriptorTag = 0x57
+	LocalTimeOffsetDescriptor           DescriptorTag = 0x58
+	SubtitlingDescriptor                DescriptorTag = 0x59
+	TerrestrialDeliverySystemDescriptor DescriptorTag = 0x5A
+	MultilingualNetworkNameDescriptor   DescriptorTag = 0x5B
+	MultilingualBouquetNameDescriptor   DescriptorTag = 0x5C
+	MultilingualServiceNameDescriptor   DescriptorTag = 0x5D
+	MultilingualComponentDescriptor     DescriptorTag = 0x5E
+	PrivateDataSpecifierDescriptor      DescriptorTag = 0x5F
+	ServiceMoveDescriptor               DescriptorTag = 0x60
+	ShortSmoothingBufferDescriptor      DescriptorTag = 0x61
+	FrequencyListDescriptor             DescriptorTag = 0x62
+	PartialTransportStreamDescriptor    DescriptorTag = 0x63
+	DataBroadcastDescriptor             DescriptorTag = 0x64
+	ScramblingDescriptor                DescriptorTag = 0x65
+	DataBroadcastIdDescriptor           DescriptorTag = 0x66
+	TransportStreamDescriptor           DescriptorTag = 0x67
+	DSNGDescriptor                      DescriptorTag = 0x68
+	PDCDescriptor                       DescriptorTag = 0x69
+	AC3Descriptor                       DescriptorTag = 0x6A
+	AncillaryDataDescriptor             DescriptorTag = 0x6B
+	CellListDescriptor                  DescriptorTag = 0x6C
+	CellFrequencyLinkDescriptor         DescriptorTag = 0x6D
+	AnnouncementSupportDescriptor       DescriptorTag = 0x6E
+	ApplicationSignallingDescriptor     DescriptorTag = 0x6F
+	AdaptationFieldDataDescriptor       DescriptorTag = 0x70
+	ServiceIdentifierDescriptor         DescriptorTag = 0x71
+	ServiceAvailabilityDescriptor       DescriptorTag = 0x72
+	DefaultAuthorityDescriptor          DescriptorTag = 0x73
+	RelatedContentDescriptor            DescriptorTag = 0x74
+	TVAIdDescriptor                     DescriptorTag = 0x75
+	ContentIdentifierDescriptor         DescriptorTag = 0x76
+	TimeSliceFECIdentifierDescriptor    DescriptorTag = 0x77
+	ECMRepetitionRateDescriptor         DescriptorTag = 0x78
+	S2SatelliteDeliverySystemDescriptor DescriptorTag = 0x79
+	EnhancedAC3Descriptor               DescriptorTag = 0x7A
+	DTSDescriptor                       DescriptorTag = 0x7B
+	AACDescriptor                       DescriptorTag = 0x7C
+	XAITLocationDescriptor              DescriptorTag = 0x7D
+	FTAContentManagementDescriptor      DescriptorTag = 0x7E
+	ExtensionDescriptor                 DescriptorTag = 0x7F
+)
+
+const (
+	ServiceTypeDigitalTelevision uint8 = 0x01
+	ServiceTypeDigitalRadio      uint8 = 0x02
+	ServiceTypeTeletext          uint8 = 0x03
+	ServiceTypeAdvancedSDTV      uint8 = 0x16
+	ServiceTypeAdvancedHDTV      uint8 = 0x19
+	ServiceTypeHEVCTelevision    uint8 = 0x1F
+)
+
+const (
+	TeletextTypeInitialPage                 uint8 = 0x01
+	TeletextTypeSubtitlePage                uint8 = 0x02
+	TeletextTypeAdditionalInformationPage   uint8 = 0x03
+	TeletextTypeProgrammeSchedulePage       uint8 = 0x04
+	TeletextTypeHearingImpairedSubtitlePage uint8 = 0x05
+)
+
+func payloadOf(d tsparser.Descriptor, tag DescriptorTag) []byte {
+	if len(d) < 2 || DescriptorTag(d.Tag()) != tag || len(d) < int(d.Length())+2 {
+		return nil
+	}
+
+	return d.Payload()
+}
+
+// lengthPrefixed splits data into the bytes following a length byte and
+// the rest.
+func lengthPrefixed(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, nil, false
+	}
+
+	return data[1 : 1+data[0]], data[1+data[0]:], true
+}
+
+type ServiceDescription struct {
+	ServiceType  uint8
+	ProviderName []byte
+	ServiceName  []byte
+}
+
+func ParseServiceDescriptor(d tsparser.Descriptor) *ServiceDescription {
+	payload := payloadOf(d, ServiceDescriptor)
+	if len(payload) < 1 {
+		return nil
+	}
+
+	provider, rest, ok := lengthPrefixed(payload[1:])
+	if !ok {
+		return nil
+	}
+	name, _, ok := lengthPrefixed(rest)
+	if !ok {
+		return nil
+	}
+
+	return &ServiceDescription{
+		ServiceType:  payload[0],
+		ProviderName: provider,
+		ServiceName:  name,
+	}
+}
+
+type ShortEvent struct {
+	Language  string
+	EventName []byte
+	Text      []byte
+}
+
+func ParseShortEventDescriptor(d tsparser.Descriptor) *ShortEvent {
+	payload := payloadOf(d, ShortEventDescriptor)
+	if len(payload) < 3 {
+		return nil
+	}
+
+	name, rest, ok := lengthPrefixed(payload[3:])
+	if !ok {
+		return nil
+	}
+	text, _, ok := lengthPrefixed(rest)
+	if !ok {
+		return nil
+	}
+
+	return &ShortEvent{
+		Language:  string(payload[0:3]),
+		EventName: name,
+		Text:      text,
+	}
+}
+
+type ExtendedEventItem struct {
+	Description []byte
+	Item        []byte
+}
+
+type ExtendedEvent struct {
+	Number     uint8
+	LastNumber uint8
+	Language   string
+	Items      []ExtendedEventItem
+	Text       []byte
+}
+
+func ParseExtendedEventDescriptor(d tsparser.Descriptor) *ExtendedEvent {
+	payload := payloadOf(d, ExtendedEventDescriptor)
+	if len(payload) < 4 {
+		return nil
+	}
+
+	e := &ExtendedEvent{
+		Number:     payload[0] >> 4,
+		LastNumber: payload[0] & 0x0f,
+		Language:   string(payload[1:4]),
+	}
+
+	items, rest, ok := lengthPrefixed(payload[4:])
+	if !ok {
+		return nil
+	}
+	for len(items) > 0 {
+		description, r, ok := lengthPrefixed(items)
+		if !ok {
+			break
+		}
+		item, r, ok := lengthPrefixed(r)
+		if !ok {
+			break
+		}
+		e.Items = append(e.Items, ExtendedEventItem{description, item})
+		items = r
+	}
+
+	e.Text, _, _ = lengthPrefixed(rest)
+	return e
+}
+
+type Component struct {
+	StreamContentExt uint8
+	StreamContent    uint8
+	ComponentType    uint8
+	ComponentTag     uint8
+	Language         string
+	Text             []byte
+}
+
+func ParseComponentDescriptor(d tsparser.Descriptor) *Component {
+	payload := payloadOf(d, ComponentDescriptor)
+	if len(payload) < 6 {
+		return nil
+	}
+
+	return &Component{
+		StreamContentExt: payload[0] >> 4,
+		StreamContent:    payload[0] & 0x0f,
+		ComponentType:    payload[1],
+		ComponentTag:     payload[2],
+		Language:         string(payload[3:6]),
+		Text:             payload[6:],
+	}
+}
+
+type ContentItem struct {
+	Level1   uint8
+	Level2   uint8
+	UserByte uint8
+}
+
+func ParseContentDescriptor(d tsparser.Descriptor) []ContentItem {
+	payload := payloadOf(d, ContentDescriptor)
+
+	var items []ContentItem
+	for i := 0; i+2 <= len(payload); i += 2 {
+		items = append(items, ContentItem{
+			Level1:   payload[i] >> 4,
+			Level2:   payload[i] & 0x0f,
+			UserByte: payload[i+1],
+		})
+	}
+
+	return items
+}
+
+type ParentalRating struct {
+	Country string
+	Rating  uint8
+}
+
+// MinimumAge returns the minimum age of the viewer.  Ratings out of
+// 0x01-0x0F are undefined or defined by the broadcaster.
+func (r ParentalRating) MinimumAge() (int, bool) {
+	if r.Rating < 0x01 || 0x0f < r.Rating {
+		return 0, false
+	}
+
+	return int(r.Rating) + 3, true
+}
+
+func ParseParentalRatingDescriptor(d tsparser.Descriptor) []ParentalRating {
+	payload := payloadOf(d, ParentalRatingDescriptor)
+
+	var ratings []ParentalRating
+	for i := 0; i+4 <= len(payload); i += 4 {
+		ratings = append(ratings, ParentalRating{
+			Country: string(payload[i : i+3]),
+			Rating:  payload[i+3],
+		})
+	}
+
+	return ratings
+}
+
+type Teletext struct {
+	Language string
+	Type     uint8
+	Magazine uint8
+	Page     uint8
+}
+
+// PageNumber returns the page number in hexadecimal such as 0x888, where
+// magazine 0 is numbered 8.
+func (t Teletext) PageNumber() uint16 {
+	magazine := uint16(t.Magazine)
+	if magazine == 0 {
+		magazine = 8
+	}
+
+	return magazine<<8 | uint16(t.Page)
+}
+
+func ParseTeletextDescriptor(d tsparser.Descriptor) []Teletext {
+	payload := payloadOf(d, TeletextDescriptor)
+
+	var teletexts []Teletext
+	for i := 0; i+5 <= len(payload); i += 5 {
+		teletexts = append(teletexts, Teletext{
+			Language: string(payload[i : i+3]),
+			Type:     payload[i+3] >> 3,
+			Magazine: payload[i+3] & 0x07,
+			Page:     payload[i+4],
+		})
+	}
+
+	return teletexts
+}
+
+type LocalTimeOffset struct {
+	Country      string
+	RegionId     uint8
+	Offset       time.Duration
+	TimeOfChange time.Time
+	NextOffset   time.Duration
+}
+
+// In returns t in the local time, switching to the next offset at the
+// time of change.
+func (o LocalTimeOffset) In(t time.Time) time.Time {
+	offset := o.Offset
+	if !o.TimeOfChange.IsZero() && !t.Before(o.TimeOfChange) {
+		offset = o.NextOffset
+	}
+
+	return t.In(time.FixedZone(o.Country, int(offset/time.Second)))
+}
+
+func parseOffset(data []byte, negative bool) time.Duration {
+	d := time.Duration(si.DecodeBCD(data[0]))*time.Hour + time.Duration(si.DecodeBCD(data[1]))*time.Minute
+	if negative {
+		return -d
+	}
+
+	return d
+}
+
+func ParseLocalTimeOffsetDescriptor(d tsparser.Descriptor) []LocalTimeOffset {
+	payload := payloadOf(d, LocalTimeOffsetDescriptor)
+
+	var offsets []LocalTimeOffset
+	for i := 0; i+13 <= len(payload); i += 13 {
+		negative := payload[i+3]&0x01 > 0
+		offsets = append(offsets, LocalTimeOffset{
+			Country:      string(payload[i : i+3]),
+			RegionId:     payload[i+3] >> 2,
+			Offset:       parseOffset(payload[i+4:i+6], negative),
+			TimeOfChange: parseUTCTime(payload[i+6 : i+11]),
+			NextOffset:   parseOffset(payload[i+11:i+13], negative),
+		})
+	}
+
+	return offsets
+}
+
+type Subtitling struct {
+	Language          string
+	Type              uint8
+	CompositionPageId uint16
+	AncillaryPageId   uint16
+}
+
+func ParseSubtitlingDescriptor(d tsparser.Descriptor) []Subtitling {
+	payload := payloadOf(d, SubtitlingDescriptor)
+
+	var subtitlings []Subtitling
+	for i := 0; i+8 <= len(payload); i += 8 {
+		subtitlings = append(subtitlings, Subtitling{
+			Language:          string(payload[i : i+3]),
+			Type:              payload[i+3],
+			CompositionPageId: uint16(payload[i+4])<<8 | uint16(payload[i+5]),
+			AncillaryPageId:   uint16(payload[i+6])<<8 | uint16(payload[i+7]),
+		})
+	}
+
+	return subtitlings
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func descriptor(tag DescriptorTag, payload string) tsparser.Descriptor {
+	return tsparser.NewDescriptor(tsparser.DescriptorTag(tag), []byte(payload))
+}
+
+func TestParseServiceDescriptor(t *testing.T) {
+	got := ParseServiceDescriptor(descriptor(ServiceDescriptor, "\x01\x03BBC\x0aBBC ONE HD"))
+	want := &ServiceDescription{ServiceType: 0x01, ProviderName: []byte("BBC"), ServiceName: []byte("BBC ONE HD")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("service = %+v, want %+v", got, want)
+	}
+
+	if got := ParseServiceDescriptor(descriptor(ServiceDescriptor, "\x01\x03BBC\x0aBBC")); got != nil {
+		t.Errorf("truncated service = %+v", got)
+	}
+	if got := ParseServiceDescriptor(descriptor(ShortEventDescriptor, "\x01\x00\x00")); got != nil {
+		t.Errorf("short event as service = %+v", got)
+	}
+}
+
+func TestParseEventDescriptors(t *testing.T) {
+	short := ParseShortEventDescriptor(descriptor(ShortEventDescriptor, "eng\x04News\x05Today"))
+	if short == nil || short.Language != "eng" || string(short.EventName) != "News" || string(short.Text) != "Today" {
+		t.Errorf("short event = %+v", short)
+	}
+
+	extended := ParseExtendedEventDescriptor(descriptor(ExtendedEventDescriptor,
+		"\x12eng\x0f\x08Director\x05Smith\x04More"))
+	want := &ExtendedEvent{
+		Number:     1,
+		LastNumber: 2,
+		Language:   "eng",
+		Items:      []ExtendedEventItem{{[]byte("Director"), []byte("Smith")}},
+		Text:       []byte("More"),
+	}
+	if !reflect.DeepEqual(extended, want) {
+		t.Errorf("extended event = %+v, want %+v", extended, want)
+	}
+
+	ratings := ParseParentalRatingDescriptor(descriptor(ParentalRatingDescriptor, "GBR\x09FRA\x00"))
+	if len(ratings) != 2 || ratings[0].Country != "GBR" || ratings[1].Country != "FRA" {
+		t.Fatalf("ratings = %+v", ratings)
+	}
+	if age, ok := ratings[0].MinimumAge(); !ok || age != 12 {
+		t.Errorf("minimum age = %d (%v)", age, ok)
+	}
+	if _, ok := ratings[1].MinimumAge(); ok {
+		t.Error("minimum age of an undefined rating")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type RunningStatus struct {
+	TransportStreamId uint16
+	OriginalNetworkId uint16
+	ServiceId         uint16
+	EventId           uint16
+	RunningStatus     uint8
+}
+
+func ParseRunningStatusSection(table tsparser.Table) []*RunningStatus {
+	payload := table.Data()
+
+	var result []*RunningStatus
+	for i := 0; i+9 <= len(payload); i += 9 {
+		result = append(result, &RunningStatus{
+			TransportStreamId: uint16(payload[i])<<8 | uint16(payload[i+1]),
+			OriginalNetworkId: uint16(payload[i+2])<<8 | uint16(payload[i+3]),
+			ServiceId:         uint16(payload[i+4])<<8 | uint16(payload[i+5]),
+			EventId:           uint16(payload[i+6])<<8 | uint16(payload[i+7]),
+			RunningStatus:     payload[i+8] & 0x07,
+		})
+	}
+
+	return result
+}
+
+func MarshalRunningStatusSection(statuses ...*RunningStatus) (tsparser.Table, error) {
+	data := make([]byte, 0, 9*len(statuses))
+	for _, s := range statuses {
+		data = append(data,
+			byte(s.TransportStreamId>>8), byte(s.TransportStreamId),
+			byte(s.OriginalNetworkId>>8), byte(s.OriginalNetworkId),
+			byte(s.ServiceId>>8), byte(s.ServiceId),
+			byte(s.EventId>>8), byte(s.EventId),
+			0xf8|s.RunningStatus&0x07)
+	}
+
+	return tsparser.NewShortTable(RunningStatusTable, true, data)
+}
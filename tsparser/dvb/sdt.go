@@ -0,0 +1,27 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/si"
+)
+
+type (
+	Service                   = si.Service
+	ServiceDescriptionSection = si.ServiceDescriptionSection
+)
+
+func NewService(serviceId uint16, descriptors ...tsparser.Descriptor) *Service {
+	return si.NewService(serviceId, descriptors...)
+}
+
+func NewServiceDescriptionSection(transportStreamId, originalNetworkId uint16) *ServiceDescriptionSection {
+	return si.NewServiceDescriptionSection(transportStreamId, originalNetworkId)
+}
+
+func ParseServiceDescriptionSection(table tsparser.Table) *ServiceDescriptionSection {
+	return si.ParseServiceDescriptionSection(table)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	NetworkInformationActualTable  tsparser.TableId = 0x40
+	NetworkInformationOtherTable   tsparser.TableId = 0x41
+	ServiceDescriptionActualTable  tsparser.TableId = 0x42
+	ServiceDescriptionOtherTable   tsparser.TableId = 0x46
+	BouquetAssociationTable        tsparser.TableId = 0x4A
+	EventInformationActualPFTable  tsparser.TableId = 0x4E
+	EventInformationOtherPFTable   tsparser.TableId = 0x4F
+	EventInformationActualSchTable tsparser.TableId = 0x50
+	EventInformationOtherSchTable  tsparser.TableId = 0x60
+	EventInformationScheduleEnd    tsparser.TableId = 0x70
+	TimeDateTable                  tsparser.TableId = 0x70
+	RunningStatusTable             tsparser.TableId = 0x71
+	StuffingTable                  tsparser.TableId = 0x72
+	TimeOffsetTable                tsparser.TableId = 0x73
+	DiscontinuityInformationTable  tsparser.TableId = 0x7E
+	SelectionInformationTable      tsparser.TableId = 0x7F
+)
+
+const (
+	NetworkInformationPID tsparser.PID = 0x0010
+	ServiceDescriptionPID tsparser.PID = 0x0011
+	BouquetAssociationPID tsparser.PID = 0x0011
+	EventInformationPID   tsparser.PID = 0x0012
+	RunningStatusPID      tsparser.PID = 0x0013
+	TimeDatePID           tsparser.PID = 0x0014
+)
+
+const (
+	RunningStatusUndefined uint8 = iota
+	RunningStatusNotRunning
+	RunningStatusStartsSoon
+	RunningStatusPausing
+	RunningStatusRunning
+	RunningStatusOffAir
+)
+
+// ParseTimeDateSection returns UTC_time of a TDT or a TOT.
+func ParseTimeDateSection(table tsparser.Table) time.Time {
+	data := table.Data()
+	if len(data) < 5 {
+		return time.Time{}
+	}
+
+	return parseUTCTime(data)
+}
+
+func MarshalTimeDateSection(t time.Time) (tsparser.Table, error) {
+	return tsparser.NewShortTable(TimeDateTable, true, encodeUTCTime(t))
+}
+
+type TimeOffsetSection struct {
+	utcTime     time.Time
+	descriptors []tsparser.Descriptor
+}
+
+func NewTimeOffsetSection(t time.Time, descriptors ...tsparser.Descriptor) *TimeOffsetSection {
+	return &TimeOffsetSection{
+		utcTime:     t,
+		descriptors: descriptors,
+	}
+}
+
+func ParseTimeOffsetSection(table tsparser.Table) *TimeOffsetSection {
+	sec := NewTimeOffsetSection(ParseTimeDateSection(table))
+
+	data := table.Data()
+	if len(data) < 7 {
+		return sec
+	}
+
+	length := int(data[5]&0x0f)<<8 | int(data[6])
+	if 7+length <= len(data) {
+		sec.descriptors = tsparser.ParseDescriptors(data[7 : 7+length])
+	}
+
+	return sec
+}
+
+func (s *TimeOffsetSection) UTCTime() time.Time {
+	return s.utcTime
+}
+
+func (s *TimeOffsetSection) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
+
+// LocalTime returns UTC_time in the local time of a country and a region
+// given by the local_time_offset_descriptor.
+func (s *TimeOffsetSection) LocalTime(country string, regionId uint8) (time.Time, bool) {
+	for _, d := range s.descriptors {
+		for _, offset := range ParseLocalTimeOffsetDescriptor(d) {
+			if offset.Country == country && offset.RegionId == regionId {
+				return offset.In(s.utcTime), true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Marshal builds a TOT.  Its CRC_32 is computed over the section although
+// section_syntax_indicator is not set.
+func (s *TimeOffsetSection) Marshal() (tsparser.Table, error) {
+	info := tsparser.MarshalDescriptors(s.descriptors)
+	data := encodeUTCTime(s.utcTime)
+	data = append(data, 0xf0|byte(len(info)>>8)&0x0f, byte(len(info)))
+	data = append(data, info...)
+	data = append(data, 0, 0, 0, 0)
+
+	table, err := tsparser.NewShortTable(TimeOffsetTable, true, data)
+	if err != nil {
+		return nil, err
+	}
+
+	end := len(table) - 4
+	crc := tsparser.CRC32(table[:end])
+	table[end], table[end+1], table[end+2], table[end+3] = byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc)
+	return table, nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func TestTimeDateSectionMarshal(t *testing.T) {
+	now := time.Date(2014, time.October, 19, 12, 54, 30, 0, time.UTC)
+	table, err := MarshalTimeDateSection(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2014-10-19 is MJD 56949.
+	if !bytes.Equal(table, []byte{0x70, 0x70, 0x05, 0xde, 0x75, 0x12, 0x54, 0x30}) {
+		t.Errorf("TDT = %x", []byte(table))
+	}
+	if got := ParseTimeDateSection(table); !got.Equal(now) {
+		t.Errorf("time = %v, want %v", got, now)
+	}
+}
+
+func TestTimeOffsetSectionMarshal(t *testing.T) {
+	now := time.Date(2014, time.October, 26, 0, 30, 0, 0, time.UTC)
+	// GBR moves from +01:00 to +00:00 at 2014-10-26 01:00:00 UTC, and PRT
+	// of region 1 is at -01:00.
+	lto := tsparser.NewDescriptor(tsparser.DescriptorTag(LocalTimeOffsetDescriptor), []byte{
+		'G', 'B', 'R', 0x02, 0x01, 0x00, 0xde, 0x7c, 0x01, 0x00, 0x00, 0x00, 0x00,
+		'P', 'R', 'T', 0x07, 0x01, 0x00, 0xde, 0x7c, 0x01, 0x00, 0x00, 0x01, 0x00,
+	})
+
+	table, err := NewTimeOffsetSection(now, lto).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.TableId() != TimeOffsetTable || tsparser.CRC32(table) != 0 {
+		t.Fatalf("TOT = %x", []byte(table))
+	}
+
+	tot := ParseTimeOffsetSection(table)
+	if !tot.UTCTime().Equal(now) {
+		t.Errorf("UTC_time = %v, want %v", tot.UTCTime(), now)
+	}
+
+	offsets := ParseLocalTimeOffsetDescriptor(tot.Descriptors()[0])
+	change := time.Date(2014, time.October, 26, 1, 0, 0, 0, time.UTC)
+	want := []LocalTimeOffset{
+		{"GBR", 0, time.Hour, change, 0},
+		{"PRT", 1, -time.Hour, change, -time.Hour},
+	}
+	if len(offsets) != len(want) {
+		t.Fatalf("%d offsets, want %d", len(offsets), len(want))
+	}
+	for i, o := range want {
+		if got := offsets[i]; got.Country != o.Country || got.RegionId != o.RegionId ||
+			got.Offset != o.Offset || !got.TimeOfChange.Equal(o.TimeOfChange) || got.NextOffset != o.NextOffset {
+			t.Errorf("%d: offset = %+v, want %+v", i, got, o)
+		}
+	}
+
+	if local, ok := tot.LocalTime("GBR", 0); !ok || local.Hour() != 1 || local.Minute() != 30 {
+		t.Errorf("local time = %v (%v)", local, ok)
+	}
+	if _, ok := tot.LocalTime("GBR", 1); ok {
+		t.Error("local time of an unknown region")
+	}
+	if local := offsets[0].In(change.Add(time.Minute)); local.Hour() != 1 || local.Minute() != 1 {
+		t.Errorf("local time after the change = %v", local)
+	}
+}
+
+func TestBouquetAssociationSectionMarshal(t *testing.T) {
+	name := tsparser.NewDescriptor(tsparser.DescriptorTag(BouquetNameDescriptor), []byte("Bouquet"))
+	bat := NewBouquetAssociationSection(0x1001, name)
+	bat.SetVersionNumber(7)
+	bat.SetSectionNumber(1, 2)
+	bat.AddTransportStream(NewTransportStream(0x0001, 0x233a))
+	bat.AddTransportStream(NewTransportStream(0x0002, 0x233a,
+		tsparser.NewDescriptor(tsparser.DescriptorTag(ServiceListDescriptor), []byte{0x10, 0x00, 0x01})))
+
+	table, err := bat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.TableId() != BouquetAssociationTable || !table.CheckCRC() {
+		t.Fatalf("BAT = %x", []byte(table))
+	}
+
+	parsed := ParseBouquetAssociationSection(table)
+	if parsed.BouquetId() != 0x1001 || parsed.VersionNumber() != 7 || parsed.SectionNumber() != 1 || parsed.LastSectionNumber() != 2 {
+		t.Errorf("bouquet_id = %#x, version_number = %d, section_number = %d/%d",
+			parsed.BouquetId(), parsed.VersionNumber(), parsed.SectionNumber(), parsed.LastSectionNumber())
+	}
+	if len(parsed.Descriptors()) != 1 || !bytes.Equal(parsed.Descriptors()[0], name) {
+		t.Errorf("descriptors = %x", parsed.Descriptors())
+	}
+
+	streams := parsed.TransportStreams()
+	if len(streams) != 2 {
+		t.Fatalf("%d transport streams, want 2", len(streams))
+	}
+	if ts := streams[0]; ts.TransportStreamId() != 0x0001 || ts.OriginalNetworkId() != 0x233a || len(ts.Descriptors()) != 0 {
+		t.Errorf("transport stream 0 = %#x %#x %x", ts.TransportStreamId(), ts.OriginalNetworkId(), ts.Descriptors())
+	}
+	if ts := streams[1]; ts.TransportStreamId() != 0x0002 || len(ts.Descriptors()) != 1 {
+		t.Errorf("transport stream 1 = %#x %#x %x", ts.TransportStreamId(), ts.OriginalNetworkId(), ts.Descriptors())
+	}
+}
+
+func TestRunningStatusSectionMarshal(t *testing.T) {
+	statuses := []*RunningStatus{
+		{0x0001, 0x233a, 0x1044, 0x0102, RunningStatusRunning},
+		{0x0001, 0x233a, 0x1045, 0x0203, RunningStatusPausing},
+	}
+	table, err := MarshalRunningStatusSection(statuses...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.TableId() != RunningStatusTable || table[1]&0xf0 != 0x70 || table.SectionLength() != 18 {
+		t.Fatalf("RST = %x", []byte(table))
+	}
+
+	parsed := ParseRunningStatusSection(table)
+	if len(parsed) != len(statuses) {
+		t.Fatalf("%d statuses, want %d", len(parsed), len(statuses))
+	}
+	for i, s := range statuses {
+		if *parsed[i] != *s {
+			t.Errorf("%d: status = %+v, want %+v", i, parsed[i], s)
+		}
+	}
+}
+
+func TestEventInformationSectionUTC(t *testing.T) {
+	start := time.Date(2014, time.October, 19, 12, 54, 30, 0, time.UTC)
+	eit := NewEventInformationSection(EventInformationActualSchTable, 0x1044, 0x0001, 0x233a)
+	eit.AddEvent(NewEvent(0x0102, start, 90*time.Minute))
+
+	table, err := eit.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// start_time is coded in UTC: MJD 56949 12:54:30 and 01:30:00.
+	if data := table.Data(); !bytes.Equal(data[8:16], []byte{0xde, 0x75, 0x12, 0x54, 0x30, 0x01, 0x30, 0x00}) {
+		t.Errorf("event = %x", data[6:])
+	}
+
+	events := ParseEventInformationSection(table).Events()
+	if len(events) != 1 || !events[0].StartTime().Equal(start) || events[0].StartTime().Location() != time.UTC {
+		t.Errorf("events = %+v", events)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"code.google.com/p/go.text/encoding/charmap"
+	"code.google.com/p/go.text/encoding/simplifiedchinese"
+	"code.google.com/p/go.text/encoding/traditionalchinese"
+	"code.google.com/p/go.text/transform"
+	"code.google.com/p/go.text/unicode/norm"
+)
+
+const (
+	emphasisOn  = 0x86
+	emphasisOff = 0x87
+	crlf        = 0x8a
+)
+
+type decoder interface {
+	NewDecoder() transform.Transformer
+}
+
+// Character code table 00 of EN 300 468 Annex A, which is ISO/IEC 6937 with
+// the euro sign at 0xA4.  Non-spacing diacritical marks at 0xC1-0xCF are
+// mapped to the combining characters.
+var iso6937 = [0x60]rune{
+	0x00a0, 0x00a1, 0x00a2, 0x00a3, 0x20ac, 0x00a5, 0x0023, 0x00a7,
+	0x00a4, 0x2018, 0x201c, 0x00ab, 0x2190, 0x2191, 0x2192, 0x2193,
+	0x00b0, 0x00b1, 0x00b2, 0x00b3, 0x00d7, 0x00b5, 0x00b6, 0x00b7,
+	0x00f7, 0x2019, 0x201d, 0x00bb, 0x00bc, 0x00bd, 0x00be, 0x00bf,
+	0x0000, 0x0300, 0x0301, 0x0302, 0x0303, 0x0304, 0x0306, 0x0307,
+	0x0308, 0x0000, 0x030a, 0x0327, 0x0000, 0x030b, 0x0328, 0x030c,
+	0x2015, 0x00b9, 0x00ae, 0x00a9, 0x2122, 0x266a, 0x00ac, 0x00a6,
+	0x0000, 0x0000, 0x0000, 0x0000, 0x215b, 0x215c, 0x215d, 0x215e,
+	0x2126, 0x00c6, 0x0110, 0x00aa, 0x0126, 0x0000, 0x0132, 0x013f,
+	0x0141, 0x00d8, 0x0152, 0x00ba, 0x00de, 0x0166, 0x014a, 0x0149,
+	0x0138, 0x00e6, 0x0111, 0x00f0, 0x0127, 0x0131, 0x0133, 0x0140,
+	0x0142, 0x00f8, 0x0153, 0x00df, 0x00fe, 0x0167, 0x014b, 0x00ad,
+}
+
+var (
+	// Upper halves of ISO/IEC 8859 parts indexed by the part number.
+	iso8859 = map[int]*[0x60]rune{}
+
+	iso8859Encodings = map[int]decoder{
+		2:  charmap.ISO8859_2,
+		3:  charmap.ISO8859_3,
+		4:  charmap.ISO8859_4,
+		5:  charmap.ISO8859_5,
+		6:  charmap.ISO8859_6,
+		7:  charmap.ISO8859_7,
+		8:  charmap.ISO8859_8,
+		9:  charmap.ISO8859_9,
+		10: charmap.ISO8859_10,
+		11: charmap.Windows874,
+		13: charmap.ISO8859_13,
+		14: charmap.ISO8859_14,
+		15: charmap.ISO8859_15,
+		16: charmap.ISO8859_16,
+	}
+)
+
+func init() {
+	upper := make([]byte, 0x60)
+	for i := range upper {
+		upper[i] = byte(0xa0 + i)
+	}
+
+	latin1 := new([0x60]rune)
+	for i, b := range upper {
+		latin1[i] = rune(b)
+	}
+	iso8859[1] = latin1
+
+	for part, enc := range iso8859Encodings {
+		result, _, err := transform.Bytes(enc.NewDecoder(), upper)
+		if err != nil {
+			panic(err)
+		}
+
+		table := new([0x60]rune)
+		for i := range table {
+			r, size := utf8.DecodeRune(result)
+			if r == utf8.RuneError {
+				r = 0
+			}
+			table[i] = r
+			result = result[size:]
+		}
+		iso8859[part] = table
+	}
+}
+
+type TextSegment struct {
+	Text     string
+	Emphasis bool
+}
+
+type textBuffer struct {
+	segments []TextSegment
+	current  bytes.Buffer
+	emphasis bool
+}
+
+func (b *textBuffer) flush() {
+	if b.current.Len() == 0 {
+		return
+	}
+
+	b.segments = append(b.segments, TextSegment{
+		Text:     norm.NFC.String(b.current.String()),
+		Emphasis: b.emphasis,
+	})
+	b.current.Reset()
+}
+
+// control interprets a control code and reports whether c is one.
+func (b *textBuffer) control(c rune) bool {
+	switch c {
+	case emphasisOn, emphasisOff:
+		if emphasis := c == emphasisOn; emphasis != b.emphasis {
+			b.flush()
+			b.emphasis = emphasis
+		}
+	case crlf:
+		b.current.WriteByte('\n')
+	default:
+		return c < 0x20 || 0x80 <= c && c <= 0x9f
+	}
+
+	return true
+}
+
+func (b *textBuffer) decodeSingleByte(data []byte, table *[0x60]rune) {
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if b.control(rune(c)) {
+			continue
+		} else if c < 0xa0 {
+			b.current.WriteByte(c)
+			continue
+		}
+
+		r := table[c-0xa0]
+		if r == 0 {
+			continue
+		}
+
+		// A diacritical mark of ISO/IEC 6937 precedes the letter it modifies.
+		if table == &iso6937 && 0xc1 <= c && c <= 0xcf {
+			if i+1 < len(data) && 0x20 < data[i+1] && data[i+1] < 0x7f {
+				i++
+				b.current.WriteByte(data[i])
+			}
+		}
+		b.current.WriteRune(r)
+	}
+}
+
+func (b *textBuffer) decodeUCS2(data []byte) {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+
+	for _, r := range utf16.Decode(units) {
+		b.decodeRune(r)
+	}
+}
+
+func (b *textBuffer) decodeUTF8(data []byte) {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		b.decodeRune(r)
+		data = data[size:]
+	}
+}
+
+// decodeRune handles a character of the multi-byte tables where control
+// codes are mapped to 0xE080-0xE09F.
+func (b *textBuffer) decodeRune(r rune) {
+	if 0xe080 <= r && r <= 0xe09f {
+		r -= 0xe000
+	}
+
+	if !b.control(r) {
+		b.current.WriteRune(r)
+	}
+}
+
+func (b *textBuffer) decode(data []byte, enc decoder) {
+	result, _, err := transform.Bytes(enc.NewDecoder(), data)
+	if err != nil {
+		return
+	}
+
+	b.decodeUTF8(result)
+}
+
+// DecodeText decodes a text field of DVB SI following the character table
+// selected by its first byte.
+func DecodeText(data []byte) []TextSegment {
+	b := new(textBuffer)
+
+	switch {
+	case len(data) == 0:
+	case data[0] >= 0x20:
+		b.decodeSingleByte(data, &iso6937)
+	case 0x01 <= data[0] && data[0] <= 0x0b:
+		if table, ok := iso8859[int(data[0])+4]; ok {
+			b.decodeSingleByte(data[1:], table)
+		}
+	case data[0] == 0x10:
+		if len(data) < 3 {
+			break
+		}
+		if table, ok := iso8859[int(data[1])<<8|int(data[2])]; ok {
+			b.decodeSingleByte(data[3:], table)
+		}
+	case data[0] == 0x11:
+		b.decodeUCS2(data[1:])
+	case data[0] == 0x13:
+		b.decode(data[1:], simplifiedchinese.GBK)
+	case data[0] == 0x14:
+		b.decode(data[1:], traditionalchinese.Big5)
+	case data[0] == 0x15:
+		b.decodeUTF8(data[1:])
+	}
+
+	b.flush()
+	return b.segments
+}
+
+// DecodeString decodes a text field of DVB SI ignoring emphasis.
+func DecodeString(data []byte) string {
+	var buf bytes.Buffer
+	for _, segment := range DecodeText(data) {
+		buf.WriteString(segment.Text)
+	}
+
+	return buf.String()
+}
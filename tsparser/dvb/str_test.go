@@ -0,0 +1,51 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"testing"
+)
+
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{[]byte("News"), "News"},
+		// A diacritical mark and the euro sign of the default table.
+		{[]byte{'C', 'a', 'f', 0xc2, 'e', ' ', 0xa4}, "Café €"},
+		{[]byte{'a', crlf, 'b'}, "a\nb"},
+		// ISO/IEC 8859-9 selected by 0x05.
+		{[]byte{0x05, 'I', 0xfd}, "Iı"},
+		// ISO/IEC 8859-2 selected by 0x10 0x00 0x02.
+		{[]byte{0x10, 0x00, 0x02, 0xb1}, "ą"},
+		{[]byte{0x11, 0x30, 0x42, 0xe0, 0x8a, 0x00, 'x'}, "あ\nx"},
+		{[]byte{0x13, 0xd6, 0xd0}, "中"},
+		{[]byte{0x14, 0xa4, 0xa4}, "中"},
+		{append([]byte{0x15}, "日本"...), "日本"},
+		{[]byte{0x10, 0x00}, ""},
+		{nil, ""},
+	}
+
+	for i, test := range tests {
+		if s := DecodeString(test.data); s != test.expected {
+			t.Errorf("%d: %q, want %q", i, s, test.expected)
+		}
+	}
+}
+
+func TestDecodeTextEmphasis(t *testing.T) {
+	segments := DecodeText([]byte{'A', emphasisOn, 'B', emphasisOn, 'C', emphasisOff, 'D'})
+
+	expected := []TextSegment{{"A", false}, {"BC", true}, {"D", false}}
+	if len(segments) != len(expected) {
+		t.Fatalf("segments = %+v, want %+v", segments, expected)
+	}
+	for i, segment := range expected {
+		if segments[i] != segment {
+			t.Errorf("%d: %+v, want %+v", i, segments[i], segment)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvb
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser/si"
+)
+
+func parseUTCTime(payload []byte) time.Time {
+	return si.ParseTime(payload, time.UTC)
+}
+
+func encodeUTCTime(t time.Time) []byte {
+	return si.EncodeTime(t, time.UTC)
+}
@@ -0,0 +1,364 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var defaultCLUT = DefaultCLUT()
+
+// Page is a display set rendered onto the display.
+type Page struct {
+	PTS     uint64
+	Timeout time.Duration
+	Display image.Rectangle
+
+	// Image is the visible part of the page placed on the display, which
+	// is nil if the page shows nothing.
+	Image *image.NRGBA
+}
+
+type pageRegion struct {
+	id uint8
+	x  int
+	y  int
+}
+
+// Decoder decodes the subtitling segments of a composition page and its
+// ancillary page into pages.
+type Decoder struct {
+	compositionPageId uint16
+	ancillaryPageId   uint16
+
+	display image.Rectangle
+	window  image.Point
+	regions map[uint8]*region
+	cluts   map[uint8]*CLUT
+
+	pending     bool
+	pts         uint64
+	timeout     time.Duration
+	pageRegions []pageRegion
+}
+
+func NewDecoder(compositionPageId, ancillaryPageId uint16) *Decoder {
+	d := &Decoder{
+		compositionPageId: compositionPageId,
+		ancillaryPageId:   ancillaryPageId,
+		display:           image.Rect(0, 0, 720, 576),
+	}
+	d.reset()
+
+	return d
+}
+
+// reset discards the regions and CLUTs at the start of an epoch.
+func (d *Decoder) reset() {
+	d.regions = make(map[uint8]*region)
+	d.cluts = make(map[uint8]*CLUT)
+}
+
+// Decode decodes PES_data_field of a PES packet with its PTS, and returns
+// the pages completed by it.
+func (d *Decoder) Decode(pts uint64, payload []byte) ([]*Page, error) {
+	segments, err := ParseSegments(payload)
+
+	var pages []*Page
+	for _, segment := range segments {
+		if segment.PageId != d.compositionPageId && segment.PageId != d.ancillaryPageId {
+			continue
+		}
+
+		switch segment.Type {
+		case PageCompositionSegment:
+			if segment.PageId != d.compositionPageId {
+				break
+			}
+			// Some encoders omit end_of_display_set_segment.
+			if d.pending {
+				pages = append(pages, d.render())
+			}
+			d.decodePageComposition(pts, segment.Data)
+		case RegionCompositionSegment:
+			d.decodeRegionComposition(segment.Data)
+		case CLUTDefinitionSegment:
+			d.decodeCLUTDefinition(segment.Data)
+		case ObjectDataSegment:
+			d.decodeObjectData(segment.Data)
+		case DisplayDefinitionSegment:
+			d.decodeDisplayDefinition(segment.Data)
+		case EndOfDisplaySetSegment:
+			if d.pending {
+				pages = append(pages, d.render())
+			}
+		}
+	}
+
+	return pages, err
+}
+
+// Flush returns the page in progress.
+func (d *Decoder) Flush() *Page {
+	if !d.pending {
+		return nil
+	}
+
+	return d.render()
+}
+
+func (d *Decoder) decodePageComposition(pts uint64, data []byte) {
+	r := bitstream.NewReader(data)
+	timeout := time.Duration(r.Bits(8)) * time.Second
+	r.Skip(4)
+	state := r.Bits(2)
+	r.Skip(2)
+	if r.Err() != nil {
+		return
+	}
+
+	if state == PageStateAcquisitionPoint || state == PageStateModeChange {
+		d.reset()
+	}
+
+	var regions []pageRegion
+	for r.Left() >= 48 {
+		id := r.Uint8(8)
+		r.Skip(8)
+		x := int(r.Bits(16))
+		y := int(r.Bits(16))
+		regions = append(regions, pageRegion{id, x, y})
+	}
+
+	d.pending, d.pts, d.timeout, d.pageRegions = true, pts, timeout, regions
+}
+
+func (d *Decoder) decodeRegionComposition(data []byte) {
+	r := bitstream.NewReader(data)
+	id := r.Uint8(8)
+	version := int(r.Bits(4))
+	fill := r.Bit()
+	r.Skip(3)
+	width := int(r.Bits(16))
+	height := int(r.Bits(16))
+	r.Skip(3)
+	depth := 1 << r.Bits(3)
+	r.Skip(2)
+	clutId := r.Uint8(8)
+	code8 := r.Uint8(8)
+	code4 := r.Uint8(4)
+	code2 := r.Uint8(2)
+	r.Skip(2)
+	if r.Err() != nil || depth < 2 || depth > 8 {
+		return
+	}
+
+	rg, ok := d.regions[id]
+	if !ok || rg.width != width || rg.height != height || rg.depth != depth {
+		rg = &region{
+			width:  width,
+			height: height,
+			depth:  depth,
+			pixels: make([]uint8, width*height),
+		}
+		d.regions[id] = rg
+		fill = true
+	} else if rg.version == version {
+		return
+	}
+	rg.version, rg.clutId = version, clutId
+
+	if fill {
+		switch depth {
+		case 2:
+			rg.fill(code2)
+		case 4:
+			rg.fill(code4)
+		default:
+			rg.fill(code8)
+		}
+	}
+
+	rg.objects = rg.objects[:0]
+	for r.Left() >= 48 {
+		objectId := r.Uint16(16)
+		objectType := r.Bits(2)
+		r.Skip(2)
+		x := int(r.Bits(12))
+		r.Skip(4)
+		y := int(r.Bits(12))
+		if objectType == 0x01 || objectType == 0x02 {
+			r.Skip(16)
+		}
+		if r.Err() != nil {
+			break
+		}
+		rg.objects = append(rg.objects, regionObject{objectId, x, y})
+	}
+}
+
+func (d *Decoder) decodeCLUTDefinition(data []byte) {
+	r := bitstream.NewReader(data)
+	id := r.Uint8(8)
+	version := int(r.Bits(4))
+	r.Skip(4)
+	if r.Err() != nil {
+		return
+	}
+
+	clut, ok := d.cluts[id]
+	if !ok {
+		clut = DefaultCLUT()
+		d.cluts[id] = clut
+	} else if clut.version == version {
+		return
+	}
+	clut.version = version
+
+	for r.Left() >= 32 {
+		entry := r.Uint8(8)
+		flags := r.Uint8(3)
+		r.Skip(4)
+
+		var c color.NRGBA
+		if r.Bit() {
+			c = ycrcbt(r.Uint8(8), r.Uint8(8), r.Uint8(8), r.Uint8(8))
+		} else {
+			c = ycrcbt(r.Uint8(6)<<2, r.Uint8(4)<<4, r.Uint8(4)<<4, r.Uint8(2)<<6)
+		}
+		if r.Err() != nil {
+			break
+		}
+
+		if flags&0x04 > 0 && entry < 4 {
+			clut.Entries2[entry] = c
+		}
+		if flags&0x02 > 0 && entry < 16 {
+			clut.Entries4[entry] = c
+		}
+		if flags&0x01 > 0 {
+			clut.Entries8[entry] = c
+		}
+	}
+}
+
+func (d *Decoder) decodeObjectData(data []byte) {
+	r := bitstream.NewReader(data)
+	id := r.Uint16(16)
+	r.Skip(4)
+	method := r.Bits(2)
+	nonModifying := r.Bit()
+	r.Skip(1)
+	// Objects coded as strings of characters are not supported.
+	if r.Err() != nil || method != 0 {
+		return
+	}
+
+	topLength := int(r.Bits(16))
+	bottomLength := int(r.Bits(16))
+	if r.Err() != nil || 7+topLength+bottomLength > len(data) {
+		return
+	}
+	top := data[7 : 7+topLength]
+	bottom := data[7+topLength : 7+topLength+bottomLength]
+	if bottomLength == 0 {
+		bottom = top
+	}
+
+	for _, rg := range d.regions {
+		for _, object := range rg.objects {
+			if object.id == id {
+				rg.drawField(top, object.x, object.y, nonModifying)
+				rg.drawField(bottom, object.x, object.y+1, nonModifying)
+			}
+		}
+	}
+}
+
+func (d *Decoder) decodeDisplayDefinition(data []byte) {
+	r := bitstream.NewReader(data)
+	r.Skip(4)
+	window := r.Bit()
+	r.Skip(3)
+	width := int(r.Bits(16)) + 1
+	height := int(r.Bits(16)) + 1
+	var x, y int
+	if window {
+		x = int(r.Bits(16))
+		r.Skip(16)
+		y = int(r.Bits(16))
+		r.Skip(16)
+	}
+	if r.Err() != nil {
+		return
+	}
+
+	d.display = image.Rect(0, 0, width, height)
+	d.window = image.Pt(x, y)
+}
+
+func (d *Decoder) render() *Page {
+	d.pending = false
+	page := &Page{
+		PTS:     d.pts,
+		Timeout: d.timeout,
+		Display: d.display,
+	}
+
+	var bounds image.Rectangle
+	for _, pr := range d.pageRegions {
+		if rg, ok := d.regions[pr.id]; ok {
+			bounds = bounds.Union(image.Rect(0, 0, rg.width, rg.height).Add(d.window).Add(image.Pt(pr.x, pr.y)))
+		}
+	}
+	if bounds = bounds.Intersect(d.display); bounds.Empty() {
+		return page
+	}
+
+	img := image.NewNRGBA(bounds)
+	visible := image.Rectangle{}
+	for _, pr := range d.pageRegions {
+		rg, ok := d.regions[pr.id]
+		if !ok {
+			continue
+		}
+
+		clut, ok := d.cluts[rg.clutId]
+		if !ok {
+			clut = defaultCLUT
+		}
+
+		origin := d.window.Add(image.Pt(pr.x, pr.y))
+		for y := 0; y < rg.height; y++ {
+			for x := 0; x < rg.width; x++ {
+				var c color.NRGBA
+				switch code := rg.pixels[y*rg.width+x]; rg.depth {
+				case 2:
+					c = clut.Entries2[code&0x03]
+				case 4:
+					c = clut.Entries4[code&0x0f]
+				default:
+					c = clut.Entries8[code]
+				}
+
+				p := origin.Add(image.Pt(x, y))
+				if c.A == 0 || !p.In(bounds) {
+					continue
+				}
+				img.SetNRGBA(p.X, p.Y, c)
+				visible = visible.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+			}
+		}
+	}
+
+	if !visible.Empty() {
+		page.Image = img.SubImage(visible).(*image.NRGBA)
+	}
+	return page
+}
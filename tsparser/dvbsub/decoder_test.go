@@ -0,0 +1,122 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func segment(t SegmentType, pageId uint16, data ...byte) []byte {
+	return append([]byte{segmentSyncByte, byte(t), byte(pageId >> 8), byte(pageId), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+func displaySet(segments ...[]byte) []byte {
+	payload := []byte{subtitleDataIdentifier, subtitleStreamId}
+	for _, s := range segments {
+		payload = append(payload, s...)
+	}
+	return append(payload, endOfPESDataMarker)
+}
+
+// testDisplaySet shows a 2-bit region of 4x2 at (100, 50) with the default
+// CLUT.  The top line is white and black, and the bottom line is grey.
+var testDisplaySet = displaySet(
+	segment(PageCompositionSegment, 1,
+		0x05, 0x04,
+		0x00, 0x00, 0x00, 0x64, 0x00, 0x32),
+	segment(RegionCompositionSegment, 1,
+		0x00, 0x08, 0x00, 0x04, 0x00, 0x02, 0x24, 0x00, 0x00, 0x00,
+		0x00, 0x01, 0x00, 0x00, 0x00, 0x00),
+	segment(ObjectDataSegment, 1,
+		0x00, 0x01, 0x00, 0x00, 0x04, 0x00, 0x04,
+		twoBitPixelCodeString, 0x5a, 0x00, endOfObjectLineCode,
+		twoBitPixelCodeString, 0xff, 0x00, endOfObjectLineCode),
+	segment(EndOfDisplaySetSegment, 1),
+)
+
+// clearDisplaySet shows a page without regions.
+var clearDisplaySet = displaySet(
+	segment(PageCompositionSegment, 1, 0x05, 0x10),
+	segment(EndOfDisplaySetSegment, 1),
+)
+
+func TestParseSegments(t *testing.T) {
+	segments, err := ParseSegments(testDisplaySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := []SegmentType{PageCompositionSegment, RegionCompositionSegment, ObjectDataSegment, EndOfDisplaySetSegment}
+	if len(segments) != len(types) {
+		t.Fatalf("%d segments, want %d", len(segments), len(types))
+	}
+	for i, s := range segments {
+		if s.Type != types[i] || s.PageId != 1 {
+			t.Errorf("%d: segment = %+v", i, s)
+		}
+	}
+
+	invalid := [][]byte{
+		{0x21, 0x00, endOfPESDataMarker},
+		testDisplaySet[:len(testDisplaySet)-3],
+		append(testDisplaySet[:len(testDisplaySet)-1:len(testDisplaySet)-1], 0x00),
+	}
+	for i, payload := range invalid {
+		if _, err := ParseSegments(payload); err != ErrInvalidSegment {
+			t.Errorf("%d: err = %v, want %v", i, err, ErrInvalidSegment)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(1, 1)
+	pages, err := d.Decode(90000, testDisplaySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("%d pages, want 1", len(pages))
+	}
+
+	page := pages[0]
+	if page.PTS != 90000 || page.Timeout != 5*time.Second || page.Display != image.Rect(0, 0, 720, 576) {
+		t.Errorf("page = %+v", page)
+	}
+	if page.Image == nil {
+		t.Fatal("page shows nothing")
+	}
+	if bounds := page.Image.Bounds(); bounds != image.Rect(100, 50, 104, 52) {
+		t.Errorf("bounds = %v", bounds)
+	}
+
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+	grey := color.NRGBA{127, 127, 127, 255}
+	expected := [2][4]color.NRGBA{
+		{white, white, black, black},
+		{grey, grey, grey, grey},
+	}
+	for y, line := range expected {
+		for x, c := range line {
+			if got := page.Image.NRGBAAt(100+x, 50+y); got != c {
+				t.Errorf("(%d, %d) = %v, want %v", x, y, got, c)
+			}
+		}
+	}
+
+	pages, err = d.Decode(180000, clearDisplaySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].Image != nil {
+		t.Errorf("pages = %+v", pages)
+	}
+	if page := d.Flush(); page != nil {
+		t.Errorf("flushed page = %+v", page)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dvb"
+)
+
+// Subtitle is an image shown from the PTS Start until End.
+type Subtitle struct {
+	Start   uint64
+	End     uint64
+	Display image.Rectangle
+	Image   *image.NRGBA
+}
+
+// Extractor decodes a subtitle stream and times the pages.  A page is
+// shown until the next page or its page_time_out.
+type Extractor struct {
+	s       *tsparser.PESScanner
+	pid     tsparser.PID
+	logger  *log.Logger
+	decoder *Decoder
+	handler func(*Subtitle) error
+
+	current *Subtitle
+	timeout time.Duration
+	lastPTS uint64
+}
+
+func NewExtractor(s *tsparser.PESScanner, pid tsparser.PID, subtitling dvb.Subtitling, logger *log.Logger) *Extractor {
+	return &Extractor{
+		s:       s,
+		pid:     pid,
+		logger:  logger,
+		decoder: NewDecoder(subtitling.CompositionPageId, subtitling.AncillaryPageId),
+	}
+}
+
+func (e *Extractor) log(v ...interface{}) {
+	if e.logger != nil {
+		e.logger.Print(v...)
+	}
+}
+
+// SetHandler sets the function which is called with every subtitle when
+// it is cleared from the display.
+func (e *Extractor) SetHandler(fn func(*Subtitle) error) {
+	e.handler = fn
+}
+
+func (e *Extractor) Run() error {
+	for e.s.Scan() {
+		if e.s.PID() != e.pid {
+			continue
+		}
+
+		pes := e.s.PES()
+		if !pes.HasPTS() {
+			continue
+		}
+		e.lastPTS = pes.PTS()
+
+		pages, err := e.decoder.Decode(pes.PTS(), pes.Payload())
+		if err != nil {
+			e.log(err)
+		}
+		for _, page := range pages {
+			if err := e.show(page); err != nil {
+				return err
+			}
+		}
+	}
+
+	if page := e.decoder.Flush(); page != nil {
+		if err := e.show(page); err != nil {
+			return err
+		}
+	}
+	return e.close(e.lastPTS)
+}
+
+func (e *Extractor) show(page *Page) error {
+	if err := e.close(page.PTS); err != nil {
+		return err
+	}
+
+	if page.Image != nil {
+		e.current = &Subtitle{
+			Start:   page.PTS,
+			Display: page.Display,
+			Image:   page.Image,
+		}
+		e.timeout = page.Timeout
+	}
+	return nil
+}
+
+func (e *Extractor) close(pts uint64) error {
+	s := e.current
+	if s == nil {
+		return nil
+	}
+	e.current = nil
+
+	s.End = pts
+	if e.timeout > 0 {
+		deadline := (s.Start + uint64(e.timeout*tsparser.ClockRate/time.Second)) & tsparser.TimestampMask
+		if tsparser.TimestampDiff(deadline, pts) < 0 || tsparser.TimestampDiff(pts, s.Start) <= 0 {
+			s.End = deadline
+		}
+	}
+
+	if e.handler == nil || tsparser.TimestampDiff(s.End, s.Start) <= 0 {
+		return nil
+	}
+	return e.handler(s)
+}
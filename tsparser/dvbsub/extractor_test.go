@@ -0,0 +1,53 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/dvb"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+func extract(t *testing.T, units ...[]byte) []*Subtitle {
+	data := fixture.Packetize(0x0130, units...)
+	s := tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(data), nil), nil)
+	e := NewExtractor(s, 0x0130, dvb.Subtitling{CompositionPageId: 1, AncillaryPageId: 1}, nil)
+
+	var subtitles []*Subtitle
+	e.SetHandler(func(s *Subtitle) error {
+		subtitles = append(subtitles, s)
+		return nil
+	})
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return subtitles
+}
+
+func TestExtractor(t *testing.T) {
+	subtitles := extract(t,
+		fixture.PES(tsparser.PrivateStream1, 90000, testDisplaySet),
+		fixture.PES(tsparser.PrivateStream1, 180000, clearDisplaySet))
+	if len(subtitles) != 1 {
+		t.Fatalf("%d subtitles, want 1", len(subtitles))
+	}
+	if s := subtitles[0]; s.Start != 90000 || s.End != 180000 || s.Image == nil {
+		t.Errorf("subtitle = %+v", s)
+	}
+}
+
+func TestExtractorTimeout(t *testing.T) {
+	// The last page is shown until its page_time_out.
+	subtitles := extract(t, fixture.PES(tsparser.PrivateStream1, 90000, testDisplaySet))
+	if len(subtitles) != 1 {
+		t.Fatalf("%d subtitles, want 1", len(subtitles))
+	}
+	if s := subtitles[0]; s.Start != 90000 || s.End != 90000+5*tsparser.ClockRate {
+		t.Errorf("subtitle = %+v", s)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+const (
+	twoBitPixelCodeString   = 0x10
+	fourBitPixelCodeString  = 0x11
+	eightBitPixelCodeString = 0x12
+	twoToFourBitMapTable    = 0x20
+	twoToEightBitMapTable   = 0x21
+	fourToEightBitMapTable  = 0x22
+	endOfObjectLineCode     = 0xF0
+)
+
+var (
+	defaultMap24 = []uint8{0x0, 0x7, 0x8, 0xf}
+	defaultMap28 = []uint8{0x00, 0x77, 0x88, 0xff}
+	defaultMap48 = []uint8{
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+	}
+)
+
+type region struct {
+	version int
+	width   int
+	height  int
+	depth   int
+	clutId  uint8
+	pixels  []uint8
+	objects []regionObject
+}
+
+type regionObject struct {
+	id uint16
+	x  int
+	y  int
+}
+
+func (r *region) fill(code uint8) {
+	for i := range r.pixels {
+		r.pixels[i] = code
+	}
+}
+
+// fieldWriter draws the pixels of a field of an object into a region.
+type fieldWriter struct {
+	r            *region
+	x0           int
+	x            int
+	y            int
+	nonModifying bool
+
+	table []uint8
+	draw  bool
+}
+
+func (w *fieldWriter) put(n int, code uint8) {
+	if !w.draw || w.nonModifying && code == 1 {
+		w.x += n
+		return
+	}
+
+	if w.table != nil {
+		code = w.table[code]
+	}
+	if 0 <= w.y && w.y < w.r.height {
+		for i := 0; i < n; i++ {
+			if x := w.x + i; 0 <= x && x < w.r.width {
+				w.r.pixels[w.y*w.r.width+x] = code
+			}
+		}
+	}
+	w.x += n
+}
+
+// drawField decodes pixel-data_sub-blocks of a field, whose lines are
+// drawn on every other line of the region from (x, y).
+func (r *region) drawField(data []byte, x, y int, nonModifying bool) {
+	w := &fieldWriter{r: r, x0: x, x: x, y: y, nonModifying: nonModifying}
+	map24 := defaultMap24
+	map28 := defaultMap28
+	map48 := defaultMap48
+
+	for i := 0; i < len(data); {
+		dataType := data[i]
+		i++
+
+		switch dataType {
+		case twoBitPixelCodeString, fourBitPixelCodeString, eightBitPixelCodeString:
+			w.table, w.draw = nil, true
+			switch {
+			case dataType == twoBitPixelCodeString && r.depth == 4:
+				w.table = map24
+			case dataType == twoBitPixelCodeString && r.depth == 8:
+				w.table = map28
+			case dataType == fourBitPixelCodeString && r.depth == 8:
+				w.table = map48
+			case dataType == fourBitPixelCodeString && r.depth < 4, dataType == eightBitPixelCodeString && r.depth < 8:
+				w.draw = false
+			}
+
+			br := bitstream.NewReader(data[i:])
+			switch dataType {
+			case twoBitPixelCodeString:
+				w.twoBitString(br)
+			case fourBitPixelCodeString:
+				w.fourBitString(br)
+			default:
+				w.eightBitString(br)
+			}
+			i += (br.Pos() + 7) / 8
+		case twoToFourBitMapTable:
+			if i+2 > len(data) {
+				return
+			}
+			map24 = []uint8{data[i] >> 4, data[i] & 0x0f, data[i+1] >> 4, data[i+1] & 0x0f}
+			i += 2
+		case twoToEightBitMapTable:
+			if i+4 > len(data) {
+				return
+			}
+			map28 = data[i : i+4]
+			i += 4
+		case fourToEightBitMapTable:
+			if i+16 > len(data) {
+				return
+			}
+			map48 = data[i : i+16]
+			i += 16
+		case endOfObjectLineCode:
+			w.x = w.x0
+			w.y += 2
+		default:
+			return
+		}
+	}
+}
+
+func (w *fieldWriter) twoBitString(r *bitstream.Reader) {
+	for r.Err() == nil {
+		if code := r.Uint8(2); code != 0 {
+			w.put(1, code)
+		} else if r.Bit() {
+			n := int(r.Bits(3)) + 3
+			w.put(n, r.Uint8(2))
+		} else if r.Bit() {
+			w.put(1, 0)
+		} else {
+			switch r.Bits(2) {
+			case 0:
+				return
+			case 1:
+				w.put(2, 0)
+			case 2:
+				n := int(r.Bits(4)) + 12
+				w.put(n, r.Uint8(2))
+			case 3:
+				n := int(r.Bits(8)) + 29
+				w.put(n, r.Uint8(2))
+			}
+		}
+	}
+}
+
+func (w *fieldWriter) fourBitString(r *bitstream.Reader) {
+	for r.Err() == nil {
+		if code := r.Uint8(4); code != 0 {
+			w.put(1, code)
+		} else if !r.Bit() {
+			n := int(r.Bits(3))
+			if n == 0 {
+				return
+			}
+			w.put(n+2, 0)
+		} else if !r.Bit() {
+			n := int(r.Bits(2)) + 4
+			w.put(n, r.Uint8(4))
+		} else {
+			switch r.Bits(2) {
+			case 0:
+				w.put(1, 0)
+			case 1:
+				w.put(2, 0)
+			case 2:
+				n := int(r.Bits(4)) + 9
+				w.put(n, r.Uint8(4))
+			case 3:
+				n := int(r.Bits(8)) + 25
+				w.put(n, r.Uint8(4))
+			}
+		}
+	}
+}
+
+func (w *fieldWriter) eightBitString(r *bitstream.Reader) {
+	for r.Err() == nil {
+		if code := r.Uint8(8); code != 0 {
+			w.put(1, code)
+		} else if !r.Bit() {
+			n := int(r.Bits(7))
+			if n == 0 {
+				return
+			}
+			w.put(n, 0)
+		} else {
+			n := int(r.Bits(7))
+			w.put(n, r.Uint8(8))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package dvbsub
+
+import (
+	"errors"
+	"image/color"
+)
+
+var ErrInvalidSegment = errors.New("Invalid subtitling segment")
+
+const (
+	subtitleDataIdentifier = 0x20
+	subtitleStreamId       = 0x00
+	segmentSyncByte        = 0x0F
+	endOfPESDataMarker     = 0xFF
+)
+
+type SegmentType uint8
+
+const (
+	PageCompositionSegment   SegmentType = 0x10
+	RegionCompositionSegment SegmentType = 0x11
+	CLUTDefinitionSegment    SegmentType = 0x12
+	ObjectDataSegment        SegmentType = 0x13
+	DisplayDefinitionSegment SegmentType = 0x14
+	EndOfDisplaySetSegment   SegmentType = 0x80
+)
+
+const (
+	PageStateNormalCase       = 0
+	PageStateAcquisitionPoint = 1
+	PageStateModeChange       = 2
+)
+
+type Segment struct {
+	Type   SegmentType
+	PageId uint16
+	Data   []byte
+}
+
+// ParseSegments splits PES_data_field of a subtitle stream into the
+// subtitling segments.
+func ParseSegments(payload []byte) ([]Segment, error) {
+	if len(payload) < 2 || payload[0] != subtitleDataIdentifier || payload[1] != subtitleStreamId {
+		return nil, ErrInvalidSegment
+	}
+
+	var segments []Segment
+	i := 2
+	for i < len(payload) && payload[i] == segmentSyncByte {
+		if i+6 > len(payload) {
+			return segments, ErrInvalidSegment
+		}
+
+		end := i + 6 + (int(payload[i+4])<<8 | int(payload[i+5]))
+		if end > len(payload) {
+			return segments, ErrInvalidSegment
+		}
+		segments = append(segments, Segment{
+			Type:   SegmentType(payload[i+1]),
+			PageId: uint16(payload[i+2])<<8 | uint16(payload[i+3]),
+			Data:   payload[i+6 : end],
+		})
+		i = end
+	}
+
+	if i < len(payload) && payload[i] != endOfPESDataMarker {
+		return segments, ErrInvalidSegment
+	}
+	return segments, nil
+}
+
+// CLUT holds the colours of the 2, 4 and 8-bit entries.
+type CLUT struct {
+	version  int
+	Entries2 [4]color.NRGBA
+	Entries4 [16]color.NRGBA
+	Entries8 [256]color.NRGBA
+}
+
+// DefaultCLUT returns the default CLUT defined in EN 300 743.
+func DefaultCLUT() *CLUT {
+	c := &CLUT{version: -1}
+	c.Entries2 = [4]color.NRGBA{{0, 0, 0, 0}, {255, 255, 255, 255}, {0, 0, 0, 255}, {127, 127, 127, 255}}
+
+	for i := 1; i < 16; i++ {
+		v := uint8(255)
+		if i >= 8 {
+			v = 127
+		}
+		c.Entries4[i] = color.NRGBA{bit(i, 0x01, v), bit(i, 0x02, v), bit(i, 0x04, v), 255}
+	}
+
+	for i := 1; i < 256; i++ {
+		if i < 8 {
+			c.Entries8[i] = color.NRGBA{bit(i, 0x01, 255), bit(i, 0x02, 255), bit(i, 0x04, 255), 63}
+			continue
+		}
+
+		switch i & 0x88 {
+		case 0x00, 0x08:
+			alpha := uint8(255)
+			if i&0x88 == 0x08 {
+				alpha = 127
+			}
+			c.Entries8[i] = color.NRGBA{
+				bit(i, 0x01, 85) + bit(i, 0x10, 170),
+				bit(i, 0x02, 85) + bit(i, 0x20, 170),
+				bit(i, 0x04, 85) + bit(i, 0x40, 170),
+				alpha,
+			}
+		case 0x80:
+			c.Entries8[i] = color.NRGBA{
+				127 + bit(i, 0x01, 43) + bit(i, 0x10, 85),
+				127 + bit(i, 0x02, 43) + bit(i, 0x20, 85),
+				127 + bit(i, 0x04, 43) + bit(i, 0x40, 85),
+				255,
+			}
+		case 0x88:
+			c.Entries8[i] = color.NRGBA{
+				bit(i, 0x01, 43) + bit(i, 0x10, 85),
+				bit(i, 0x02, 43) + bit(i, 0x20, 85),
+				bit(i, 0x04, 43) + bit(i, 0x40, 85),
+				255,
+			}
+		}
+	}
+
+	return c
+}
+
+func bit(i, mask int, v uint8) uint8 {
+	if i&mask > 0 {
+		return v
+	}
+
+	return 0
+}
+
+func clamp(v int) uint8 {
+	if v < 0 {
+		return 0
+	} else if v > 255 {
+		return 255
+	}
+
+	return uint8(v)
+}
+
+// ycrcbt converts a CLUT entry of ITU-R BT.601 into a colour.  Y of zero
+// means full transparency.
+func ycrcbt(y, cr, cb, t uint8) color.NRGBA {
+	if y == 0 {
+		return color.NRGBA{}
+	}
+
+	c := 298 * (int(y) - 16)
+	d := int(cb) - 128
+	e := int(cr) - 128
+	return color.NRGBA{
+		R: clamp((c + 409*e + 128) >> 8),
+		G: clamp((c - 100*d - 208*e + 128) >> 8),
+		B: clamp((c + 516*d + 128) >> 8),
+		A: 255 - t,
+	}
+}
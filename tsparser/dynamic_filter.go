@@ -0,0 +1,237 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"fmt"
+	"log"
+)
+
+type StreamSelector func(programNumber uint16, es *ElementaryStream) bool
+
+func SelectAll() StreamSelector {
+	return func(uint16, *ElementaryStream) bool {
+		return true
+	}
+}
+
+func SelectProgram(programNumber uint16) StreamSelector {
+	return func(number uint16, es *ElementaryStream) bool {
+		return number == programNumber
+	}
+}
+
+func SelectStreamTypes(types ...StreamType) StreamSelector {
+	return func(number uint16, es *ElementaryStream) bool {
+		for _, t := range types {
+			if es.StreamType() == t {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func SelectAudioVideo() StreamSelector {
+	return func(number uint16, es *ElementaryStream) bool {
+		return es.StreamType().IsAudio() || es.StreamType().IsVideo()
+	}
+}
+
+func (s StreamSelector) And(other StreamSelector) StreamSelector {
+	return func(number uint16, es *ElementaryStream) bool {
+		return s(number, es) && other(number, es)
+	}
+}
+
+func (s StreamSelector) Or(other StreamSelector) StreamSelector {
+	return func(number uint16, es *ElementaryStream) bool {
+		return s(number, es) || other(number, es)
+	}
+}
+
+type DynamicPacketFilter struct {
+	PacketFilter
+
+	selector  StreamSelector
+	static    PIDSlice
+	logger    *log.Logger
+	collector *TableCollector
+	programs  map[PID]uint16
+	pmts      map[uint16]*ProgramMapSection
+}
+
+func NewDynamicPacketFilter(s PacketStream, selector StreamSelector, logger *log.Logger, pids ...PID) *DynamicPacketFilter {
+	f := &DynamicPacketFilter{
+		PacketFilter: PacketFilter{s: s},
+		selector:     selector,
+		static:       append(PIDSlice(nil), pids...),
+		logger:       logger,
+		collector:    NewTableCollector(),
+		programs:     make(map[PID]uint16),
+		pmts:         make(map[uint16]*ProgramMapSection),
+	}
+	f.update()
+
+	return f
+}
+
+func (f *DynamicPacketFilter) log(p Packet, v ...interface{}) {
+	if f.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	f.logger.Print(values...)
+}
+
+func (f *DynamicPacketFilter) SetSelector(selector StreamSelector) {
+	f.selector = selector
+	f.update()
+}
+
+// SetPIDs sets the PIDs passed in addition to the ones selected from the
+// PMTs.
+func (f *DynamicPacketFilter) SetPIDs(pids ...PID) {
+	f.static = append(PIDSlice(nil), pids...)
+	f.update()
+}
+
+func (f *DynamicPacketFilter) AddPID(pid PID) {
+	for _, p := range f.static {
+		if p == pid {
+			return
+		}
+	}
+
+	f.static = append(f.static, pid)
+	f.update()
+}
+
+func (f *DynamicPacketFilter) RemovePID(pid PID) {
+	for i, p := range f.static {
+		if p == pid {
+			f.static = append(f.static[:i], f.static[i+1:]...)
+			f.update()
+			return
+		}
+	}
+}
+
+func (f *DynamicPacketFilter) ProgramMaps() map[uint16]*ProgramMapSection {
+	return f.pmts
+}
+
+func (f *DynamicPacketFilter) Scan() bool {
+	for f.s.Scan() {
+		packet := f.s.Packet()
+		if _, ok := f.programs[packet.PID()]; ok || packet.PID() == ProgramAssociationPID {
+			f.handleTables(packet)
+		}
+
+		if f.isTarget(packet.PID()) {
+			f.next = packet
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *DynamicPacketFilter) handleTables(packet Packet) {
+	tables, err := f.collector.Feed(packet)
+	if err != nil {
+		f.log(packet, err)
+	}
+
+	changed := false
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			f.log(packet, "CRC mismatch")
+			continue
+		} else if !table.CurrentNextIndicator() {
+			continue
+		}
+
+		switch table.TableId() {
+		case ProgramAssociationTable:
+			changed = f.handleProgramAssociation(ParseProgramAssociationSection(table)) || changed
+		case ProgramMapTable:
+			pmt := ParseProgramMapSection(table)
+			if old, ok := f.pmts[pmt.ProgramNumber()]; !ok || old.VersionNumber() != pmt.VersionNumber() {
+				f.pmts[pmt.ProgramNumber()] = pmt
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		f.update()
+	}
+}
+
+func (f *DynamicPacketFilter) handleProgramAssociation(pat *ProgramAssociationSection) bool {
+	programs := make(map[PID]uint16)
+	for number, pid := range pat.ProgramMap() {
+		programs[pid] = number
+	}
+
+	changed := len(programs) != len(f.programs)
+	for pid, number := range programs {
+		if old, ok := f.programs[pid]; !ok || old != number {
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+
+	for pid := range f.programs {
+		if _, ok := programs[pid]; !ok {
+			f.collector.Reset(pid)
+		}
+	}
+	for number := range f.pmts {
+		if _, ok := pat.ProgramMap()[number]; !ok {
+			delete(f.pmts, number)
+		}
+	}
+
+	f.programs = programs
+	return true
+}
+
+func (f *DynamicPacketFilter) update() {
+	pids := make(PIDSlice, 0, len(f.static)+1)
+	pids = append(pids, f.static...)
+	pids = append(pids, ProgramAssociationPID)
+
+	for pid, number := range f.programs {
+		pmt, ok := f.pmts[number]
+		if !ok {
+			continue
+		}
+
+		selected := false
+		for _, es := range pmt.Streams() {
+			if f.selector(number, es) {
+				pids = append(pids, es.PID())
+				selected = true
+			}
+		}
+
+		if selected {
+			pids = append(pids, pid)
+			if pmt.PCRPID() != NullPID {
+				pids = append(pids, pmt.PCRPID())
+			}
+		}
+	}
+
+	f.PacketFilter.SetPIDs(pids...)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDynamicPacketFilterVersionChange(t *testing.T) {
+	pat := NewProgramAssociationSection(0x7fe0)
+	pat.SetProgram(0x0001, 0x1000)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pmtTables []Table
+	for i, audioPID := range []PID{0x0110, 0x0111} {
+		pmt := NewProgramMapSection(0x0001, 0x0100)
+		pmt.SetVersionNumber(uint8(i))
+		pmt.AddStream(NewElementaryStream(H264VideoStream, 0x0100))
+		pmt.AddStream(NewElementaryStream(AACADTSStream, audioPID))
+		table, err := pmt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pmtTables = append(pmtTables, table)
+	}
+
+	// The version 1 is announced with current_next_indicator of 0 first.
+	nextPMT := append(Table(nil), pmtTables[1]...)
+	nextPMT[5] &^= 0x01
+	nextPMT.updateCRC()
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	w.WriteTables(ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTables[0])
+	w.WriteUnit(0x0100, nil, []byte{0x01, 0x00})
+	w.WriteTables(0x1000, nextPMT)
+	w.WriteUnit(0x0110, nil, []byte{0x01, 0x10})
+	w.WriteUnit(0x0111, nil, []byte{0x01, 0x11})
+	w.WriteTables(0x1000, pmtTables[1])
+	w.WriteUnit(0x0110, nil, []byte{0x01, 0x10})
+	w.WriteUnit(0x0111, nil, []byte{0x01, 0x11})
+
+	var packets []Packet
+	for i := 0; i < buf.Len(); i += PacketSize {
+		packets = append(packets, Packet(buf.Bytes()[i:i+PacketSize]))
+	}
+
+	f := NewDynamicPacketFilter(&packetSlice{packets: packets}, SelectAudioVideo(), nil)
+	var pids []PID
+	for f.Scan() {
+		pids = append(pids, f.Packet().PID())
+	}
+
+	expected := []PID{ProgramAssociationPID, 0x1000, 0x0100, 0x1000, 0x0110, 0x1000, 0x0111}
+	if len(pids) != len(expected) {
+		t.Fatalf("PIDs = %#x, want %#x", pids, expected)
+	}
+	for i, pid := range expected {
+		if pids[i] != pid {
+			t.Errorf("PIDs = %#x, want %#x", pids, expected)
+			break
+		}
+	}
+	if pmt := f.ProgramMaps()[0x0001]; pmt == nil || pmt.VersionNumber() != 1 {
+		t.Errorf("PMT = %v", pmt)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"fmt"
+	"log"
+)
+
+type ExtractOption uint8
+
+const (
+	ExtractECM ExtractOption = 1 << iota
+	ExtractSDT
+	ExtractEIT
+	ExtractTOT
+)
+
+const (
+	ProgramAssociationPID PID = 0x0000
+	ServiceDescriptionPID PID = 0x0011
+	EventInformationPID   PID = 0x0012
+	TimeDatePID           PID = 0x0014
+
+	conditionalAccessDescriptor DescriptorTag = 0x09
+
+	serviceDescriptionActualTable TableId = 0x42
+	eventInformationActualPFTable TableId = 0x4e
+	eventInformationActualSchMin  TableId = 0x50
+	eventInformationActualSchMax  TableId = 0x5f
+)
+
+type packetQueue []Packet
+
+func (q *packetQueue) Write(b []byte) (int, error) {
+	for i := 0; i+PacketSize <= len(b); i += PacketSize {
+		p := make(Packet, PacketSize)
+		copy(p, b[i:i+PacketSize])
+		*q = append(*q, p)
+	}
+
+	return len(b), nil
+}
+
+func (q *packetQueue) pop() Packet {
+	p := (*q)[0]
+	*q = (*q)[1:]
+	return p
+}
+
+type ServiceExtractor struct {
+	s         PacketStream
+	serviceId uint16
+	options   ExtractOption
+	logger    *log.Logger
+
+	collector *TableCollector
+	queue     packetQueue
+	writer    *PacketWriter
+	next      Packet
+
+	pmtPID PID
+	pmt    *ProgramMapSection
+	pids   map[PID]bool
+}
+
+func NewServiceExtractor(s PacketStream, serviceId uint16, options ExtractOption, logger *log.Logger) *ServiceExtractor {
+	e := &ServiceExtractor{
+		s:         s,
+		serviceId: serviceId,
+		options:   options,
+		logger:    logger,
+		collector: NewTableCollector(),
+		pids:      make(map[PID]bool),
+	}
+	e.writer = NewPacketWriter(&e.queue)
+
+	return e
+}
+
+func (e *ServiceExtractor) log(p Packet, v ...interface{}) {
+	if e.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	e.logger.Print(values...)
+}
+
+func (e *ServiceExtractor) ServiceId() uint16 {
+	return e.serviceId
+}
+
+func (e *ServiceExtractor) ProgramMapPID() PID {
+	return e.pmtPID
+}
+
+func (e *ServiceExtractor) ProgramMap() *ProgramMapSection {
+	return e.pmt
+}
+
+func (e *ServiceExtractor) Scan() bool {
+	for len(e.queue) == 0 {
+		if !e.s.Scan() {
+			return false
+		}
+
+		packet := e.s.Packet()
+		if e.process(packet) {
+			e.next = packet
+			return true
+		}
+	}
+
+	e.next = e.queue.pop()
+	return true
+}
+
+func (e *ServiceExtractor) Bytes() []byte {
+	return []byte(e.next)
+}
+
+func (e *ServiceExtractor) Packet() Packet {
+	return e.next
+}
+
+func (e *ServiceExtractor) process(packet Packet) (passThrough bool) {
+	pid := packet.PID()
+	switch {
+	case pid == ProgramAssociationPID:
+		e.rewriteTables(packet, e.rewriteProgramAssociation)
+	case pid == e.pmtPID && pid != 0:
+		e.rewriteTables(packet, e.rewriteProgramMap)
+	case pid == ServiceDescriptionPID && e.options&ExtractSDT > 0:
+		e.rewriteTables(packet, e.rewriteServiceDescription)
+	case pid == EventInformationPID && e.options&ExtractEIT > 0:
+		e.rewriteTables(packet, e.rewriteEventInformation)
+	case pid == TimeDatePID && e.options&ExtractTOT > 0:
+		return true
+	default:
+		return e.pids[pid]
+	}
+
+	return false
+}
+
+func (e *ServiceExtractor) rewriteTables(packet Packet, rewrite func(Table) Table) {
+	tables, err := e.collector.Feed(packet)
+	if err != nil {
+		e.log(packet, err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			e.log(packet, "CRC mismatch")
+			continue
+		}
+
+		if table = rewrite(table); table != nil {
+			if err := e.writer.WriteTables(packet.PID(), table); err != nil {
+				e.log(packet, err)
+			}
+		}
+	}
+}
+
+func (e *ServiceExtractor) rewriteProgramAssociation(table Table) Table {
+	if table.TableId() != ProgramAssociationTable || !table.CurrentNextIndicator() {
+		return nil
+	}
+
+	pat := ParseProgramAssociationSection(table)
+	pmtPID, ok := pat.ProgramMap()[e.serviceId]
+	if !ok {
+		return nil
+	}
+
+	if pmtPID != e.pmtPID {
+		e.collector.Reset(e.pmtPID)
+		e.pmtPID = pmtPID
+		e.pmt = nil
+		e.pids = make(map[PID]bool)
+	}
+
+	rewritten := NewProgramAssociationSection(pat.TransportStreamId())
+	rewritten.SetVersionNumber(pat.VersionNumber())
+	rewritten.SetProgram(e.serviceId, pmtPID)
+
+	result, err := rewritten.Marshal()
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+func (e *ServiceExtractor) rewriteProgramMap(table Table) Table {
+	if table.TableId() != ProgramMapTable || table.TableIdExtension() != e.serviceId {
+		return nil
+	} else if !table.CurrentNextIndicator() {
+		return nil
+	}
+
+	pmt := ParseProgramMapSection(table)
+	if e.pmt == nil || e.pmt.VersionNumber() != pmt.VersionNumber() {
+		e.pmt = pmt
+		e.updatePIDs()
+	}
+
+	return table
+}
+
+func (e *ServiceExtractor) updatePIDs() {
+	pids := make(map[PID]bool)
+	pids[e.pmt.PCRPID()] = true
+
+	descriptors := e.pmt.Descriptors()
+	for _, es := range e.pmt.Streams() {
+		pids[es.PID()] = true
+		descriptors = append(descriptors[:len(descriptors):len(descriptors)], es.Descriptors()...)
+	}
+
+	if e.options&ExtractECM > 0 {
+		for _, d := range descriptors {
+			if d.Tag() == conditionalAccessDescriptor && d.Length() >= 4 {
+				payload := d.Payload()
+				pids[PID(payload[2]&0x1f)<<8|PID(payload[3])] = true
+			}
+		}
+	}
+
+	delete(pids, NullPID)
+	e.pids = pids
+}
+
+func (e *ServiceExtractor) rewriteServiceDescription(table Table) Table {
+	if table.TableId() != serviceDescriptionActualTable {
+		return nil
+	}
+
+	data := table.Data()
+	if len(data) < 3 {
+		return nil
+	}
+
+	for i := 3; i+5 <= len(data); {
+		serviceId := uint16(data[i])<<8 | uint16(data[i+1])
+		length := 5 + (int(data[i+3]&0x0f)<<8 | int(data[i+4]))
+		if i+length > len(data) {
+			return nil
+		}
+
+		if serviceId == e.serviceId {
+			header := &TableHeader{
+				TableId:              table.TableId(),
+				PrivateIndicator:     table.PrivateIndicator(),
+				TableIdExtension:     table.TableIdExtension(),
+				VersionNumber:        table.VersionNumber(),
+				CurrentNextIndicator: table.CurrentNextIndicator(),
+			}
+
+			result, err := header.Build(append(data[0:3:3], data[i:i+length]...))
+			if err != nil {
+				return nil
+			}
+			return result
+		}
+		i += length
+	}
+
+	return nil
+}
+
+func (e *ServiceExtractor) rewriteEventInformation(table Table) Table {
+	id := table.TableId()
+	if id != eventInformationActualPFTable && (id < eventInformationActualSchMin || eventInformationActualSchMax < id) {
+		return nil
+	} else if table.TableIdExtension() != e.serviceId {
+		return nil
+	}
+
+	return table
+}
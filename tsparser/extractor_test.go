@@ -0,0 +1,155 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestServiceExtractor(t *testing.T) {
+	pat := NewProgramAssociationSection(0x7fe0)
+	pat.SetNetworkPID(0x0010)
+	pat.SetProgram(0x0400, 0x01f0)
+	pat.SetProgram(0x0408, 0x01f8)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pmtTables []Table
+	for i, number := range []uint16{0x0400, 0x0408} {
+		pid := PID(0x0100 + i*0x10)
+		pmt := NewProgramMapSection(number, pid)
+		pmt.AddStream(NewElementaryStream(H264VideoStream, pid))
+		pmt.AddStream(NewElementaryStream(0x0f, pid+1))
+		table, err := pmt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pmtTables = append(pmtTables, table)
+	}
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	w.WriteTables(ProgramAssociationPID, patTable)
+	w.WriteTables(0x01f0, pmtTables[0])
+	w.WriteTables(0x01f8, pmtTables[1])
+	for _, pid := range []PID{0x0100, 0x0101, 0x0110, 0x0111, 0x0100} {
+		w.WriteUnit(pid, nil, []byte{byte(pid >> 8), byte(pid)})
+	}
+
+	var packets []Packet
+	for i := 0; i < buf.Len(); i += PacketSize {
+		packets = append(packets, Packet(buf.Bytes()[i:i+PacketSize]))
+	}
+
+	e := NewServiceExtractor(&packetSlice{packets: packets}, 0x0400, 0, nil)
+	collector := NewTableCollector()
+	var pids []PID
+	var tables []Table
+	for e.Scan() {
+		packet := e.Packet()
+		pids = append(pids, packet.PID())
+		if packet.PID() == ProgramAssociationPID || packet.PID() == 0x01f0 {
+			collected, _ := collector.Feed(packet)
+			tables = append(tables, collected...)
+		}
+	}
+
+	expected := []PID{ProgramAssociationPID, 0x01f0, 0x0100, 0x0101, 0x0100}
+	if len(pids) != len(expected) {
+		t.Fatalf("PIDs = %#x, want %#x", pids, expected)
+	}
+	for i, pid := range expected {
+		if pids[i] != pid {
+			t.Errorf("PIDs = %#x, want %#x", pids, expected)
+			break
+		}
+	}
+
+	if len(tables) != 2 {
+		t.Fatalf("%d tables, want 2", len(tables))
+	}
+	programs := ParseProgramAssociationSection(tables[0]).ProgramMap()
+	if len(programs) != 1 || programs[0x0400] != 0x01f0 {
+		t.Errorf("programs = %v", programs)
+	}
+	if !bytes.Equal(tables[1], pmtTables[0]) {
+		t.Errorf("PMT = %x, want %x", tables[1], pmtTables[0])
+	}
+	if e.ProgramMapPID() != 0x01f0 || e.ProgramMap() == nil || e.ProgramMap().ProgramNumber() != 0x0400 {
+		t.Errorf("PMT PID = %#x, PMT = %v", e.ProgramMapPID(), e.ProgramMap())
+	}
+}
+
+func TestServiceExtractorNextSections(t *testing.T) {
+	var tables []Table
+	for i, pmtPID := range []PID{0x01f0, 0x01f8} {
+		pat := NewProgramAssociationSection(0x7fe0)
+		pat.SetVersionNumber(uint8(i))
+		pat.SetProgram(0x0400, pmtPID)
+		table, err := pat.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+	for i, pid := range []PID{0x0100, 0x0120} {
+		pmt := NewProgramMapSection(0x0400, pid)
+		pmt.SetVersionNumber(uint8(i))
+		pmt.AddStream(NewElementaryStream(H264VideoStream, pid))
+		table, err := pmt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+
+	// Sections not applicable yet have current_next_indicator of 0.
+	nextPAT := append(Table(nil), tables[1]...)
+	nextPMT := append(Table(nil), tables[3]...)
+	for _, table := range []Table{nextPAT, nextPMT} {
+		table[5] &^= 0x01
+		table.updateCRC()
+	}
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	w.WriteTables(ProgramAssociationPID, tables[0])
+	w.WriteTables(0x01f0, tables[2])
+	w.WriteTables(ProgramAssociationPID, nextPAT)
+	w.WriteTables(0x01f0, nextPMT)
+	w.WriteUnit(0x0100, nil, []byte{0x01, 0x00})
+	w.WriteUnit(0x0120, nil, []byte{0x01, 0x20})
+	w.WriteTables(0x01f0, tables[3])
+	w.WriteUnit(0x0100, nil, []byte{0x01, 0x00})
+	w.WriteUnit(0x0120, nil, []byte{0x01, 0x20})
+
+	var packets []Packet
+	for i := 0; i < buf.Len(); i += PacketSize {
+		packets = append(packets, Packet(buf.Bytes()[i:i+PacketSize]))
+	}
+
+	e := NewServiceExtractor(&packetSlice{packets: packets}, 0x0400, 0, nil)
+	var pids []PID
+	for e.Scan() {
+		pids = append(pids, e.Packet().PID())
+	}
+
+	expected := []PID{ProgramAssociationPID, 0x01f0, 0x0100, 0x01f0, 0x0120}
+	if len(pids) != len(expected) {
+		t.Fatalf("PIDs = %#x, want %#x", pids, expected)
+	}
+	for i, pid := range expected {
+		if pids[i] != pid {
+			t.Errorf("PIDs = %#x, want %#x", pids, expected)
+			break
+		}
+	}
+	if e.ProgramMapPID() != 0x01f0 || e.ProgramMap().VersionNumber() != 1 {
+		t.Errorf("PMT PID = %#x, PMT = %v", e.ProgramMapPID(), e.ProgramMap())
+	}
+}
@@ -44,6 +44,41 @@ func NewPacketFilter(s PacketStream, pids ...PID) *PacketFilter {
 	}
 }
 
+func (f *PacketFilter) PIDs() PIDSlice {
+	return f.pids
+}
+
+func (f *PacketFilter) SetPIDs(pids ...PID) {
+	pidSlice := make(PIDSlice, len(pids))
+	copy(pidSlice, pids)
+	sort.Sort(pidSlice)
+
+	f.pids = pidSlice[:0]
+	for _, pid := range pidSlice {
+		if f.pids.Len() == 0 || f.pids[f.pids.Len()-1] != pid {
+			f.pids = append(f.pids, pid)
+		}
+	}
+}
+
+func (f *PacketFilter) AddPID(pid PID) {
+	i := f.pids.Search(pid)
+	if f.pids.Len() > i && f.pids[i] == pid {
+		return
+	}
+
+	f.pids = append(f.pids, 0)
+	copy(f.pids[i+1:], f.pids[i:])
+	f.pids[i] = pid
+}
+
+func (f *PacketFilter) RemovePID(pid PID) {
+	i := f.pids.Search(pid)
+	if f.pids.Len() > i && f.pids[i] == pid {
+		f.pids = append(f.pids[:i], f.pids[i+1:]...)
+	}
+}
+
 func (f *PacketFilter) isTarget(pid PID) bool {
 	i := f.pids.Search(pid)
 	return f.pids.Len() > i && f.pids[i] == pid
@@ -68,3 +103,13 @@ func (f *PacketFilter) Bytes() []byte {
 func (f *PacketFilter) Packet() Packet {
 	return f.next
 }
+
+// Offset returns the byte offset of the current packet in the input, or -1
+// if the stream filtered is not an OffsetStream.
+func (f *PacketFilter) Offset() int64 {
+	if s, ok := f.s.(OffsetStream); ok {
+		return s.Offset()
+	}
+
+	return -1
+}
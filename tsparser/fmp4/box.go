@@ -0,0 +1,81 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+type boxWriter struct {
+	buf   []byte
+	stack []int
+}
+
+func (b *boxWriter) start(boxType string) {
+	b.stack = append(b.stack, len(b.buf))
+	b.u32(0)
+	b.buf = append(b.buf, boxType[:4]...)
+}
+
+func (b *boxWriter) startFull(boxType string, version uint8, flags uint32) {
+	b.start(boxType)
+	b.u32(uint32(version)<<24 | flags&0xffffff)
+}
+
+func (b *boxWriter) end() {
+	i := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	b.put32(i, uint32(len(b.buf)-i))
+}
+
+func (b *boxWriter) u8(v uint8) {
+	b.buf = append(b.buf, v)
+}
+
+func (b *boxWriter) u16(v uint16) {
+	b.buf = append(b.buf, byte(v>>8), byte(v))
+}
+
+func (b *boxWriter) u32(v uint32) {
+	b.buf = append(b.buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (b *boxWriter) u64(v uint64) {
+	b.u32(uint32(v >> 32))
+	b.u32(uint32(v))
+}
+
+func (b *boxWriter) bytes(v []byte) {
+	b.buf = append(b.buf, v...)
+}
+
+func (b *boxWriter) zeros(n int) {
+	for i := 0; i < n; i++ {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *boxWriter) put32(i int, v uint32) {
+	b.buf[i], b.buf[i+1], b.buf[i+2], b.buf[i+3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+}
+
+// descriptor writes an MPEG-4 descriptor of ISO/IEC 14496-1 whose payload
+// is written by fn.
+func (b *boxWriter) descriptor(tag uint8, fn func()) {
+	b.u8(tag)
+	i := len(b.buf)
+	b.zeros(4)
+	fn()
+
+	length := uint32(len(b.buf) - i - 4)
+	b.buf[i] = 0x80 | byte(length>>21)&0x7f
+	b.buf[i+1] = 0x80 | byte(length>>14)&0x7f
+	b.buf[i+2] = 0x80 | byte(length>>7)&0x7f
+	b.buf[i+3] = byte(length) & 0x7f
+}
+
+var unityMatrix = []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000}
+
+func (b *boxWriter) matrix() {
+	for _, v := range unityMatrix {
+		b.u32(v)
+	}
+}
@@ -0,0 +1,520 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+import (
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/aac"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/h264"
+	"github.com/yosida95/tsparser/tsparser/hevc"
+)
+
+var ErrNoStream = errors.New("Supported stream is not found")
+
+const (
+	videoTrackId = 1
+	audioTrackId = 2
+
+	DefaultFragmentDuration = 2 * time.Second
+)
+
+type videoSample struct {
+	data []byte
+	dts  int64
+	pts  int64
+	sync bool
+}
+
+type audioSample struct {
+	data     []byte
+	pts      int64
+	duration uint32
+}
+
+// tableTap passes every packet through while feeding PSI to the remuxer.
+type tableTap struct {
+	s  tsparser.PacketStream
+	fn func(tsparser.Packet)
+}
+
+func (t *tableTap) Scan() bool {
+	if !t.s.Scan() {
+		return false
+	}
+
+	t.fn(t.s.Packet())
+	return true
+}
+
+func (t *tableTap) Packet() tsparser.Packet {
+	return t.s.Packet()
+}
+
+type Remuxer struct {
+	s        *tsparser.PESScanner
+	w        io.Writer
+	program  *tsparser.ProgramTracker
+	logger   *log.Logger
+	duration time.Duration
+
+	collector *tsparser.TableCollector
+	video     *tsparser.ElementaryStream
+	audio     *tsparser.ElementaryStream
+	audioTag  int
+	h264      *h264.AccessUnitParser
+	hevc      *hevc.AccessUnitParser
+	aac       *aac.FrameParser
+
+	timeline *tsparser.Timeline
+	origin   int64
+	started  bool
+
+	videoTrack   *Track
+	audioTrack   *Track
+	initTracks   []*Track
+	videoSamples []videoSample
+	audioSamples []audioSample
+	lastDuration int64
+	sequence     uint32
+}
+
+func NewRemuxer(s tsparser.PacketStream, w io.Writer, serviceId uint16, logger *log.Logger) *Remuxer {
+	r := &Remuxer{
+		w:         w,
+		program:   tsparser.NewProgramTracker(serviceId),
+		logger:    logger,
+		duration:  DefaultFragmentDuration,
+		collector: tsparser.NewTableCollector(),
+		audioTag:  -1,
+		timeline:  tsparser.NewTimeline(tsparser.NullPID),
+	}
+	r.s = tsparser.NewPESScanner(&tableTap{s, r.handlePacket}, logger)
+
+	return r
+}
+
+func (r *Remuxer) log(v ...interface{}) {
+	if r.logger != nil {
+		r.logger.Print(v...)
+	}
+}
+
+// SetFragmentDuration sets the minimum duration of a fragment.  Fragments
+// are cut at the first random access point after the duration.
+func (r *Remuxer) SetFragmentDuration(d time.Duration) {
+	r.duration = d
+}
+
+func (r *Remuxer) Run() error {
+	for r.s.Scan() {
+		pes, pid, offset := r.s.PES(), r.s.PID(), r.s.Offset()
+		switch {
+		case r.video != nil && pid == r.video.PID():
+			if err := r.pushVideo(pes, offset); err != nil {
+				return err
+			}
+		case r.audio != nil && pid == r.audio.PID():
+			if err := r.pushAudio(pes, offset); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case r.h264 != nil:
+		r.h264.Flush()
+		for _, au := range r.h264.AccessUnits() {
+			if err := r.handleH264(au); err != nil {
+				return err
+			}
+		}
+	case r.hevc != nil:
+		r.hevc.Flush()
+		for _, au := range r.hevc.AccessUnits() {
+			if err := r.handleHEVC(au); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !r.started {
+		return ErrNoStream
+	}
+	return r.writeFragment(true, 0, 0, true)
+}
+
+func (r *Remuxer) handlePacket(packet tsparser.Packet) {
+	if r.timeline.Feed(packet) {
+		r.log("PCR discontinuity")
+	}
+
+	pid := packet.PID()
+	if pid != tsparser.ProgramAssociationPID && pid != r.program.PMTPID() && pid != arib.EventInformationPID {
+		return
+	}
+
+	tables, err := r.collector.Feed(packet)
+	if err != nil {
+		r.log(err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			r.program.Update(tsparser.ParseProgramAssociationSection(table), r.collector)
+		case tsparser.ProgramMapTable:
+			if table.TableIdExtension() == r.program.ProgramNumber() && r.video == nil && r.audio == nil {
+				r.selectStreams(tsparser.ParseProgramMapSection(table))
+			}
+		case arib.EventInformationActualPFTable:
+			r.handleEvent(arib.ParseEventInformationSection(table))
+		}
+	}
+}
+
+// handleEvent follows the audio component descriptor of the present event,
+// which signals the dual mono audio without the channel configuration.
+func (r *Remuxer) handleEvent(eit *arib.EventInformationSection) {
+	if r.aac == nil || r.audioTag < 0 || eit.ServiceId() != r.program.ProgramNumber() || eit.SectionNumber() != 0 {
+		return
+	}
+
+	for _, event := range eit.Events() {
+		if c := arib.FindAudioComponent(event, uint8(r.audioTag)); c != nil {
+			r.aac.SetDualMono(c.DualMono())
+		}
+	}
+}
+
+func (r *Remuxer) selectStreams(pmt *tsparser.ProgramMapSection) {
+	r.timeline.SetPCRPID(pmt.PCRPID())
+	for _, es := range pmt.Streams() {
+		switch es.StreamType() {
+		case tsparser.H264VideoStream:
+			if r.video == nil {
+				r.video, r.h264 = es, h264.NewAccessUnitParser(r.logger)
+			}
+		case tsparser.HEVCVideoStream:
+			if r.video == nil {
+				r.video, r.hevc = es, hevc.NewAccessUnitParser(r.logger)
+			}
+		case tsparser.AACADTSStream, tsparser.AACLATMStream:
+			if r.audio == nil {
+				r.audio, r.aac = es, aac.NewFrameParser(es.StreamType(), r.logger)
+				if tag, ok := arib.ComponentTag(es); ok {
+					r.audioTag = int(tag)
+				}
+			}
+		}
+	}
+}
+
+func (r *Remuxer) pushVideo(pes tsparser.PES, offset int64) error {
+	if r.h264 != nil {
+		r.h264.Push(pes, offset)
+		for _, au := range r.h264.AccessUnits() {
+			if err := r.handleH264(au); err != nil {
+				return err
+			}
+		}
+	} else {
+		r.hevc.Push(pes, offset)
+		for _, au := range r.hevc.AccessUnits() {
+			if err := r.handleHEVC(au); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Remuxer) handleH264(au *h264.AccessUnit) error {
+	var spss, ppss [][]byte
+	var data []byte
+	for _, nal := range au.NALUnits {
+		switch nal.Type() {
+		case h264.SPSNAL:
+			spss = append(spss, nal)
+		case h264.PPSNAL:
+			ppss = append(ppss, nal)
+		case h264.AccessUnitDelimiter, h264.FillerDataNAL:
+		default:
+			data = appendNALUnit(data, nal)
+		}
+	}
+
+	var track *Track
+	if len(spss) > 0 {
+		var err error
+		if track, err = NewAVCTrack(videoTrackId, spss, ppss); err != nil {
+			r.log(err)
+		}
+	}
+
+	return r.handleVideo(data, track, au.PTS, au.DTS, au.HasPTS, au.RandomAccess())
+}
+
+func (r *Remuxer) handleHEVC(au *hevc.AccessUnit) error {
+	var vpss, spss, ppss [][]byte
+	var data []byte
+	for _, nal := range au.NALUnits {
+		switch nal.Type() {
+		case hevc.VPSNUT:
+			vpss = append(vpss, nal)
+		case hevc.SPSNUT:
+			spss = append(spss, nal)
+		case hevc.PPSNUT:
+			ppss = append(ppss, nal)
+		case hevc.AUDNUT, hevc.FDNUT:
+		default:
+			data = appendNALUnit(data, nal)
+		}
+	}
+
+	var track *Track
+	if len(spss) > 0 {
+		var err error
+		if track, err = NewHEVCTrack(videoTrackId, vpss, spss, ppss); err != nil {
+			r.log(err)
+		}
+	}
+
+	return r.handleVideo(data, track, au.PTS, au.DTS, au.HasPTS, au.RandomAccess())
+}
+
+func appendNALUnit(data []byte, nal []byte) []byte {
+	length := len(nal)
+	data = append(data, byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	return append(data, nal...)
+}
+
+// handleVideo adds an access unit.  track is not nil when the access
+// unit carries parameter sets.
+func (r *Remuxer) handleVideo(data []byte, track *Track, pts, dts uint64, hasPTS, sync bool) error {
+	sample := videoSample{data: data, sync: sync}
+	if hasPTS {
+		sample.dts = r.timeline.Timestamp(dts)
+		sample.pts = sample.dts + int64((pts-dts)&tsparser.TimestampMask)
+	} else if n := len(r.videoSamples); n > 0 {
+		sample.dts = r.videoSamples[n-1].dts + r.lastDuration
+		sample.pts = sample.dts
+	} else {
+		return nil
+	}
+
+	if !r.started {
+		if track != nil {
+			r.videoTrack = track
+		}
+		if !sync || r.videoTrack == nil {
+			return nil
+		}
+		r.started, r.origin = true, sample.dts
+	}
+
+	if n := len(r.videoSamples); n > 0 {
+		if d := sample.dts - r.videoSamples[n-1].dts; d > 0 {
+			r.lastDuration = d
+		}
+
+		elapsed := time.Duration(sample.dts-r.videoSamples[0].dts) * time.Second / tsparser.ClockRate
+		changed := track != nil && !track.Equal(r.videoTrack)
+		if sync && (elapsed >= r.duration || changed) {
+			if err := r.writeFragment(true, sample.dts, sample.pts, false); err != nil {
+				return err
+			}
+		}
+	}
+	if track != nil && sync {
+		r.videoTrack = track
+	}
+
+	r.videoSamples = append(r.videoSamples, sample)
+	return nil
+}
+
+func (r *Remuxer) pushAudio(pes tsparser.PES, offset int64) error {
+	r.aac.Push(pes, offset)
+	for _, frame := range r.aac.Frames() {
+		if !frame.HasPTS || frame.Config.SamplingFrequency == 0 {
+			continue
+		}
+
+		pts := r.timeline.Timestamp(frame.PTS)
+		if r.video == nil && !r.started {
+			r.started, r.origin = true, pts
+		}
+		if !r.started || pts < r.origin {
+			continue
+		}
+
+		if n := len(r.audioSamples); n > 0 {
+			elapsed := time.Duration(pts-r.audioSamples[0].pts) * time.Second / tsparser.ClockRate
+			if frame.ConfigChanged || r.video == nil && elapsed >= r.duration {
+				if err := r.writeFragment(false, 0, pts, false); err != nil {
+					return err
+				}
+			}
+		}
+		if r.audioTrack == nil || frame.ConfigChanged {
+			r.audioTrack = NewAACTrack(audioTrackId, frame.Config)
+		}
+
+		r.audioSamples = append(r.audioSamples, audioSample{
+			data:     frame.Payload,
+			pts:      pts,
+			duration: uint32(frame.Samples),
+		})
+	}
+
+	return nil
+}
+
+func (r *Remuxer) initVideoTrack() *Track {
+	for _, t := range r.initTracks {
+		if t.Id == videoTrackId {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (r *Remuxer) initAudioTrack() *Track {
+	for _, t := range r.initTracks {
+		if t.Id == audioTrackId {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// writeFragment writes the pending video samples followed by the access
+// unit decoded at next, if withVideo is set, and the audio samples
+// presented before end.  An init segment precedes the fragment when the
+// tracks have changed.
+func (r *Remuxer) writeFragment(withVideo bool, next, end int64, last bool) error {
+	var tracks []*Track
+	if r.videoTrack != nil {
+		tracks = append(tracks, r.videoTrack)
+	}
+	if r.audioTrack != nil {
+		tracks = append(tracks, r.audioTrack)
+	}
+
+	if !sameTracks(tracks, r.initTracks) && len(tracks) > 0 {
+		if _, err := r.w.Write(MarshalInitSegment(tracks...)); err != nil {
+			return err
+		}
+		r.initTracks = tracks
+	}
+
+	var fragments []*TrackFragment
+	if withVideo {
+		if f := r.videoFragment(next, last); f != nil {
+			fragments = append(fragments, f)
+		}
+	}
+	if f := r.audioFragment(end, last); f != nil {
+		fragments = append(fragments, f)
+	}
+	if len(fragments) == 0 {
+		return nil
+	}
+
+	r.sequence++
+	_, err := r.w.Write(MarshalFragment(r.sequence, fragments...))
+	return err
+}
+
+func sameTracks(a, b []*Track) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Remuxer) videoFragment(next int64, last bool) *TrackFragment {
+	samples := r.videoSamples
+	if len(samples) == 0 || r.initVideoTrack() == nil {
+		r.videoSamples = nil
+		return nil
+	}
+
+	f := &TrackFragment{
+		Track:               r.initVideoTrack(),
+		BaseMediaDecodeTime: uint64(samples[0].dts - r.origin),
+		Samples:             make([]Sample, len(samples)),
+	}
+	for i, s := range samples {
+		var duration int64
+		switch {
+		case i+1 < len(samples):
+			duration = samples[i+1].dts - s.dts
+		case !last:
+			duration = next - s.dts
+		}
+		if duration <= 0 {
+			duration = r.lastDuration
+		}
+
+		f.Samples[i] = Sample{
+			Data:              s.data,
+			Duration:          uint32(duration),
+			CompositionOffset: int32(s.pts - s.dts),
+			Sync:              s.sync,
+		}
+	}
+
+	r.videoSamples = nil
+	return f
+}
+
+func (r *Remuxer) audioFragment(end int64, last bool) *TrackFragment {
+	track := r.initAudioTrack()
+	n := 0
+	for n < len(r.audioSamples) && (last || r.audioSamples[n].pts < end) {
+		n++
+	}
+	if n == 0 || track == nil {
+		return nil
+	}
+
+	samples := r.audioSamples[:n]
+	r.audioSamples = append([]audioSample(nil), r.audioSamples[n:]...)
+
+	f := &TrackFragment{
+		Track:               track,
+		BaseMediaDecodeTime: uint64((samples[0].pts - r.origin) * int64(track.Timescale) / tsparser.ClockRate),
+		Samples:             make([]Sample, len(samples)),
+	}
+	for i, s := range samples {
+		f.Samples[i] = Sample{
+			Data:     s.data,
+			Duration: s.duration,
+			Sync:     true,
+		}
+	}
+
+	return f
+}
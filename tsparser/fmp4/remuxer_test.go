@@ -0,0 +1,140 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	accessUnitDelimiter = []byte{0x09, 0xf0}
+	// High profile 1920x1080 progressive at 29.97 fps.
+	testSPS = []byte{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78, 0x02, 0x27, 0xe5, 0xc0, 0x5a,
+		0x80, 0x80, 0x80, 0xa0, 0x00, 0x00, 0x7d, 0x20, 0x00, 0x1d, 0x4c, 0x10, 0x80,
+	}
+	testPPS  = []byte{0x68, 0xee, 0x3c, 0x80}
+	idrSlice = []byte{
+		0x65, 0x88, 0x80, 0x00, 0x04, 0x00, 0x00, 0x03, 0x03, 0x00, 0x00, 0x03, 0x01, 0x55,
+	}
+	pSlice = []byte{0x41, 0x9a, 0x22, 0x04}
+	// An ADTS frame of AAC LC 48 kHz stereo.
+	adtsFrame = []byte{0xff, 0xf1, 0x4c, 0x80, 0x01, 0x7f, 0xfc, 0x21, 0x10, 0x05, 0x00}
+)
+
+type trackRun struct {
+	trackId uint32
+	samples int
+}
+
+func trackRuns(moof []byte) []trackRun {
+	var runs []trackRun
+	for _, traf := range parseBoxes(moof) {
+		if traf.typ != "traf" {
+			continue
+		}
+
+		children := parseBoxes(traf.payload)
+		tfhd, trun := findBox(children, "tfhd"), findBox(children, "trun")
+		if tfhd != nil && trun != nil {
+			runs = append(runs, trackRun{
+				trackId: binary.BigEndian.Uint32(tfhd.payload[4:]),
+				samples: int(binary.BigEndian.Uint32(trun.payload[4:])),
+			})
+		}
+	}
+	return runs
+}
+
+func TestRemuxer(t *testing.T) {
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.AACADTSStream, 0x0110))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ts bytes.Buffer
+	w := tsparser.NewPacketWriter(&ts)
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTable)
+	for i := 0; i < 6; i++ {
+		picture := fixture.AnnexB(accessUnitDelimiter, pSlice)
+		if i%3 == 0 {
+			picture = fixture.AnnexB(accessUnitDelimiter, testSPS, testPPS, idrSlice)
+		}
+
+		pts := uint64(90000 + i*3003)
+		w.WriteUnit(0x0100, nil, fixture.PES(0xe0, pts, picture))
+		// The audio is multiplexed behind the video by a picture.
+		if i > 0 {
+			w.WriteUnit(0x0110, nil, fixture.PES(0xc0, pts-3003, adtsFrame))
+		}
+	}
+	w.WriteUnit(0x0110, nil, fixture.PES(0xc0, 90000+5*3003, adtsFrame))
+
+	var buf bytes.Buffer
+	r := NewRemuxer(tsparser.NewPacketScanner(&ts, nil), &buf, 0, nil)
+	r.SetFragmentDuration(100 * time.Millisecond)
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	boxes := parseBoxes(buf.Bytes())
+	var types []string
+	for _, b := range boxes {
+		types = append(types, b.typ)
+	}
+	if len(boxes) != 6 || boxes[0].typ != "ftyp" || boxes[1].typ != "moov" || boxes[2].typ != "moof" || boxes[4].typ != "moof" {
+		t.Fatalf("boxes = %v", types)
+	}
+
+	moov := parseBoxes(boxes[1].payload)
+	var traks int
+	for _, b := range moov {
+		if b.typ == "trak" {
+			traks++
+		}
+	}
+	if traks != 2 || !bytes.Contains(boxes[1].payload, []byte("avcC")) {
+		t.Errorf("%d tracks", traks)
+	}
+
+	var samples [3]int
+	for _, i := range []int{2, 4} {
+		runs := trackRuns(boxes[i].payload)
+		if len(runs) == 0 || runs[0].trackId != videoTrackId || runs[0].samples != 3 {
+			t.Errorf("fragment %d: runs = %+v", i/2, runs)
+		}
+		for _, run := range runs {
+			samples[run.trackId] += run.samples
+		}
+	}
+	if samples[videoTrackId] != 6 || samples[audioTrackId] != 6 {
+		t.Errorf("%d video samples, %d audio samples", samples[videoTrackId], samples[audioTrackId])
+	}
+}
+
+func TestRemuxerNoStream(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRemuxer(tsparser.NewPacketScanner(bytes.NewReader(nil), nil), &buf, 0, nil)
+	if err := r.Run(); err != ErrNoStream {
+		t.Errorf("err = %v, want %v", err, ErrNoStream)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+const (
+	syncSampleFlags    uint32 = 0x02000000 // sample_depends_on = 2
+	nonSyncSampleFlags uint32 = 0x01010000 // sample_depends_on = 1, non-sync
+
+	trunDataOffset        = 0x000001
+	trunSampleDuration    = 0x000100
+	trunSampleSize        = 0x000200
+	trunSampleFlags       = 0x000400
+	trunCompositionOffset = 0x000800
+
+	tfhdDefaultBaseIsMoof = 0x020000
+)
+
+type Sample struct {
+	Data              []byte
+	Duration          uint32
+	CompositionOffset int32
+	Sync              bool
+}
+
+type TrackFragment struct {
+	Track               *Track
+	BaseMediaDecodeTime uint64
+	Samples             []Sample
+}
+
+func MarshalInitSegment(tracks ...*Track) []byte {
+	b := new(boxWriter)
+	b.start("ftyp")
+	b.bytes([]byte("iso6"))
+	b.u32(0)
+	b.bytes([]byte("iso6cmfcmp41"))
+	b.end()
+
+	nextId := uint32(1)
+	for _, t := range tracks {
+		if t.Id >= nextId {
+			nextId = t.Id + 1
+		}
+	}
+
+	b.start("moov")
+	b.startFull("mvhd", 0, 0)
+	b.zeros(8)        // creation_time, modification_time
+	b.u32(1000)       // timescale
+	b.u32(0)          // duration
+	b.u32(0x00010000) // rate
+	b.u16(0x0100)     // volume
+	b.zeros(10)
+	b.matrix()
+	b.zeros(24)
+	b.u32(nextId)
+	b.end()
+
+	for _, t := range tracks {
+		marshalTrack(b, t)
+	}
+
+	b.start("mvex")
+	for _, t := range tracks {
+		b.startFull("trex", 0, 0)
+		b.u32(t.Id)
+		b.u32(1) // default_sample_description_index
+		b.zeros(12)
+		b.end()
+	}
+	b.end()
+	b.end()
+
+	return b.buf
+}
+
+func marshalTrack(b *boxWriter, t *Track) {
+	b.start("trak")
+	b.startFull("tkhd", 0, 0x000003) // enabled, in movie
+	b.zeros(8)
+	b.u32(t.Id)
+	b.zeros(4 + 4 + 8)
+	b.u16(0) // layer
+	b.u16(0) // alternate_group
+	if t.Handler == SoundHandler {
+		b.u16(0x0100)
+	} else {
+		b.u16(0)
+	}
+	b.zeros(2)
+	b.matrix()
+	b.u32(uint32(t.Width) << 16)
+	b.u32(uint32(t.Height) << 16)
+	b.end()
+
+	b.start("mdia")
+	b.startFull("mdhd", 0, 0)
+	b.zeros(8)
+	b.u32(t.Timescale)
+	b.u32(0)
+	b.u16(0x55c4) // und
+	b.u16(0)
+	b.end()
+
+	b.startFull("hdlr", 0, 0)
+	b.u32(0)
+	b.bytes([]byte(t.Handler))
+	b.zeros(12)
+	if t.Handler == SoundHandler {
+		b.bytes([]byte("SoundHandler\x00"))
+	} else {
+		b.bytes([]byte("VideoHandler\x00"))
+	}
+	b.end()
+
+	b.start("minf")
+	if t.Handler == SoundHandler {
+		b.startFull("smhd", 0, 0)
+		b.zeros(4)
+	} else {
+		b.startFull("vmhd", 0, 1)
+		b.zeros(8)
+	}
+	b.end()
+
+	b.start("dinf")
+	b.startFull("dref", 0, 0)
+	b.u32(1)
+	b.startFull("url ", 0, 1) // self-contained
+	b.end()
+	b.end()
+	b.end()
+
+	b.start("stbl")
+	b.startFull("stsd", 0, 0)
+	b.u32(1)
+	b.bytes(t.sampleEntry)
+	b.end()
+	for _, empty := range []string{"stts", "stsc", "stco"} {
+		b.startFull(empty, 0, 0)
+		b.u32(0)
+		b.end()
+	}
+	b.startFull("stsz", 0, 0)
+	b.zeros(8)
+	b.end()
+	b.end()
+
+	b.end() // minf
+	b.end() // mdia
+	b.end() // trak
+}
+
+// MarshalFragment returns a moof box followed by an mdat box carrying the
+// samples of every track fragment.
+func MarshalFragment(sequence uint32, fragments ...*TrackFragment) []byte {
+	b := new(boxWriter)
+	b.start("moof")
+	b.startFull("mfhd", 0, 0)
+	b.u32(sequence)
+	b.end()
+
+	offsets := make([]int, len(fragments))
+	for i, f := range fragments {
+		flags := uint32(trunDataOffset | trunSampleDuration | trunSampleSize | trunSampleFlags)
+		if f.Track.Handler == VideoHandler {
+			flags |= trunCompositionOffset
+		}
+
+		b.start("traf")
+		b.startFull("tfhd", 0, tfhdDefaultBaseIsMoof)
+		b.u32(f.Track.Id)
+		b.end()
+
+		b.startFull("tfdt", 1, 0)
+		b.u64(f.BaseMediaDecodeTime)
+		b.end()
+
+		b.startFull("trun", 1, flags)
+		b.u32(uint32(len(f.Samples)))
+		offsets[i] = len(b.buf)
+		b.u32(0)
+		for _, s := range f.Samples {
+			b.u32(s.Duration)
+			b.u32(uint32(len(s.Data)))
+			if s.Sync {
+				b.u32(syncSampleFlags)
+			} else {
+				b.u32(nonSyncSampleFlags)
+			}
+			if flags&trunCompositionOffset > 0 {
+				b.u32(uint32(s.CompositionOffset))
+			}
+		}
+		b.end()
+		b.end()
+	}
+	b.end()
+
+	dataOffset := len(b.buf) + 8
+	b.start("mdat")
+	for i, f := range fragments {
+		b.put32(offsets[i], uint32(dataOffset))
+		for _, s := range f.Samples {
+			b.bytes(s.Data)
+			dataOffset += len(s.Data)
+		}
+	}
+	b.end()
+
+	return b.buf
+}
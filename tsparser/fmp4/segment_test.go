@@ -0,0 +1,115 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser/aac"
+)
+
+type box struct {
+	typ     string
+	offset  int
+	payload []byte
+}
+
+func parseBoxes(data []byte) []box {
+	var boxes []box
+	for offset := 0; offset+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[offset:]))
+		if size < 8 || offset+size > len(data) {
+			break
+		}
+
+		boxes = append(boxes, box{
+			typ:     string(data[offset+4 : offset+8]),
+			offset:  offset,
+			payload: data[offset+8 : offset+size],
+		})
+		offset += size
+	}
+	return boxes
+}
+
+func findBox(boxes []box, typ string) *box {
+	for i := range boxes {
+		if boxes[i].typ == typ {
+			return &boxes[i]
+		}
+	}
+	return nil
+}
+
+func TestMarshalFragment(t *testing.T) {
+	track := NewAACTrack(audioTrackId, aac.Config{ObjectType: aac.AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2})
+	data := MarshalFragment(7, &TrackFragment{
+		Track:               track,
+		BaseMediaDecodeTime: 1024,
+		Samples: []Sample{
+			{Data: []byte{0x01, 0x02}, Duration: 1024, Sync: true},
+			{Data: []byte{0x03}, Duration: 1024, Sync: true},
+		},
+	})
+
+	boxes := parseBoxes(data)
+	if len(boxes) != 2 || boxes[0].typ != "moof" || boxes[1].typ != "mdat" {
+		t.Fatalf("boxes = %+v", boxes)
+	}
+	if !bytes.Equal(boxes[1].payload, []byte{0x01, 0x02, 0x03}) {
+		t.Errorf("mdat = % x", boxes[1].payload)
+	}
+
+	moof := parseBoxes(boxes[0].payload)
+	if mfhd := findBox(moof, "mfhd"); mfhd == nil || binary.BigEndian.Uint32(mfhd.payload[4:]) != 7 {
+		t.Errorf("mfhd = %+v", mfhd)
+	}
+	traf := findBox(moof, "traf")
+	if traf == nil {
+		t.Fatal("traf is not found")
+	}
+
+	children := parseBoxes(traf.payload)
+	if tfhd := findBox(children, "tfhd"); tfhd == nil || binary.BigEndian.Uint32(tfhd.payload[4:]) != audioTrackId {
+		t.Errorf("tfhd = %+v", tfhd)
+	}
+	if tfdt := findBox(children, "tfdt"); tfdt == nil || binary.BigEndian.Uint64(tfdt.payload[4:]) != 1024 {
+		t.Errorf("tfdt = %+v", tfdt)
+	}
+
+	trun := findBox(children, "trun")
+	if trun == nil {
+		t.Fatal("trun is not found")
+	}
+	count := binary.BigEndian.Uint32(trun.payload[4:])
+	dataOffset := int(binary.BigEndian.Uint32(trun.payload[8:]))
+	if count != 2 || dataOffset != len(boxes[0].payload)+16 {
+		t.Errorf("sample_count = %d, data_offset = %d", count, dataOffset)
+	}
+	if size := binary.BigEndian.Uint32(trun.payload[16:]); size != 2 {
+		t.Errorf("sample_size = %d", size)
+	}
+}
+
+func TestMarshalInitSegment(t *testing.T) {
+	track := NewAACTrack(audioTrackId, aac.Config{ObjectType: aac.AACLC, SamplingFrequency: 48000, ChannelConfiguration: 2, Channels: 2})
+	boxes := parseBoxes(MarshalInitSegment(track))
+	if len(boxes) != 2 || boxes[0].typ != "ftyp" || boxes[1].typ != "moov" {
+		t.Fatalf("boxes = %+v", boxes)
+	}
+
+	moov := parseBoxes(boxes[1].payload)
+	if mvhd := findBox(moov, "mvhd"); mvhd == nil || binary.BigEndian.Uint32(mvhd.payload[len(mvhd.payload)-4:]) != audioTrackId+1 {
+		t.Errorf("mvhd = %+v", mvhd)
+	}
+	if findBox(moov, "trak") == nil || findBox(moov, "mvex") == nil {
+		t.Errorf("moov = %+v", moov)
+	}
+	if !bytes.Contains(boxes[1].payload, []byte("mp4a")) || !bytes.Contains(boxes[1].payload, []byte("esds")) {
+		t.Error("sample entry is not found")
+	}
+}
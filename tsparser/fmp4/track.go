@@ -0,0 +1,211 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package fmp4
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser/aac"
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+	"github.com/yosida95/tsparser/tsparser/h264"
+	"github.com/yosida95/tsparser/tsparser/hevc"
+)
+
+var ErrNoParameterSet = errors.New("Parameter set is not found")
+
+const (
+	VideoHandler = "vide"
+	SoundHandler = "soun"
+)
+
+type Track struct {
+	Id        uint32
+	Handler   string
+	Timescale uint32
+	Width     int
+	Height    int
+
+	sampleEntry []byte
+}
+
+func (t *Track) Equal(other *Track) bool {
+	return other != nil && t.Id == other.Id && string(t.sampleEntry) == string(other.sampleEntry)
+}
+
+func visualSampleEntry(b *boxWriter, format string, width, height int) {
+	b.start(format)
+	b.zeros(6)
+	b.u16(1) // data_reference_index
+	b.zeros(16)
+	b.u16(uint16(width))
+	b.u16(uint16(height))
+	b.u32(0x00480000) // horizresolution
+	b.u32(0x00480000) // vertresolution
+	b.u32(0)
+	b.u16(1) // frame_count
+	b.zeros(32)
+	b.u16(0x0018) // depth
+	b.u16(0xffff) // pre_defined
+}
+
+func pixelAspectRatio(b *boxWriter, width, height uint16) {
+	if width == 0 || height == 0 || width == height {
+		return
+	}
+
+	b.start("pasp")
+	b.u32(uint32(width))
+	b.u32(uint32(height))
+	b.end()
+}
+
+func parameterSets(b *boxWriter, units [][]byte) {
+	for _, unit := range units {
+		b.u16(uint16(len(unit)))
+		b.bytes(unit)
+	}
+}
+
+// NewAVCTrack returns a track with an avc1 sample entry built from the
+// first SPS and all PPS NAL units given.
+func NewAVCTrack(id uint32, spss, ppss [][]byte) (*Track, error) {
+	if len(spss) == 0 || len(ppss) == 0 || len(spss[0]) < 4 {
+		return nil, ErrNoParameterSet
+	}
+
+	sps, err := h264.ParseSPS(h264.NALUnit(spss[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(boxWriter)
+	visualSampleEntry(b, "avc1", sps.Width, sps.Height)
+	b.start("avcC")
+	b.u8(1) // configurationVersion
+	b.bytes(spss[0][1:4])
+	b.u8(0xfc | 3) // lengthSizeMinusOne
+	b.u8(0xe0 | 1)
+	parameterSets(b, spss[:1])
+	b.u8(uint8(len(ppss)))
+	parameterSets(b, ppss)
+	switch sps.ProfileIdc {
+	case 100, 110, 122, 144:
+		b.u8(0xfc | uint8(sps.ChromaFormatIdc))
+		b.u8(0xf8 | uint8(sps.BitDepthLuma-8))
+		b.u8(0xf8 | uint8(sps.BitDepthChroma-8))
+		b.u8(0)
+	}
+	b.end()
+	pixelAspectRatio(b, sps.SarWidth, sps.SarHeight)
+	b.end()
+
+	return &Track{
+		Id:          id,
+		Handler:     VideoHandler,
+		Timescale:   90000,
+		Width:       sps.Width,
+		Height:      sps.Height,
+		sampleEntry: b.buf,
+	}, nil
+}
+
+// NewHEVCTrack returns a track with an hvc1 sample entry built from the
+// parameter sets given.
+func NewHEVCTrack(id uint32, vpss, spss, ppss [][]byte) (*Track, error) {
+	if len(vpss) == 0 || len(spss) == 0 || len(ppss) == 0 {
+		return nil, ErrNoParameterSet
+	}
+
+	sps, err := hevc.ParseSPS(hevc.NALUnit(spss[0]))
+	if err != nil {
+		return nil, err
+	}
+	rbsp := bitstream.UnescapeRBSP(spss[0])
+	if len(rbsp) < 15 {
+		return nil, ErrNoParameterSet
+	}
+
+	b := new(boxWriter)
+	visualSampleEntry(b, "hvc1", sps.Width, sps.Height)
+	b.start("hvcC")
+	b.u8(1)             // configurationVersion
+	b.bytes(rbsp[3:15]) // general profile, tier and level
+	b.u16(0xf000)       // min_spatial_segmentation_idc
+	b.u8(0xfc)          // parallelismType
+	b.u8(0xfc | uint8(sps.ChromaFormatIdc))
+	b.u8(0xf8 | uint8(sps.BitDepthLuma-8))
+	b.u8(0xf8 | uint8(sps.BitDepthChroma-8))
+	b.u16(0) // avgFrameRate
+
+	nesting := uint8(0)
+	if sps.TemporalIdNesting {
+		nesting = 1
+	}
+	b.u8(sps.MaxSubLayers<<3 | nesting<<2 | 3)
+
+	b.u8(3) // numOfArrays
+	for i, units := range [][][]byte{vpss, spss, ppss} {
+		b.u8(0x80 | uint8(hevc.VPSNUT+hevc.NALUnitType(i))) // array_completeness
+		b.u16(uint16(len(units)))
+		parameterSets(b, units)
+	}
+	b.end()
+	pixelAspectRatio(b, sps.SarWidth, sps.SarHeight)
+	b.end()
+
+	return &Track{
+		Id:          id,
+		Handler:     VideoHandler,
+		Timescale:   90000,
+		Width:       sps.Width,
+		Height:      sps.Height,
+		sampleEntry: b.buf,
+	}, nil
+}
+
+// NewAACTrack returns a track with an mp4a sample entry whose timescale is
+// the sampling frequency.
+func NewAACTrack(id uint32, config aac.Config) *Track {
+	channels := config.Channels
+	if channels == 0 {
+		channels = 2
+	}
+
+	b := new(boxWriter)
+	b.start("mp4a")
+	b.zeros(6)
+	b.u16(1) // data_reference_index
+	b.zeros(8)
+	b.u16(uint16(channels))
+	b.u16(16) // samplesize
+	b.zeros(4)
+	b.u32(uint32(config.SamplingFrequency) << 16)
+
+	b.startFull("esds", 0, 0)
+	b.descriptor(0x03, func() { // ES_Descriptor
+		b.u16(uint16(id))
+		b.u8(0)
+		b.descriptor(0x04, func() { // DecoderConfigDescriptor
+			b.u8(0x40)        // Audio ISO/IEC 14496-3
+			b.u8(0x05<<2 | 1) // AudioStream
+			b.zeros(3 + 4 + 4)
+			b.descriptor(0x05, func() { // DecoderSpecificInfo
+				b.bytes(config.AudioSpecificConfig())
+			})
+		})
+		b.descriptor(0x06, func() { // SLConfigDescriptor
+			b.u8(0x02)
+		})
+	})
+	b.end()
+	b.end()
+
+	return &Track{
+		Id:          id,
+		Handler:     SoundHandler,
+		Timescale:   uint32(config.SamplingFrequency),
+		sampleEntry: b.buf,
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var (
+	ErrInvalidNALUnit = errors.New("Invalid NAL unit")
+	ErrUnknownSPS     = errors.New("Unknown sequence parameter set")
+	ErrUnknownPPS     = errors.New("Unknown picture parameter set")
+)
+
+type NALUnitType uint8
+
+const (
+	NonIDRSliceNAL      NALUnitType = 1
+	SliceDataPartANAL   NALUnitType = 2
+	SliceDataPartBNAL   NALUnitType = 3
+	SliceDataPartCNAL   NALUnitType = 4
+	IDRSliceNAL         NALUnitType = 5
+	SEINAL              NALUnitType = 6
+	SPSNAL              NALUnitType = 7
+	PPSNAL              NALUnitType = 8
+	AccessUnitDelimiter NALUnitType = 9
+	EndOfSequenceNAL    NALUnitType = 10
+	EndOfStreamNAL      NALUnitType = 11
+	FillerDataNAL       NALUnitType = 12
+	SPSExtensionNAL     NALUnitType = 13
+	PrefixNAL           NALUnitType = 14
+	SubsetSPSNAL        NALUnitType = 15
+)
+
+func (t NALUnitType) IsVCL() bool {
+	return NonIDRSliceNAL <= t && t <= IDRSliceNAL
+}
+
+type NALUnit []byte
+
+func (n NALUnit) RefIdc() uint8 {
+	return uint8(n[0]&0x60) >> 5
+}
+
+func (n NALUnit) Type() NALUnitType {
+	return NALUnitType(n[0] & 0x1f)
+}
+
+func (n NALUnit) RBSP() []byte {
+	return bitstream.UnescapeRBSP(n[1:])
+}
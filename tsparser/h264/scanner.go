@@ -0,0 +1,284 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type AccessUnit struct {
+	NALUnits []NALUnit
+
+	PTS    uint64
+	DTS    uint64
+	HasPTS bool
+	Offset int64
+
+	SPS           *SPS
+	SliceType     SliceType
+	IDR           bool
+	RecoveryPoint bool
+	FieldPic      bool
+	BottomField   bool
+
+	hasSlice bool
+	header   *SliceHeader
+}
+
+func (au *AccessUnit) RandomAccess() bool {
+	return au.IDR || au.RecoveryPoint
+}
+
+func (au *AccessUnit) Keyframe() bool {
+	return au.RandomAccess() && au.SliceType.IsIntra()
+}
+
+type origin struct {
+	pts    uint64
+	dts    uint64
+	hasPTS bool
+	offset int64
+}
+
+type AccessUnitParser struct {
+	logger *log.Logger
+
+	spss map[uint32]*SPS
+	ppss map[uint32]*PPS
+
+	splitter bitstream.AnnexBSplitter
+	current  *AccessUnit
+	queue    []*AccessUnit
+}
+
+func NewAccessUnitParser(logger *log.Logger) *AccessUnitParser {
+	return &AccessUnitParser{
+		logger: logger,
+		spss:   make(map[uint32]*SPS),
+		ppss:   make(map[uint32]*PPS),
+	}
+}
+
+func (p *AccessUnitParser) log(v ...interface{}) {
+	if p.logger != nil {
+		p.logger.Print(v...)
+	}
+}
+
+// Push feeds the payload of a PES packet found at offset.  Completed
+// access units are returned by AccessUnits.
+func (p *AccessUnitParser) Push(pes tsparser.PES, offset int64) {
+	o := origin{pes.PTS(), pes.DTS(), pes.HasPTS(), offset}
+	p.splitter.Push(pes.Payload(), o, p.handleUnit)
+}
+
+// Flush completes the access unit in progress at the end of the stream.
+func (p *AccessUnitParser) Flush() {
+	p.splitter.Flush(p.handleUnit)
+	p.finish()
+}
+
+func (p *AccessUnitParser) AccessUnits() []*AccessUnit {
+	queue := p.queue
+	p.queue = nil
+	return queue
+}
+
+func (p *AccessUnitParser) SPS() map[uint32]*SPS {
+	return p.spss
+}
+
+type AccessUnitScanner struct {
+	s      *tsparser.PESScanner
+	pid    tsparser.PID
+	parser *AccessUnitParser
+
+	queue []*AccessUnit
+	au    *AccessUnit
+	eof   bool
+}
+
+func NewAccessUnitScanner(s *tsparser.PESScanner, pid tsparser.PID, logger *log.Logger) *AccessUnitScanner {
+	return &AccessUnitScanner{
+		s:      s,
+		pid:    pid,
+		parser: NewAccessUnitParser(logger),
+	}
+}
+
+func (s *AccessUnitScanner) Scan() bool {
+	for len(s.queue) == 0 {
+		if s.eof {
+			return false
+		}
+
+		if !s.s.Scan() {
+			s.eof = true
+			s.parser.Flush()
+		} else if s.s.PID() == s.pid {
+			s.parser.Push(s.s.PES(), s.s.Offset())
+		}
+		s.queue = append(s.queue, s.parser.AccessUnits()...)
+	}
+
+	s.au, s.queue = s.queue[0], s.queue[1:]
+	return true
+}
+
+func (s *AccessUnitScanner) AccessUnit() *AccessUnit {
+	return s.au
+}
+
+func (s *AccessUnitScanner) SPS() map[uint32]*SPS {
+	return s.parser.SPS()
+}
+
+func (p *AccessUnitParser) handleUnit(unit []byte, tag interface{}) {
+	p.handle(NALUnit(unit), tag.(origin))
+}
+
+func (p *AccessUnitParser) finish() {
+	if p.current != nil && p.current.hasSlice {
+		p.queue = append(p.queue, p.current)
+	}
+	p.current = nil
+}
+
+func (p *AccessUnitParser) begin(o origin) {
+	p.finish()
+	p.current = &AccessUnit{
+		PTS:    o.pts,
+		DTS:    o.dts,
+		HasPTS: o.hasPTS,
+		Offset: o.offset,
+	}
+}
+
+func (p *AccessUnitParser) handle(nal NALUnit, o origin) {
+	if len(nal) == 0 || nal[0]&0x80 > 0 {
+		return
+	}
+
+	switch t := nal.Type(); {
+	case t == AccessUnitDelimiter, t == SPSNAL, t == PPSNAL, t == SEINAL, PrefixNAL <= t && t <= 18:
+		if p.current == nil || p.current.hasSlice {
+			p.begin(o)
+		}
+	case t.IsVCL():
+		header, err := ParseSliceHeader(nal, p.spss, p.ppss)
+		if err != nil {
+			p.log(err)
+			break
+		}
+
+		if p.current == nil || p.current.hasSlice && isFirstSliceOfPicture(p.current.header, header) {
+			p.begin(o)
+		}
+		p.addSlice(nal, header)
+	case t == EndOfSequenceNAL, t == EndOfStreamNAL:
+		if p.current != nil {
+			p.current.NALUnits = append(p.current.NALUnits, nal)
+		}
+		p.finish()
+		return
+	}
+
+	if p.current == nil {
+		return
+	}
+	if !nal.Type().IsVCL() {
+		p.current.NALUnits = append(p.current.NALUnits, nal)
+	}
+
+	switch nal.Type() {
+	case SPSNAL:
+		if sps, err := ParseSPS(nal); err == nil {
+			p.spss[sps.Id] = sps
+		} else {
+			p.log(err)
+		}
+	case PPSNAL:
+		if pps, err := ParsePPS(nal); err == nil {
+			p.ppss[pps.Id] = pps
+		} else {
+			p.log(err)
+		}
+	case SEINAL:
+		for _, message := range ParseSEI(nal) {
+			if message.PayloadType == RecoveryPointSEI {
+				p.current.RecoveryPoint = true
+			}
+		}
+	}
+}
+
+func isFirstSliceOfPicture(prev, next *SliceHeader) bool {
+	return next.FirstMbInSlice == 0 ||
+		prev.FrameNum != next.FrameNum ||
+		prev.PPSId != next.PPSId ||
+		prev.FieldPic != next.FieldPic ||
+		prev.BottomField != next.BottomField
+}
+
+func (p *AccessUnitParser) addSlice(nal NALUnit, header *SliceHeader) {
+	au := p.current
+	au.NALUnits = append(au.NALUnits, nal)
+
+	if !au.hasSlice {
+		au.SliceType = header.SliceType
+		au.FieldPic = header.FieldPic
+		au.BottomField = header.BottomField
+		if pps, ok := p.ppss[header.PPSId]; ok {
+			au.SPS = p.spss[pps.SPSId]
+		}
+	} else if sliceRank(header.SliceType) > sliceRank(au.SliceType) {
+		au.SliceType = header.SliceType
+	}
+
+	au.IDR = au.IDR || nal.Type() == IDRSliceNAL
+	au.hasSlice = true
+	au.header = header
+}
+
+func sliceRank(t SliceType) int {
+	switch t {
+	case ISlice, SISlice:
+		return 0
+	case PSlice, SPSlice:
+		return 1
+	}
+
+	return 2
+}
+
+func IsRandomAccess(data []byte) bool {
+	units, rest := bitstream.SplitAnnexB(data)
+	if len(rest) > 3 {
+		units = append(units, rest[3:])
+	}
+
+	for _, unit := range units {
+		nal := NALUnit(unit)
+		if len(nal) < 2 {
+			continue
+		}
+
+		switch nal.Type() {
+		case IDRSliceNAL:
+			return true
+		case SEINAL:
+			for _, message := range ParseSEI(nal) {
+				if message.PayloadType == RecoveryPointSEI {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var accessUnitDelimiter = NALUnit{0x09, 0xf0}
+
+func TestAccessUnitScanner(t *testing.T) {
+	ts := fixture.Packetize(0x0100,
+		fixture.PES(0xe0, 90000, fixture.AnnexB(accessUnitDelimiter, progressiveSPS, testPPS, idrSlice)),
+		fixture.PES(0xe0, 93003, fixture.AnnexB(accessUnitDelimiter, pSlice)),
+		fixture.PES(0xe0, 96006, fixture.AnnexB(accessUnitDelimiter, recoveryPointSEI, pSlice)),
+	)
+
+	s := NewAccessUnitScanner(tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(ts), nil), nil), 0x0100, nil)
+	var aus []*AccessUnit
+	for s.Scan() {
+		aus = append(aus, s.AccessUnit())
+	}
+	if len(aus) != 3 {
+		t.Fatalf("%d access units, want 3", len(aus))
+	}
+
+	tests := []struct {
+		pts           uint64
+		units         int
+		sliceType     SliceType
+		idr           bool
+		recoveryPoint bool
+		keyframe      bool
+	}{
+		{90000, 4, ISlice, true, false, true},
+		{93003, 2, PSlice, false, false, false},
+		{96006, 3, PSlice, false, true, false},
+	}
+	for i, test := range tests {
+		au := aus[i]
+		if !au.HasPTS || au.PTS != test.pts {
+			t.Errorf("%d: PTS = %d (%v)", i, au.PTS, au.HasPTS)
+		}
+		if len(au.NALUnits) != test.units {
+			t.Errorf("%d: %d NAL units, want %d", i, len(au.NALUnits), test.units)
+		}
+		if au.SliceType != test.sliceType || au.IDR != test.idr || au.RecoveryPoint != test.recoveryPoint {
+			t.Errorf("%d: slice type = %v, IDR = %v, recovery point = %v", i, au.SliceType, au.IDR, au.RecoveryPoint)
+		}
+		if au.RandomAccess() != (test.idr || test.recoveryPoint) || au.Keyframe() != test.keyframe {
+			t.Errorf("%d: random access = %v, keyframe = %v", i, au.RandomAccess(), au.Keyframe())
+		}
+		if au.SPS == nil || au.SPS.Width != 1920 {
+			t.Errorf("%d: SPS = %+v", i, au.SPS)
+		}
+	}
+
+	if sps := s.SPS()[0]; sps == nil || sps.Height != 1080 {
+		t.Errorf("SPS = %+v", sps)
+	}
+}
+
+func TestAccessUnitParser(t *testing.T) {
+	p := NewAccessUnitParser(nil)
+	p.Push(fixture.PES(0xe0, 90000, fixture.AnnexB(accessUnitDelimiter, progressiveSPS, testPPS, idrSlice)), 0)
+	p.Push(fixture.PES(0xe0, 93003, fixture.AnnexB(accessUnitDelimiter, pSlice)), 188)
+	p.Push(fixture.PES(0xe0, 96006, fixture.AnnexB(accessUnitDelimiter, recoveryPointSEI, pSlice)), 376)
+	p.Flush()
+
+	aus := p.AccessUnits()
+	if len(aus) != 3 {
+		t.Fatalf("%d access units, want 3", len(aus))
+	}
+
+	tests := []struct {
+		pts           uint64
+		offset        int64
+		units         int
+		sliceType     SliceType
+		idr           bool
+		recoveryPoint bool
+		keyframe      bool
+	}{
+		{90000, 0, 4, ISlice, true, false, true},
+		{93003, 188, 2, PSlice, false, false, false},
+		{96006, 376, 3, PSlice, false, true, false},
+	}
+	for i, test := range tests {
+		au := aus[i]
+		if !au.HasPTS || au.PTS != test.pts || au.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, au.PTS, au.HasPTS, au.Offset)
+		}
+		if len(au.NALUnits) != test.units {
+			t.Errorf("%d: %d NAL units, want %d", i, len(au.NALUnits), test.units)
+		}
+		if au.SliceType != test.sliceType || au.IDR != test.idr || au.RecoveryPoint != test.recoveryPoint {
+			t.Errorf("%d: slice type = %v, IDR = %v, recovery point = %v", i, au.SliceType, au.IDR, au.RecoveryPoint)
+		}
+		if au.RandomAccess() != (test.idr || test.recoveryPoint) || au.Keyframe() != test.keyframe {
+			t.Errorf("%d: random access = %v, keyframe = %v", i, au.RandomAccess(), au.Keyframe())
+		}
+		if au.SPS == nil || au.SPS.Width != 1920 {
+			t.Errorf("%d: SPS = %+v", i, au.SPS)
+		}
+	}
+}
+
+func TestIsRandomAccess(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected bool
+	}{
+		{fixture.AnnexB(accessUnitDelimiter, progressiveSPS, testPPS, idrSlice), true},
+		{fixture.AnnexB(accessUnitDelimiter, recoveryPointSEI, pSlice), true},
+		{fixture.AnnexB(accessUnitDelimiter, pSlice), false},
+		{fixture.StartCodes(idrSlice), true},
+	}
+
+	for i, test := range tests {
+		if got := IsRandomAccess(test.data); got != test.expected {
+			t.Errorf("%d: IsRandomAccess = %v, want %v", i, got, test.expected)
+		}
+	}
+}
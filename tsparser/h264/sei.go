@@ -0,0 +1,66 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+type SEIPayloadType uint32
+
+const (
+	BufferingPeriodSEI           SEIPayloadType = 0
+	PicTimingSEI                 SEIPayloadType = 1
+	UserDataRegisteredITUTT35SEI SEIPayloadType = 4
+	UserDataUnregisteredSEI      SEIPayloadType = 5
+	RecoveryPointSEI             SEIPayloadType = 6
+)
+
+type SEIMessage struct {
+	PayloadType SEIPayloadType
+	Payload     []byte
+}
+
+func ParseSEIMessages(rbsp []byte) []SEIMessage {
+	messages := make([]SEIMessage, 0, 1)
+	for i := 0; i < len(rbsp) && rbsp[i] != 0x80; {
+		payloadType := 0
+		for i < len(rbsp) && rbsp[i] == 0xff {
+			payloadType += 255
+			i++
+		}
+		if i >= len(rbsp) {
+			break
+		}
+		payloadType += int(rbsp[i])
+		i++
+
+		payloadSize := 0
+		for i < len(rbsp) && rbsp[i] == 0xff {
+			payloadSize += 255
+			i++
+		}
+		if i >= len(rbsp) {
+			break
+		}
+		payloadSize += int(rbsp[i])
+		i++
+
+		if i+payloadSize > len(rbsp) {
+			break
+		}
+		messages = append(messages, SEIMessage{
+			PayloadType: SEIPayloadType(payloadType),
+			Payload:     rbsp[i : i+payloadSize],
+		})
+		i += payloadSize
+	}
+
+	return messages
+}
+
+func ParseSEI(nal NALUnit) []SEIMessage {
+	if len(nal) < 2 || nal.Type() != SEINAL {
+		return nil
+	}
+
+	return ParseSEIMessages(nal.RBSP())
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type SliceType uint8
+
+const (
+	PSlice  SliceType = 0
+	BSlice  SliceType = 1
+	ISlice  SliceType = 2
+	SPSlice SliceType = 3
+	SISlice SliceType = 4
+)
+
+func (t SliceType) String() string {
+	switch t {
+	case PSlice:
+		return "P"
+	case BSlice:
+		return "B"
+	case ISlice:
+		return "I"
+	case SPSlice:
+		return "SP"
+	case SISlice:
+		return "SI"
+	}
+
+	return "?"
+}
+
+func (t SliceType) IsIntra() bool {
+	return t == ISlice || t == SISlice
+}
+
+type PPS struct {
+	Id                                uint32
+	SPSId                             uint32
+	EntropyCodingMode                 bool
+	BottomFieldPicOrderInFramePresent bool
+}
+
+func ParsePPS(nal NALUnit) (*PPS, error) {
+	if len(nal) < 2 || nal.Type() != PPSNAL {
+		return nil, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	pps := new(PPS)
+	pps.Id = r.UE()
+	pps.SPSId = r.UE()
+	pps.EntropyCodingMode = r.Bit()
+	pps.BottomFieldPicOrderInFramePresent = r.Bit()
+
+	return pps, r.Err()
+}
+
+type SliceHeader struct {
+	FirstMbInSlice uint32
+	SliceType      SliceType
+	PPSId          uint32
+	FrameNum       uint32
+	FieldPic       bool
+	BottomField    bool
+	IdrPicId       uint32
+	PicOrderCntLsb uint32
+}
+
+func ParseSliceHeader(nal NALUnit, spss map[uint32]*SPS, ppss map[uint32]*PPS) (*SliceHeader, error) {
+	if len(nal) < 2 || !nal.Type().IsVCL() {
+		return nil, ErrInvalidNALUnit
+	}
+
+	// The fields parsed lie in the first bytes of the slice, so that the
+	// rest is not unescaped.
+	head := nal[1:]
+	if len(head) > 80 {
+		head = head[:80]
+	}
+
+	r := bitstream.NewReader(bitstream.UnescapeRBSP(head))
+	h := new(SliceHeader)
+	h.FirstMbInSlice = r.UE()
+	h.SliceType = SliceType(r.UE() % 5)
+	h.PPSId = r.UE()
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+
+	pps, ok := ppss[h.PPSId]
+	if !ok {
+		return h, ErrUnknownPPS
+	}
+	sps, ok := spss[pps.SPSId]
+	if !ok {
+		return h, ErrUnknownSPS
+	}
+
+	if sps.SeparateColorPlane {
+		r.Skip(2)
+	}
+	h.FrameNum = r.Uint32(int(sps.Log2MaxFrameNum))
+	if !sps.FrameMbsOnly {
+		h.FieldPic = r.Bit()
+		if h.FieldPic {
+			h.BottomField = r.Bit()
+		}
+	}
+	if nal.Type() == IDRSliceNAL {
+		h.IdrPicId = r.UE()
+	}
+	if sps.PicOrderCntType == 0 {
+		h.PicOrderCntLsb = r.Uint32(int(sps.Log2MaxPicOrderCntLsb))
+	}
+
+	return h, r.Err()
+}
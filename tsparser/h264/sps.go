@@ -0,0 +1,212 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type SPS struct {
+	ProfileIdc         uint8
+	ConstraintFlags    uint8
+	LevelIdc           uint8
+	Id                 uint32
+	ChromaFormatIdc    uint32
+	SeparateColorPlane bool
+	BitDepthLuma       uint32
+	BitDepthChroma     uint32
+
+	Log2MaxFrameNum         uint32
+	PicOrderCntType         uint32
+	Log2MaxPicOrderCntLsb   uint32
+	DeltaPicOrderAlwaysZero bool
+	MaxNumRefFrames         uint32
+
+	Width  int
+	Height int
+
+	FrameMbsOnly         bool
+	MbAdaptiveFrameField bool
+
+	AspectRatioIdc uint8
+	SarWidth       uint16
+	SarHeight      uint16
+
+	VideoFullRange          bool
+	ColourPrimaries         uint8
+	TransferCharacteristics uint8
+	MatrixCoefficients      uint8
+
+	NumUnitsInTick uint32
+	TimeScale      uint32
+	FixedFrameRate bool
+}
+
+var sampleAspectRatios = [][2]uint16{
+	{0, 0}, {1, 1}, {12, 11}, {10, 11}, {16, 11}, {40, 33}, {24, 11}, {20, 11},
+	{32, 11}, {80, 33}, {18, 11}, {15, 11}, {64, 33}, {160, 99}, {4, 3}, {3, 2}, {2, 1},
+}
+
+func hasChromaInfo(profileIdc uint8) bool {
+	switch profileIdc {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		return true
+	}
+
+	return false
+}
+
+func skipScalingList(r *bitstream.Reader, size int) {
+	last, next := int32(8), int32(8)
+	for i := 0; i < size && next != 0; i++ {
+		delta := r.SE()
+		next = (last + delta + 256) % 256
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+func ParseSPS(nal NALUnit) (*SPS, error) {
+	if len(nal) < 4 || nal.Type() != SPSNAL {
+		return nil, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	sps := &SPS{ChromaFormatIdc: 1, BitDepthLuma: 8, BitDepthChroma: 8}
+	sps.ProfileIdc = r.Uint8(8)
+	sps.ConstraintFlags = r.Uint8(8)
+	sps.LevelIdc = r.Uint8(8)
+	sps.Id = r.UE()
+
+	if hasChromaInfo(sps.ProfileIdc) {
+		sps.ChromaFormatIdc = r.UE()
+		if sps.ChromaFormatIdc == 3 {
+			sps.SeparateColorPlane = r.Bit()
+		}
+		sps.BitDepthLuma = r.UE() + 8
+		sps.BitDepthChroma = r.UE() + 8
+		r.Skip(1) // qpprime_y_zero_transform_bypass_flag
+		if r.Bit() {
+			count := 8
+			if sps.ChromaFormatIdc == 3 {
+				count = 12
+			}
+			for i := 0; i < count; i++ {
+				if r.Bit() {
+					if i < 6 {
+						skipScalingList(r, 16)
+					} else {
+						skipScalingList(r, 64)
+					}
+				}
+			}
+		}
+	}
+
+	sps.Log2MaxFrameNum = r.UE() + 4
+	sps.PicOrderCntType = r.UE()
+	if sps.PicOrderCntType == 0 {
+		sps.Log2MaxPicOrderCntLsb = r.UE() + 4
+	} else if sps.PicOrderCntType == 1 {
+		sps.DeltaPicOrderAlwaysZero = r.Bit()
+		r.SE()
+		r.SE()
+		cycle := r.UE()
+		for i := uint32(0); i < cycle && r.Err() == nil; i++ {
+			r.SE()
+		}
+	}
+	sps.MaxNumRefFrames = r.UE()
+	r.Skip(1) // gaps_in_frame_num_value_allowed_flag
+
+	widthInMbs := int(r.UE()) + 1
+	heightInMapUnits := int(r.UE()) + 1
+	sps.FrameMbsOnly = r.Bit()
+	if !sps.FrameMbsOnly {
+		sps.MbAdaptiveFrameField = r.Bit()
+	}
+	r.Skip(1) // direct_8x8_inference_flag
+
+	frameHeightInMbs := heightInMapUnits
+	if !sps.FrameMbsOnly {
+		frameHeightInMbs *= 2
+	}
+	sps.Width = widthInMbs * 16
+	sps.Height = frameHeightInMbs * 16
+
+	if r.Bit() {
+		cropUnitX, cropUnitY := 1, 1
+		if !sps.FrameMbsOnly {
+			cropUnitY = 2
+		}
+		if sps.ChromaFormatIdc == 1 && !sps.SeparateColorPlane {
+			cropUnitX, cropUnitY = 2, cropUnitY*2
+		} else if sps.ChromaFormatIdc == 2 && !sps.SeparateColorPlane {
+			cropUnitX = 2
+		}
+
+		left, right := int(r.UE()), int(r.UE())
+		top, bottom := int(r.UE()), int(r.UE())
+		sps.Width -= (left + right) * cropUnitX
+		sps.Height -= (top + bottom) * cropUnitY
+	}
+
+	if r.Bit() {
+		parseVUI(r, sps)
+	}
+
+	return sps, r.Err()
+}
+
+func parseVUI(r *bitstream.Reader, sps *SPS) {
+	if r.Bit() {
+		sps.AspectRatioIdc = r.Uint8(8)
+		if sps.AspectRatioIdc == 255 {
+			sps.SarWidth = r.Uint16(16)
+			sps.SarHeight = r.Uint16(16)
+		} else if int(sps.AspectRatioIdc) < len(sampleAspectRatios) {
+			sar := sampleAspectRatios[sps.AspectRatioIdc]
+			sps.SarWidth, sps.SarHeight = sar[0], sar[1]
+		}
+	}
+
+	if r.Bit() { // overscan_info_present_flag
+		r.Skip(1)
+	}
+
+	if r.Bit() {
+		r.Skip(3) // video_format
+		sps.VideoFullRange = r.Bit()
+		if r.Bit() {
+			sps.ColourPrimaries = r.Uint8(8)
+			sps.TransferCharacteristics = r.Uint8(8)
+			sps.MatrixCoefficients = r.Uint8(8)
+		}
+	}
+
+	if r.Bit() { // chroma_loc_info_present_flag
+		r.UE()
+		r.UE()
+	}
+
+	if r.Bit() {
+		sps.NumUnitsInTick = r.Uint32(32)
+		sps.TimeScale = r.Uint32(32)
+		sps.FixedFrameRate = r.Bit()
+	}
+}
+
+func (sps *SPS) Interlaced() bool {
+	return !sps.FrameMbsOnly
+}
+
+func (sps *SPS) FrameRate() float64 {
+	if sps.NumUnitsInTick == 0 {
+		return 0
+	}
+
+	return float64(sps.TimeScale) / float64(2*sps.NumUnitsInTick)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package h264
+
+import (
+	"testing"
+)
+
+var (
+	// High profile 1920x1080 progressive at 29.97 fps, cropped from 1088.
+	progressiveSPS = NALUnit{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x78, 0x02, 0x27, 0xe5, 0xc0, 0x5a,
+		0x80, 0x80, 0x80, 0xa0, 0x00, 0x00, 0x7d, 0x20, 0x00, 0x1d, 0x4c, 0x10, 0x80,
+	}
+	// High profile 1440x1080 MBAFF with SAR 4:3, whose time_scale has an
+	// emulation prevention byte.
+	interlacedSPS = NALUnit{
+		0x67, 0x64, 0x00, 0x28, 0xac, 0xd9, 0x40, 0x5a, 0x04, 0x4f, 0xde, 0x1c, 0xd4,
+		0x04, 0x04, 0x05, 0x00, 0x00, 0x03, 0x03, 0xe9, 0x00, 0x00, 0xea, 0x60, 0x84,
+	}
+	testPPS = NALUnit{0x68, 0xee, 0x3c, 0x80}
+	// An I slice of idr_pic_id 65535, whose header has emulation prevention
+	// bytes.
+	idrSlice = NALUnit{
+		0x65, 0x88, 0x80, 0x00, 0x04, 0x00, 0x00, 0x03, 0x03, 0x00, 0x00, 0x03, 0x01, 0x55,
+	}
+	// A P slice of frame_num 1 and pic_order_cnt_lsb 4.
+	pSlice = NALUnit{0x41, 0x9a, 0x22, 0x04}
+	// A recovery point SEI.
+	recoveryPointSEI = NALUnit{0x06, 0x06, 0x01, 0xc4, 0x80}
+)
+
+func TestParseSPS(t *testing.T) {
+	tests := []struct {
+		nal        NALUnit
+		width      int
+		height     int
+		interlaced bool
+		sar        [2]uint16
+	}{
+		{progressiveSPS, 1920, 1080, false, [2]uint16{1, 1}},
+		{interlacedSPS, 1440, 1080, true, [2]uint16{4, 3}},
+	}
+
+	for i, test := range tests {
+		sps, err := ParseSPS(test.nal)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+
+		if sps.ProfileIdc != 100 || sps.LevelIdc != 40 || sps.ChromaFormatIdc != 1 || sps.BitDepthLuma != 8 {
+			t.Errorf("%d: profile_idc = %d, level_idc = %d, chroma_format_idc = %d, bit depth = %d",
+				i, sps.ProfileIdc, sps.LevelIdc, sps.ChromaFormatIdc, sps.BitDepthLuma)
+		}
+		if sps.Log2MaxFrameNum != 4 || sps.PicOrderCntType != 0 || sps.Log2MaxPicOrderCntLsb != 6 || sps.MaxNumRefFrames != 4 {
+			t.Errorf("%d: log2_max_frame_num = %d, pic_order_cnt_type = %d, log2_max_pic_order_cnt_lsb = %d, max_num_ref_frames = %d",
+				i, sps.Log2MaxFrameNum, sps.PicOrderCntType, sps.Log2MaxPicOrderCntLsb, sps.MaxNumRefFrames)
+		}
+		if sps.Width != test.width || sps.Height != test.height {
+			t.Errorf("%d: size = %dx%d, want %dx%d", i, sps.Width, sps.Height, test.width, test.height)
+		}
+		if sps.Interlaced() != test.interlaced || sps.MbAdaptiveFrameField != test.interlaced {
+			t.Errorf("%d: interlaced = %v, MBAFF = %v", i, sps.Interlaced(), sps.MbAdaptiveFrameField)
+		}
+		if sps.SarWidth != test.sar[0] || sps.SarHeight != test.sar[1] {
+			t.Errorf("%d: SAR = %d:%d, want %d:%d", i, sps.SarWidth, sps.SarHeight, test.sar[0], test.sar[1])
+		}
+		if sps.ColourPrimaries != 1 || sps.TransferCharacteristics != 1 || sps.MatrixCoefficients != 1 || sps.VideoFullRange {
+			t.Errorf("%d: colour = %d/%d/%d, full range %v", i,
+				sps.ColourPrimaries, sps.TransferCharacteristics, sps.MatrixCoefficients, sps.VideoFullRange)
+		}
+		if sps.NumUnitsInTick != 1001 || sps.TimeScale != 60000 || !sps.FixedFrameRate {
+			t.Errorf("%d: timing = %d/%d", i, sps.NumUnitsInTick, sps.TimeScale)
+		}
+		if rate := sps.FrameRate(); rate < 29.97 || rate > 29.98 {
+			t.Errorf("%d: frame rate = %f", i, rate)
+		}
+	}
+
+	if _, err := ParseSPS(testPPS); err != ErrInvalidNALUnit {
+		t.Errorf("PPS parsed as SPS: %v", err)
+	}
+}
+
+func TestParseSliceHeader(t *testing.T) {
+	sps, err := ParseSPS(progressiveSPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pps, err := ParsePPS(testPPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pps.Id != 0 || pps.SPSId != 0 || !pps.EntropyCodingMode {
+		t.Errorf("PPS = %+v", pps)
+	}
+
+	spss := map[uint32]*SPS{sps.Id: sps}
+	ppss := map[uint32]*PPS{pps.Id: pps}
+
+	tests := []struct {
+		nal      NALUnit
+		expected SliceHeader
+	}{
+		{idrSlice, SliceHeader{SliceType: ISlice, IdrPicId: 65535}},
+		{pSlice, SliceHeader{SliceType: PSlice, FrameNum: 1, PicOrderCntLsb: 4}},
+	}
+
+	for i, test := range tests {
+		h, err := ParseSliceHeader(test.nal, spss, ppss)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if *h != test.expected {
+			t.Errorf("%d: header = %+v, want %+v", i, *h, test.expected)
+		}
+	}
+
+	if _, err := ParseSliceHeader(pSlice, spss, nil); err != ErrUnknownPPS {
+		t.Errorf("err = %v, want %v", err, ErrUnknownPPS)
+	}
+	if _, err := ParseSliceHeader(pSlice, nil, ppss); err != ErrUnknownSPS {
+		t.Errorf("err = %v, want %v", err, ErrUnknownSPS)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var (
+	ErrInvalidNALUnit = errors.New("Invalid NAL unit")
+)
+
+type NALUnitType uint8
+
+const (
+	TrailN       NALUnitType = 0
+	TrailR       NALUnitType = 1
+	TSAN         NALUnitType = 2
+	TSAR         NALUnitType = 3
+	STSAN        NALUnitType = 4
+	STSAR        NALUnitType = 5
+	RADLN        NALUnitType = 6
+	RADLR        NALUnitType = 7
+	RASLN        NALUnitType = 8
+	RASLR        NALUnitType = 9
+	BLAWLP       NALUnitType = 16
+	BLAWRADL     NALUnitType = 17
+	BLANLP       NALUnitType = 18
+	IDRWRADL     NALUnitType = 19
+	IDRNLP       NALUnitType = 20
+	CRANUT       NALUnitType = 21
+	VPSNUT       NALUnitType = 32
+	SPSNUT       NALUnitType = 33
+	PPSNUT       NALUnitType = 34
+	AUDNUT       NALUnitType = 35
+	EOSNUT       NALUnitType = 36
+	EOBNUT       NALUnitType = 37
+	FDNUT        NALUnitType = 38
+	PrefixSEINUT NALUnitType = 39
+	SuffixSEINUT NALUnitType = 40
+)
+
+func (t NALUnitType) IsVCL() bool {
+	return t < 32
+}
+
+func (t NALUnitType) IsIRAP() bool {
+	return BLAWLP <= t && t <= 23
+}
+
+func (t NALUnitType) IsIDR() bool {
+	return t == IDRWRADL || t == IDRNLP
+}
+
+func (t NALUnitType) IsBLA() bool {
+	return BLAWLP <= t && t <= BLANLP
+}
+
+func (t NALUnitType) IsCRA() bool {
+	return t == CRANUT
+}
+
+type NALUnit []byte
+
+func (n NALUnit) Type() NALUnitType {
+	return NALUnitType(n[0]&0x7e) >> 1
+}
+
+func (n NALUnit) LayerId() uint8 {
+	return uint8(n[0]&0x01)<<5 | uint8(n[1])>>3
+}
+
+// IsValid reports whether the NAL unit header is valid, which has
+// forbidden_zero_bit of 0 and nuh_temporal_id_plus1 other than 0.
+func (n NALUnit) IsValid() bool {
+	return len(n) >= 2 && n[0]&0x80 == 0 && n[1]&0x07 != 0
+}
+
+// TemporalId returns TemporalId, which is 0 for an invalid NAL unit.
+func (n NALUnit) TemporalId() uint8 {
+	if n[1]&0x07 == 0 {
+		return 0
+	}
+
+	return uint8(n[1]&0x07) - 1
+}
+
+func (n NALUnit) RBSP() []byte {
+	return bitstream.UnescapeRBSP(n[2:])
+}
+
+func (n NALUnit) FirstSliceSegmentInPic() bool {
+	return n.Type().IsVCL() && len(n) > 2 && n[2]&0x80 > 0
+}
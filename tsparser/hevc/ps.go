@@ -0,0 +1,365 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type ProfileTierLevel struct {
+	ProfileSpace       uint8
+	Tier               bool
+	ProfileIdc         uint8
+	CompatibilityFlags uint32
+	ProgressiveSource  bool
+	InterlacedSource   bool
+	FrameOnly          bool
+	LevelIdc           uint8
+}
+
+func parseProfileTierLevel(r *bitstream.Reader, maxSubLayersMinus1 int) ProfileTierLevel {
+	var ptl ProfileTierLevel
+	ptl.ProfileSpace = r.Uint8(2)
+	ptl.Tier = r.Bit()
+	ptl.ProfileIdc = r.Uint8(5)
+	ptl.CompatibilityFlags = r.Uint32(32)
+	ptl.ProgressiveSource = r.Bit()
+	ptl.InterlacedSource = r.Bit()
+	r.Skip(1) // general_non_packed_constraint_flag
+	ptl.FrameOnly = r.Bit()
+	r.Skip(44)
+	ptl.LevelIdc = r.Uint8(8)
+
+	profilePresent := make([]bool, maxSubLayersMinus1)
+	levelPresent := make([]bool, maxSubLayersMinus1)
+	for i := 0; i < maxSubLayersMinus1; i++ {
+		profilePresent[i] = r.Bit()
+		levelPresent[i] = r.Bit()
+	}
+	if maxSubLayersMinus1 > 0 {
+		for i := maxSubLayersMinus1; i < 8; i++ {
+			r.Skip(2)
+		}
+	}
+	for i := 0; i < maxSubLayersMinus1; i++ {
+		if profilePresent[i] {
+			r.Skip(88)
+		}
+		if levelPresent[i] {
+			r.Skip(8)
+		}
+	}
+
+	return ptl
+}
+
+type VPS struct {
+	Id                uint8
+	MaxLayers         uint8
+	MaxSubLayers      uint8
+	TemporalIdNesting bool
+	ProfileTierLevel  ProfileTierLevel
+}
+
+func ParseVPS(nal NALUnit) (*VPS, error) {
+	if len(nal) < 3 || nal.Type() != VPSNUT {
+		return nil, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	vps := new(VPS)
+	vps.Id = r.Uint8(4)
+	r.Skip(2)
+	vps.MaxLayers = r.Uint8(6) + 1
+	vps.MaxSubLayers = r.Uint8(3) + 1
+	vps.TemporalIdNesting = r.Bit()
+	r.Skip(16)
+	vps.ProfileTierLevel = parseProfileTierLevel(r, int(vps.MaxSubLayers)-1)
+
+	return vps, r.Err()
+}
+
+type SPS struct {
+	VPSId             uint8
+	MaxSubLayers      uint8
+	TemporalIdNesting bool
+	ProfileTierLevel  ProfileTierLevel
+	Id                uint32
+	ChromaFormatIdc   uint32
+
+	Width          int
+	Height         int
+	BitDepthLuma   uint32
+	BitDepthChroma uint32
+
+	AspectRatioIdc uint8
+	SarWidth       uint16
+	SarHeight      uint16
+
+	VideoFullRange          bool
+	ColourPrimaries         uint8
+	TransferCharacteristics uint8
+	MatrixCoefficients      uint8
+
+	FieldSeq bool
+
+	NumUnitsInTick uint32
+	TimeScale      uint32
+}
+
+const (
+	TransferBT709         = 1
+	TransferBT2020_10     = 14
+	TransferBT2020_12     = 15
+	TransferSMPTE2084     = 16
+	TransferARIBSTDB67    = 18
+	ColourPrimariesBT709  = 1
+	ColourPrimariesBT2020 = 9
+)
+
+var sampleAspectRatios = [][2]uint16{
+	{0, 0}, {1, 1}, {12, 11}, {10, 11}, {16, 11}, {40, 33}, {24, 11}, {20, 11},
+	{32, 11}, {80, 33}, {18, 11}, {15, 11}, {64, 33}, {160, 99}, {4, 3}, {3, 2}, {2, 1},
+}
+
+func skipScalingListData(r *bitstream.Reader) {
+	for sizeId := 0; sizeId < 4; sizeId++ {
+		step := 1
+		if sizeId == 3 {
+			step = 3
+		}
+		for matrixId := 0; matrixId < 6; matrixId += step {
+			if !r.Bit() {
+				r.UE()
+				continue
+			}
+
+			coefNum := 1 << uint(4+sizeId<<1)
+			if coefNum > 64 {
+				coefNum = 64
+			}
+			if sizeId > 1 {
+				r.SE()
+			}
+			for i := 0; i < coefNum && r.Err() == nil; i++ {
+				r.SE()
+			}
+		}
+	}
+}
+
+func skipShortTermRefPicSets(r *bitstream.Reader, count int) {
+	numDeltaPocs := make([]int, count)
+	for idx := 0; idx < count && r.Err() == nil; idx++ {
+		interPrediction := false
+		if idx != 0 {
+			interPrediction = r.Bit()
+		}
+
+		if interPrediction {
+			r.Bit() // delta_rps_sign
+			r.UE()  // abs_delta_rps_minus1
+			ref := numDeltaPocs[idx-1]
+			n := 0
+			for j := 0; j <= ref; j++ {
+				used := r.Bit()
+				if used || r.Bit() {
+					n++
+				}
+			}
+			numDeltaPocs[idx] = n
+		} else {
+			negative := int(r.UE())
+			positive := int(r.UE())
+			for i := 0; i < negative+positive && r.Err() == nil; i++ {
+				r.UE()
+				r.Skip(1)
+			}
+			numDeltaPocs[idx] = negative + positive
+		}
+	}
+}
+
+func ParseSPS(nal NALUnit) (*SPS, error) {
+	if len(nal) < 3 || nal.Type() != SPSNUT {
+		return nil, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	sps := new(SPS)
+	sps.VPSId = r.Uint8(4)
+	sps.MaxSubLayers = r.Uint8(3) + 1
+	sps.TemporalIdNesting = r.Bit()
+	sps.ProfileTierLevel = parseProfileTierLevel(r, int(sps.MaxSubLayers)-1)
+	sps.Id = r.UE()
+	sps.ChromaFormatIdc = r.UE()
+	separateColourPlane := false
+	if sps.ChromaFormatIdc == 3 {
+		separateColourPlane = r.Bit()
+	}
+	sps.Width = int(r.UE())
+	sps.Height = int(r.UE())
+
+	if r.Bit() {
+		subWidth, subHeight := 1, 1
+		if !separateColourPlane && (sps.ChromaFormatIdc == 1 || sps.ChromaFormatIdc == 2) {
+			subWidth = 2
+		}
+		if !separateColourPlane && sps.ChromaFormatIdc == 1 {
+			subHeight = 2
+		}
+
+		left, right := int(r.UE()), int(r.UE())
+		top, bottom := int(r.UE()), int(r.UE())
+		sps.Width -= (left + right) * subWidth
+		sps.Height -= (top + bottom) * subHeight
+	}
+
+	sps.BitDepthLuma = r.UE() + 8
+	sps.BitDepthChroma = r.UE() + 8
+	log2MaxPicOrderCntLsb := int(r.UE()) + 4
+
+	start := int(sps.MaxSubLayers) - 1
+	if r.Bit() { // sps_sub_layer_ordering_info_present_flag
+		start = 0
+	}
+	for i := start; i < int(sps.MaxSubLayers); i++ {
+		r.UE()
+		r.UE()
+		r.UE()
+	}
+
+	for i := 0; i < 6; i++ { // coding/transform block sizes and hierarchy depths
+		r.UE()
+	}
+
+	if r.Bit() && r.Bit() { // scaling_list_enabled_flag, sps_scaling_list_data_present_flag
+		skipScalingListData(r)
+	}
+	r.Skip(2)    // amp_enabled_flag, sample_adaptive_offset_enabled_flag
+	if r.Bit() { // pcm_enabled_flag
+		r.Skip(8)
+		r.UE()
+		r.UE()
+		r.Skip(1)
+	}
+
+	skipShortTermRefPicSets(r, int(r.UE()))
+	if r.Bit() { // long_term_ref_pics_present_flag
+		count := int(r.UE())
+		for i := 0; i < count && r.Err() == nil; i++ {
+			r.Skip(log2MaxPicOrderCntLsb + 1)
+		}
+	}
+	r.Skip(2) // sps_temporal_mvp_enabled_flag, strong_intra_smoothing_enabled_flag
+
+	if r.Bit() {
+		parseVUI(r, sps)
+	}
+
+	return sps, r.Err()
+}
+
+func parseVUI(r *bitstream.Reader, sps *SPS) {
+	if r.Bit() {
+		sps.AspectRatioIdc = r.Uint8(8)
+		if sps.AspectRatioIdc == 255 {
+			sps.SarWidth = r.Uint16(16)
+			sps.SarHeight = r.Uint16(16)
+		} else if int(sps.AspectRatioIdc) < len(sampleAspectRatios) {
+			sar := sampleAspectRatios[sps.AspectRatioIdc]
+			sps.SarWidth, sps.SarHeight = sar[0], sar[1]
+		}
+	}
+
+	if r.Bit() { // overscan_info_present_flag
+		r.Skip(1)
+	}
+
+	if r.Bit() {
+		r.Skip(3) // video_format
+		sps.VideoFullRange = r.Bit()
+		if r.Bit() {
+			sps.ColourPrimaries = r.Uint8(8)
+			sps.TransferCharacteristics = r.Uint8(8)
+			sps.MatrixCoefficients = r.Uint8(8)
+		}
+	}
+
+	if r.Bit() { // chroma_loc_info_present_flag
+		r.UE()
+		r.UE()
+	}
+
+	r.Skip(1) // neutral_chroma_indication_flag
+	sps.FieldSeq = r.Bit()
+	r.Skip(1) // frame_field_info_present_flag
+
+	if r.Bit() { // default_display_window_flag
+		r.UE()
+		r.UE()
+		r.UE()
+		r.UE()
+	}
+
+	if r.Bit() {
+		sps.NumUnitsInTick = r.Uint32(32)
+		sps.TimeScale = r.Uint32(32)
+	}
+}
+
+func (sps *SPS) FrameRate() float64 {
+	if sps.NumUnitsInTick == 0 {
+		return 0
+	}
+
+	rate := float64(sps.TimeScale) / float64(sps.NumUnitsInTick)
+	if sps.FieldSeq {
+		rate /= 2
+	}
+	return rate
+}
+
+func (sps *SPS) HDR() bool {
+	switch sps.TransferCharacteristics {
+	case TransferSMPTE2084, TransferARIBSTDB67:
+		return true
+	}
+
+	return false
+}
+
+type PPS struct {
+	Id    uint32
+	SPSId uint32
+}
+
+func ParsePPS(nal NALUnit) (*PPS, error) {
+	if len(nal) < 3 || nal.Type() != PPSNUT {
+		return nil, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	pps := new(PPS)
+	pps.Id = r.UE()
+	pps.SPSId = r.UE()
+
+	return pps, r.Err()
+}
+
+func SlicePPSId(nal NALUnit) (uint32, error) {
+	if len(nal) < 3 || !nal.Type().IsVCL() {
+		return 0, ErrInvalidNALUnit
+	}
+
+	r := bitstream.NewReader(nal.RBSP())
+	r.Skip(1) // first_slice_segment_in_pic_flag
+	if nal.Type().IsIRAP() {
+		r.Skip(1) // no_output_of_prior_pics_flag
+	}
+	id := r.UE()
+
+	return id, r.Err()
+}
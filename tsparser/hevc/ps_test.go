@@ -0,0 +1,152 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"testing"
+)
+
+var (
+	// Main 10 profile, Main tier, level 5.1.
+	testVPS = NALUnit{
+		0x40, 0x01, 0x0c, 0x01, 0xff, 0xff, 0x02, 0x20, 0x00, 0x00, 0x03, 0x00,
+		0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x99, 0x99, 0xc0, 0x90,
+	}
+	// 3840x2160 10 bits BT.2020 PQ at 59.94 fps with two short term
+	// reference picture sets, the second of which is predicted.
+	testSPS = NALUnit{
+		0x42, 0x01, 0x01, 0x02, 0x20, 0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x03, 0x00, 0x99, 0xa0, 0x01, 0xe0, 0x20, 0x02, 0x1c,
+		0x4d, 0x96, 0x67, 0x92, 0x46, 0xd9, 0xaf, 0x77, 0x80, 0xb5, 0x09, 0x10,
+		0x09, 0x04, 0x00, 0x00, 0x0f, 0xa4, 0x00, 0x03, 0xa9, 0x80, 0x20,
+	}
+	testPPS = NALUnit{0x44, 0x01, 0xc0, 0x71}
+	// Mastering display colour volume and content light level of
+	// 1000/400 cd/m2.
+	hdrSEI = NALUnit{
+		0x4e, 0x01, 0x89, 0x18, 0x21, 0x34, 0x9b, 0xaa, 0x19, 0x96, 0x08, 0xfc,
+		0x8a, 0x48, 0x39, 0x08, 0x3d, 0x13, 0x40, 0x42, 0x00, 0x98, 0x96, 0x80,
+		0x00, 0x00, 0x03, 0x00, 0x32, 0x90, 0x04, 0x03, 0xe8, 0x01, 0x90, 0x80,
+	}
+	accessUnitDelimiter = NALUnit{0x46, 0x01, 0x50}
+	idrSlice            = NALUnit{0x26, 0x01, 0xb5, 0x00, 0x12, 0x34}
+	trailSlice          = NALUnit{0x02, 0x01, 0xd5, 0x56}
+	// The second slice segment of a picture.
+	trailSlice2 = NALUnit{0x02, 0x01, 0x55, 0x78}
+)
+
+func TestNALUnitHeader(t *testing.T) {
+	tests := []struct {
+		nal        NALUnit
+		valid      bool
+		typ        NALUnitType
+		layerId    uint8
+		temporalId uint8
+	}{
+		{testSPS, true, SPSNUT, 0, 0},
+		{idrSlice, true, IDRWRADL, 0, 0},
+		{NALUnit{0x02, 0x03}, true, TrailR, 0, 2},
+		{NALUnit{0x03, 0x09}, true, TrailR, 33, 0},
+		{NALUnit{0x02, 0x00}, false, TrailR, 0, 0},
+		{NALUnit{0x82, 0x01}, false, TrailR, 0, 0},
+		{NALUnit{0x02}, false, TrailR, 0, 0},
+	}
+
+	for i, test := range tests {
+		if test.nal.IsValid() != test.valid {
+			t.Errorf("%d: valid = %v, want %v", i, test.nal.IsValid(), test.valid)
+		}
+		if !test.valid {
+			continue
+		}
+		if test.nal.Type() != test.typ || test.nal.LayerId() != test.layerId || test.nal.TemporalId() != test.temporalId {
+			t.Errorf("%d: type = %d, layer = %d, temporal = %d", i, test.nal.Type(), test.nal.LayerId(), test.nal.TemporalId())
+		}
+	}
+}
+
+func TestParseVPS(t *testing.T) {
+	vps, err := ParseVPS(testVPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vps.Id != 0 || vps.MaxLayers != 1 || vps.MaxSubLayers != 1 || !vps.TemporalIdNesting {
+		t.Errorf("VPS = %+v", vps)
+	}
+	ptl := vps.ProfileTierLevel
+	if ptl.ProfileIdc != 2 || ptl.Tier || ptl.CompatibilityFlags != 0x20000000 || ptl.LevelIdc != 153 {
+		t.Errorf("profile_tier_level = %+v", ptl)
+	}
+	if !ptl.ProgressiveSource || ptl.InterlacedSource || !ptl.FrameOnly {
+		t.Errorf("profile_tier_level = %+v", ptl)
+	}
+}
+
+func TestParseSPS(t *testing.T) {
+	sps, err := ParseSPS(testSPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sps.ProfileTierLevel.ProfileIdc != 2 || sps.ProfileTierLevel.LevelIdc != 153 {
+		t.Errorf("profile_tier_level = %+v", sps.ProfileTierLevel)
+	}
+	if sps.Width != 3840 || sps.Height != 2160 || sps.ChromaFormatIdc != 1 {
+		t.Errorf("size = %dx%d, chroma_format_idc = %d", sps.Width, sps.Height, sps.ChromaFormatIdc)
+	}
+	if sps.BitDepthLuma != 10 || sps.BitDepthChroma != 10 {
+		t.Errorf("bit depth = %d/%d", sps.BitDepthLuma, sps.BitDepthChroma)
+	}
+	if sps.SarWidth != 1 || sps.SarHeight != 1 {
+		t.Errorf("SAR = %d:%d", sps.SarWidth, sps.SarHeight)
+	}
+	if sps.ColourPrimaries != ColourPrimariesBT2020 || sps.TransferCharacteristics != TransferSMPTE2084 || sps.MatrixCoefficients != 9 {
+		t.Errorf("colour = %d/%d/%d", sps.ColourPrimaries, sps.TransferCharacteristics, sps.MatrixCoefficients)
+	}
+	if !sps.HDR() {
+		t.Error("HDR is not detected")
+	}
+	if rate := sps.FrameRate(); rate < 59.94 || rate > 59.95 {
+		t.Errorf("frame rate = %f", rate)
+	}
+
+	pps, err := ParsePPS(testPPS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pps.Id != 0 || pps.SPSId != 0 {
+		t.Errorf("PPS = %+v", pps)
+	}
+	if id, err := SlicePPSId(idrSlice); err != nil || id != 0 {
+		t.Errorf("slice_pic_parameter_set_id = %d, %v", id, err)
+	}
+}
+
+func TestParseHDRSEI(t *testing.T) {
+	messages := ParseSEI(hdrSEI)
+	if len(messages) != 2 {
+		t.Fatalf("%d messages, want 2", len(messages))
+	}
+
+	m := ParseMasteringDisplayColourVolume(messages[0].Payload)
+	expected := MasteringDisplayColourVolume{
+		DisplayPrimariesX: [3]uint16{8500, 6550, 35400},
+		DisplayPrimariesY: [3]uint16{39850, 2300, 14600},
+		WhitePointX:       15635,
+		WhitePointY:       16450,
+		MaxLuminance:      10000000,
+		MinLuminance:      50,
+	}
+	if messages[0].PayloadType != MasteringDisplayColourVolumeSEI || m == nil || *m != expected {
+		t.Errorf("mastering display colour volume = %+v", m)
+	}
+
+	cll := ParseContentLightLevel(messages[1].Payload)
+	if messages[1].PayloadType != ContentLightLevelInformationSEI || cll == nil ||
+		cll.MaxContentLightLevel != 1000 || cll.MaxPicAverageLightLevel != 400 {
+		t.Errorf("content light level = %+v", cll)
+	}
+}
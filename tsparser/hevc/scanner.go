@@ -0,0 +1,250 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type AccessUnit struct {
+	NALUnits []NALUnit
+
+	PTS    uint64
+	DTS    uint64
+	HasPTS bool
+	Offset int64
+
+	VPS        *VPS
+	SPS        *SPS
+	Type       NALUnitType
+	TemporalId uint8
+
+	MasteringDisplay  *MasteringDisplayColourVolume
+	ContentLightLevel *ContentLightLevel
+
+	hasSlice bool
+}
+
+func (au *AccessUnit) IRAP() bool {
+	return au.Type.IsIRAP()
+}
+
+func (au *AccessUnit) RandomAccess() bool {
+	return au.IRAP()
+}
+
+type origin struct {
+	pts    uint64
+	dts    uint64
+	hasPTS bool
+	offset int64
+}
+
+type AccessUnitParser struct {
+	logger *log.Logger
+
+	vpss map[uint8]*VPS
+	spss map[uint32]*SPS
+	ppss map[uint32]*PPS
+
+	splitter bitstream.AnnexBSplitter
+	current  *AccessUnit
+	queue    []*AccessUnit
+}
+
+func NewAccessUnitParser(logger *log.Logger) *AccessUnitParser {
+	return &AccessUnitParser{
+		logger: logger,
+		vpss:   make(map[uint8]*VPS),
+		spss:   make(map[uint32]*SPS),
+		ppss:   make(map[uint32]*PPS),
+	}
+}
+
+func (p *AccessUnitParser) log(v ...interface{}) {
+	if p.logger != nil {
+		p.logger.Print(v...)
+	}
+}
+
+// Push feeds the payload of a PES packet found at offset.  Completed
+// access units are returned by AccessUnits.
+func (p *AccessUnitParser) Push(pes tsparser.PES, offset int64) {
+	o := origin{pes.PTS(), pes.DTS(), pes.HasPTS(), offset}
+	p.splitter.Push(pes.Payload(), o, p.handleUnit)
+}
+
+// Flush completes the access unit in progress at the end of the stream.
+func (p *AccessUnitParser) Flush() {
+	p.splitter.Flush(p.handleUnit)
+	p.finish()
+}
+
+func (p *AccessUnitParser) AccessUnits() []*AccessUnit {
+	queue := p.queue
+	p.queue = nil
+	return queue
+}
+
+type AccessUnitScanner struct {
+	s      *tsparser.PESScanner
+	pid    tsparser.PID
+	parser *AccessUnitParser
+
+	queue []*AccessUnit
+	au    *AccessUnit
+	eof   bool
+}
+
+func NewAccessUnitScanner(s *tsparser.PESScanner, pid tsparser.PID, logger *log.Logger) *AccessUnitScanner {
+	return &AccessUnitScanner{
+		s:      s,
+		pid:    pid,
+		parser: NewAccessUnitParser(logger),
+	}
+}
+
+func (s *AccessUnitScanner) Scan() bool {
+	for len(s.queue) == 0 {
+		if s.eof {
+			return false
+		}
+
+		if !s.s.Scan() {
+			s.eof = true
+			s.parser.Flush()
+		} else if s.s.PID() == s.pid {
+			s.parser.Push(s.s.PES(), s.s.Offset())
+		}
+		s.queue = append(s.queue, s.parser.AccessUnits()...)
+	}
+
+	s.au, s.queue = s.queue[0], s.queue[1:]
+	return true
+}
+
+func (s *AccessUnitScanner) AccessUnit() *AccessUnit {
+	return s.au
+}
+
+func (p *AccessUnitParser) handleUnit(unit []byte, tag interface{}) {
+	p.handle(NALUnit(unit), tag.(origin))
+}
+
+func (p *AccessUnitParser) finish() {
+	if p.current != nil && p.current.hasSlice {
+		p.queue = append(p.queue, p.current)
+	}
+	p.current = nil
+}
+
+func (p *AccessUnitParser) begin(o origin) {
+	p.finish()
+	p.current = &AccessUnit{
+		PTS:    o.pts,
+		DTS:    o.dts,
+		HasPTS: o.hasPTS,
+		Offset: o.offset,
+	}
+}
+
+func (p *AccessUnitParser) handle(nal NALUnit, o origin) {
+	if !nal.IsValid() {
+		return
+	}
+
+	t := nal.Type()
+	switch {
+	case t == AUDNUT, t == VPSNUT, t == SPSNUT, t == PPSNUT, t == PrefixSEINUT, 41 <= t && t <= 44, 48 <= t && t <= 55:
+		if p.current == nil || p.current.hasSlice {
+			p.begin(o)
+		}
+	case t.IsVCL():
+		if p.current == nil || p.current.hasSlice && nal.FirstSliceSegmentInPic() {
+			p.begin(o)
+		}
+	}
+
+	if p.current == nil {
+		return
+	}
+	au := p.current
+	au.NALUnits = append(au.NALUnits, nal)
+
+	switch {
+	case t.IsVCL():
+		if !au.hasSlice {
+			au.Type = t
+			au.TemporalId = nal.TemporalId()
+			au.SPS = p.activeSPS(nal)
+			if au.SPS != nil {
+				au.VPS = p.vpss[au.SPS.VPSId]
+			}
+		}
+		au.hasSlice = true
+	case t == VPSNUT:
+		if vps, err := ParseVPS(nal); err == nil {
+			p.vpss[vps.Id] = vps
+		} else {
+			p.log(err)
+		}
+	case t == SPSNUT:
+		if sps, err := ParseSPS(nal); err == nil {
+			p.spss[sps.Id] = sps
+		} else {
+			p.log(err)
+		}
+	case t == PPSNUT:
+		if pps, err := ParsePPS(nal); err == nil {
+			p.ppss[pps.Id] = pps
+		} else {
+			p.log(err)
+		}
+	case t == PrefixSEINUT, t == SuffixSEINUT:
+		for _, message := range ParseSEI(nal) {
+			switch message.PayloadType {
+			case MasteringDisplayColourVolumeSEI:
+				au.MasteringDisplay = ParseMasteringDisplayColourVolume(message.Payload)
+			case ContentLightLevelInformationSEI:
+				au.ContentLightLevel = ParseContentLightLevel(message.Payload)
+			}
+		}
+	case t == EOSNUT, t == EOBNUT:
+		p.finish()
+	}
+}
+
+func (p *AccessUnitParser) activeSPS(nal NALUnit) *SPS {
+	id, err := SlicePPSId(nal)
+	if err != nil {
+		p.log(err)
+		return nil
+	}
+
+	pps, ok := p.ppss[id]
+	if !ok {
+		return nil
+	}
+	return p.spss[pps.SPSId]
+}
+
+func IsRandomAccess(data []byte) bool {
+	units, rest := bitstream.SplitAnnexB(data)
+	if len(rest) > 3 {
+		units = append(units, rest[3:])
+	}
+
+	for _, unit := range units {
+		nal := NALUnit(unit)
+		if len(nal) >= 2 && nal.Type().IsIRAP() {
+			return true
+		}
+	}
+
+	return false
+}
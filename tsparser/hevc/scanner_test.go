@@ -0,0 +1,124 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+// invalidNAL has nuh_temporal_id_plus1 of 0, which is dropped.
+var invalidNAL = NALUnit{0x02, 0x00, 0xaa}
+
+func TestAccessUnitScanner(t *testing.T) {
+	ts := fixture.Packetize(0x0100,
+		fixture.PES(0xe0, 90000, fixture.AnnexB(accessUnitDelimiter, testVPS, testSPS, testPPS, hdrSEI, idrSlice)),
+		fixture.PES(0xe0, 91501, fixture.AnnexB(accessUnitDelimiter, trailSlice, invalidNAL, trailSlice2)),
+		fixture.PES(0xe0, 93003, fixture.AnnexB(accessUnitDelimiter, trailSlice)),
+	)
+
+	s := NewAccessUnitScanner(tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(ts), nil), nil), 0x0100, nil)
+	var aus []*AccessUnit
+	for s.Scan() {
+		aus = append(aus, s.AccessUnit())
+	}
+	if len(aus) != 3 {
+		t.Fatalf("%d access units, want 3", len(aus))
+	}
+
+	tests := []struct {
+		pts   uint64
+		units int
+		typ   NALUnitType
+		irap  bool
+	}{
+		{90000, 6, IDRWRADL, true},
+		{91501, 3, TrailR, false},
+		{93003, 2, TrailR, false},
+	}
+	for i, test := range tests {
+		au := aus[i]
+		if !au.HasPTS || au.PTS != test.pts {
+			t.Errorf("%d: PTS = %d (%v)", i, au.PTS, au.HasPTS)
+		}
+		if len(au.NALUnits) != test.units {
+			t.Errorf("%d: %d NAL units, want %d", i, len(au.NALUnits), test.units)
+		}
+		if au.Type != test.typ || au.IRAP() != test.irap || au.RandomAccess() != test.irap {
+			t.Errorf("%d: type = %d, IRAP = %v", i, au.Type, au.IRAP())
+		}
+		if au.SPS == nil || au.SPS.Width != 3840 || au.VPS == nil {
+			t.Errorf("%d: SPS = %+v, VPS = %+v", i, au.SPS, au.VPS)
+		}
+	}
+
+	if aus[0].MasteringDisplay == nil || aus[0].ContentLightLevel == nil {
+		t.Error("HDR metadata is not found")
+	}
+}
+
+func TestAccessUnitParser(t *testing.T) {
+	p := NewAccessUnitParser(nil)
+	p.Push(fixture.PES(0xe0, 90000, fixture.AnnexB(accessUnitDelimiter, testVPS, testSPS, testPPS, hdrSEI, idrSlice)), 0)
+	p.Push(fixture.PES(0xe0, 91501, fixture.AnnexB(accessUnitDelimiter, trailSlice, invalidNAL, trailSlice2)), 188)
+	p.Push(fixture.PES(0xe0, 93003, fixture.AnnexB(accessUnitDelimiter, trailSlice)), 376)
+	p.Flush()
+
+	aus := p.AccessUnits()
+	if len(aus) != 3 {
+		t.Fatalf("%d access units, want 3", len(aus))
+	}
+
+	tests := []struct {
+		pts    uint64
+		offset int64
+		units  int
+		typ    NALUnitType
+		irap   bool
+	}{
+		{90000, 0, 6, IDRWRADL, true},
+		{91501, 188, 3, TrailR, false},
+		{93003, 376, 2, TrailR, false},
+	}
+	for i, test := range tests {
+		au := aus[i]
+		if !au.HasPTS || au.PTS != test.pts || au.Offset != test.offset {
+			t.Errorf("%d: PTS = %d (%v), offset = %d", i, au.PTS, au.HasPTS, au.Offset)
+		}
+		if len(au.NALUnits) != test.units {
+			t.Errorf("%d: %d NAL units, want %d", i, len(au.NALUnits), test.units)
+		}
+		if au.Type != test.typ || au.IRAP() != test.irap || au.RandomAccess() != test.irap {
+			t.Errorf("%d: type = %d, IRAP = %v", i, au.Type, au.IRAP())
+		}
+		if au.SPS == nil || au.SPS.Width != 3840 || au.VPS == nil {
+			t.Errorf("%d: SPS = %+v, VPS = %+v", i, au.SPS, au.VPS)
+		}
+	}
+
+	if aus[0].MasteringDisplay == nil || aus[0].ContentLightLevel == nil {
+		t.Error("HDR metadata is not found")
+	}
+}
+
+func TestIsRandomAccess(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected bool
+	}{
+		{fixture.AnnexB(accessUnitDelimiter, testVPS, testSPS, testPPS, idrSlice), true},
+		{fixture.AnnexB(accessUnitDelimiter, trailSlice), false},
+		{fixture.AnnexB(NALUnit{0x2a, 0x01, 0x80}), true},
+	}
+
+	for i, test := range tests {
+		if got := IsRandomAccess(test.data); got != test.expected {
+			t.Errorf("%d: IsRandomAccess = %v, want %v", i, got, test.expected)
+		}
+	}
+}
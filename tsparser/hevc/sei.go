@@ -0,0 +1,67 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hevc
+
+import (
+	"github.com/yosida95/tsparser/tsparser/h264"
+)
+
+const (
+	RecoveryPointSEI                      h264.SEIPayloadType = 6
+	MasteringDisplayColourVolumeSEI       h264.SEIPayloadType = 137
+	ContentLightLevelInformationSEI       h264.SEIPayloadType = 144
+	AlternativeTransferCharacteristicsSEI h264.SEIPayloadType = 147
+)
+
+type MasteringDisplayColourVolume struct {
+	DisplayPrimariesX [3]uint16
+	DisplayPrimariesY [3]uint16
+	WhitePointX       uint16
+	WhitePointY       uint16
+	MaxLuminance      uint32
+	MinLuminance      uint32
+}
+
+func ParseMasteringDisplayColourVolume(payload []byte) *MasteringDisplayColourVolume {
+	if len(payload) < 24 {
+		return nil
+	}
+
+	m := new(MasteringDisplayColourVolume)
+	for i := 0; i < 3; i++ {
+		m.DisplayPrimariesX[i] = uint16(payload[i*4])<<8 | uint16(payload[i*4+1])
+		m.DisplayPrimariesY[i] = uint16(payload[i*4+2])<<8 | uint16(payload[i*4+3])
+	}
+	m.WhitePointX = uint16(payload[12])<<8 | uint16(payload[13])
+	m.WhitePointY = uint16(payload[14])<<8 | uint16(payload[15])
+	m.MaxLuminance = uint32(payload[16])<<24 | uint32(payload[17])<<16 | uint32(payload[18])<<8 | uint32(payload[19])
+	m.MinLuminance = uint32(payload[20])<<24 | uint32(payload[21])<<16 | uint32(payload[22])<<8 | uint32(payload[23])
+
+	return m
+}
+
+type ContentLightLevel struct {
+	MaxContentLightLevel    uint16
+	MaxPicAverageLightLevel uint16
+}
+
+func ParseContentLightLevel(payload []byte) *ContentLightLevel {
+	if len(payload) < 4 {
+		return nil
+	}
+
+	return &ContentLightLevel{
+		MaxContentLightLevel:    uint16(payload[0])<<8 | uint16(payload[1]),
+		MaxPicAverageLightLevel: uint16(payload[2])<<8 | uint16(payload[3]),
+	}
+}
+
+func ParseSEI(nal NALUnit) []h264.SEIMessage {
+	if len(nal) < 3 || (nal.Type() != PrefixSEINUT && nal.Type() != SuffixSEINUT) {
+		return nil
+	}
+
+	return h264.ParseSEIMessages(nal.RBSP())
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hls
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const programDateTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+type Segment struct {
+	Sequence        int
+	URI             string
+	Duration        time.Duration
+	Discontinuity   bool
+	ProgramDateTime time.Time
+}
+
+type Playlist struct {
+	targetDuration        time.Duration
+	windowSize            int
+	mediaSequence         int
+	discontinuitySequence int
+	segments              []*Segment
+	ended                 bool
+}
+
+// NewPlaylist returns a playlist which keeps the last windowSize segments
+// as a live sliding window, or every segment as VOD when windowSize is 0.
+func NewPlaylist(targetDuration time.Duration, windowSize int) *Playlist {
+	return &Playlist{
+		targetDuration: targetDuration,
+		windowSize:     windowSize,
+	}
+}
+
+func (p *Playlist) IsVOD() bool {
+	return p.windowSize == 0
+}
+
+func (p *Playlist) TargetDuration() time.Duration {
+	return p.targetDuration
+}
+
+func (p *Playlist) MediaSequence() int {
+	return p.mediaSequence
+}
+
+func (p *Playlist) Segments() []*Segment {
+	return p.segments
+}
+
+// Duration returns the total duration of the segments in the playlist.
+func (p *Playlist) Duration() time.Duration {
+	var d time.Duration
+	for _, s := range p.segments {
+		d += s.Duration
+	}
+
+	return d
+}
+
+func (p *Playlist) Ended() bool {
+	return p.ended
+}
+
+func (p *Playlist) End() {
+	p.ended = true
+}
+
+// Append adds the segment and returns the segments slid out of the window.
+func (p *Playlist) Append(segment *Segment) (removed []*Segment) {
+	if len(p.segments) == 0 {
+		p.mediaSequence = segment.Sequence
+	}
+	p.segments = append(p.segments, segment)
+
+	if p.windowSize > 0 && len(p.segments) > p.windowSize {
+		n := len(p.segments) - p.windowSize
+		removed = append(removed, p.segments[:n]...)
+		p.segments = p.segments[n:]
+		p.mediaSequence = p.segments[0].Sequence
+
+		for _, s := range removed {
+			if s.Discontinuity {
+				p.discontinuitySequence++
+			}
+		}
+	}
+
+	return
+}
+
+func roundSeconds(d time.Duration) int {
+	return int(math.Floor(d.Seconds() + 0.5))
+}
+
+func (p *Playlist) targetDurationSeconds() int {
+	target := int(math.Ceil(p.targetDuration.Seconds()))
+	for _, s := range p.segments {
+		if d := roundSeconds(s.Duration); d > target {
+			target = d
+		}
+	}
+
+	return target
+}
+
+func (p *Playlist) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&buf, "#EXT-X-TARGETDURATION:%d\n", p.targetDurationSeconds())
+	fmt.Fprintf(&buf, "#EXT-X-MEDIA-SEQUENCE:%d\n", p.mediaSequence)
+	if p.discontinuitySequence > 0 {
+		fmt.Fprintf(&buf, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", p.discontinuitySequence)
+	}
+	if p.IsVOD() {
+		buf.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	}
+
+	for _, s := range p.segments {
+		if s.Discontinuity {
+			buf.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if !s.ProgramDateTime.IsZero() {
+			fmt.Fprintf(&buf, "#EXT-X-PROGRAM-DATE-TIME:%s\n", s.ProgramDateTime.Format(programDateTimeLayout))
+		}
+		fmt.Fprintf(&buf, "#EXTINF:%.3f,\n%s\n", s.Duration.Seconds(), s.URI)
+	}
+
+	if p.ended {
+		buf.WriteString("#EXT-X-ENDLIST\n")
+	}
+
+	return buf.WriteTo(w)
+}
@@ -0,0 +1,439 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/video"
+)
+
+var ErrNoSegment = errors.New("No segment is written")
+
+const maxInterval = tsparser.ClockRate / 10
+
+type SegmentCreator func(sequence int) (w io.WriteCloser, uri string, err error)
+
+type switchWriter struct {
+	w io.Writer
+}
+
+func (w *switchWriter) Write(b []byte) (int, error) {
+	return w.w.Write(b)
+}
+
+type Segmenter struct {
+	s        tsparser.PacketStream
+	program  *tsparser.ProgramTracker
+	playlist *Playlist
+	create   SegmentCreator
+	update   func(*Playlist) error
+	logger   *log.Logger
+
+	collector *tsparser.TableCollector
+	pat       tsparser.Table
+	pmtTable  tsparser.Table
+	pmt       *tsparser.ProgramMapSection
+	key       *tsparser.ElementaryStream
+	pids      map[tsparser.PID]bool
+
+	timeline   *tsparser.Timeline
+	pendingGap bool
+
+	out      switchWriter
+	writer   *tsparser.PacketWriter
+	current  *Segment
+	closer   io.Closer
+	sequence int
+	startPTS uint64
+	lastPTS  uint64
+	prevPTS  uint64
+	interval uint64
+	started  map[tsparser.PID]bool
+
+	buffer []tsparser.Packet
+}
+
+func NewSegmenter(s tsparser.PacketStream, serviceId uint16, playlist *Playlist, create SegmentCreator, logger *log.Logger) *Segmenter {
+	sg := &Segmenter{
+		s:         s,
+		program:   tsparser.NewProgramTracker(serviceId),
+		playlist:  playlist,
+		create:    create,
+		logger:    logger,
+		collector: tsparser.NewTableCollector(),
+		timeline:  tsparser.NewTimeline(tsparser.NullPID),
+		pids:      make(map[tsparser.PID]bool),
+		started:   make(map[tsparser.PID]bool),
+	}
+	sg.writer = tsparser.NewPacketWriter(&sg.out)
+
+	return sg
+}
+
+func (sg *Segmenter) log(p tsparser.Packet, v ...interface{}) {
+	if sg.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	sg.logger.Print(values...)
+}
+
+// SetPlaylistHandler sets the function which is called with the playlist
+// every time a segment is completed and once more at the end of the stream.
+func (sg *Segmenter) SetPlaylistHandler(fn func(*Playlist) error) {
+	sg.update = fn
+}
+
+func (sg *Segmenter) Playlist() *Playlist {
+	return sg.playlist
+}
+
+func (sg *Segmenter) Run() error {
+	for sg.s.Scan() {
+		if err := sg.handle(sg.s.Packet()); err != nil {
+			return err
+		}
+	}
+
+	if err := sg.flush(); err != nil {
+		return err
+	}
+	if sg.current == nil {
+		return ErrNoSegment
+	}
+
+	if err := sg.closeSegment(0, true); err != nil {
+		return err
+	}
+
+	sg.playlist.End()
+	return sg.notify()
+}
+
+func (sg *Segmenter) handle(packet tsparser.Packet) error {
+	pid := packet.PID()
+	switch {
+	case pid == tsparser.ProgramAssociationPID, pid == sg.program.PMTPID(), pid == arib.TimeDatePID:
+		sg.handleTables(packet)
+	}
+
+	isKeyStart := sg.key != nil && pid == sg.key.PID() && packet.PayloadUnitStartIndicator()
+	if isKeyStart {
+		if err := sg.flush(); err != nil {
+			return err
+		}
+	}
+
+	if sg.timeline.Feed(packet) {
+		sg.log(packet, "PCR discontinuity")
+		sg.discontinue()
+	}
+
+	if !sg.pids[pid] || sg.key == nil {
+		return nil
+	} else if len(sg.buffer) == 0 && sg.current == nil && !isKeyStart {
+		return nil
+	}
+
+	copied := make(tsparser.Packet, len(packet))
+	copy(copied, packet)
+	sg.buffer = append(sg.buffer, copied)
+	return nil
+}
+
+// flush decides which segment the buffered access unit of the key stream
+// belongs to and writes it out.
+func (sg *Segmenter) flush() error {
+	if len(sg.buffer) == 0 {
+		return nil
+	}
+
+	buffer := sg.buffer
+	sg.buffer = sg.buffer[:0]
+	var err error
+
+	first := tsparser.PES(buffer[0].Payload())
+	if len(first) < 14 || !first.HasPTS() {
+		return sg.writePackets(buffer)
+	}
+	pts := first.PTS()
+
+	if sg.isRandomAccess(buffer) {
+		switch {
+		case sg.current == nil:
+			if err := sg.openSegment(pts, false); err != nil {
+				return err
+			}
+		case sg.pendingGap:
+			if buffer, err = sg.carry(buffer); err != nil {
+				return err
+			}
+			if err := sg.closeSegment(0, true); err != nil {
+				return err
+			}
+			if err := sg.openSegment(pts, true); err != nil {
+				return err
+			}
+		case ptsDuration(pts, sg.startPTS) >= sg.playlist.TargetDuration():
+			if buffer, err = sg.carry(buffer); err != nil {
+				return err
+			}
+			if err := sg.closeSegment(pts, false); err != nil {
+				return err
+			}
+			if err := sg.openSegment(pts, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if sg.current == nil {
+		return nil
+	}
+
+	if delta := (pts - sg.prevPTS) & tsparser.TimestampMask; delta > 0 && delta < maxInterval {
+		if sg.interval == 0 || delta < sg.interval {
+			sg.interval = delta
+		}
+	}
+	if !sg.pendingGap && ptsOffset(pts, sg.lastPTS) > 0 {
+		sg.lastPTS = pts
+	}
+	sg.prevPTS = pts
+
+	return sg.writePackets(buffer)
+}
+
+func (sg *Segmenter) isRandomAccess(buffer []tsparser.Packet) bool {
+	if !video.IsSupported(sg.key.StreamType()) {
+		return true
+	}
+
+	data := make([]byte, 0, len(buffer)*tsparser.PacketPayloadSize)
+	for _, packet := range buffer {
+		if packet.PID() == sg.key.PID() {
+			data = append(data, packet.Payload()...)
+		}
+	}
+	return video.IsRandomAccess(sg.key.StreamType(), tsparser.PES(data).Payload())
+}
+
+// carry writes the packets of buffer which continue the PES started in
+// the current segment into it before the segment is closed, so that such a
+// PES is not split at the boundary, and returns the rest of buffer.
+func (sg *Segmenter) carry(buffer []tsparser.Packet) ([]tsparser.Packet, error) {
+	restarted := make(map[tsparser.PID]bool)
+	rest := buffer[:0]
+	for _, packet := range buffer {
+		pid := packet.PID()
+		if packet.PayloadUnitStartIndicator() {
+			restarted[pid] = true
+		}
+
+		if restarted[pid] || !packet.HasPayload() || !sg.started[pid] {
+			rest = append(rest, packet)
+		} else if err := sg.writer.WritePacket(packet); err != nil {
+			return nil, err
+		}
+	}
+
+	return rest, nil
+}
+
+func (sg *Segmenter) writePackets(packets []tsparser.Packet) error {
+	for _, packet := range packets {
+		pid := packet.PID()
+		if !sg.started[pid] {
+			if packet.HasPayload() && !packet.PayloadUnitStartIndicator() {
+				continue
+			}
+			sg.started[pid] = packet.HasPayload()
+		}
+
+		if err := sg.writer.WritePacket(packet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sg *Segmenter) openSegment(pts uint64, discontinuity bool) error {
+	w, uri, err := sg.create(sg.sequence)
+	if err != nil {
+		return err
+	}
+
+	sg.current = &Segment{
+		Sequence:      sg.sequence,
+		URI:           uri,
+		Discontinuity: discontinuity,
+	}
+	sg.closer = w
+	sg.out.w = w
+	sg.sequence++
+	sg.startPTS, sg.lastPTS = pts, pts
+	sg.pendingGap = false
+
+	if err := sg.writer.WriteTables(tsparser.ProgramAssociationPID, sg.pat); err != nil {
+		return err
+	}
+	if err := sg.writer.WriteTables(sg.program.PMTPID(), sg.pmtTable); err != nil {
+		return err
+	}
+
+	// Every segment starts the streams at their PES boundaries.
+	sg.started = map[tsparser.PID]bool{
+		tsparser.ProgramAssociationPID: true,
+		sg.program.PMTPID():            true,
+	}
+
+	return nil
+}
+
+// closeSegment completes the current segment.  The duration is measured up
+// to end, or estimated from the last access unit when estimate is set.
+func (sg *Segmenter) closeSegment(end uint64, estimate bool) error {
+	segment := sg.current
+	if estimate {
+		end = sg.lastPTS + sg.interval
+	}
+	segment.Duration = ptsDuration(end, sg.startPTS)
+
+	if t, ok := sg.timeline.WallClock(sg.timeline.Timestamp(sg.startPTS)); ok {
+		segment.ProgramDateTime = t
+	}
+
+	sg.current = nil
+	if err := sg.closer.Close(); err != nil {
+		return err
+	}
+
+	sg.playlist.Append(segment)
+	return sg.notify()
+}
+
+func (sg *Segmenter) notify() error {
+	if sg.update == nil {
+		return nil
+	}
+
+	return sg.update(sg.playlist)
+}
+
+func (sg *Segmenter) discontinue() {
+	if sg.current != nil {
+		sg.pendingGap = true
+	}
+}
+
+func (sg *Segmenter) handleTables(packet tsparser.Packet) {
+	tables, err := sg.collector.Feed(packet)
+	if err != nil {
+		sg.log(packet, err)
+	}
+
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			sg.log(packet, "CRC mismatch")
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			sg.handleProgramAssociation(table)
+		case tsparser.ProgramMapTable:
+			if table.TableIdExtension() == sg.program.ProgramNumber() {
+				sg.handleProgramMap(packet, table)
+			}
+		case arib.TimeOffsetTable, arib.TimeDateTable:
+			sg.handleTime(arib.ParseTimeDateSection(table))
+		}
+	}
+}
+
+// handleTime anchors the wall clock of a TOT at the PTS of the access unit
+// of the key stream being buffered when the TOT arrives, which begins
+// sg.buffer.  Between access units, the latest PTS written is taken.
+func (sg *Segmenter) handleTime(now time.Time) {
+	if sg.current == nil || sg.pendingGap {
+		return
+	}
+
+	pts := sg.lastPTS
+	if len(sg.buffer) > 0 && sg.buffer[0].PID() == sg.key.PID() {
+		if pes := tsparser.PES(sg.buffer[0].Payload()); len(pes) >= 14 && pes.HasPTS() {
+			pts = pes.PTS()
+		}
+	}
+	sg.timeline.AddTimeSampleAt(sg.timeline.Timestamp(pts), now)
+}
+
+func (sg *Segmenter) handleProgramAssociation(table tsparser.Table) {
+	pat := tsparser.ParseProgramAssociationSection(table)
+	found, changed := sg.program.Update(pat, sg.collector)
+	if !found {
+		return
+	}
+
+	rewritten := tsparser.NewProgramAssociationSection(pat.TransportStreamId())
+	rewritten.SetVersionNumber(pat.VersionNumber())
+	rewritten.SetProgram(sg.program.ProgramNumber(), sg.program.PMTPID())
+	if result, err := rewritten.Marshal(); err == nil {
+		sg.pat = result
+	}
+
+	if changed {
+		sg.pmt, sg.pmtTable = nil, nil
+	}
+}
+
+func (sg *Segmenter) handleProgramMap(packet tsparser.Packet, table tsparser.Table) {
+	pmt := tsparser.ParseProgramMapSection(table)
+	sg.pmtTable = table
+	if sg.pmt != nil && sg.pmt.VersionNumber() == pmt.VersionNumber() {
+		return
+	} else if sg.pmt != nil {
+		sg.log(packet, "PMT version changed")
+		sg.discontinue()
+	}
+	sg.pmt = pmt
+	sg.timeline.SetPCRPID(pmt.PCRPID())
+
+	pids := make(map[tsparser.PID]bool)
+	pids[pmt.PCRPID()] = true
+	sg.key = video.FindStream(pmt)
+	for _, es := range pmt.Streams() {
+		pids[es.PID()] = true
+		if sg.key == nil && es.StreamType().IsAudio() {
+			sg.key = es
+		}
+	}
+
+	delete(pids, tsparser.NullPID)
+	sg.pids = pids
+}
+
+func ptsOffset(a, b uint64) time.Duration {
+	return time.Duration(tsparser.TimestampDiff(a, b)) * time.Second / tsparser.ClockRate
+}
+
+func ptsDuration(end, start uint64) time.Duration {
+	if d := ptsOffset(end, start); d > 0 {
+		return d
+	}
+
+	return 0
+}
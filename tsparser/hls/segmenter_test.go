@@ -0,0 +1,306 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package hls
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/arib"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	idrPicture = fixture.AnnexB([]byte{0x09, 0xf0}, []byte{0x65, 0x88, 0x80})
+	pPicture   = fixture.AnnexB([]byte{0x09, 0xf0}, []byte{0x41, 0x9a})
+)
+
+type segmentBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *segmentBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+// testStream makes a program of an H.264 stream with an IDR picture every 3
+// pictures and an ADTS stream.  extra is called after each picture.
+func testStream(t *testing.T, pictures int, extra func(w *tsparser.PacketWriter, i int)) []byte {
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	pat.SetProgram(2, 0x1010)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.AACADTSStream, 0x0110))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := tsparser.NewPacketWriter(&buf)
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTable)
+	for i := 0; i < pictures; i++ {
+		picture := pPicture
+		if i%3 == 0 {
+			picture = idrPicture
+		}
+
+		pts := uint64(90000 + i*3003)
+		w.WriteUnit(0x0100, nil, fixture.PES(0xe0, pts, picture))
+		if extra != nil {
+			extra(w, i)
+		}
+		w.WriteUnit(0x0110, nil, fixture.PES(0xc0, pts, []byte{0xff, 0xf1}))
+		// A stream of the other program is never written.
+		w.WriteUnit(0x0200, nil, fixture.PES(0xe0, pts, picture))
+	}
+	return buf.Bytes()
+}
+
+func segmentPackets(data []byte) []tsparser.Packet {
+	var packets []tsparser.Packet
+	s := tsparser.NewPacketScanner(bytes.NewReader(data), nil)
+	for s.Scan() {
+		packets = append(packets, append(tsparser.Packet(nil), s.Packet()...))
+	}
+	return packets
+}
+
+func TestSegmenter(t *testing.T) {
+	var segments []*segmentBuffer
+	create := func(sequence int) (io.WriteCloser, string, error) {
+		segment := &segmentBuffer{}
+		segments = append(segments, segment)
+		return segment, fmt.Sprintf("segment%d.ts", sequence), nil
+	}
+
+	playlist := NewPlaylist(100*time.Millisecond, 0)
+	sg := NewSegmenter(tsparser.NewPacketScanner(bytes.NewReader(testStream(t, 8, nil)), nil), 0, playlist, create, nil)
+	var updates int
+	sg.SetPlaylistHandler(func(*Playlist) error {
+		updates++
+		return nil
+	})
+	if err := sg.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(segments) != 3 || len(playlist.Segments()) != 3 || updates != 4 {
+		t.Fatalf("%d segments in playlist of %d, %d updates", len(segments), len(playlist.Segments()), updates)
+	}
+	if !playlist.Ended() {
+		t.Error("playlist is not ended")
+	}
+
+	tests := []struct {
+		pts      uint64
+		pictures int
+		duration time.Duration
+	}{
+		{90000, 3, 100100 * time.Microsecond},
+		{99009, 3, 100100 * time.Microsecond},
+		{108018, 2, 66733333 * time.Nanosecond},
+	}
+	for i, test := range tests {
+		if !segments[i].closed {
+			t.Errorf("%d: segment is not closed", i)
+		}
+
+		packets := segmentPackets(segments[i].Bytes())
+		if len(packets) != 2+test.pictures*2 {
+			t.Errorf("%d: %d packets", i, len(packets))
+			continue
+		}
+		if packets[0].PID() != tsparser.ProgramAssociationPID || packets[1].PID() != 0x1000 {
+			t.Errorf("%d: PIDs = %#x, %#x", i, packets[0].PID(), packets[1].PID())
+		}
+		if pts := tsparser.PES(packets[2].Payload()).PTS(); packets[2].PID() != 0x0100 || pts != test.pts {
+			t.Errorf("%d: PID = %#x, PTS = %d", i, packets[2].PID(), pts)
+		}
+
+		s := playlist.Segments()[i]
+		if s.Sequence != i || s.URI != fmt.Sprintf("segment%d.ts", i) || s.Duration != test.duration {
+			t.Errorf("%d: segment = %+v", i, s)
+		}
+	}
+}
+
+func TestSegmenterPESAcrossBoundary(t *testing.T) {
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.AACADTSStream, 0x0110))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every audio PES takes 3 packets, and the picture following its
+	// first packet comes in the middle of it.
+	var buf bytes.Buffer
+	w := tsparser.NewPacketWriter(&buf)
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTable)
+	var pending []tsparser.Packet
+	for i := 0; i < 6; i++ {
+		picture := pPicture
+		if i%3 == 0 {
+			picture = idrPicture
+		}
+
+		pts := uint64(90000 + i*3003)
+		w.WriteUnit(0x0100, nil, fixture.PES(0xe0, pts, picture))
+		for _, p := range pending {
+			w.WritePacket(p)
+		}
+
+		audio := fixture.Packetize(0x0110, fixture.PES(0xc0, pts, bytes.Repeat([]byte{byte(i)}, 400)))
+		pending = segmentPackets(audio)
+		w.WritePacket(pending[0])
+		pending = pending[1:]
+	}
+	for _, p := range pending {
+		w.WritePacket(p)
+	}
+
+	var segments []*segmentBuffer
+	create := func(sequence int) (io.WriteCloser, string, error) {
+		segment := &segmentBuffer{}
+		segments = append(segments, segment)
+		return segment, fmt.Sprintf("segment%d.ts", sequence), nil
+	}
+	sg := NewSegmenter(tsparser.NewPacketScanner(&buf, nil), 0, NewPlaylist(100*time.Millisecond, 0), create, nil)
+	if err := sg.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("%d segments", len(segments))
+	}
+
+	for i, segment := range segments {
+		var audio []int
+		s := tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(segment.Bytes()), nil), nil)
+		for s.Scan() {
+			if s.PID() != 0x0110 {
+				continue
+			}
+			payload := s.PES().Payload()
+			if len(payload) != 400 {
+				t.Errorf("%d: audio PES %d of %d bytes", i, payload[0], len(payload))
+			}
+			audio = append(audio, int(payload[0]))
+		}
+
+		if expected := []int{3 * i, 3*i + 1, 3*i + 2}; fmt.Sprint(audio) != fmt.Sprint(expected) {
+			t.Errorf("%d: audio PES %v, want %v", i, audio, expected)
+		}
+	}
+}
+
+func TestSegmenterProgramDateTime(t *testing.T) {
+	create := func(sequence int) (io.WriteCloser, string, error) {
+		return &segmentBuffer{}, fmt.Sprintf("segment%d.ts", sequence), nil
+	}
+
+	now := time.Date(2014, 4, 1, 12, 0, 0, 0, time.UTC)
+	// The TOT is sent along with picture 4, the second of segment 1.
+	data := testStream(t, 8, func(w *tsparser.PacketWriter, i int) {
+		if i != 4 {
+			return
+		}
+		tot, err := arib.MarshalTimeDateSection(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteTables(arib.TimeDatePID, tot)
+	})
+
+	playlist := NewPlaylist(100*time.Millisecond, 0)
+	sg := NewSegmenter(tsparser.NewPacketScanner(bytes.NewReader(data), nil), 0, playlist, create, nil)
+	if err := sg.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{
+		{},
+		now.Add(-3003 * time.Second / tsparser.ClockRate),
+		now.Add(6006 * time.Second / tsparser.ClockRate),
+	}
+	segments := playlist.Segments()
+	if len(segments) != len(expected) {
+		t.Fatalf("%d segments, want %d", len(segments), len(expected))
+	}
+	for i, s := range segments {
+		if !s.ProgramDateTime.Equal(expected[i]) {
+			t.Errorf("%d: program date time = %v, want %v", i, s.ProgramDateTime, expected[i])
+		}
+	}
+}
+
+func TestSegmenterNoSegment(t *testing.T) {
+	create := func(sequence int) (io.WriteCloser, string, error) {
+		return &segmentBuffer{}, "", nil
+	}
+
+	sg := NewSegmenter(tsparser.NewPacketScanner(bytes.NewReader(nil), nil), 0, NewPlaylist(time.Second, 0), create, nil)
+	if err := sg.Run(); err != ErrNoSegment {
+		t.Errorf("err = %v, want %v", err, ErrNoSegment)
+	}
+}
+
+func TestPlaylistWindow(t *testing.T) {
+	p := NewPlaylist(6*time.Second, 2)
+	for i := 0; i < 4; i++ {
+		removed := p.Append(&Segment{
+			Sequence:      i,
+			URI:           fmt.Sprintf("segment%d.ts", i),
+			Duration:      6006 * time.Millisecond,
+			Discontinuity: i == 1,
+		})
+		if expected := i >= 2; (len(removed) == 1) != expected {
+			t.Errorf("%d: removed = %v", i, removed)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"#EXTM3U",
+		"#EXT-X-VERSION:3",
+		"#EXT-X-TARGETDURATION:6",
+		"#EXT-X-MEDIA-SEQUENCE:2",
+		"#EXT-X-DISCONTINUITY-SEQUENCE:1",
+		"#EXTINF:6.006,",
+		"segment2.ts",
+		"#EXTINF:6.006,",
+		"segment3.ts",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Errorf("playlist = %q, want %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+// Package fixture builds the elementary streams, PES packets and transport
+// streams which the tests of the parsers are fed with.
+package fixture
+
+import (
+	"bytes"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+// AnnexB joins NAL units with four byte start codes.
+func AnnexB(units ...[]byte) []byte {
+	var data []byte
+	for _, unit := range units {
+		data = append(data, 0x00, 0x00, 0x00, 0x01)
+		data = append(data, unit...)
+	}
+	return data
+}
+
+// StartCodes joins units with three byte start code prefixes.
+func StartCodes(units ...[]byte) []byte {
+	var data []byte
+	for _, unit := range units {
+		data = append(data, 0x00, 0x00, 0x01)
+		data = append(data, unit...)
+	}
+	return data
+}
+
+// PES makes a PES packet of unbounded length with a PTS.
+func PES(streamId tsparser.StreamId, pts uint64, payload ...[]byte) tsparser.PES {
+	pes := tsparser.PES{
+		0x00, 0x00, 0x01, byte(streamId), 0x00, 0x00, 0x80, 0x80, 0x05,
+		0x21 | byte(pts>>29)&0x0e, byte(pts >> 22), byte(pts>>14) | 0x01, byte(pts >> 7), byte(pts<<1) | 0x01,
+	}
+	for _, data := range payload {
+		pes = append(pes, data...)
+	}
+	return pes
+}
+
+// Packetize writes each of units from the start of a packet on pid.
+func Packetize(pid tsparser.PID, units ...[]byte) []byte {
+	var buf bytes.Buffer
+	w := tsparser.NewPacketWriter(&buf)
+	for _, unit := range units {
+		if err := w.WriteUnit(pid, nil, unit); err != nil {
+			panic(err)
+		}
+	}
+	return buf.Bytes()
+}
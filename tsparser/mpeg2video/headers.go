@@ -0,0 +1,298 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package mpeg2video
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+var (
+	ErrInvalidHeader = errors.New("Invalid header")
+)
+
+const (
+	PictureStartCode   byte = 0x00
+	SliceStartCodeMin  byte = 0x01
+	SliceStartCodeMax  byte = 0xAF
+	UserDataStartCode  byte = 0xB2
+	SequenceHeaderCode byte = 0xB3
+	SequenceErrorCode  byte = 0xB4
+	ExtensionStartCode byte = 0xB5
+	SequenceEndCode    byte = 0xB7
+	GroupStartCode     byte = 0xB8
+)
+
+const (
+	SequenceExtensionId        = 1
+	SequenceDisplayExtensionId = 2
+	PictureCodingExtensionId   = 8
+)
+
+type PictureCodingType uint8
+
+const (
+	IPicture PictureCodingType = 1
+	PPicture PictureCodingType = 2
+	BPicture PictureCodingType = 3
+	DPicture PictureCodingType = 4
+)
+
+func (t PictureCodingType) String() string {
+	switch t {
+	case IPicture:
+		return "I"
+	case PPicture:
+		return "P"
+	case BPicture:
+		return "B"
+	case DPicture:
+		return "D"
+	}
+
+	return "?"
+}
+
+type PictureStructure uint8
+
+const (
+	TopField     PictureStructure = 1
+	BottomField  PictureStructure = 2
+	FramePicture PictureStructure = 3
+)
+
+var frameRates = [][2]int{
+	{0, 1}, {24000, 1001}, {24, 1}, {25, 1}, {30000, 1001}, {30, 1}, {50, 1}, {60000, 1001}, {60, 1},
+}
+
+var displayAspectRatios = [][2]int{
+	{0, 1}, {1, 1}, {4, 3}, {16, 9}, {221, 100},
+}
+
+type SequenceHeader struct {
+	Width                 int
+	Height                int
+	AspectRatioCode       uint8
+	FrameRateCode         uint8
+	BitRateValue          uint32
+	VBVBufferSizeValue    uint32
+	ConstrainedParameters bool
+
+	Extension       bool
+	ProfileAndLevel uint8
+	Progressive     bool
+	ChromaFormat    uint8
+	LowDelay        bool
+	FrameRateExtN   uint8
+	FrameRateExtD   uint8
+
+	DisplayExtension        bool
+	VideoFormat             uint8
+	ColourPrimaries         uint8
+	TransferCharacteristics uint8
+	MatrixCoefficients      uint8
+	DisplayWidth            int
+	DisplayHeight           int
+}
+
+func ParseSequenceHeader(data []byte) (*SequenceHeader, error) {
+	if len(data) < 8 || data[0] != SequenceHeaderCode {
+		return nil, ErrInvalidHeader
+	}
+
+	r := bitstream.NewReader(data[1:])
+	h := new(SequenceHeader)
+	h.Width = int(r.Bits(12))
+	h.Height = int(r.Bits(12))
+	h.AspectRatioCode = r.Uint8(4)
+	h.FrameRateCode = r.Uint8(4)
+	h.BitRateValue = r.Uint32(18)
+	r.Skip(1)
+	h.VBVBufferSizeValue = r.Uint32(10)
+	h.ConstrainedParameters = r.Bit()
+	h.ChromaFormat = 1
+	h.Progressive = true
+
+	return h, r.Err()
+}
+
+func (h *SequenceHeader) parseExtension(data []byte) error {
+	r := bitstream.NewReader(data[1:])
+	switch r.Uint8(4) {
+	case SequenceExtensionId:
+		h.Extension = true
+		h.ProfileAndLevel = r.Uint8(8)
+		h.Progressive = r.Bit()
+		h.ChromaFormat = r.Uint8(2)
+		h.Width = h.Width&0x0fff | int(r.Bits(2))<<12
+		h.Height = h.Height&0x0fff | int(r.Bits(2))<<12
+		h.BitRateValue = h.BitRateValue&0x3ffff | r.Uint32(12)<<18
+		r.Skip(1)
+		h.VBVBufferSizeValue = h.VBVBufferSizeValue&0x3ff | r.Uint32(8)<<10
+		h.LowDelay = r.Bit()
+		h.FrameRateExtN = r.Uint8(2)
+		h.FrameRateExtD = r.Uint8(5)
+	case SequenceDisplayExtensionId:
+		h.DisplayExtension = true
+		h.VideoFormat = r.Uint8(3)
+		if r.Bit() {
+			h.ColourPrimaries = r.Uint8(8)
+			h.TransferCharacteristics = r.Uint8(8)
+			h.MatrixCoefficients = r.Uint8(8)
+		}
+		h.DisplayWidth = int(r.Bits(14))
+		r.Skip(1)
+		h.DisplayHeight = int(r.Bits(14))
+	}
+
+	return r.Err()
+}
+
+func (h *SequenceHeader) FrameRate() (num, den int) {
+	if int(h.FrameRateCode) >= len(frameRates) {
+		return 0, 1
+	}
+
+	rate := frameRates[h.FrameRateCode]
+	return rate[0] * (int(h.FrameRateExtN) + 1), rate[1] * (int(h.FrameRateExtD) + 1)
+}
+
+func (h *SequenceHeader) BitRate() int {
+	return int(h.BitRateValue) * 400
+}
+
+func (h *SequenceHeader) VBVBufferSize() int {
+	return int(h.VBVBufferSizeValue) * 16 * 1024
+}
+
+func (h *SequenceHeader) DisplayAspectRatio() (num, den int) {
+	if h.AspectRatioCode == 1 || int(h.AspectRatioCode) >= len(displayAspectRatios) {
+		return h.displaySize()
+	}
+
+	ratio := displayAspectRatios[h.AspectRatioCode]
+	return ratio[0], ratio[1]
+}
+
+func (h *SequenceHeader) displaySize() (width, height int) {
+	if h.DisplayExtension && h.DisplayWidth > 0 && h.DisplayHeight > 0 {
+		return h.DisplayWidth, h.DisplayHeight
+	}
+
+	return h.Width, h.Height
+}
+
+func (h *SequenceHeader) SampleAspectRatio() (num, den int) {
+	if h.AspectRatioCode == 1 || int(h.AspectRatioCode) >= len(displayAspectRatios) {
+		return 1, 1
+	}
+
+	ratio := displayAspectRatios[h.AspectRatioCode]
+	width, height := h.displaySize()
+	num, den = ratio[0]*height, ratio[1]*width
+	g := gcd(num, den)
+	if g == 0 {
+		return 1, 1
+	}
+	return num / g, den / g
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+type GOPHeader struct {
+	DropFrame  bool
+	Hours      uint8
+	Minutes    uint8
+	Seconds    uint8
+	Pictures   uint8
+	ClosedGOP  bool
+	BrokenLink bool
+}
+
+func ParseGOPHeader(data []byte) (*GOPHeader, error) {
+	if len(data) < 5 || data[0] != GroupStartCode {
+		return nil, ErrInvalidHeader
+	}
+
+	r := bitstream.NewReader(data[1:])
+	h := new(GOPHeader)
+	h.DropFrame = r.Bit()
+	h.Hours = r.Uint8(5)
+	h.Minutes = r.Uint8(6)
+	r.Skip(1)
+	h.Seconds = r.Uint8(6)
+	h.Pictures = r.Uint8(6)
+	h.ClosedGOP = r.Bit()
+	h.BrokenLink = r.Bit()
+
+	return h, r.Err()
+}
+
+func (h *GOPHeader) Timecode() string {
+	sep := ":"
+	if h.DropFrame {
+		sep = ";"
+	}
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", h.Hours, h.Minutes, h.Seconds, sep, h.Pictures)
+}
+
+type PictureHeader struct {
+	TemporalReference uint16
+	CodingType        PictureCodingType
+	VBVDelay          uint16
+
+	Extension         bool
+	IntraDCPrecision  uint8
+	Structure         PictureStructure
+	TopFieldFirst     bool
+	FramePredFrameDCT bool
+	RepeatFirstField  bool
+	ProgressiveFrame  bool
+}
+
+func ParsePictureHeader(data []byte) (*PictureHeader, error) {
+	if len(data) < 5 || data[0] != PictureStartCode {
+		return nil, ErrInvalidHeader
+	}
+
+	r := bitstream.NewReader(data[1:])
+	h := new(PictureHeader)
+	h.TemporalReference = r.Uint16(10)
+	h.CodingType = PictureCodingType(r.Uint8(3))
+	h.VBVDelay = r.Uint16(16)
+	h.Structure = FramePicture
+	h.ProgressiveFrame = true
+
+	return h, r.Err()
+}
+
+func (h *PictureHeader) parseExtension(data []byte) error {
+	r := bitstream.NewReader(data[1:])
+	if r.Uint8(4) != PictureCodingExtensionId {
+		return nil
+	}
+
+	h.Extension = true
+	r.Skip(16) // f_code
+	h.IntraDCPrecision = r.Uint8(2)
+	h.Structure = PictureStructure(r.Uint8(2))
+	h.TopFieldFirst = r.Bit()
+	h.FramePredFrameDCT = r.Bit()
+	r.Skip(4) // concealment_motion_vectors, q_scale_type, intra_vlc_format, alternate_scan
+	h.RepeatFirstField = r.Bit()
+	r.Skip(1) // chroma_420_type
+	h.ProgressiveFrame = r.Bit()
+
+	return r.Err()
+}
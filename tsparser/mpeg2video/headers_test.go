@@ -0,0 +1,116 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package mpeg2video
+
+import (
+	"testing"
+)
+
+var (
+	// 1440x1080 16:9 at 29.97 fps, 20 Mbps, MP@HL interlaced.
+	sequenceHeader    = []byte{0xb3, 0x5a, 0x04, 0x38, 0x34, 0x30, 0xd4, 0x32, 0xa8}
+	sequenceExtension = []byte{0xb5, 0x14, 0x42, 0x00, 0x01, 0x00, 0x00}
+	displayExtension  = []byte{0xb5, 0x21, 0x01, 0x01, 0x01, 0x16, 0x82, 0x21, 0xc0}
+	// 01:02:03:04 of a closed GOP.
+	gopHeader = []byte{0xb8, 0x04, 0x28, 0x62, 0x40}
+	// An I picture of temporal_reference 2 coded as a frame with top field
+	// first.
+	iPictureHeader    = []byte{0x00, 0x00, 0x8f, 0xff, 0xf8}
+	iPictureExtension = []byte{0xb5, 0x8f, 0xff, 0xfb, 0x98, 0x00}
+	pPictureHeader    = []byte{0x00, 0x01, 0x57, 0xff, 0xfb, 0x80}
+	pPictureExtension = []byte{0xb5, 0x81, 0x1f, 0xf3, 0x80, 0x00}
+	slice             = []byte{0x01, 0x12, 0x34}
+)
+
+func TestParseSequenceHeader(t *testing.T) {
+	h, err := ParseSequenceHeader(sequenceHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Width != 1440 || h.Height != 1080 || !h.Progressive || h.ChromaFormat != 1 {
+		t.Errorf("size = %dx%d, progressive = %v, chroma_format = %d", h.Width, h.Height, h.Progressive, h.ChromaFormat)
+	}
+	if h.BitRate() != 20000000 || h.VBVBufferSize() != 597*16*1024 {
+		t.Errorf("bit rate = %d, VBV buffer size = %d", h.BitRate(), h.VBVBufferSize())
+	}
+	if num, den := h.FrameRate(); num != 30000 || den != 1001 {
+		t.Errorf("frame rate = %d/%d", num, den)
+	}
+	if num, den := h.DisplayAspectRatio(); num != 16 || den != 9 {
+		t.Errorf("display aspect ratio = %d:%d", num, den)
+	}
+
+	for _, ext := range [][]byte{sequenceExtension, displayExtension} {
+		if err := h.parseExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !h.Extension || h.ProfileAndLevel != 0x44 || h.Progressive || h.LowDelay {
+		t.Errorf("sequence extension = %+v", h)
+	}
+	if !h.DisplayExtension || h.DisplayWidth != 1440 || h.DisplayHeight != 1080 || h.ColourPrimaries != 1 {
+		t.Errorf("sequence display extension = %+v", h)
+	}
+	if num, den := h.SampleAspectRatio(); num != 4 || den != 3 {
+		t.Errorf("sample aspect ratio = %d:%d", num, den)
+	}
+
+	if _, err := ParseSequenceHeader(gopHeader); err != ErrInvalidHeader {
+		t.Errorf("err = %v, want %v", err, ErrInvalidHeader)
+	}
+}
+
+func TestParseGOPHeader(t *testing.T) {
+	h, err := ParseGOPHeader(gopHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Timecode() != "01:02:03:04" || !h.ClosedGOP || h.BrokenLink {
+		t.Errorf("GOP = %s, closed = %v, broken = %v", h.Timecode(), h.ClosedGOP, h.BrokenLink)
+	}
+}
+
+func TestParsePictureHeader(t *testing.T) {
+	tests := []struct {
+		header    []byte
+		extension []byte
+		expected  PictureHeader
+	}{
+		{iPictureHeader, iPictureExtension, PictureHeader{
+			TemporalReference: 2,
+			CodingType:        IPicture,
+			VBVDelay:          0xffff,
+			Extension:         true,
+			IntraDCPrecision:  2,
+			Structure:         FramePicture,
+			TopFieldFirst:     true,
+		}},
+		{pPictureHeader, pPictureExtension, PictureHeader{
+			TemporalReference: 5,
+			CodingType:        PPicture,
+			VBVDelay:          0xffff,
+			Extension:         true,
+			Structure:         FramePicture,
+			TopFieldFirst:     true,
+		}},
+	}
+
+	for i, test := range tests {
+		h, err := ParsePictureHeader(test.header)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if !h.ProgressiveFrame {
+			t.Errorf("%d: a picture without extension is not progressive", i)
+		}
+		if err := h.parseExtension(test.extension); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if *h != test.expected {
+			t.Errorf("%d: header = %+v, want %+v", i, *h, test.expected)
+		}
+	}
+}
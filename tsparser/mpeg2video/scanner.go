@@ -0,0 +1,224 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package mpeg2video
+
+import (
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/bitstream"
+)
+
+type Picture struct {
+	Units [][]byte
+
+	PTS    uint64
+	DTS    uint64
+	HasPTS bool
+	Offset int64
+
+	Sequence       *SequenceHeader
+	SequenceHeader bool
+	GOP            *GOPHeader
+	Header         *PictureHeader
+	UserData       [][]byte
+
+	hasSlice bool
+}
+
+func (p *Picture) CodingType() PictureCodingType {
+	return p.Header.CodingType
+}
+
+func (p *Picture) RandomAccess() bool {
+	return p.SequenceHeader && p.Header.CodingType == IPicture
+}
+
+type origin struct {
+	pts    uint64
+	dts    uint64
+	hasPTS bool
+	offset int64
+}
+
+type PictureScanner struct {
+	s      *tsparser.PESScanner
+	pid    tsparser.PID
+	logger *log.Logger
+
+	sequence *SequenceHeader
+	lastUnit byte
+
+	splitter bitstream.AnnexBSplitter
+	current  *Picture
+	queue    []*Picture
+	picture  *Picture
+	eof      bool
+}
+
+func NewPictureScanner(s *tsparser.PESScanner, pid tsparser.PID, logger *log.Logger) *PictureScanner {
+	return &PictureScanner{
+		s:      s,
+		pid:    pid,
+		logger: logger,
+	}
+}
+
+func (s *PictureScanner) log(v ...interface{}) {
+	if s.logger != nil {
+		s.logger.Print(v...)
+	}
+}
+
+func (s *PictureScanner) Scan() bool {
+	for len(s.queue) == 0 {
+		if s.eof {
+			return false
+		}
+
+		if !s.s.Scan() {
+			s.eof = true
+			s.splitter.Flush(s.handleUnit)
+			s.finish()
+			continue
+		}
+
+		if s.s.PID() == s.pid {
+			pes := s.s.PES()
+			o := origin{pes.PTS(), pes.DTS(), pes.HasPTS(), s.s.Offset()}
+			s.splitter.Push(pes.Payload(), o, s.handleUnit)
+		}
+	}
+
+	s.picture, s.queue = s.queue[0], s.queue[1:]
+	return true
+}
+
+func (s *PictureScanner) Picture() *Picture {
+	return s.picture
+}
+
+func (s *PictureScanner) Sequence() *SequenceHeader {
+	return s.sequence
+}
+
+func (s *PictureScanner) handleUnit(unit []byte, tag interface{}) {
+	s.handle(unit, tag.(origin))
+}
+
+func (s *PictureScanner) finish() {
+	if s.current != nil && s.current.Header != nil && s.current.hasSlice {
+		s.queue = append(s.queue, s.current)
+	}
+	s.current = nil
+}
+
+func (s *PictureScanner) begin(o origin) {
+	s.finish()
+	s.current = &Picture{
+		PTS:      o.pts,
+		DTS:      o.dts,
+		HasPTS:   o.hasPTS,
+		Offset:   o.offset,
+		Sequence: s.sequence,
+	}
+}
+
+func (s *PictureScanner) handle(unit []byte, o origin) {
+	if len(unit) == 0 {
+		return
+	}
+
+	code := unit[0]
+	lastUnit := s.lastUnit
+	s.lastUnit = code
+
+	switch code {
+	case SequenceHeaderCode, GroupStartCode:
+		if s.current == nil || s.current.Header != nil {
+			s.begin(o)
+		}
+	case PictureStartCode:
+		if s.current == nil || s.current.Header != nil {
+			s.begin(o)
+		}
+	case SequenceEndCode:
+		if s.current != nil {
+			s.current.Units = append(s.current.Units, unit)
+		}
+		s.finish()
+		return
+	}
+
+	if s.current == nil {
+		return
+	}
+	p := s.current
+	p.Units = append(p.Units, unit)
+
+	switch {
+	case code == SequenceHeaderCode:
+		sequence, err := ParseSequenceHeader(unit)
+		if err != nil {
+			s.log(err)
+			break
+		}
+		s.sequence = sequence
+		p.Sequence = sequence
+		p.SequenceHeader = true
+	case code == GroupStartCode:
+		if gop, err := ParseGOPHeader(unit); err == nil {
+			p.GOP = gop
+		} else {
+			s.log(err)
+		}
+	case code == PictureStartCode:
+		if header, err := ParsePictureHeader(unit); err == nil {
+			p.Header = header
+		} else {
+			s.log(err)
+		}
+	case code == ExtensionStartCode && len(unit) > 1:
+		var err error
+		if lastUnit == PictureStartCode && p.Header != nil {
+			err = p.Header.parseExtension(unit)
+		} else if p.Header == nil && s.sequence != nil {
+			err = s.sequence.parseExtension(unit)
+		}
+		if err != nil {
+			s.log(err)
+		}
+		s.lastUnit = lastUnit
+	case code == UserDataStartCode:
+		p.UserData = append(p.UserData, unit[1:])
+		s.lastUnit = lastUnit
+	case SliceStartCodeMin <= code && code <= SliceStartCodeMax:
+		p.hasSlice = true
+	}
+}
+
+func IsRandomAccess(data []byte) bool {
+	units, rest := bitstream.SplitAnnexB(data)
+	if len(rest) > 3 {
+		units = append(units, rest[3:])
+	}
+
+	sequence := false
+	for _, unit := range units {
+		if len(unit) == 0 {
+			continue
+		}
+
+		switch unit[0] {
+		case SequenceHeaderCode:
+			sequence = true
+		case PictureStartCode:
+			header, err := ParsePictureHeader(unit)
+			return sequence && err == nil && header.CodingType == IPicture
+		}
+	}
+
+	return false
+}
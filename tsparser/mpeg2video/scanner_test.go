@@ -0,0 +1,76 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package mpeg2video
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+func TestPictureScanner(t *testing.T) {
+	ts := fixture.Packetize(0x0100,
+		fixture.PES(0xe0, 90000, fixture.StartCodes(sequenceHeader, sequenceExtension, displayExtension, gopHeader, iPictureHeader, iPictureExtension, slice)),
+		fixture.PES(0xe0, 93003, fixture.StartCodes(pPictureHeader, pPictureExtension, []byte{0xb2, 'G', 'A', '9', '4'}, slice, slice)),
+	)
+
+	s := NewPictureScanner(tsparser.NewPESScanner(tsparser.NewPacketScanner(bytes.NewReader(ts), nil), nil), 0x0100, nil)
+	var pictures []*Picture
+	for s.Scan() {
+		pictures = append(pictures, s.Picture())
+	}
+	if len(pictures) != 2 {
+		t.Fatalf("%d pictures, want 2", len(pictures))
+	}
+
+	tests := []struct {
+		pts          uint64
+		units        int
+		codingType   PictureCodingType
+		randomAccess bool
+		userData     int
+	}{
+		{90000, 7, IPicture, true, 0},
+		{93003, 5, PPicture, false, 1},
+	}
+	for i, test := range tests {
+		p := pictures[i]
+		if !p.HasPTS || p.PTS != test.pts || len(p.Units) != test.units {
+			t.Errorf("%d: PTS = %d (%v), %d units", i, p.PTS, p.HasPTS, len(p.Units))
+		}
+		if p.CodingType() != test.codingType || p.RandomAccess() != test.randomAccess || len(p.UserData) != test.userData {
+			t.Errorf("%d: coding type = %v, random access = %v, %d user data", i, p.CodingType(), p.RandomAccess(), len(p.UserData))
+		}
+		if p.Sequence == nil || p.Sequence.Width != 1440 || p.Sequence.Progressive || !p.Header.Extension {
+			t.Errorf("%d: sequence = %+v, header = %+v", i, p.Sequence, p.Header)
+		}
+	}
+
+	if gop := pictures[0].GOP; gop == nil || gop.Timecode() != "01:02:03:04" {
+		t.Errorf("GOP = %+v", gop)
+	}
+	if !bytes.Equal(pictures[1].UserData[0], []byte("GA94")) {
+		t.Errorf("user data = %q", pictures[1].UserData[0])
+	}
+}
+
+func TestIsRandomAccess(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected bool
+	}{
+		{fixture.StartCodes(sequenceHeader, sequenceExtension, gopHeader, iPictureHeader, iPictureExtension, slice), true},
+		{fixture.StartCodes(gopHeader, iPictureHeader, iPictureExtension, slice), false},
+		{fixture.StartCodes(sequenceHeader, sequenceExtension, pPictureHeader, pPictureExtension, slice), false},
+	}
+
+	for i, test := range tests {
+		if got := IsRandomAccess(test.data); got != test.expected {
+			t.Errorf("%d: IsRandomAccess = %v, want %v", i, got, test.expected)
+		}
+	}
+}
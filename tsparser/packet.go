@@ -8,15 +8,15 @@ type PID uint16
 
 type Packet []byte
 
-func (p Packet) transportErrorIndicator() bool {
+func (p Packet) TransportErrorIndicator() bool {
 	return uint8(p[1]&0x80)>>7 == 1
 }
 
-func (p Packet) payloadUnitStartIndicator() bool {
+func (p Packet) PayloadUnitStartIndicator() bool {
 	return uint8(p[1]&0x40)>>6 == 1
 }
 
-func (p Packet) transportPriority() uint8 {
+func (p Packet) TransportPriority() uint8 {
 	return uint8(p[1]&0x20) >> 5
 }
 
@@ -24,7 +24,7 @@ func (p Packet) PID() PID {
 	return PID(p[1]&0x1f)<<8 | PID(p[2])
 }
 
-func (p Packet) transportScramblingControl() uint8 {
+func (p Packet) TransportScramblingControl() uint8 {
 	return uint8(p[3]&0xc0) >> 6
 }
 
@@ -40,11 +40,11 @@ func (p Packet) HasPayload() bool {
 	return p.adaptationFieldControl()&1 > 0
 }
 
-func (p Packet) continuityCounter() uint8 {
+func (p Packet) ContinuityCounter() uint8 {
 	return uint8(p[3] & 0x0f)
 }
 
-func (p Packet) AdaptationField() []byte {
+func (p Packet) AdaptationField() AdaptationField {
 	if !p.HasAdaptationField() {
 		return nil
 	}
@@ -59,7 +59,7 @@ func (p Packet) AdaptationField() []byte {
 			return nil
 		}
 	}
-	return p[4 : 5+length]
+	return AdaptationField(p[4 : 5+length])
 }
 
 func (p Packet) Payload() []byte {
@@ -73,7 +73,7 @@ func (p Packet) Payload() []byte {
 		if af == nil {
 			return nil
 		}
-		offset += len(p.AdaptationField())
+		offset += len(af)
 	}
 
 	return p[offset:]
@@ -0,0 +1,331 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+var (
+	ErrInvalidPESLength = errors.New("Invalid PES length")
+)
+
+type StreamId uint8
+
+const (
+	ProgramStreamMap       StreamId = 0xBC
+	PrivateStream1         StreamId = 0xBD
+	PaddingStream          StreamId = 0xBE
+	PrivateStream2         StreamId = 0xBF
+	ECMStream              StreamId = 0xF0
+	EMMStream              StreamId = 0xF1
+	DSMCCStream            StreamId = 0xF2
+	H2221TypeEStream       StreamId = 0xF8
+	ProgramStreamDirectory StreamId = 0xFF
+)
+
+func (id StreamId) IsAudio() bool {
+	return id&0xe0 == 0xc0
+}
+
+func (id StreamId) IsVideo() bool {
+	return id&0xf0 == 0xe0
+}
+
+func (id StreamId) hasOptionalHeader() bool {
+	switch id {
+	case ProgramStreamMap, PaddingStream, PrivateStream2, ECMStream, EMMStream,
+		DSMCCStream, H2221TypeEStream, ProgramStreamDirectory:
+		return false
+	}
+
+	return true
+}
+
+const (
+	TimestampBits = 33
+	TimestampMask = 1<<TimestampBits - 1
+	ClockRate     = 90000
+)
+
+type PES []byte
+
+func isPESStart(payload []byte) bool {
+	return len(payload) >= 6 && payload[0] == 0x00 && payload[1] == 0x00 && payload[2] == 0x01
+}
+
+func (p PES) StreamId() StreamId {
+	return StreamId(p[3])
+}
+
+func (p PES) PacketLength() int {
+	return int(p[4])<<8 | int(p[5])
+}
+
+func (p PES) hasOptionalHeader() bool {
+	return p.StreamId().hasOptionalHeader() && len(p) >= 9 && p[6]&0xc0 == 0x80
+}
+
+func (p PES) ScramblingControl() uint8 {
+	if !p.hasOptionalHeader() {
+		return 0
+	}
+
+	return uint8(p[6]&0x30) >> 4
+}
+
+func (p PES) DataAlignmentIndicator() bool {
+	return p.hasOptionalHeader() && p[6]&0x04 > 0
+}
+
+func (p PES) HasPTS() bool {
+	return p.hasOptionalHeader() && p[7]&0x80 > 0 && len(p) >= 14
+}
+
+func (p PES) HasDTS() bool {
+	return p.hasOptionalHeader() && p[7]&0xc0 == 0xc0 && len(p) >= 19
+}
+
+func (p PES) PTS() uint64 {
+	if !p.HasPTS() {
+		return 0
+	}
+
+	return parseTimestamp(p[9:14])
+}
+
+func (p PES) DTS() uint64 {
+	if !p.HasDTS() {
+		return p.PTS()
+	}
+
+	return parseTimestamp(p[14:19])
+}
+
+func (p PES) HeaderLength() int {
+	if !p.hasOptionalHeader() {
+		return 6
+	}
+
+	return 9 + int(p[8])
+}
+
+func (p PES) Payload() []byte {
+	start := p.HeaderLength()
+	if start > len(p) {
+		return nil
+	}
+
+	end := len(p)
+	if length := p.PacketLength(); length > 0 && 6+length < end {
+		end = 6 + length
+	}
+	return p[start:end]
+}
+
+func parseTimestamp(data []byte) uint64 {
+	return uint64(data[0]&0x0e)<<29 | uint64(data[1])<<22 | uint64(data[2]&0xfe)<<14 |
+		uint64(data[3])<<7 | uint64(data[4])>>1
+}
+
+type pesBuffer struct {
+	sectionBuffer
+	offset int64
+}
+
+func (b *pesBuffer) isComplete() bool {
+	if len(b.data) < 6 {
+		return false
+	}
+
+	length := PES(b.data).PacketLength()
+	return length > 0 && len(b.data) >= 6+length
+}
+
+type PESScanner struct {
+	s       PacketStream
+	logger  *log.Logger
+	buffers map[PID]*pesBuffer
+	index   int64
+	eof     bool
+
+	queue   []PES
+	pids    []PID
+	offsets []int64
+	current PES
+	pid     PID
+	offset  int64
+}
+
+func NewPESScanner(s PacketStream, logger *log.Logger) *PESScanner {
+	return &PESScanner{
+		s:       s,
+		logger:  logger,
+		buffers: make(map[PID]*pesBuffer),
+	}
+}
+
+func (s *PESScanner) log(p Packet, v ...interface{}) {
+	if s.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	s.logger.Print(values...)
+}
+
+func (s *PESScanner) emit(pid PID, b *pesBuffer) {
+	if len(b.data) >= 6 {
+		pes := make(PES, len(b.data))
+		copy(pes, b.data)
+		s.queue = append(s.queue, pes)
+		s.pids = append(s.pids, pid)
+		s.offsets = append(s.offsets, b.offset)
+	}
+
+	b.data = b.data[:0]
+}
+
+func (s *PESScanner) Scan() bool {
+	for len(s.queue) == 0 {
+		if s.eof {
+			return false
+		}
+
+		if !s.s.Scan() {
+			s.eof = true
+			s.flush()
+			continue
+		}
+
+		s.handle(s.s.Packet())
+		s.index++
+	}
+
+	s.current, s.queue = s.queue[0], s.queue[1:]
+	s.pid, s.pids = s.pids[0], s.pids[1:]
+	s.offset, s.offsets = s.offsets[0], s.offsets[1:]
+	return true
+}
+
+func (s *PESScanner) flush() {
+	pids := make(PIDSlice, 0, len(s.buffers))
+	for pid := range s.buffers {
+		pids = append(pids, pid)
+	}
+	sort.Sort(pids)
+
+	for _, pid := range pids {
+		s.emit(pid, s.buffers[pid])
+	}
+}
+
+func (s *PESScanner) handle(packet Packet) {
+	if !packet.HasPayload() {
+		return
+	} else if packet.TransportScramblingControl() > 0 {
+		s.log(packet, ErrPacketScrambled)
+		return
+	}
+
+	pid := packet.PID()
+	payload := packet.Payload()
+	buffer, ok := s.buffers[pid]
+	if !ok {
+		if !packet.PayloadUnitStartIndicator() || !isPESStart(payload) {
+			return
+		}
+
+		buffer = new(pesBuffer)
+		s.buffers[pid] = buffer
+	}
+
+	duplicated, err := buffer.checkContinuity(packet.ContinuityCounter())
+	if err != nil {
+		s.log(packet, err)
+	}
+	if duplicated {
+		return
+	}
+
+	if packet.PayloadUnitStartIndicator() {
+		if len(buffer.data) > 0 {
+			if isPESStart(buffer.data) && PES(buffer.data).PacketLength() > 0 {
+				s.log(packet, ErrInvalidPESLength)
+				buffer.data = buffer.data[:0]
+			}
+			s.emit(pid, buffer)
+		}
+
+		if !isPESStart(payload) {
+			return
+		}
+		buffer.offset = s.packetOffset()
+		buffer.data = append(buffer.data, payload...)
+	} else if len(buffer.data) > 0 {
+		buffer.data = append(buffer.data, payload...)
+	}
+
+	if buffer.isComplete() {
+		buffer.data = buffer.data[:6+PES(buffer.data).PacketLength()]
+		s.emit(pid, buffer)
+	}
+}
+
+// packetOffset returns the byte offset of the current packet, which is
+// counted in the packets read when the stream is not an OffsetStream.
+func (s *PESScanner) packetOffset() int64 {
+	if stream, ok := s.s.(OffsetStream); ok {
+		if offset := stream.Offset(); offset >= 0 {
+			return offset
+		}
+	}
+
+	return s.index * PacketSize
+}
+
+func (s *PESScanner) PES() PES {
+	return s.current
+}
+
+func (s *PESScanner) PID() PID {
+	return s.pid
+}
+
+// Offset returns the byte offset of the first packet of the PES in the
+// input.  It is the count of the packets read times PacketSize if the
+// stream does not know the offsets.
+func (s *PESScanner) Offset() int64 {
+	return s.offset
+}
+
+func putTimestamp(data []byte, ts uint64) {
+	data[0] = data[0]&0xf0 | byte(ts>>29)&0x0e | 0x01
+	data[1] = byte(ts >> 22)
+	data[2] = byte(ts>>14)&0xfe | 0x01
+	data[3] = byte(ts >> 7)
+	data[4] = byte(ts<<1) | 0x01
+}
+
+// SetPTS rewrites the PTS in place.  It does nothing if the header has no
+// PTS.
+func (p PES) SetPTS(pts uint64) {
+	if p.HasPTS() {
+		putTimestamp(p[9:14], pts&TimestampMask)
+	}
+}
+
+// SetDTS rewrites the DTS in place.  It does nothing if the header has no
+// DTS.
+func (p PES) SetDTS(dts uint64) {
+	if p.HasDTS() {
+		putTimestamp(p[14:19], dts&TimestampMask)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newPES returns a PES without PTS.  PES_packet_length is 0 unless bounded.
+func newPES(streamId StreamId, bounded bool, size int) PES {
+	pes := PES{0x00, 0x00, 0x01, byte(streamId), 0x00, 0x00, 0x80, 0x00, 0x00}
+	for i := 0; i < size; i++ {
+		pes = append(pes, byte(i))
+	}
+	if bounded {
+		length := len(pes) - 6
+		pes[4], pes[5] = byte(length>>8), byte(length)
+	}
+	return pes
+}
+
+func unitPackets(t *testing.T, pid PID, units ...PES) []Packet {
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	for _, unit := range units {
+		if err := w.WriteUnit(pid, nil, unit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return readPackets(t, buf.Bytes())
+}
+
+type scannedPES struct {
+	pid    PID
+	offset int64
+	pes    PES
+}
+
+func scanPES(packets ...Packet) []scannedPES {
+	var got []scannedPES
+	s := NewPESScanner(&packetSlice{packets: packets}, nil)
+	for s.Scan() {
+		got = append(got, scannedPES{s.PID(), s.Offset(), s.PES()})
+	}
+	return got
+}
+
+func checkPES(t *testing.T, got []scannedPES, want []scannedPES) {
+	if len(got) != len(want) {
+		t.Fatalf("%d PES, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].pid != want[i].pid || got[i].offset != want[i].offset || !bytes.Equal(got[i].pes, want[i].pes) {
+			t.Errorf("%d: PID = %#x, offset = %d, PES = %x, want PID = %#x, offset = %d, PES = %x",
+				i, got[i].pid, got[i].offset, got[i].pes, want[i].pid, want[i].offset, want[i].pes)
+		}
+	}
+}
+
+func TestPESScannerReassembly(t *testing.T) {
+	video := newPES(0xe0, true, 400)
+	audio := newPES(0xc0, true, 100)
+	videoPackets := unitPackets(t, 0x0100, video)
+	audioPackets := unitPackets(t, 0x0101, audio)
+	if len(videoPackets) != 3 || len(audioPackets) != 1 {
+		t.Fatalf("%d video and %d audio packets", len(videoPackets), len(audioPackets))
+	}
+
+	// A bounded PES is emitted as soon as it is complete.
+	got := scanPES(videoPackets[0], audioPackets[0], videoPackets[1], videoPackets[2])
+	checkPES(t, got, []scannedPES{
+		{0x0101, PacketSize, audio},
+		{0x0100, 0, video},
+	})
+}
+
+func TestPESScannerContinuityError(t *testing.T) {
+	first := newPES(0xe0, true, 400)
+	second := newPES(0xe0, true, 100)
+	packets := unitPackets(t, 0x0100, first, second)
+	if len(packets) != 4 {
+		t.Fatalf("%d packets", len(packets))
+	}
+
+	// The PES which lost a packet is discarded.
+	got := scanPES(packets[0], packets[2], packets[3])
+	checkPES(t, got, []scannedPES{
+		{0x0100, 2 * PacketSize, second},
+	})
+}
+
+func TestPESScannerUnboundedLength(t *testing.T) {
+	first := newPES(0xe0, false, 300)
+	second := newPES(0xe0, false, 10)
+	packets := unitPackets(t, 0x0100, first, second)
+	if len(packets) != 3 {
+		t.Fatalf("%d packets", len(packets))
+	}
+
+	// A PES of PES_packet_length 0 ends at the start of the next one, or
+	// at the end of the stream.
+	got := scanPES(packets...)
+	checkPES(t, got, []scannedPES{
+		{0x0100, 0, first},
+		{0x0100, 2 * PacketSize, second},
+	})
+}
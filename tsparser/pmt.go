@@ -0,0 +1,213 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+type StreamType uint8
+
+const (
+	MPEG1VideoStream      StreamType = 0x01
+	MPEG2VideoStream      StreamType = 0x02
+	MPEG1AudioStream      StreamType = 0x03
+	MPEG2AudioStream      StreamType = 0x04
+	PrivateSectionStream  StreamType = 0x05
+	PrivatePESStream      StreamType = 0x06
+	DSMCCTypeAStream      StreamType = 0x0A
+	DSMCCTypeBStream      StreamType = 0x0B
+	DSMCCTypeCStream      StreamType = 0x0C
+	DSMCCTypeDStream      StreamType = 0x0D
+	AACADTSStream         StreamType = 0x0F
+	AACLATMStream         StreamType = 0x11
+	H264VideoStream       StreamType = 0x1B
+	HEVCVideoStream       StreamType = 0x24
+	AC3AudioStream        StreamType = 0x81
+	SCTE35Stream          StreamType = 0x86
+	EAC3AudioStream       StreamType = 0x87
+	MPEG2VideoStreamDigit StreamType = 0x80
+)
+
+func (t StreamType) IsVideo() bool {
+	switch t {
+	case MPEG1VideoStream, MPEG2VideoStream, H264VideoStream, HEVCVideoStream, MPEG2VideoStreamDigit:
+		return true
+	}
+
+	return false
+}
+
+func (t StreamType) IsAudio() bool {
+	switch t {
+	case MPEG1AudioStream, MPEG2AudioStream, AACADTSStream, AACLATMStream, AC3AudioStream, EAC3AudioStream:
+		return true
+	}
+
+	return false
+}
+
+type ElementaryStream struct {
+	streamType  StreamType
+	pid         PID
+	descriptors []Descriptor
+}
+
+func NewElementaryStream(streamType StreamType, pid PID, descriptors ...Descriptor) *ElementaryStream {
+	return &ElementaryStream{
+		streamType:  streamType,
+		pid:         pid,
+		descriptors: descriptors,
+	}
+}
+
+func (es *ElementaryStream) StreamType() StreamType {
+	return es.streamType
+}
+
+func (es *ElementaryStream) PID() PID {
+	return es.pid
+}
+
+func (es *ElementaryStream) Descriptors() []Descriptor {
+	return es.descriptors
+}
+
+func (es *ElementaryStream) Descriptor(tag DescriptorTag) Descriptor {
+	for _, d := range es.descriptors {
+		if d.Tag() == tag {
+			return d
+		}
+	}
+
+	return nil
+}
+
+type ProgramMapSection struct {
+	programNumber uint16
+	version       uint8
+	pcrPID        PID
+	descriptors   []Descriptor
+	streams       []*ElementaryStream
+}
+
+func NewProgramMapSection(programNumber uint16, pcrPID PID) *ProgramMapSection {
+	return &ProgramMapSection{
+		programNumber: programNumber,
+		pcrPID:        pcrPID,
+	}
+}
+
+func ParseProgramMapSection(table Table) *ProgramMapSection {
+	sec := NewProgramMapSection(table.TableIdExtension(), 0)
+	sec.version = table.VersionNumber()
+
+	payload := table.Data()
+	if len(payload) < 4 {
+		return sec
+	}
+
+	sec.pcrPID = PID(payload[0]&0x1f)<<8 | PID(payload[1])
+	infoLength := int(payload[2]&0x0f)<<8 | int(payload[3])
+	if 4+infoLength > len(payload) {
+		return sec
+	}
+	sec.descriptors = ParseDescriptors(payload[4 : 4+infoLength])
+
+	for i := 4 + infoLength; i+5 <= len(payload); {
+		streamType := StreamType(payload[i])
+		pid := PID(payload[i+1]&0x1f)<<8 | PID(payload[i+2])
+		esInfoLength := int(payload[i+3]&0x0f)<<8 | int(payload[i+4])
+		i += 5
+		if i+esInfoLength > len(payload) {
+			break
+		}
+
+		es := NewElementaryStream(streamType, pid, ParseDescriptors(payload[i:i+esInfoLength])...)
+		sec.streams = append(sec.streams, es)
+		i += esInfoLength
+	}
+
+	return sec
+}
+
+func (s *ProgramMapSection) ProgramNumber() uint16 {
+	return s.programNumber
+}
+
+func (s *ProgramMapSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *ProgramMapSection) SetVersionNumber(version uint8) {
+	s.version = version & 0x1f
+}
+
+func (s *ProgramMapSection) PCRPID() PID {
+	return s.pcrPID
+}
+
+func (s *ProgramMapSection) SetPCRPID(pid PID) {
+	s.pcrPID = pid
+}
+
+func (s *ProgramMapSection) Descriptors() []Descriptor {
+	return s.descriptors
+}
+
+func (s *ProgramMapSection) SetDescriptors(descriptors ...Descriptor) {
+	s.descriptors = descriptors
+}
+
+func (s *ProgramMapSection) Streams() []*ElementaryStream {
+	return s.streams
+}
+
+func (s *ProgramMapSection) Stream(pid PID) *ElementaryStream {
+	for _, es := range s.streams {
+		if es.pid == pid {
+			return es
+		}
+	}
+
+	return nil
+}
+
+func (s *ProgramMapSection) AddStream(es *ElementaryStream) {
+	s.RemoveStream(es.pid)
+	s.streams = append(s.streams, es)
+}
+
+func (s *ProgramMapSection) RemoveStream(pid PID) {
+	streams := s.streams[:0]
+	for _, es := range s.streams {
+		if es.pid != pid {
+			streams = append(streams, es)
+		}
+	}
+	s.streams = streams
+}
+
+func (s *ProgramMapSection) Marshal() (Table, error) {
+	info := MarshalDescriptors(s.descriptors)
+	data := make([]byte, 0, 4+len(info)+5*len(s.streams))
+	data = append(data,
+		0xe0|byte(s.pcrPID>>8), byte(s.pcrPID),
+		0xf0|byte(len(info)>>8)&0x0f, byte(len(info)))
+	data = append(data, info...)
+
+	for _, es := range s.streams {
+		esInfo := MarshalDescriptors(es.descriptors)
+		data = append(data,
+			byte(es.streamType),
+			0xe0|byte(es.pid>>8), byte(es.pid),
+			0xf0|byte(len(esInfo)>>8)&0x0f, byte(len(esInfo)))
+		data = append(data, esInfo...)
+	}
+
+	header := &TableHeader{
+		TableId:              ProgramMapTable,
+		TableIdExtension:     s.programNumber,
+		VersionNumber:        s.version,
+		CurrentNextIndicator: true,
+	}
+	return header.Build(data)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"io"
+)
+
+// ProgramTracker follows the PMT PID of a program through the PAT.  With
+// program number 0, the program of the lowest program_number in the first
+// PAT is chosen.
+type ProgramTracker struct {
+	number uint16
+	pmtPID PID
+}
+
+func NewProgramTracker(programNumber uint16) *ProgramTracker {
+	return &ProgramTracker{
+		number: programNumber,
+		pmtPID: NullPID,
+	}
+}
+
+func (t *ProgramTracker) ProgramNumber() uint16 {
+	return t.number
+}
+
+func (t *ProgramTracker) PMTPID() PID {
+	return t.pmtPID
+}
+
+// Update reports whether the program is in the PAT and whether its PMT PID
+// has changed.  On a change, the section being collected on the former PMT
+// PID is discarded from collector, which may be nil.
+func (t *ProgramTracker) Update(pat *ProgramAssociationSection, collector *TableCollector) (found, changed bool) {
+	programs := pat.ProgramMap()
+	if t.number == 0 {
+		for number := range programs {
+			if t.number == 0 || number < t.number {
+				t.number = number
+			}
+		}
+	}
+
+	pid, ok := programs[t.number]
+	if !ok || pid == t.pmtPID {
+		return ok, false
+	}
+
+	if collector != nil {
+		collector.Reset(t.pmtPID)
+	}
+	t.pmtPID = pid
+	return true, true
+}
+
+// FindProgram reads r up to the first PMT of the program, or of the lowest
+// program_number with program number 0.  It returns nil if no PMT is found.
+func FindProgram(r io.Reader, programNumber uint16) *ProgramMapSection {
+	scanner := NewPacketScanner(r, nil)
+	collector := NewTableCollector()
+	program := NewProgramTracker(programNumber)
+
+	for scanner.Scan() {
+		packet := scanner.Packet()
+		if packet.PID() != ProgramAssociationPID && packet.PID() != program.PMTPID() {
+			continue
+		}
+
+		tables, _ := collector.Feed(packet)
+		for _, table := range tables {
+			switch table.TableId() {
+			case ProgramAssociationTable:
+				program.Update(ParseProgramAssociationSection(table), collector)
+			case ProgramMapTable:
+				if table.TableIdExtension() == program.ProgramNumber() {
+					return ParseProgramMapSection(table)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProgramTracker(t *testing.T) {
+	pat := NewProgramAssociationSection(0x7fe0)
+	pat.SetNetworkPID(0x0010)
+	pat.SetProgram(0x0408, 0x01f8)
+	pat.SetProgram(0x0400, 0x01f0)
+
+	tracker := NewProgramTracker(0)
+	if found, changed := tracker.Update(pat, nil); !found || !changed {
+		t.Errorf("found = %v, changed = %v", found, changed)
+	}
+	if tracker.ProgramNumber() != 0x0400 || tracker.PMTPID() != 0x01f0 {
+		t.Errorf("program_number = %#x, PMT PID = %#x", tracker.ProgramNumber(), tracker.PMTPID())
+	}
+	if found, changed := tracker.Update(pat, nil); !found || changed {
+		t.Errorf("found = %v, changed = %v for the same PAT", found, changed)
+	}
+
+	pat.SetProgram(0x0400, 0x01f1)
+	pat.SetProgram(0x0300, 0x01e0)
+	if found, changed := tracker.Update(pat, NewTableCollector()); !found || !changed {
+		t.Errorf("found = %v, changed = %v after the PMT PID changes", found, changed)
+	}
+	if tracker.ProgramNumber() != 0x0400 || tracker.PMTPID() != 0x01f1 {
+		t.Errorf("program_number = %#x, PMT PID = %#x", tracker.ProgramNumber(), tracker.PMTPID())
+	}
+
+	if found, _ := NewProgramTracker(0x0500).Update(pat, nil); found {
+		t.Error("program 0x500 is found")
+	}
+}
+
+func TestFindProgram(t *testing.T) {
+	pat := NewProgramAssociationSection(0x7fe0)
+	pat.SetProgram(0x0400, 0x01f0)
+	pat.SetProgram(0x0408, 0x01f8)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	for i, number := range []uint16{0x0408, 0x0400} {
+		pmt := NewProgramMapSection(number, PID(0x0100+i))
+		pmt.AddStream(NewElementaryStream(H264VideoStream, PID(0x0100+i)))
+		table, err := pmt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The PMT before the PAT is not taken.
+		w.WriteTables(PID(0x01f0+i*8), table)
+		w.WriteTables(ProgramAssociationPID, patTable)
+		w.WriteTables(PID(0x01f8-i*8), table)
+	}
+
+	tests := []struct {
+		number   uint16
+		expected uint16
+		pcrPID   PID
+	}{
+		{0, 0x0400, 0x0101},
+		{0x0408, 0x0408, 0x0100},
+	}
+	for _, test := range tests {
+		pmt := FindProgram(bytes.NewReader(buf.Bytes()), test.number)
+		if pmt == nil {
+			t.Errorf("program %#x is not found", test.number)
+		} else if pmt.ProgramNumber() != test.expected || pmt.PCRPID() != test.pcrPID {
+			t.Errorf("program %#x: program_number = %#x, PCR_PID = %#x", test.number, pmt.ProgramNumber(), pmt.PCRPID())
+		}
+	}
+
+	if pmt := FindProgram(bytes.NewReader(buf.Bytes()), 0x0500); pmt != nil {
+		t.Errorf("program 0x500 = %v", pmt)
+	}
+}
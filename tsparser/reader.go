@@ -0,0 +1,97 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"io"
+	"os"
+)
+
+// MultiFileReader reads files one after another as a single stream.  A
+// truncated packet at the end of a file is dropped, so the next file starts
+// at a packet boundary.
+type MultiFileReader struct {
+	paths     []string
+	index     int
+	file      *os.File
+	remaining int64
+}
+
+func NewMultiFileReader(paths ...string) *MultiFileReader {
+	return &MultiFileReader{
+		paths: paths,
+	}
+}
+
+// Path returns the path of the file being read.
+func (r *MultiFileReader) Path() string {
+	if r.index == 0 {
+		return ""
+	}
+
+	return r.paths[r.index-1]
+}
+
+func (r *MultiFileReader) open() error {
+	file, err := os.Open(r.paths[r.index])
+	if err != nil {
+		return err
+	}
+	r.index++
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.remaining = info.Size() - info.Size()%PacketSize
+	return nil
+}
+
+func (r *MultiFileReader) Read(b []byte) (int, error) {
+	for {
+		if r.file == nil {
+			if r.index >= len(r.paths) {
+				return 0, io.EOF
+			}
+			if err := r.open(); err != nil {
+				return 0, err
+			}
+		}
+
+		if r.remaining <= 0 {
+			if err := r.Close(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if int64(len(b)) > r.remaining {
+			b = b[:r.remaining]
+		}
+		n, err := r.file.Read(b)
+		r.remaining -= int64(n)
+		if err == io.EOF {
+			r.remaining = 0
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *MultiFileReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -30,6 +30,8 @@ type PacketScanner struct {
 	buffer [PacketSize * BufferedPacketCount]byte
 	seek   int
 	eof    bool
+	base   int64
+	read   int64
 	logger *log.Logger
 }
 
@@ -45,15 +47,21 @@ func NewPacketScanner(r io.Reader, logger *log.Logger) *PacketScanner {
 func (s *PacketScanner) leftShift(n int) {
 	copy(s.buffer[:BufferSize-n], s.buffer[n:])
 	s.seek -= n
+	s.base += int64(n)
 }
 
 func (s *PacketScanner) rightShift(n int) {
 	copy(s.buffer[n:], s.buffer[:BufferSize-n])
 	s.seek += n
+	s.base -= int64(n)
 }
 
 func (s *PacketScanner) fillBuffer(n int) bool {
-	switch m, err := io.ReadFull(s.r, s.buffer[BufferSize-n:]); err {
+	m, err := io.ReadFull(s.r, s.buffer[BufferSize-n:])
+	s.base = s.read - int64(BufferSize-n)
+	s.read += int64(m)
+
+	switch err {
 	case io.ErrUnexpectedEOF, io.EOF:
 		s.eof = true
 
@@ -137,6 +145,11 @@ func (s *PacketScanner) Packet() Packet {
 	return Packet(s.Bytes())
 }
 
+// Offset returns the byte offset of the current packet in the input.
+func (s *PacketScanner) Offset() int64 {
+	return s.base + int64(s.seek)
+}
+
 type tableScannerBuffer struct {
 	data    []byte
 	lastCC  uint8
@@ -177,7 +190,7 @@ func (b *tableScannerBuffer) freeze() (err error) {
 }
 
 func (b *tableScannerBuffer) Begin(cc uint8, payload []byte) (err error) {
-	packetStartCodePrefix := payload[0]<<16 | payload[1]<<8 | payload[2]
+	packetStartCodePrefix := uint32(payload[0])<<16 | uint32(payload[1])<<8 | uint32(payload[2])
 	b.isPES = packetStartCodePrefix == 0x000001
 
 	cc, b.lastCC = b.lastCC, cc
@@ -264,7 +277,7 @@ func (s *TableScanner) Scan() bool {
 		packet := s.s.Packet()
 		if !packet.HasPayload() {
 			continue
-		} else if packet.transportScramblingControl() > 0 {
+		} else if packet.TransportScramblingControl() > 0 {
 			s.log(packet, ErrPacketScrambled)
 			continue
 		}
@@ -276,14 +289,14 @@ func (s *TableScanner) Scan() bool {
 		}
 
 		var err error
-		if packet.payloadUnitStartIndicator() {
-			err = buffer.Begin(packet.continuityCounter(), packet.Payload())
+		if packet.PayloadUnitStartIndicator() {
+			err = buffer.Begin(packet.ContinuityCounter(), packet.Payload())
 			if err == nil && buffer.isFull() {
 				s.pid = packet.PID()
 				return true
 			}
 		} else if ok {
-			err = buffer.Extend(packet.continuityCounter(), packet.Payload())
+			err = buffer.Extend(packet.ContinuityCounter(), packet.Payload())
 		}
 
 		if err != nil {
@@ -0,0 +1,74 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+type packetSlice struct {
+	packets []Packet
+	current Packet
+}
+
+func (s *packetSlice) Scan() bool {
+	if len(s.packets) == 0 {
+		return false
+	}
+
+	s.current, s.packets = s.packets[0], s.packets[1:]
+	return true
+}
+
+func (s *packetSlice) Packet() Packet {
+	return s.current
+}
+
+func newPacket(pid PID, pusi bool, cc uint8, payload []byte) Packet {
+	p := make(Packet, PacketSize)
+	p[0] = SyncByte
+	p[1] = byte(pid>>8) & 0x1f
+	if pusi {
+		p[1] |= 0x40
+	}
+	p[2] = byte(pid)
+	p[3] = 0x10 | cc&0x0f
+	n := copy(p[4:], payload)
+	for i := 4 + n; i < PacketSize; i++ {
+		p[i] = 0xff
+	}
+	return p
+}
+
+func TestTableScannerPointerField(t *testing.T) {
+	// A private section of 184 bytes leaves its last byte to the next
+	// packet, so the CAT in the next packet follows pointer_field 1.  The
+	// third byte of that payload is the table_id 0x01, which used to be
+	// taken for the last byte of packet_start_code_prefix.
+	private := append([]byte{0x80, 0x70, 0xb5}, bytes.Repeat([]byte{0x5a}, 181)...)
+	cat := []byte{0x01, 0xb0, 0x09, 0xff, 0xff, 0xc1, 0x00, 0x00, 0x12, 0x34, 0x56, 0x78}
+
+	s := NewTableScanner(&packetSlice{packets: []Packet{
+		newPacket(0x0030, true, 0, append([]byte{0x00}, private[:183]...)),
+		newPacket(0x0030, true, 1, append([]byte{0x01, private[183]}, cat...)),
+		newPacket(0x0030, true, 2, []byte{0x00}),
+	}}, nil)
+
+	var tables []Table
+	for s.Scan() {
+		tables = append(tables, s.Table())
+	}
+
+	if len(tables) != 2 {
+		t.Fatalf("%d tables, want 2", len(tables))
+	}
+	if !bytes.HasPrefix(tables[0], private) {
+		t.Errorf("table 0 = %x, want %x", tables[0], private)
+	}
+	if !bytes.HasPrefix(tables[1], cat) {
+		t.Errorf("table 1 = %x, want %x", tables[1], cat)
+	}
+}
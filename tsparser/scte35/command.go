@@ -0,0 +1,239 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	SpliceNullCommand           uint8 = 0x00
+	SpliceScheduleCommand       uint8 = 0x04
+	SpliceInsertCommand         uint8 = 0x05
+	TimeSignalCommand           uint8 = 0x06
+	BandwidthReservationCommand uint8 = 0x07
+	PrivateCommand              uint8 = 0xFF
+)
+
+type SpliceCommand interface {
+	CommandType() uint8
+}
+
+// SpliceTime is a splice_time().  PTS is a pts_time without
+// pts_adjustment applied.
+type SpliceTime struct {
+	Specified bool
+	PTS       uint64
+}
+
+func (c *cursor) spliceTime() SpliceTime {
+	if c.remaining() > 0 && c.data[c.pos]&0x80 > 0 {
+		_, pts := c.uint33()
+		return SpliceTime{true, pts}
+	}
+
+	c.uint8()
+	return SpliceTime{}
+}
+
+type BreakDuration struct {
+	AutoReturn bool
+	Duration   uint64
+}
+
+func (d *BreakDuration) Time() time.Duration {
+	return time.Duration(d.Duration) * time.Second / tsparser.ClockRate
+}
+
+func (c *cursor) breakDuration() *BreakDuration {
+	flags, duration := c.uint33()
+	return &BreakDuration{flags&0x80 > 0, duration}
+}
+
+type SpliceNull struct{}
+
+func (*SpliceNull) CommandType() uint8 {
+	return SpliceNullCommand
+}
+
+type ScheduleComponent struct {
+	ComponentTag  uint8
+	UTCSpliceTime uint32
+}
+
+type ScheduledSplice struct {
+	SpliceEventId   uint32
+	CancelIndicator bool
+	OutOfNetwork    bool
+	ProgramSplice   bool
+	UTCSpliceTime   uint32
+	Components      []ScheduleComponent
+	BreakDuration   *BreakDuration
+	UniqueProgramId uint16
+	AvailNum        uint8
+	AvailsExpected  uint8
+}
+
+// UTCTime converts utc_splice_time, which counts seconds since the GPS
+// epoch in UTC.
+func UTCTime(t uint32) time.Time {
+	return time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC).Add(time.Duration(t) * time.Second)
+}
+
+type SpliceSchedule struct {
+	Splices []*ScheduledSplice
+}
+
+func (*SpliceSchedule) CommandType() uint8 {
+	return SpliceScheduleCommand
+}
+
+func parseSpliceSchedule(c *cursor) *SpliceSchedule {
+	cmd := new(SpliceSchedule)
+	count := int(c.uint8())
+	for i := 0; i < count && !c.failed; i++ {
+		s := &ScheduledSplice{
+			SpliceEventId:   c.uint32(),
+			CancelIndicator: c.uint8()&0x80 > 0,
+		}
+		if !s.CancelIndicator {
+			flags := c.uint8()
+			s.OutOfNetwork = flags&0x80 > 0
+			s.ProgramSplice = flags&0x40 > 0
+			if s.ProgramSplice {
+				s.UTCSpliceTime = c.uint32()
+			} else {
+				components := int(c.uint8())
+				for j := 0; j < components && !c.failed; j++ {
+					s.Components = append(s.Components, ScheduleComponent{c.uint8(), c.uint32()})
+				}
+			}
+			if flags&0x20 > 0 {
+				s.BreakDuration = c.breakDuration()
+			}
+			s.UniqueProgramId = c.uint16()
+			s.AvailNum = c.uint8()
+			s.AvailsExpected = c.uint8()
+		}
+		cmd.Splices = append(cmd.Splices, s)
+	}
+
+	return cmd
+}
+
+type InsertComponent struct {
+	ComponentTag uint8
+	SpliceTime   SpliceTime
+}
+
+type SpliceInsert struct {
+	SpliceEventId     uint32
+	CancelIndicator   bool
+	OutOfNetwork      bool
+	ProgramSplice     bool
+	SpliceImmediate   bool
+	EventIdCompliance bool
+	SpliceTime        SpliceTime
+	Components        []InsertComponent
+	BreakDuration     *BreakDuration
+	UniqueProgramId   uint16
+	AvailNum          uint8
+	AvailsExpected    uint8
+}
+
+func (*SpliceInsert) CommandType() uint8 {
+	return SpliceInsertCommand
+}
+
+func parseSpliceInsert(c *cursor) *SpliceInsert {
+	cmd := &SpliceInsert{
+		SpliceEventId:   c.uint32(),
+		CancelIndicator: c.uint8()&0x80 > 0,
+	}
+	if cmd.CancelIndicator {
+		return cmd
+	}
+
+	flags := c.uint8()
+	cmd.OutOfNetwork = flags&0x80 > 0
+	cmd.ProgramSplice = flags&0x40 > 0
+	cmd.SpliceImmediate = flags&0x10 > 0
+	cmd.EventIdCompliance = flags&0x08 > 0
+	if cmd.ProgramSplice && !cmd.SpliceImmediate {
+		cmd.SpliceTime = c.spliceTime()
+	}
+	if !cmd.ProgramSplice {
+		components := int(c.uint8())
+		for i := 0; i < components && !c.failed; i++ {
+			component := InsertComponent{ComponentTag: c.uint8()}
+			if !cmd.SpliceImmediate {
+				component.SpliceTime = c.spliceTime()
+			}
+			cmd.Components = append(cmd.Components, component)
+		}
+	}
+	if flags&0x20 > 0 {
+		cmd.BreakDuration = c.breakDuration()
+	}
+	cmd.UniqueProgramId = c.uint16()
+	cmd.AvailNum = c.uint8()
+	cmd.AvailsExpected = c.uint8()
+
+	return cmd
+}
+
+type TimeSignal struct {
+	SpliceTime SpliceTime
+}
+
+func (*TimeSignal) CommandType() uint8 {
+	return TimeSignalCommand
+}
+
+type BandwidthReservation struct{}
+
+func (*BandwidthReservation) CommandType() uint8 {
+	return BandwidthReservationCommand
+}
+
+type Private struct {
+	Identifier uint32
+	Data       []byte
+}
+
+func (*Private) CommandType() uint8 {
+	return PrivateCommand
+}
+
+// parseSpliceCommand returns the command and the number of bytes it takes.
+func parseSpliceCommand(commandType uint8, data []byte) (SpliceCommand, int, error) {
+	c := &cursor{data: data}
+
+	var cmd SpliceCommand
+	switch commandType {
+	case SpliceNullCommand:
+		cmd = new(SpliceNull)
+	case SpliceScheduleCommand:
+		cmd = parseSpliceSchedule(c)
+	case SpliceInsertCommand:
+		cmd = parseSpliceInsert(c)
+	case TimeSignalCommand:
+		cmd = &TimeSignal{c.spliceTime()}
+	case BandwidthReservationCommand:
+		cmd = new(BandwidthReservation)
+	case PrivateCommand:
+		cmd = &Private{c.uint32(), c.data[c.pos:]}
+		c.pos = len(c.data)
+	default:
+		return nil, 0, ErrUnknownCommandType
+	}
+
+	if c.failed {
+		return nil, 0, ErrInvalidSection
+	}
+	return cmd, c.pos, nil
+}
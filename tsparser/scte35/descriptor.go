@@ -0,0 +1,350 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	AvailDescriptor        uint8 = 0x00
+	DTMFDescriptor         uint8 = 0x01
+	SegmentationDescriptor uint8 = 0x02
+	TimeDescriptor         uint8 = 0x03
+	AudioDescriptor        uint8 = 0x04
+
+	// CUEIdentifier is the identifier "CUEI" of the descriptors defined by
+	// SCTE 35.
+	CUEIdentifier uint32 = 0x43554549
+)
+
+// SpliceDescriptor is a splice_descriptor() made of splice_descriptor_tag,
+// descriptor_length, identifier and the private bytes.
+type SpliceDescriptor []byte
+
+func ParseSpliceDescriptors(data []byte) []SpliceDescriptor {
+	var descriptors []SpliceDescriptor
+	for i := 0; i+2 <= len(data); {
+		end := i + 2 + int(data[i+1])
+		if end > len(data) {
+			break
+		}
+
+		descriptors = append(descriptors, SpliceDescriptor(data[i:end]))
+		i = end
+	}
+
+	return descriptors
+}
+
+func (d SpliceDescriptor) Tag() uint8 {
+	return d[0]
+}
+
+func (d SpliceDescriptor) Identifier() uint32 {
+	if len(d) < 6 {
+		return 0
+	}
+
+	return uint32(d[2])<<24 | uint32(d[3])<<16 | uint32(d[4])<<8 | uint32(d[5])
+}
+
+// Payload returns the bytes following identifier.
+func (d SpliceDescriptor) Payload() []byte {
+	if len(d) < 6 {
+		return nil
+	}
+
+	return d[6:]
+}
+
+func payloadOf(d SpliceDescriptor, tag uint8) []byte {
+	if len(d) < 6 || d.Tag() != tag || d.Identifier() != CUEIdentifier {
+		return nil
+	}
+
+	return d.Payload()
+}
+
+// ParseAvailDescriptor returns provider_avail_id.
+func ParseAvailDescriptor(d SpliceDescriptor) (uint32, bool) {
+	c := &cursor{data: payloadOf(d, AvailDescriptor)}
+	id := c.uint32()
+	return id, !c.failed
+}
+
+type DTMF struct {
+	Preroll uint8
+	Chars   string
+}
+
+func ParseDTMFDescriptor(d SpliceDescriptor) *DTMF {
+	c := &cursor{data: payloadOf(d, DTMFDescriptor)}
+	dtmf := &DTMF{Preroll: c.uint8()}
+	dtmf.Chars = string(c.bytes(int(c.uint8() >> 5)))
+	if c.failed {
+		return nil
+	}
+
+	return dtmf
+}
+
+// ParseTimeDescriptor returns the TAI time and UTC_offset.
+func ParseTimeDescriptor(d SpliceDescriptor) (time.Time, uint16, bool) {
+	c := &cursor{data: payloadOf(d, TimeDescriptor)}
+	b := c.bytes(6)
+	seconds := int64(b[0])<<40 | int64(b[1])<<32 | int64(b[2])<<24 | int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+	nanoseconds := int64(c.uint32())
+	offset := c.uint16()
+	if c.failed {
+		return time.Time{}, 0, false
+	}
+
+	return time.Unix(seconds, nanoseconds).UTC(), offset, true
+}
+
+type AudioComponent struct {
+	ComponentTag     uint8
+	Language         string
+	BitStreamMode    uint8
+	NumChannels      uint8
+	FullServiceAudio bool
+}
+
+func ParseAudioDescriptor(d SpliceDescriptor) []AudioComponent {
+	c := &cursor{data: payloadOf(d, AudioDescriptor)}
+	count := int(c.uint8() >> 4)
+
+	var components []AudioComponent
+	for i := 0; i < count; i++ {
+		tag := c.uint8()
+		language := string(c.bytes(3))
+		flags := c.uint8()
+		if c.failed {
+			break
+		}
+
+		components = append(components, AudioComponent{
+			ComponentTag:     tag,
+			Language:         language,
+			BitStreamMode:    flags >> 5,
+			NumChannels:      flags >> 1 & 0x0f,
+			FullServiceAudio: flags&0x01 > 0,
+		})
+	}
+
+	return components
+}
+
+const (
+	UPIDNotUsed     uint8 = 0x00
+	UPIDUserDefined uint8 = 0x01
+	UPIDISCI        uint8 = 0x02
+	UPIDAdID        uint8 = 0x03
+	UPIDUMID        uint8 = 0x04
+	UPIDISANLegacy  uint8 = 0x05
+	UPIDISAN        uint8 = 0x06
+	UPIDTID         uint8 = 0x07
+	UPIDTI          uint8 = 0x08
+	UPIDADI         uint8 = 0x09
+	UPIDEIDR        uint8 = 0x0A
+	UPIDATSC        uint8 = 0x0B
+	UPIDMPU         uint8 = 0x0C
+	UPIDMID         uint8 = 0x0D
+	UPIDADS         uint8 = 0x0E
+	UPIDURI         uint8 = 0x0F
+	UPIDUUID        uint8 = 0x10
+)
+
+// UPID is a segmentation_upid.
+type UPID struct {
+	Type  uint8
+	Value []byte
+}
+
+// String formats the UPID in the notation of its type.  Types without a
+// textual notation are formatted in hexadecimal.
+func (u UPID) String() string {
+	switch u.Type {
+	case UPIDISCI, UPIDAdID, UPIDTID, UPIDADI, UPIDADS, UPIDURI:
+		return string(u.Value)
+	case UPIDTI:
+		if len(u.Value) == 8 {
+			var ti uint64
+			for _, b := range u.Value {
+				ti = ti<<8 | uint64(b)
+			}
+			return fmt.Sprintf("%d", ti)
+		}
+	case UPIDEIDR:
+		if len(u.Value) == 12 {
+			v := hex.EncodeToString(u.Value[2:])
+			return fmt.Sprintf("10.%d/%s-%s-%s-%s-%s", uint16(u.Value[0])<<8|uint16(u.Value[1]),
+				v[0:4], v[4:8], v[8:12], v[12:16], v[16:20])
+		}
+	case UPIDMID:
+		var s string
+		for i, upid := range u.MID() {
+			if i > 0 {
+				s += ","
+			}
+			s += upid.String()
+		}
+		return s
+	}
+
+	return hex.EncodeToString(u.Value)
+}
+
+// MID returns the UPIDs contained in a UPID of the type MID.
+func (u UPID) MID() []UPID {
+	if u.Type != UPIDMID {
+		return nil
+	}
+
+	var upids []UPID
+	for i := 0; i+2 <= len(u.Value); {
+		end := i + 2 + int(u.Value[i+1])
+		if end > len(u.Value) {
+			break
+		}
+
+		upids = append(upids, UPID{u.Value[i], u.Value[i+2 : end]})
+		i = end
+	}
+
+	return upids
+}
+
+const (
+	SegmentationNotIndicated                         uint8 = 0x00
+	SegmentationContentIdentification                uint8 = 0x01
+	SegmentationProgramStart                         uint8 = 0x10
+	SegmentationProgramEnd                           uint8 = 0x11
+	SegmentationProgramEarlyTermination              uint8 = 0x12
+	SegmentationProgramBreakaway                     uint8 = 0x13
+	SegmentationProgramResumption                    uint8 = 0x14
+	SegmentationProgramRunoverPlanned                uint8 = 0x15
+	SegmentationProgramRunoverUnplanned              uint8 = 0x16
+	SegmentationProgramOverlapStart                  uint8 = 0x17
+	SegmentationProgramBlackoutOverride              uint8 = 0x18
+	SegmentationProgramStartInProgress               uint8 = 0x19
+	SegmentationChapterStart                         uint8 = 0x20
+	SegmentationChapterEnd                           uint8 = 0x21
+	SegmentationBreakStart                           uint8 = 0x22
+	SegmentationBreakEnd                             uint8 = 0x23
+	SegmentationOpeningCreditStart                   uint8 = 0x24
+	SegmentationOpeningCreditEnd                     uint8 = 0x25
+	SegmentationClosingCreditStart                   uint8 = 0x26
+	SegmentationClosingCreditEnd                     uint8 = 0x27
+	SegmentationProviderAdvertisementStart           uint8 = 0x30
+	SegmentationProviderAdvertisementEnd             uint8 = 0x31
+	SegmentationDistributorAdvertisementStart        uint8 = 0x32
+	SegmentationDistributorAdvertisementEnd          uint8 = 0x33
+	SegmentationProviderPlacementOpportunityStart    uint8 = 0x34
+	SegmentationProviderPlacementOpportunityEnd      uint8 = 0x35
+	SegmentationDistributorPlacementOpportunityStart uint8 = 0x36
+	SegmentationDistributorPlacementOpportunityEnd   uint8 = 0x37
+	SegmentationProviderOverlayPlacementStart        uint8 = 0x38
+	SegmentationProviderOverlayPlacementEnd          uint8 = 0x39
+	SegmentationDistributorOverlayPlacementStart     uint8 = 0x3A
+	SegmentationDistributorOverlayPlacementEnd       uint8 = 0x3B
+	SegmentationUnscheduledEventStart                uint8 = 0x40
+	SegmentationUnscheduledEventEnd                  uint8 = 0x41
+	SegmentationNetworkStart                         uint8 = 0x50
+	SegmentationNetworkEnd                           uint8 = 0x51
+)
+
+type SegmentationComponent struct {
+	ComponentTag uint8
+	PTSOffset    uint64
+}
+
+type Segmentation struct {
+	SegmentationEventId   uint32
+	CancelIndicator       bool
+	ProgramSegmentation   bool
+	DeliveryNotRestricted bool
+	WebDeliveryAllowed    bool
+	NoRegionalBlackout    bool
+	ArchiveAllowed        bool
+	DeviceRestrictions    uint8
+	Components            []SegmentationComponent
+
+	// Duration is segmentation_duration in 90 kHz, which is valid if
+	// HasDuration is set.
+	Duration    uint64
+	HasDuration bool
+
+	UPID                UPID
+	TypeId              uint8
+	SegmentNum          uint8
+	SegmentsExpected    uint8
+	SubSegmentNum       uint8
+	SubSegmentsExpected uint8
+}
+
+func (s *Segmentation) Time() time.Duration {
+	return time.Duration(s.Duration) * time.Second / tsparser.ClockRate
+}
+
+func ParseSegmentationDescriptor(d SpliceDescriptor) *Segmentation {
+	c := &cursor{data: payloadOf(d, SegmentationDescriptor)}
+	s := &Segmentation{
+		SegmentationEventId: c.uint32(),
+		CancelIndicator:     c.uint8()&0x80 > 0,
+	}
+	if c.failed {
+		return nil
+	} else if s.CancelIndicator {
+		return s
+	}
+
+	flags := c.uint8()
+	s.ProgramSegmentation = flags&0x80 > 0
+	s.HasDuration = flags&0x40 > 0
+	s.DeliveryNotRestricted = flags&0x20 > 0
+	if !s.DeliveryNotRestricted {
+		s.WebDeliveryAllowed = flags&0x10 > 0
+		s.NoRegionalBlackout = flags&0x08 > 0
+		s.ArchiveAllowed = flags&0x04 > 0
+		s.DeviceRestrictions = flags & 0x03
+	}
+
+	if !s.ProgramSegmentation {
+		count := int(c.uint8())
+		for i := 0; i < count && !c.failed; i++ {
+			tag := c.uint8()
+			_, offset := c.uint33()
+			s.Components = append(s.Components, SegmentationComponent{tag, offset})
+		}
+	}
+	if s.HasDuration {
+		b := c.bytes(5)
+		s.Duration = uint64(b[0])<<32 | uint64(b[1])<<24 | uint64(b[2])<<16 | uint64(b[3])<<8 | uint64(b[4])
+	}
+
+	s.UPID.Type = c.uint8()
+	s.UPID.Value = c.bytes(int(c.uint8()))
+	s.TypeId = c.uint8()
+	s.SegmentNum = c.uint8()
+	s.SegmentsExpected = c.uint8()
+	if c.failed {
+		return nil
+	}
+
+	// sub_segment_num and sub_segments_expected were added later, so they
+	// may be absent.
+	if hasSubSegments(s.TypeId) && c.remaining() >= 2 {
+		s.SubSegmentNum = c.uint8()
+		s.SubSegmentsExpected = c.uint8()
+	}
+
+	return s
+}
@@ -0,0 +1,518 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/video"
+)
+
+var (
+	ErrCuesLeft     = errors.New("Some cues are not injected before the end of the stream")
+	ErrPIDCollision = errors.New("The PID of the SCTE-35 stream is used in the input")
+)
+
+const (
+	registrationDescriptor      tsparser.DescriptorTag = 0x05
+	conditionalAccessDescriptor tsparser.DescriptorTag = 0x09
+	cueIdentifierDescriptor     tsparser.DescriptorTag = 0x8A
+	cueStreamTypeAllCommands    byte                   = 0x01
+
+	// maxPMTWaits bounds the repetitions of the PMT of the program held
+	// back while waiting for the PMTs of the other programs to choose the
+	// PID of the SCTE-35 stream.
+	maxPMTWaits = 10
+	minPID      = 0x0020
+
+	// maxHeldPackets bounds the packets held while waiting for a random
+	// access point to condition a splice point, about 20 MB.
+	maxHeldPackets = 100000
+	maxPESData     = 64 * 1024
+)
+
+type pendingCue struct {
+	section *SpliceInfoSection
+	preroll int64
+}
+
+// heldItem is a packet held back, or a PSI table to be written in place
+// of the packets which carried it.
+type heldItem struct {
+	packet tsparser.Packet
+	pid    tsparser.PID
+	table  tsparser.Table
+}
+
+// Injector inserts splice_info_sections into a program.  The PMT is
+// rewritten to announce an SCTE-35 stream and a section is sent when the
+// program clock reaches its splice time less the preroll.  With
+// conditioning, the splice time is moved to the first random access point
+// of the video stream at or after it, so that the splice point falls on a
+// segment boundary of the downstream segmenters.
+type Injector struct {
+	s         tsparser.PacketStream
+	w         *tsparser.PacketWriter
+	program   *tsparser.ProgramTracker
+	logger    *log.Logger
+	pid       tsparser.PID
+	condition bool
+	cues      []*pendingCue
+	injected  int
+
+	collector  *tsparser.TableCollector
+	networkPID tsparser.PID
+	programs   map[uint16]tsparser.PID
+	pmts       map[uint16]*tsparser.ProgramMapSection
+	waits      int
+	video      *tsparser.ElementaryStream
+	timeline   *tsparser.Timeline
+	seen       map[tsparser.PID]bool
+	ready      bool
+
+	holding  *pendingCue
+	held     []heldItem
+	hasPES   bool
+	pesIndex int
+	pesPTS   uint64
+	pesData  []byte
+}
+
+func NewInjector(s tsparser.PacketStream, w io.Writer, serviceId uint16, logger *log.Logger) *Injector {
+	return &Injector{
+		s:          s,
+		w:          tsparser.NewPacketWriter(w),
+		program:    tsparser.NewProgramTracker(serviceId),
+		logger:     logger,
+		pid:        tsparser.NullPID,
+		collector:  tsparser.NewTableCollector(),
+		networkPID: tsparser.NullPID,
+		programs:   make(map[uint16]tsparser.PID),
+		pmts:       make(map[uint16]*tsparser.ProgramMapSection),
+		timeline:   tsparser.NewTimeline(tsparser.NullPID),
+		seen:       make(map[tsparser.PID]bool),
+	}
+}
+
+func (inj *Injector) log(p tsparser.Packet, v ...interface{}) {
+	if inj.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	inj.logger.Print(values...)
+}
+
+// SetPID sets the PID of the SCTE-35 stream.  By default, a PID next to the
+// highest one of the program that is referenced by neither the PAT nor any
+// PMT is chosen.  Run fails with ErrPIDCollision once the PID turns out to
+// be used in the input.
+func (inj *Injector) SetPID(pid tsparser.PID) {
+	inj.pid = pid
+}
+
+// SetConditioning enables moving splice points onto random access points
+// of the video stream.
+func (inj *Injector) SetConditioning(condition bool) {
+	inj.condition = condition
+}
+
+// AddCue schedules a section to be sent preroll ahead of its splice time.
+// A section without splice time, e.g. a splice_insert with
+// splice_immediate_flag, is sent as soon as the PMT is rewritten.
+func (inj *Injector) AddCue(section *SpliceInfoSection, preroll time.Duration) {
+	inj.cues = append(inj.cues, &pendingCue{
+		section: section,
+		preroll: int64(preroll * tsparser.ClockRate / time.Second),
+	})
+}
+
+// Injected returns the number of sections sent so far.
+func (inj *Injector) Injected() int {
+	return inj.injected
+}
+
+func (inj *Injector) Run() error {
+	for inj.s.Scan() {
+		if err := inj.handle(inj.s.Packet()); err != nil {
+			return err
+		}
+	}
+
+	if inj.holding != nil {
+		if err := inj.release(-1); err != nil {
+			return err
+		}
+	}
+	if len(inj.cues) > 0 {
+		return ErrCuesLeft
+	}
+	return nil
+}
+
+func (inj *Injector) handle(packet tsparser.Packet) error {
+	pid := packet.PID()
+	inj.seen[pid] = true
+	if inj.timeline.Feed(packet) {
+		inj.log(packet, "PCR discontinuity")
+	}
+
+	switch {
+	case pid == inj.pid && inj.ready:
+		return ErrPIDCollision
+	case pid == inj.program.PMTPID():
+		return inj.handleTables(packet)
+	case pid == tsparser.ProgramAssociationPID || inj.isProgramMapPID(pid):
+		if err := inj.handleTables(packet); err != nil {
+			return err
+		}
+	}
+
+	if inj.holding == nil {
+		if err := inj.inject(); err != nil {
+			return err
+		}
+	}
+	if inj.holding != nil {
+		return inj.hold(packet)
+	}
+	return inj.w.WritePacket(packet)
+}
+
+func (inj *Injector) handleTables(packet tsparser.Packet) error {
+	tables, err := inj.collector.Feed(packet)
+	if err != nil {
+		inj.log(packet, err)
+	}
+
+	pid := packet.PID()
+	for _, table := range tables {
+		if !table.CheckCRC() {
+			inj.log(packet, "CRC mismatch")
+			continue
+		}
+
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			if err := inj.handleProgramAssociation(tsparser.ParseProgramAssociationSection(table)); err != nil {
+				return err
+			}
+		case tsparser.ProgramMapTable:
+			pmt := tsparser.ParseProgramMapSection(table)
+			if inj.pid != tsparser.NullPID && referencedPIDs(pmt)[inj.pid] {
+				return ErrPIDCollision
+			}
+			inj.pmts[pmt.ProgramNumber()] = pmt
+			if pid != inj.program.PMTPID() || pmt.ProgramNumber() != inj.program.ProgramNumber() {
+				break
+			}
+
+			if !inj.ready {
+				if ok, err := inj.choosePID(packet, pmt); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+			}
+			rewritten, err := inj.rewriteProgramMap(tsparser.ParseProgramMapSection(table))
+			if err != nil {
+				return err
+			}
+			table = rewritten
+		}
+
+		if pid == inj.program.PMTPID() {
+			if err := inj.writeTable(pid, table); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (inj *Injector) handleProgramAssociation(pat *tsparser.ProgramAssociationSection) error {
+	inj.program.Update(pat, nil)
+
+	programs := pat.ProgramMap()
+	for number, pid := range inj.programs {
+		if programs[number] != pid {
+			inj.collector.Reset(pid)
+			delete(inj.pmts, number)
+		}
+	}
+	inj.programs = make(map[uint16]tsparser.PID, len(programs))
+	for number, pid := range programs {
+		inj.programs[number] = pid
+		if pid == inj.pid && inj.pid != tsparser.NullPID {
+			return ErrPIDCollision
+		}
+	}
+	inj.networkPID = pat.NetworkPID()
+	if inj.networkPID == inj.pid && inj.pid != tsparser.NullPID {
+		return ErrPIDCollision
+	}
+
+	return nil
+}
+
+func (inj *Injector) isProgramMapPID(pid tsparser.PID) bool {
+	for _, p := range inj.programs {
+		if p == pid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// referencedPIDs returns the PIDs of the PCR, the elementary streams and the
+// ECMs of a program.
+func referencedPIDs(pmt *tsparser.ProgramMapSection) map[tsparser.PID]bool {
+	pids := map[tsparser.PID]bool{pmt.PCRPID(): true}
+	descriptors := pmt.Descriptors()
+	for _, es := range pmt.Streams() {
+		pids[es.PID()] = true
+		descriptors = append(descriptors[:len(descriptors):len(descriptors)], es.Descriptors()...)
+	}
+
+	for _, d := range descriptors {
+		if d.Tag() == conditionalAccessDescriptor && d.Length() >= 4 {
+			payload := d.Payload()
+			pids[tsparser.PID(payload[2]&0x1f)<<8|tsparser.PID(payload[3])] = true
+		}
+	}
+
+	return pids
+}
+
+// choosePID decides the PID of the SCTE-35 stream from the PIDs referenced
+// by the PAT and the PMTs of all programs.  The PMT of the program is held
+// back until the PMTs of the other programs arrive, at most maxPMTWaits
+// times.
+func (inj *Injector) choosePID(packet tsparser.Packet, pmt *tsparser.ProgramMapSection) (bool, error) {
+	missing := 0
+	for number := range inj.programs {
+		if _, ok := inj.pmts[number]; !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		if inj.waits++; inj.waits <= maxPMTWaits {
+			return false, nil
+		}
+		inj.log(packet, missing, " PMTs are missing to choose the PID of the SCTE-35 stream")
+	}
+
+	used := map[tsparser.PID]bool{
+		tsparser.ProgramAssociationPID: true,
+		inj.networkPID:                 true,
+		tsparser.NullPID:               true,
+	}
+	for _, pid := range inj.programs {
+		used[pid] = true
+	}
+	for _, pmt := range inj.pmts {
+		for pid := range referencedPIDs(pmt) {
+			used[pid] = true
+		}
+	}
+
+	if inj.pid != tsparser.NullPID {
+		if used[inj.pid] || inj.seen[inj.pid] {
+			return false, ErrPIDCollision
+		}
+		return true, nil
+	}
+
+	highest := inj.program.PMTPID()
+	for pid := range referencedPIDs(pmt) {
+		if pid > highest && pid != tsparser.NullPID {
+			highest = pid
+		}
+	}
+	pid := highest + 1
+	for n := 0; n < int(tsparser.NullPID-minPID); n, pid = n+1, pid+1 {
+		if pid < minPID || pid >= tsparser.NullPID {
+			pid = minPID
+		}
+		if !used[pid] && !inj.seen[pid] {
+			inj.pid = pid
+			return true, nil
+		}
+	}
+
+	return false, ErrPIDCollision
+}
+
+func (inj *Injector) rewriteProgramMap(pmt *tsparser.ProgramMapSection) (tsparser.Table, error) {
+	inj.timeline.SetPCRPID(pmt.PCRPID())
+	inj.video = video.FindStream(pmt)
+
+	registered := false
+	for _, d := range pmt.Descriptors() {
+		if d.Tag() == registrationDescriptor && string(d.Payload()) == "CUEI" {
+			registered = true
+		}
+	}
+	if !registered {
+		descriptors := append(pmt.Descriptors(), tsparser.NewDescriptor(registrationDescriptor, []byte("CUEI")))
+		pmt.SetDescriptors(descriptors...)
+	}
+
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.SCTE35Stream, inj.pid,
+		tsparser.NewDescriptor(cueIdentifierDescriptor, []byte{cueStreamTypeAllCommands})))
+	inj.ready = true
+	return pmt.Marshal()
+}
+
+// inject sends the sections whose time has come, or starts holding the
+// stream back to condition the splice point.
+func (inj *Injector) inject() error {
+	if !inj.ready {
+		return nil
+	}
+
+	cues := inj.cues[:0]
+	for i, cue := range inj.cues {
+		pts, ok := cue.section.SpliceTime()
+		if ok && (!inj.timeline.HasPCR() || inj.timeline.Clock() < inj.timeline.Timestamp(pts)-cue.preroll) {
+			cues = append(cues, cue)
+			continue
+		}
+
+		if ok && inj.condition && inj.video != nil {
+			inj.holding = cue
+			cues = append(cues, inj.cues[i+1:]...)
+			break
+		}
+		if err := inj.send(cue.section); err != nil {
+			return err
+		}
+	}
+	inj.cues = cues
+
+	return nil
+}
+
+func (inj *Injector) send(section *SpliceInfoSection) error {
+	table, err := section.Marshal()
+	if err != nil {
+		return err
+	}
+
+	inj.injected++
+	return inj.w.WriteTables(inj.pid, table)
+}
+
+func (inj *Injector) writeTable(pid tsparser.PID, table tsparser.Table) error {
+	if inj.holding != nil {
+		inj.held = append(inj.held, heldItem{pid: pid, table: table})
+		return nil
+	}
+
+	return inj.w.WriteTables(pid, table)
+}
+
+// hold keeps packets back until a random access point of the video stream
+// is found at or after the splice time.  A PES packet is examined when the
+// next one begins.
+func (inj *Injector) hold(packet tsparser.Packet) error {
+	copied := make(tsparser.Packet, len(packet))
+	copy(copied, packet)
+	inj.held = append(inj.held, heldItem{packet: copied})
+
+	if packet.PID() == inj.video.PID() && packet.HasPayload() {
+		if packet.PayloadUnitStartIndicator() {
+			if inj.isSplicePoint() {
+				return inj.release(inj.pesIndex)
+			}
+
+			pes := tsparser.PES(packet.Payload())
+			inj.hasPES = len(pes) >= 14 && pes.HasPTS()
+			if inj.hasPES {
+				inj.pesIndex, inj.pesPTS = len(inj.held)-1, pes.PTS()
+				inj.pesData = append(inj.pesData[:0], pes...)
+			}
+		} else if inj.hasPES && len(inj.pesData) < maxPESData {
+			inj.pesData = append(inj.pesData, packet.Payload()...)
+		}
+	}
+
+	if len(inj.held) > maxHeldPackets {
+		inj.log(packet, "random access point is not found for the splice point")
+		return inj.release(-1)
+	}
+	return nil
+}
+
+func (inj *Injector) isSplicePoint() bool {
+	if !inj.hasPES {
+		return false
+	}
+
+	pts, _ := inj.holding.section.SpliceTime()
+	return tsparser.TimestampDiff(inj.pesPTS, pts) >= 0 &&
+		video.IsRandomAccess(inj.video.StreamType(), tsparser.PES(inj.pesData).Payload())
+}
+
+// release sends the held section followed by the held packets.  If index
+// points to a held packet beginning a random access point, the splice time
+// is moved onto its PTS.
+func (inj *Injector) release(index int) error {
+	section := inj.holding.section
+	if index >= 0 {
+		section = withSpliceTime(section, (inj.pesPTS-section.PTSAdjustment())&tsparser.TimestampMask)
+
+		if af := inj.held[index].packet.AdaptationField(); af != nil {
+			af.SetRandomAccessIndicator(true)
+		}
+	}
+
+	held := inj.held
+	inj.holding, inj.held, inj.hasPES = nil, nil, false
+	if err := inj.send(section); err != nil {
+		return err
+	}
+
+	for _, item := range held {
+		var err error
+		if item.table != nil {
+			err = inj.w.WriteTables(item.pid, item.table)
+		} else {
+			err = inj.w.WritePacket(item.packet)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withSpliceTime returns a copy of the section whose splice time is moved
+// to pts, leaving the section of the caller intact.
+func withSpliceTime(section *SpliceInfoSection, pts uint64) *SpliceInfoSection {
+	copied := *section
+	switch cmd := section.command.(type) {
+	case *SpliceInsert:
+		c := *cmd
+		c.SpliceTime.PTS = pts
+		copied.command = &c
+	case *TimeSignal:
+		c := *cmd
+		c.SpliceTime.PTS = pts
+		copied.command = &c
+	}
+
+	return &copied
+}
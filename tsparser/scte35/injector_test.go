@@ -0,0 +1,230 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/internal/fixture"
+)
+
+var (
+	idrSlice = []byte{0x65, 0x88, 0x84, 0x00}
+	pSlice   = []byte{0x41, 0x9a, 0x02, 0x00}
+)
+
+const firstPTS = 900000
+
+func picturePTS(i int) uint64 {
+	return firstPTS + uint64(i)*3003
+}
+
+// injectorStream makes a program of PMT PID 0x1000 with an H.264 stream on
+// 0x0100, which carries PCR, and pictures with an IDR every 6 pictures.
+// The PCR of each picture is the PTS of the previous one.  The PMTs of the
+// other programs are sent on 0x1010, 0x1020 and so on before the program.
+func injectorStream(t *testing.T, pictures int, others ...*tsparser.ProgramMapSection) *bytes.Buffer {
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	for i, other := range others {
+		pat.SetProgram(other.ProgramNumber(), tsparser.PID(0x1010+0x10*i))
+	}
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w := tsparser.NewPacketWriter(buf)
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	for i, other := range others {
+		table, err := other.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteTables(tsparser.PID(0x1010+0x10*i), table)
+	}
+	w.WriteTables(0x1000, pmtTable)
+	for i := 0; i < pictures; i++ {
+		slice := pSlice
+		if i%6 == 0 {
+			slice = idrSlice
+		}
+
+		pts := picturePTS(i)
+		af := tsparser.NewPCRAdaptationField((pts-3003)*300, false, false)
+		if err := w.WriteUnit(0x0100, af, fixture.PES(0xe0, pts, fixture.AnnexB(slice))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf
+}
+
+type injected struct {
+	packets  []tsparser.Packet
+	pmt      *tsparser.ProgramMapSection
+	sections []*SpliceInfoSection
+	// positions are the indices of the packets carrying the sections.
+	positions []int
+}
+
+func readInjected(t *testing.T, buf *bytes.Buffer, pid tsparser.PID) *injected {
+	out := new(injected)
+	collector := tsparser.NewTableCollector()
+	s := tsparser.NewPacketScanner(buf, nil)
+	for s.Scan() {
+		packet := append(tsparser.Packet(nil), s.Packet()...)
+		out.packets = append(out.packets, packet)
+		if packet.PID() != 0x1000 && packet.PID() != pid {
+			continue
+		}
+
+		tables, err := collector.Feed(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, table := range tables {
+			switch packet.PID() {
+			case 0x1000:
+				out.pmt = tsparser.ParseProgramMapSection(table)
+			case pid:
+				section, err := ParseSpliceInfoSection(table)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out.sections = append(out.sections, section)
+				out.positions = append(out.positions, len(out.packets)-1)
+			}
+		}
+	}
+	return out
+}
+
+func TestInjector(t *testing.T) {
+	var out bytes.Buffer
+	inj := NewInjector(tsparser.NewPacketScanner(injectorStream(t, 8), nil), &out, 0, nil)
+	inj.AddCue(NewSpliceInfoSection(&SpliceInsert{
+		SpliceEventId:   1,
+		OutOfNetwork:    true,
+		ProgramSplice:   true,
+		SpliceImmediate: true,
+	}), 0)
+	inj.AddCue(NewSpliceInfoSection(&TimeSignal{SpliceTime{Specified: true, PTS: picturePTS(5)}}), 0)
+	if err := inj.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if inj.Injected() != 2 {
+		t.Errorf("%d sections are injected, want 2", inj.Injected())
+	}
+
+	// The PID next to the PMT PID is chosen.
+	result := readInjected(t, &out, 0x1001)
+	if result.pmt == nil {
+		t.Fatal("PMT is not found")
+	}
+	streams := result.pmt.Streams()
+	if len(streams) != 2 || streams[1].StreamType() != tsparser.SCTE35Stream || streams[1].PID() != 0x1001 {
+		t.Errorf("streams = %+v", streams)
+	}
+	registered := false
+	for _, d := range result.pmt.Descriptors() {
+		registered = registered || string(d.Payload()) == "CUEI"
+	}
+	if !registered {
+		t.Error("registration_descriptor is not added")
+	}
+
+	if len(result.sections) != 2 {
+		t.Fatalf("%d sections, want 2", len(result.sections))
+	}
+	if result.sections[0].CommandType() != SpliceInsertCommand || result.sections[1].CommandType() != TimeSignalCommand {
+		t.Errorf("commands = %d, %d", result.sections[0].CommandType(), result.sections[1].CommandType())
+	}
+	// The splice_insert follows the PMT and the time_signal precedes the
+	// picture whose PCR reaches its splice time.
+	if result.positions[0] != 2 || result.positions[1] != 9 {
+		t.Errorf("positions = %v, want [2 9]", result.positions)
+	}
+	if len(result.packets) != 12 {
+		t.Errorf("%d packets, want 12", len(result.packets))
+	}
+}
+
+func TestInjectorConditioning(t *testing.T) {
+	var out bytes.Buffer
+	inj := NewInjector(tsparser.NewPacketScanner(injectorStream(t, 8), nil), &out, 1, nil)
+	inj.SetPID(0x01f0)
+	inj.SetConditioning(true)
+	inj.AddCue(NewSpliceInfoSection(&TimeSignal{SpliceTime{Specified: true, PTS: picturePTS(4)}}), 0)
+	if err := inj.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := readInjected(t, &out, 0x01f0)
+	if len(result.sections) != 1 {
+		t.Fatalf("%d sections, want 1", len(result.sections))
+	}
+	// The splice point is moved onto the IDR picture 6 and the section is
+	// sent before the packets held since the PCR reached picture 4.
+	if pts, ok := result.sections[0].SpliceTime(); !ok || pts != picturePTS(6) {
+		t.Errorf("splice time = %d (%v), want %d", pts, ok, picturePTS(6))
+	}
+	if result.positions[0] != 7 {
+		t.Errorf("position = %d, want 7", result.positions[0])
+	}
+	for i, packet := range result.packets[8:] {
+		if got := packet.AdaptationField().RandomAccessIndicator(); got != (i == 1) {
+			t.Errorf("picture %d: random_access_indicator = %v", i+5, got)
+		}
+	}
+}
+
+func TestInjectorCuesLeft(t *testing.T) {
+	var out bytes.Buffer
+	inj := NewInjector(tsparser.NewPacketScanner(injectorStream(t, 2), nil), &out, 0, nil)
+	inj.AddCue(NewSpliceInfoSection(&TimeSignal{SpliceTime{Specified: true, PTS: picturePTS(10)}}), 0)
+	if err := inj.Run(); err != ErrCuesLeft {
+		t.Errorf("err = %v, want %v", err, ErrCuesLeft)
+	}
+	if inj.Injected() != 0 {
+		t.Errorf("%d sections are injected, want 0", inj.Injected())
+	}
+}
+
+func TestInjectorPIDOfOtherPrograms(t *testing.T) {
+	// The PID next to the PMT PID is taken by another program.
+	other := tsparser.NewProgramMapSection(2, 0x1001)
+	other.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x1001))
+
+	var out bytes.Buffer
+	inj := NewInjector(tsparser.NewPacketScanner(injectorStream(t, 2, other), nil), &out, 1, nil)
+	inj.AddCue(NewSpliceInfoSection(&SpliceInsert{SpliceEventId: 1, ProgramSplice: true, SpliceImmediate: true}), 0)
+	if err := inj.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := readInjected(t, &out, 0x1002)
+	if len(result.sections) != 1 {
+		t.Errorf("%d sections on 0x1002, want 1", len(result.sections))
+	}
+}
+
+func TestInjectorPIDCollision(t *testing.T) {
+	var out bytes.Buffer
+	inj := NewInjector(tsparser.NewPacketScanner(injectorStream(t, 2), nil), &out, 0, nil)
+	inj.SetPID(0x0100)
+	if err := inj.Run(); err != ErrPIDCollision {
+		t.Errorf("err = %v, want %v", err, ErrPIDCollision)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+var ErrEncrypted = errors.New("Encrypted section can not be marshalled")
+
+func NewSpliceInfoSection(command SpliceCommand, descriptors ...SpliceDescriptor) *SpliceInfoSection {
+	return &SpliceInfoSection{
+		cwIndex:     0xff,
+		tier:        0xfff,
+		commandType: command.CommandType(),
+		command:     command,
+		descriptors: descriptors,
+	}
+}
+
+func (s *SpliceInfoSection) SetPTSAdjustment(adjustment uint64) {
+	s.ptsAdjustment = adjustment & tsparser.TimestampMask
+}
+
+func (s *SpliceInfoSection) SetTier(tier uint16) {
+	s.tier = tier & 0xfff
+}
+
+func (s *SpliceInfoSection) AddDescriptor(d SpliceDescriptor) {
+	s.descriptors = append(s.descriptors, d)
+}
+
+func (s *SpliceInfoSection) Marshal() (tsparser.Table, error) {
+	if s.encrypted || s.command == nil {
+		return nil, ErrEncrypted
+	}
+
+	command, err := marshalSpliceCommand(s.command)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{
+		s.protocolVersion,
+		byte(s.ptsAdjustment>>32) & 0x01,
+		byte(s.ptsAdjustment >> 24), byte(s.ptsAdjustment >> 16), byte(s.ptsAdjustment >> 8), byte(s.ptsAdjustment),
+		s.cwIndex,
+		byte(s.tier >> 4), byte(s.tier<<4) | byte(len(command)>>8)&0x0f, byte(len(command)),
+		s.commandType,
+	}
+	data = append(data, command...)
+
+	var loop []byte
+	for _, d := range s.descriptors {
+		loop = append(loop, d...)
+	}
+	data = append(data, byte(len(loop)>>8), byte(len(loop)))
+	data = append(data, loop...)
+	data = append(data, 0, 0, 0, 0)
+
+	table, err := tsparser.NewShortTable(SpliceInfoTable, false, data)
+	if err != nil {
+		return nil, err
+	}
+
+	end := len(table) - 4
+	crc := tsparser.CRC32(table[:end])
+	table[end], table[end+1], table[end+2], table[end+3] = byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc)
+	return table, nil
+}
+
+func putUint33(b []byte, flags byte, v uint64) []byte {
+	return append(b, flags|byte(v>>32)&0x01, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func putUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func putSpliceTime(b []byte, t SpliceTime) []byte {
+	if !t.Specified {
+		return append(b, 0x7f)
+	}
+
+	return putUint33(b, 0xfe, t.PTS)
+}
+
+func putBreakDuration(b []byte, d *BreakDuration) []byte {
+	flags := byte(0x7e)
+	if d.AutoReturn {
+		flags |= 0x80
+	}
+
+	return putUint33(b, flags, d.Duration)
+}
+
+func flag(v bool, bit byte) byte {
+	if v {
+		return bit
+	}
+
+	return 0
+}
+
+func marshalSpliceCommand(command SpliceCommand) ([]byte, error) {
+	var b []byte
+	switch cmd := command.(type) {
+	case *SpliceNull, *BandwidthReservation:
+	case *SpliceSchedule:
+		b = append(b, byte(len(cmd.Splices)))
+		for _, s := range cmd.Splices {
+			b = putUint32(b, s.SpliceEventId)
+			b = append(b, flag(s.CancelIndicator, 0x80)|0x7f)
+			if s.CancelIndicator {
+				continue
+			}
+
+			b = append(b, flag(s.OutOfNetwork, 0x80)|flag(s.ProgramSplice, 0x40)|flag(s.BreakDuration != nil, 0x20)|0x1f)
+			if s.ProgramSplice {
+				b = putUint32(b, s.UTCSpliceTime)
+			} else {
+				b = append(b, byte(len(s.Components)))
+				for _, component := range s.Components {
+					b = append(b, component.ComponentTag)
+					b = putUint32(b, component.UTCSpliceTime)
+				}
+			}
+			if s.BreakDuration != nil {
+				b = putBreakDuration(b, s.BreakDuration)
+			}
+			b = append(b, byte(s.UniqueProgramId>>8), byte(s.UniqueProgramId), s.AvailNum, s.AvailsExpected)
+		}
+	case *SpliceInsert:
+		b = putUint32(b, cmd.SpliceEventId)
+		b = append(b, flag(cmd.CancelIndicator, 0x80)|0x7f)
+		if cmd.CancelIndicator {
+			break
+		}
+
+		b = append(b, flag(cmd.OutOfNetwork, 0x80)|flag(cmd.ProgramSplice, 0x40)|flag(cmd.BreakDuration != nil, 0x20)|
+			flag(cmd.SpliceImmediate, 0x10)|flag(cmd.EventIdCompliance, 0x08)|0x07)
+		if cmd.ProgramSplice && !cmd.SpliceImmediate {
+			b = putSpliceTime(b, cmd.SpliceTime)
+		}
+		if !cmd.ProgramSplice {
+			b = append(b, byte(len(cmd.Components)))
+			for _, component := range cmd.Components {
+				b = append(b, component.ComponentTag)
+				if !cmd.SpliceImmediate {
+					b = putSpliceTime(b, component.SpliceTime)
+				}
+			}
+		}
+		if cmd.BreakDuration != nil {
+			b = putBreakDuration(b, cmd.BreakDuration)
+		}
+		b = append(b, byte(cmd.UniqueProgramId>>8), byte(cmd.UniqueProgramId), cmd.AvailNum, cmd.AvailsExpected)
+	case *TimeSignal:
+		b = putSpliceTime(b, cmd.SpliceTime)
+	case *Private:
+		b = putUint32(b, cmd.Identifier)
+		b = append(b, cmd.Data...)
+	default:
+		return nil, ErrUnknownCommandType
+	}
+
+	if len(b) >= 0xfff {
+		return nil, ErrInvalidCommandLength
+	}
+	return b, nil
+}
+
+// NewSpliceDescriptor builds a splice_descriptor with the identifier
+// "CUEI".
+func NewSpliceDescriptor(tag uint8, payload []byte) SpliceDescriptor {
+	d := SpliceDescriptor{tag, byte(4 + len(payload))}
+	d = putUint32(d, CUEIdentifier)
+	return append(d, payload...)
+}
+
+func NewAvailDescriptor(providerAvailId uint32) SpliceDescriptor {
+	return NewSpliceDescriptor(AvailDescriptor, putUint32(nil, providerAvailId))
+}
+
+func hasSubSegments(typeId uint8) bool {
+	switch typeId {
+	case SegmentationProviderPlacementOpportunityStart, SegmentationDistributorPlacementOpportunityStart,
+		SegmentationProviderOverlayPlacementStart, SegmentationDistributorOverlayPlacementStart:
+		return true
+	}
+
+	return false
+}
+
+func NewSegmentationDescriptor(s *Segmentation) SpliceDescriptor {
+	b := putUint32(nil, s.SegmentationEventId)
+	b = append(b, flag(s.CancelIndicator, 0x80)|0x7f)
+	if s.CancelIndicator {
+		return NewSpliceDescriptor(SegmentationDescriptor, b)
+	}
+
+	flags := flag(s.ProgramSegmentation, 0x80) | flag(s.HasDuration, 0x40) | flag(s.DeliveryNotRestricted, 0x20)
+	if s.DeliveryNotRestricted {
+		flags |= 0x1f
+	} else {
+		flags |= flag(s.WebDeliveryAllowed, 0x10) | flag(s.NoRegionalBlackout, 0x08) |
+			flag(s.ArchiveAllowed, 0x04) | s.DeviceRestrictions&0x03
+	}
+	b = append(b, flags)
+
+	if !s.ProgramSegmentation {
+		b = append(b, byte(len(s.Components)))
+		for _, component := range s.Components {
+			b = append(b, component.ComponentTag)
+			b = putUint33(b, 0xfe, component.PTSOffset)
+		}
+	}
+	if s.HasDuration {
+		d := s.Duration
+		b = append(b, byte(d>>32), byte(d>>24), byte(d>>16), byte(d>>8), byte(d))
+	}
+
+	b = append(b, s.UPID.Type, byte(len(s.UPID.Value)))
+	b = append(b, s.UPID.Value...)
+	b = append(b, s.TypeId, s.SegmentNum, s.SegmentsExpected)
+	if hasSubSegments(s.TypeId) {
+		b = append(b, s.SubSegmentNum, s.SubSegmentsExpected)
+	}
+
+	return NewSpliceDescriptor(SegmentationDescriptor, b)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalSamples(t *testing.T) {
+	for _, sample := range []string{timeSignalSample, spliceInsertSample} {
+		table := decodeSample(t, sample)
+		sec, err := ParseSpliceInfoSection(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		marshalled, err := sec.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(marshalled, table) {
+			t.Errorf("marshalled = % x, want % x", marshalled, table)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	commands := []SpliceCommand{
+		&SpliceNull{},
+		&TimeSignal{SpliceTime{Specified: true, PTS: 0x1deadbeef}},
+		&SpliceInsert{
+			SpliceEventId:   1,
+			OutOfNetwork:    true,
+			ProgramSplice:   true,
+			SpliceImmediate: true,
+			BreakDuration:   &BreakDuration{AutoReturn: true, Duration: 30 * 90000},
+			UniqueProgramId: 2,
+		},
+		&SpliceInsert{
+			SpliceEventId: 3,
+			Components: []InsertComponent{
+				{ComponentTag: 1, SpliceTime: SpliceTime{Specified: true, PTS: 90000}},
+				{ComponentTag: 2},
+			},
+			AvailNum:       1,
+			AvailsExpected: 2,
+		},
+		&SpliceInsert{SpliceEventId: 4, CancelIndicator: true},
+		&SpliceSchedule{
+			Splices: []*ScheduledSplice{
+				{SpliceEventId: 5, OutOfNetwork: true, ProgramSplice: true, UTCSpliceTime: 1000000000},
+				{SpliceEventId: 6, Components: []ScheduleComponent{{1, 1000000000}}, BreakDuration: &BreakDuration{Duration: 90000}},
+			},
+		},
+	}
+
+	for i, command := range commands {
+		sec := NewSpliceInfoSection(command, NewAvailDescriptor(0x135))
+		sec.SetPTSAdjustment(0x100000000)
+		table, err := sec.Marshal()
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if !table.CheckCRC() {
+			t.Errorf("%d: CRC mismatch", i)
+		}
+
+		parsed, err := ParseSpliceInfoSection(table)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if parsed.PTSAdjustment() != 0x100000000 || parsed.Tier() != 0xfff || parsed.CWIndex() != 0xff {
+			t.Errorf("%d: section = %+v", i, parsed)
+		}
+		if !reflect.DeepEqual(parsed.Command(), command) {
+			t.Errorf("%d: command = %+v, want %+v", i, parsed.Command(), command)
+		}
+
+		descriptors := parsed.Descriptors()
+		if len(descriptors) != 1 {
+			t.Fatalf("%d: descriptors = % x", i, descriptors)
+		}
+		if id, ok := ParseAvailDescriptor(descriptors[0]); !ok || id != 0x135 {
+			t.Errorf("%d: provider_avail_id = %#x (%v)", i, id, ok)
+		}
+	}
+}
+
+func TestNewAvailDescriptor(t *testing.T) {
+	sec, err := ParseSpliceInfoSection(decodeSample(t, spliceInsertSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := NewAvailDescriptor(0x135); !bytes.Equal(d, sec.Descriptors()[0]) {
+		t.Errorf("avail_descriptor = % x, want % x", d, sec.Descriptors()[0])
+	}
+}
+
+func TestNewSegmentationDescriptor(t *testing.T) {
+	sec, err := ParseSpliceInfoSection(decodeSample(t, timeSignalSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*Segmentation{
+		ParseSegmentationDescriptor(sec.Descriptors()[0]),
+		{SegmentationEventId: 1, CancelIndicator: true},
+		{
+			SegmentationEventId:   2,
+			DeliveryNotRestricted: true,
+			Components:            []SegmentationComponent{{1, 0}, {2, 3003}},
+			UPID:                  UPID{Type: UPIDTI, Value: []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+			TypeId:                SegmentationProgramStart,
+			SegmentNum:            1,
+			SegmentsExpected:      1,
+		},
+	}
+
+	for i, s := range tests {
+		parsed := ParseSegmentationDescriptor(NewSegmentationDescriptor(s))
+		if !reflect.DeepEqual(parsed, s) {
+			t.Errorf("%d: segmentation = %+v, want %+v", i, parsed, s)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+// Cue is a splice_info_section placed on the program clock of the
+// Timeline, so it can be compared with the PTS of video frames converted
+// by the same Timeline.
+type Cue struct {
+	PID     tsparser.PID
+	Section *SpliceInfoSection
+
+	// Clock is the program clock at the last PCR before the arrival of
+	// the section.
+	Clock int64
+
+	// SpliceClock is the program splice point on the program clock,
+	// which is valid if HasSpliceTime is set.
+	SpliceClock   int64
+	HasSpliceTime bool
+
+	// ComponentClocks are the splice points of the components of a
+	// splice_insert keyed by component_tag.
+	ComponentClocks map[uint8]int64
+}
+
+type CueScanner struct {
+	s       tsparser.PacketStream
+	program *tsparser.ProgramTracker
+	handler func(*Cue) error
+	logger  *log.Logger
+
+	collector *tsparser.TableCollector
+	pids      map[tsparser.PID]bool
+	timeline  *tsparser.Timeline
+}
+
+func NewCueScanner(s tsparser.PacketStream, serviceId uint16, logger *log.Logger) *CueScanner {
+	return &CueScanner{
+		s:         s,
+		program:   tsparser.NewProgramTracker(serviceId),
+		logger:    logger,
+		collector: tsparser.NewTableCollector(),
+		pids:      make(map[tsparser.PID]bool),
+		timeline:  tsparser.NewTimeline(tsparser.NullPID),
+	}
+}
+
+func (s *CueScanner) log(p tsparser.Packet, v ...interface{}) {
+	if s.logger == nil {
+		return
+	}
+
+	values := make([]interface{}, len(v)+1)
+	values[0] = fmt.Sprintf("pid=0x%04x: ", p.PID())
+	copy(values[1:], v)
+	s.logger.Print(values...)
+}
+
+// SetHandler sets the function which is called with every cue.
+func (s *CueScanner) SetHandler(fn func(*Cue) error) {
+	s.handler = fn
+}
+
+// Timeline returns the Timeline of the program which the cues are placed
+// on.
+func (s *CueScanner) Timeline() *tsparser.Timeline {
+	return s.timeline
+}
+
+func (s *CueScanner) Run() error {
+	for s.s.Scan() {
+		if err := s.handle(s.s.Packet()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *CueScanner) handle(packet tsparser.Packet) error {
+	if s.timeline.Feed(packet) {
+		s.log(packet, "PCR discontinuity")
+	}
+
+	pid := packet.PID()
+	if pid != tsparser.ProgramAssociationPID && pid != s.program.PMTPID() && !s.pids[pid] {
+		return nil
+	}
+
+	tables, err := s.collector.Feed(packet)
+	if err != nil {
+		s.log(packet, err)
+	}
+
+	for _, table := range tables {
+		switch table.TableId() {
+		case tsparser.ProgramAssociationTable:
+			if table.CheckCRC() {
+				s.handleProgramAssociation(tsparser.ParseProgramAssociationSection(table))
+			}
+		case tsparser.ProgramMapTable:
+			if table.CheckCRC() && table.TableIdExtension() == s.program.ProgramNumber() {
+				s.handleProgramMap(tsparser.ParseProgramMapSection(table))
+			}
+		case SpliceInfoTable:
+			if !s.pids[pid] {
+				continue
+			}
+
+			section, err := ParseSpliceInfoSection(table)
+			if err != nil {
+				s.log(packet, err)
+				continue
+			}
+			if err := s.emit(pid, section); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *CueScanner) handleProgramAssociation(pat *tsparser.ProgramAssociationSection) {
+	s.program.Update(pat, s.collector)
+}
+
+func (s *CueScanner) handleProgramMap(pmt *tsparser.ProgramMapSection) {
+	s.timeline.SetPCRPID(pmt.PCRPID())
+
+	pids := make(map[tsparser.PID]bool)
+	for _, es := range pmt.Streams() {
+		if es.StreamType() == tsparser.SCTE35Stream {
+			pids[es.PID()] = true
+		}
+	}
+	s.pids = pids
+}
+
+func (s *CueScanner) emit(pid tsparser.PID, section *SpliceInfoSection) error {
+	cue := &Cue{
+		PID:     pid,
+		Section: section,
+		Clock:   s.timeline.Clock(),
+	}
+
+	if pts, ok := section.SpliceTime(); ok {
+		cue.SpliceClock, cue.HasSpliceTime = s.timeline.Timestamp(pts), true
+	}
+	if cmd, ok := section.Command().(*SpliceInsert); ok && !cmd.CancelIndicator {
+		for _, component := range cmd.Components {
+			if !component.SpliceTime.Specified {
+				continue
+			}
+			if cue.ComponentClocks == nil {
+				cue.ComponentClocks = make(map[uint8]int64)
+			}
+			cue.ComponentClocks[component.ComponentTag] = s.timeline.Timestamp(section.AdjustPTS(component.SpliceTime.PTS))
+		}
+	}
+
+	if s.handler == nil {
+		return nil
+	}
+	return s.handler(cue)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func TestCueScanner(t *testing.T) {
+	pat := tsparser.NewProgramAssociationSection(1)
+	pat.SetProgram(1, 0x1000)
+	patTable, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmt := tsparser.NewProgramMapSection(1, 0x0100)
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.H264VideoStream, 0x0100))
+	pmt.AddStream(tsparser.NewElementaryStream(tsparser.SCTE35Stream, 0x01f0))
+	pmtTable, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := tsparser.NewPacketWriter(&buf)
+	// A splice_info_section before PMT is not taken.
+	w.WriteTables(0x01f0, decodeSample(t, timeSignalSample))
+	w.WriteTables(tsparser.ProgramAssociationPID, patTable)
+	w.WriteTables(0x1000, pmtTable)
+	w.WriteTables(0x01f0, decodeSample(t, spliceInsertSample))
+	// Neither is one on the PID not listed in PMT.
+	w.WriteTables(0x01f1, decodeSample(t, timeSignalSample))
+
+	s := NewCueScanner(tsparser.NewPacketScanner(&buf, nil), 0, nil)
+	var cues []*Cue
+	s.SetHandler(func(cue *Cue) error {
+		cues = append(cues, cue)
+		return nil
+	})
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cues) != 1 {
+		t.Fatalf("%d cues, want 1", len(cues))
+	}
+	cue := cues[0]
+	if cue.PID != 0x01f0 || cue.Section.CommandType() != SpliceInsertCommand {
+		t.Errorf("cue = %+v", cue)
+	}
+	if !cue.HasSpliceTime || cue.SpliceClock != 0x07369c02e || len(cue.ComponentClocks) != 0 {
+		t.Errorf("splice clock = %d (%v), components = %v", cue.SpliceClock, cue.HasSpliceTime, cue.ComponentClocks)
+	}
+	if s.Timeline().PCRPID() != 0x0100 {
+		t.Errorf("PCR PID = %#x", s.Timeline().PCRPID())
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"errors"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+var (
+	ErrInvalidSection       = errors.New("Invalid splice_info_section")
+	ErrCRCMismatch          = errors.New("CRC mismatch")
+	ErrUnknownCommandType   = errors.New("Unknown splice_command_type")
+	ErrInvalidCommandLength = errors.New("Invalid splice_command_length")
+)
+
+const (
+	SpliceInfoTable tsparser.TableId = 0xFC
+)
+
+// cursor reads fields of a splice_info_section.  Reading beyond the data
+// marks it as failed instead of panicking.
+type cursor struct {
+	data   []byte
+	pos    int
+	failed bool
+}
+
+func (c *cursor) bytes(n int) []byte {
+	if c.failed || c.pos+n > len(c.data) {
+		c.failed = true
+		return make([]byte, n)
+	}
+
+	b := c.data[c.pos : c.pos+n]
+	c.pos += n
+	return b
+}
+
+func (c *cursor) uint8() uint8 {
+	return c.bytes(1)[0]
+}
+
+func (c *cursor) uint16() uint16 {
+	b := c.bytes(2)
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func (c *cursor) uint32() uint32 {
+	b := c.bytes(4)
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// uint33 reads a 33-bit field whose first byte carries the flags and the
+// most significant bit.
+func (c *cursor) uint33() (uint8, uint64) {
+	b := c.bytes(5)
+	return b[0], uint64(b[0]&0x01)<<32 | uint64(b[1])<<24 | uint64(b[2])<<16 | uint64(b[3])<<8 | uint64(b[4])
+}
+
+func (c *cursor) remaining() int {
+	return len(c.data) - c.pos
+}
+
+type SpliceInfoSection struct {
+	protocolVersion     uint8
+	encrypted           bool
+	encryptionAlgorithm uint8
+	ptsAdjustment       uint64
+	cwIndex             uint8
+	tier                uint16
+	commandType         uint8
+	command             SpliceCommand
+	descriptors         []SpliceDescriptor
+}
+
+// ParseSpliceInfoSection parses a splice_info_section.  The splice command
+// and the descriptors are left undecoded if the section is encrypted.
+func ParseSpliceInfoSection(table tsparser.Table) (*SpliceInfoSection, error) {
+	if len(table) < 3 || table.TableId() != SpliceInfoTable {
+		return nil, ErrInvalidSection
+	}
+
+	end := 3 + table.SectionLength()
+	if end > len(table) || end < 3+11+2+4 {
+		return nil, ErrInvalidSection
+	} else if !tsparser.CheckCRC32(table[:end]) {
+		return nil, ErrCRCMismatch
+	}
+
+	c := &cursor{data: table[3 : end-4]}
+	sec := new(SpliceInfoSection)
+	sec.protocolVersion = c.uint8()
+	var flags uint8
+	flags, sec.ptsAdjustment = c.uint33()
+	sec.encrypted = flags&0x80 > 0
+	sec.encryptionAlgorithm = flags >> 1 & 0x3f
+	sec.cwIndex = c.uint8()
+	b := c.bytes(3)
+	sec.tier = uint16(b[0])<<4 | uint16(b[1])>>4
+	length := int(b[1]&0x0f)<<8 | int(b[2])
+	sec.commandType = c.uint8()
+	if sec.encrypted {
+		return sec, nil
+	}
+
+	// splice_command_length of 0xFFF is left by the legacy encoders, where
+	// the command has to be parsed to know its length.
+	var data []byte
+	if length == 0xfff {
+		data = c.data[c.pos:]
+	} else {
+		data = c.bytes(length)
+	}
+	command, n, err := parseSpliceCommand(sec.commandType, data)
+	if err != nil {
+		return nil, err
+	} else if length == 0xfff {
+		c.bytes(n)
+	} else if n > length {
+		return nil, ErrInvalidCommandLength
+	}
+	sec.command = command
+
+	length = int(c.uint16())
+	sec.descriptors = ParseSpliceDescriptors(c.bytes(length))
+	if c.failed {
+		return nil, ErrInvalidSection
+	}
+
+	return sec, nil
+}
+
+func (s *SpliceInfoSection) ProtocolVersion() uint8 {
+	return s.protocolVersion
+}
+
+func (s *SpliceInfoSection) Encrypted() bool {
+	return s.encrypted
+}
+
+func (s *SpliceInfoSection) EncryptionAlgorithm() uint8 {
+	return s.encryptionAlgorithm
+}
+
+func (s *SpliceInfoSection) PTSAdjustment() uint64 {
+	return s.ptsAdjustment
+}
+
+func (s *SpliceInfoSection) CWIndex() uint8 {
+	return s.cwIndex
+}
+
+func (s *SpliceInfoSection) Tier() uint16 {
+	return s.tier
+}
+
+func (s *SpliceInfoSection) CommandType() uint8 {
+	return s.commandType
+}
+
+// Command returns the splice command, which is nil if the section is
+// encrypted.
+func (s *SpliceInfoSection) Command() SpliceCommand {
+	return s.command
+}
+
+func (s *SpliceInfoSection) Descriptors() []SpliceDescriptor {
+	return s.descriptors
+}
+
+// AdjustPTS adds pts_adjustment to a pts_time of the section.
+func (s *SpliceInfoSection) AdjustPTS(pts uint64) uint64 {
+	return (pts + s.ptsAdjustment) & tsparser.TimestampMask
+}
+
+// SpliceTime returns the adjusted PTS of the program splice point of a
+// splice_insert or a time_signal.
+func (s *SpliceInfoSection) SpliceTime() (uint64, bool) {
+	var t SpliceTime
+	switch cmd := s.command.(type) {
+	case *SpliceInsert:
+		if cmd.CancelIndicator || !cmd.ProgramSplice {
+			return 0, false
+		}
+		t = cmd.SpliceTime
+	case *TimeSignal:
+		t = cmd.SpliceTime
+	}
+
+	if !t.Specified {
+		return 0, false
+	}
+	return s.AdjustPTS(t.PTS), true
+}
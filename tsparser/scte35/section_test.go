@@ -0,0 +1,123 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package scte35
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+// Sample messages of SCTE 35 section 14.
+var (
+	timeSignalSample   = "/DA0AAAAAAAA///wBQb+cr0AUAAeAhxDVUVJSAAAjn/PAAGlmbAICAAAAAAsoKGKNAIAmsnRfg=="
+	spliceInsertSample = "/DAvAAAAAAAA///wFAVIAACPf+/+c2nALv4AUsz1AAAAAAAKAAhDVUVJAAABNWLbowo="
+)
+
+func decodeSample(t *testing.T, sample string) tsparser.Table {
+	data, err := base64.StdEncoding.DecodeString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsparser.Table(data)
+}
+
+func TestParseTimeSignal(t *testing.T) {
+	sec, err := ParseSpliceInfoSection(decodeSample(t, timeSignalSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sec.Encrypted() || sec.PTSAdjustment() != 0 || sec.Tier() != 0xfff || sec.CommandType() != TimeSignalCommand {
+		t.Errorf("section = %+v", sec)
+	}
+	if pts, ok := sec.SpliceTime(); !ok || pts != 0x072bd0050 {
+		t.Errorf("splice time = %d (%v)", pts, ok)
+	}
+
+	descriptors := sec.Descriptors()
+	if len(descriptors) != 1 || descriptors[0].Tag() != SegmentationDescriptor || descriptors[0].Identifier() != CUEIdentifier {
+		t.Fatalf("descriptors = % x", descriptors)
+	}
+	s := ParseSegmentationDescriptor(descriptors[0])
+	if s == nil {
+		t.Fatal("segmentation_descriptor is not parsed")
+	}
+	if s.SegmentationEventId != 0x4800008e || s.CancelIndicator || !s.ProgramSegmentation || s.DeliveryNotRestricted {
+		t.Errorf("segmentation = %+v", s)
+	}
+	if s.WebDeliveryAllowed || !s.NoRegionalBlackout || !s.ArchiveAllowed || s.DeviceRestrictions != 3 {
+		t.Errorf("restrictions = %+v", s)
+	}
+	if !s.HasDuration || s.Duration != 27630000 || s.Time() != 307*time.Second {
+		t.Errorf("duration = %d (%v)", s.Duration, s.HasDuration)
+	}
+	if s.UPID.Type != UPIDTI || s.UPID.String() != "748724618" {
+		t.Errorf("UPID = %d %s", s.UPID.Type, s.UPID)
+	}
+	if s.TypeId != SegmentationProviderPlacementOpportunityStart || s.SegmentNum != 2 || s.SegmentsExpected != 0 || s.SubSegmentNum != 0 {
+		t.Errorf("segmentation type = %+v", s)
+	}
+}
+
+func TestParseSpliceInsert(t *testing.T) {
+	sec, err := ParseSpliceInfoSection(decodeSample(t, spliceInsertSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, ok := sec.Command().(*SpliceInsert)
+	if !ok {
+		t.Fatalf("command = %#v", sec.Command())
+	}
+	if cmd.SpliceEventId != 0x4800008f || cmd.CancelIndicator || !cmd.OutOfNetwork || !cmd.ProgramSplice || cmd.SpliceImmediate || !cmd.EventIdCompliance {
+		t.Errorf("splice_insert = %+v", cmd)
+	}
+	if !cmd.SpliceTime.Specified || cmd.SpliceTime.PTS != 0x07369c02e {
+		t.Errorf("splice time = %+v", cmd.SpliceTime)
+	}
+	if d := cmd.BreakDuration; d == nil || !d.AutoReturn || d.Duration != 0x00052ccf5 {
+		t.Errorf("break duration = %+v", d)
+	}
+
+	descriptors := sec.Descriptors()
+	if len(descriptors) != 1 {
+		t.Fatalf("descriptors = % x", descriptors)
+	}
+	if id, ok := ParseAvailDescriptor(descriptors[0]); !ok || id != 0x135 {
+		t.Errorf("provider_avail_id = %#x (%v)", id, ok)
+	}
+	if ParseSegmentationDescriptor(descriptors[0]) != nil {
+		t.Error("avail_descriptor is parsed as segmentation_descriptor")
+	}
+}
+
+func TestParseSpliceInfoSectionErrors(t *testing.T) {
+	corrupted := decodeSample(t, spliceInsertSample)
+	corrupted[20] ^= 0x01
+
+	unknown := decodeSample(t, spliceInsertSample)
+	unknown[13] = 0x03
+	crc := tsparser.CRC32(unknown[:len(unknown)-4])
+	unknown[len(unknown)-4], unknown[len(unknown)-3], unknown[len(unknown)-2], unknown[len(unknown)-1] = byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc)
+
+	tests := []struct {
+		table tsparser.Table
+		err   error
+	}{
+		{corrupted, ErrCRCMismatch},
+		{unknown, ErrUnknownCommandType},
+		{decodeSample(t, spliceInsertSample)[:20], ErrInvalidSection},
+		{tsparser.Table{0x00, 0xb0, 0x0d}, ErrInvalidSection},
+	}
+
+	for i, test := range tests {
+		if _, err := ParseSpliceInfoSection(test.table); err != test.err {
+			t.Errorf("%d: err = %v, want %v", i, err, test.err)
+		}
+	}
+}
@@ -4,17 +4,30 @@
 
 package tsparser
 
+import (
+	"sort"
+)
+
 type ProgramAssociationSection struct {
-	network    PID
-	programMap map[uint16]PID
+	transportStreamId uint16
+	version           uint8
+	network           PID
+	programMap        map[uint16]PID
+}
+
+func NewProgramAssociationSection(transportStreamId uint16) *ProgramAssociationSection {
+	return &ProgramAssociationSection{
+		transportStreamId: transportStreamId,
+		programMap:        make(map[uint16]PID),
+	}
 }
 
 func ParseProgramAssociationSection(table Table) *ProgramAssociationSection {
-	sec := new(ProgramAssociationSection)
-	sec.programMap = make(map[uint16]PID)
+	sec := NewProgramAssociationSection(table.TableIdExtension())
+	sec.version = table.VersionNumber()
 
 	payload := table.Data()
-	for i := 0; i < len(payload); i += 4 {
+	for i := 0; i+4 <= len(payload); i += 4 {
 		programNumber := uint16(payload[i])<<8 | uint16(payload[i+1])
 		if programNumber == 0 {
 			sec.network = PID(payload[i+2]&0x1f)<<8 | PID(payload[i+3])
@@ -26,10 +39,59 @@ func ParseProgramAssociationSection(table Table) *ProgramAssociationSection {
 	return sec
 }
 
+func (s *ProgramAssociationSection) TransportStreamId() uint16 {
+	return s.transportStreamId
+}
+
+func (s *ProgramAssociationSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *ProgramAssociationSection) SetVersionNumber(version uint8) {
+	s.version = version & 0x1f
+}
+
 func (s *ProgramAssociationSection) NetworkPID() PID {
 	return s.network
 }
 
+func (s *ProgramAssociationSection) SetNetworkPID(pid PID) {
+	s.network = pid
+}
+
 func (s *ProgramAssociationSection) ProgramMap() map[uint16]PID {
 	return s.programMap
 }
+
+func (s *ProgramAssociationSection) SetProgram(programNumber uint16, pid PID) {
+	s.programMap[programNumber] = pid
+}
+
+func (s *ProgramAssociationSection) RemoveProgram(programNumber uint16) {
+	delete(s.programMap, programNumber)
+}
+
+func (s *ProgramAssociationSection) Marshal() (Table, error) {
+	numbers := make([]int, 0, len(s.programMap))
+	for number := range s.programMap {
+		numbers = append(numbers, int(number))
+	}
+	sort.Ints(numbers)
+
+	data := make([]byte, 0, 4*(len(numbers)+1))
+	if s.network > 0 {
+		data = append(data, 0x00, 0x00, 0xe0|byte(s.network>>8), byte(s.network))
+	}
+	for _, number := range numbers {
+		pid := s.programMap[uint16(number)]
+		data = append(data, byte(number>>8), byte(number), 0xe0|byte(pid>>8), byte(pid))
+	}
+
+	header := &TableHeader{
+		TableId:              ProgramAssociationTable,
+		TableIdExtension:     s.transportStreamId,
+		VersionNumber:        s.version,
+		CurrentNextIndicator: true,
+	}
+	return header.Build(data)
+}
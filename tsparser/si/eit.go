@@ -0,0 +1,242 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package si
+
+import (
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type Event struct {
+	eventId           uint16
+	startTime         time.Time
+	duration          time.Duration
+	undefinedDuration bool
+	runningStatus     uint8
+	freeCAMode        bool
+	descriptors       []tsparser.Descriptor
+}
+
+func NewEvent(eventId uint16, startTime time.Time, duration time.Duration, descriptors ...tsparser.Descriptor) *Event {
+	return &Event{
+		eventId:     eventId,
+		startTime:   startTime,
+		duration:    duration,
+		descriptors: descriptors,
+	}
+}
+
+func (e *Event) EventId() uint16 {
+	return e.eventId
+}
+
+func (e *Event) StartTime() time.Time {
+	return e.startTime
+}
+
+func (e *Event) Duration() time.Duration {
+	return e.duration
+}
+
+// DurationUndefined reports whether duration is coded as undefined, in all
+// ones.
+func (e *Event) DurationUndefined() bool {
+	return e.undefinedDuration
+}
+
+func (e *Event) SetDurationUndefined(undefined bool) {
+	e.undefinedDuration = undefined
+}
+
+func (e *Event) RunningStatus() uint8 {
+	return e.runningStatus
+}
+
+func (e *Event) FreeCAMode() bool {
+	return e.freeCAMode
+}
+
+func (e *Event) Descriptors() []tsparser.Descriptor {
+	return e.descriptors
+}
+
+type EventInformationSection struct {
+	tableId                  tsparser.TableId
+	serviceId                uint16
+	version                  uint8
+	sectionNumber            uint8
+	lastSectionNumber        uint8
+	transportStreamId        uint16
+	originalNetworkId        uint16
+	segmentLastSectionNumber uint8
+	lastTableId              tsparser.TableId
+	events                   []*Event
+	location                 *time.Location
+}
+
+// NewEventInformationSection returns an EIT whose start_time is encoded in
+// the time zone, which is UTC for DVB and JST for ARIB.
+func NewEventInformationSection(tableId tsparser.TableId, serviceId, transportStreamId, originalNetworkId uint16, location *time.Location) *EventInformationSection {
+	return &EventInformationSection{
+		tableId:           tableId,
+		serviceId:         serviceId,
+		transportStreamId: transportStreamId,
+		originalNetworkId: originalNetworkId,
+		lastTableId:       tableId,
+		location:          location,
+	}
+}
+
+func ParseEventInformationSection(table tsparser.Table, location *time.Location) *EventInformationSection {
+	sec := NewEventInformationSection(table.TableId(), table.TableIdExtension(), 0, 0, location)
+	sec.version = table.VersionNumber()
+	sec.sectionNumber = table.SectionNumber()
+	sec.lastSectionNumber = table.LastSectionNumber()
+
+	payload := table.Data()
+	if len(payload) < 6 {
+		return sec
+	}
+	sec.transportStreamId = uint16(payload[0])<<8 | uint16(payload[1])
+	sec.originalNetworkId = uint16(payload[2])<<8 | uint16(payload[3])
+	sec.segmentLastSectionNumber = payload[4]
+	sec.lastTableId = tsparser.TableId(payload[5])
+
+	for i := 6; i+12 <= len(payload); {
+		event := NewEvent(uint16(payload[i])<<8|uint16(payload[i+1]), time.Time{}, 0)
+		if !isUndefined(payload[i+2 : i+7]) {
+			event.startTime = ParseTime(payload[i+2:i+7], location)
+		}
+		if isUndefined(payload[i+7 : i+10]) {
+			event.undefinedDuration = true
+		} else {
+			event.duration = parseDuration(payload[i+7 : i+10])
+		}
+		event.runningStatus = uint8(payload[i+10]&0xe0) >> 5
+		event.freeCAMode = payload[i+10]&0x10 > 0
+
+		length := int(payload[i+10]&0x0f)<<8 | int(payload[i+11])
+		i += 12
+		if i+length > len(payload) {
+			break
+		}
+		event.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.events = append(sec.events, event)
+	}
+
+	return sec
+}
+
+func (s *EventInformationSection) TableId() tsparser.TableId {
+	return s.tableId
+}
+
+func (s *EventInformationSection) IsPresentFollowing() bool {
+	return s.tableId == EventInformationActualPFTable || s.tableId == EventInformationOtherPFTable
+}
+
+func (s *EventInformationSection) IsActual() bool {
+	return s.tableId == EventInformationActualPFTable ||
+		EventInformationActualSchTable <= s.tableId && s.tableId < EventInformationOtherSchTable
+}
+
+func (s *EventInformationSection) IsSchedule() bool {
+	return EventInformationActualSchTable <= s.tableId && s.tableId < EventInformationScheduleEnd
+}
+
+func (s *EventInformationSection) ServiceId() uint16 {
+	return s.serviceId
+}
+
+func (s *EventInformationSection) TransportStreamId() uint16 {
+	return s.transportStreamId
+}
+
+func (s *EventInformationSection) OriginalNetworkId() uint16 {
+	return s.originalNetworkId
+}
+
+func (s *EventInformationSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *EventInformationSection) SetVersionNumber(version uint8) {
+	s.version = version & 0x1f
+}
+
+func (s *EventInformationSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *EventInformationSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *EventInformationSection) SegmentLastSectionNumber() uint8 {
+	return s.segmentLastSectionNumber
+}
+
+func (s *EventInformationSection) LastTableId() tsparser.TableId {
+	return s.lastTableId
+}
+
+func (s *EventInformationSection) SetSectionNumber(number, segmentLast, last uint8, lastTableId tsparser.TableId) {
+	s.sectionNumber = number
+	s.segmentLastSectionNumber = segmentLast
+	s.lastSectionNumber = last
+	s.lastTableId = lastTableId
+}
+
+func (s *EventInformationSection) Events() []*Event {
+	return s.events
+}
+
+func (s *EventInformationSection) AddEvent(event *Event) {
+	s.events = append(s.events, event)
+}
+
+func (s *EventInformationSection) Marshal() (tsparser.Table, error) {
+	data := []byte{
+		byte(s.transportStreamId >> 8), byte(s.transportStreamId),
+		byte(s.originalNetworkId >> 8), byte(s.originalNetworkId),
+		s.segmentLastSectionNumber, byte(s.lastTableId),
+	}
+
+	for _, event := range s.events {
+		data = append(data, byte(event.eventId>>8), byte(event.eventId))
+		if event.startTime.IsZero() {
+			data = append(data, 0xff, 0xff, 0xff, 0xff, 0xff)
+		} else {
+			data = append(data, EncodeTime(event.startTime, s.location)...)
+		}
+		if event.undefinedDuration {
+			data = append(data, 0xff, 0xff, 0xff)
+		} else {
+			data = append(data, encodeDuration(event.duration)...)
+		}
+
+		info := tsparser.MarshalDescriptors(event.descriptors)
+		status := event.runningStatus<<5 | byte(len(info)>>8)&0x0f
+		if event.freeCAMode {
+			status |= 0x10
+		}
+		data = append(data, status, byte(len(info)))
+		data = append(data, info...)
+	}
+
+	header := &tsparser.TableHeader{
+		TableId:              s.tableId,
+		PrivateIndicator:     true,
+		TableIdExtension:     s.serviceId,
+		VersionNumber:        s.version,
+		CurrentNextIndicator: true,
+		SectionNumber:        s.sectionNumber,
+		LastSectionNumber:    s.lastSectionNumber,
+	}
+	return header.Build(data)
+}
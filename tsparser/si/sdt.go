@@ -0,0 +1,210 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package si
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+type Service struct {
+	serviceId               uint16
+	eitUserDefinedFlags     uint8
+	eitScheduleFlag         bool
+	eitPresentFollowingFlag bool
+	runningStatus           uint8
+	freeCAMode              bool
+	descriptors             []tsparser.Descriptor
+}
+
+func NewService(serviceId uint16, descriptors ...tsparser.Descriptor) *Service {
+	return &Service{
+		serviceId:   serviceId,
+		descriptors: descriptors,
+	}
+}
+
+func (s *Service) ServiceId() uint16 {
+	return s.serviceId
+}
+
+func (s *Service) EITUserDefinedFlags() uint8 {
+	return s.eitUserDefinedFlags
+}
+
+func (s *Service) EITScheduleFlag() bool {
+	return s.eitScheduleFlag
+}
+
+func (s *Service) SetEITScheduleFlag(flag bool) {
+	s.eitScheduleFlag = flag
+}
+
+func (s *Service) EITPresentFollowingFlag() bool {
+	return s.eitPresentFollowingFlag
+}
+
+func (s *Service) SetEITPresentFollowingFlag(flag bool) {
+	s.eitPresentFollowingFlag = flag
+}
+
+func (s *Service) RunningStatus() uint8 {
+	return s.runningStatus
+}
+
+func (s *Service) FreeCAMode() bool {
+	return s.freeCAMode
+}
+
+func (s *Service) Descriptors() []tsparser.Descriptor {
+	return s.descriptors
+}
+
+type ServiceDescriptionSection struct {
+	tableId           tsparser.TableId
+	transportStreamId uint16
+	originalNetworkId uint16
+	version           uint8
+	sectionNumber     uint8
+	lastSectionNumber uint8
+	services          []*Service
+}
+
+func NewServiceDescriptionSection(transportStreamId, originalNetworkId uint16) *ServiceDescriptionSection {
+	return &ServiceDescriptionSection{
+		tableId:           ServiceDescriptionActualTable,
+		transportStreamId: transportStreamId,
+		originalNetworkId: originalNetworkId,
+	}
+}
+
+func ParseServiceDescriptionSection(table tsparser.Table) *ServiceDescriptionSection {
+	sec := NewServiceDescriptionSection(table.TableIdExtension(), 0)
+	sec.tableId = table.TableId()
+	sec.version = table.VersionNumber()
+	sec.sectionNumber = table.SectionNumber()
+	sec.lastSectionNumber = table.LastSectionNumber()
+
+	payload := table.Data()
+	if len(payload) < 3 {
+		return sec
+	}
+	sec.originalNetworkId = uint16(payload[0])<<8 | uint16(payload[1])
+
+	for i := 3; i+5 <= len(payload); {
+		service := NewService(uint16(payload[i])<<8 | uint16(payload[i+1]))
+		service.eitUserDefinedFlags = uint8(payload[i+2]&0x1c) >> 2
+		service.eitScheduleFlag = payload[i+2]&0x02 > 0
+		service.eitPresentFollowingFlag = payload[i+2]&0x01 > 0
+		service.runningStatus = uint8(payload[i+3]&0xe0) >> 5
+		service.freeCAMode = payload[i+3]&0x10 > 0
+
+		length := int(payload[i+3]&0x0f)<<8 | int(payload[i+4])
+		i += 5
+		if i+length > len(payload) {
+			break
+		}
+		service.descriptors = tsparser.ParseDescriptors(payload[i : i+length])
+		i += length
+
+		sec.services = append(sec.services, service)
+	}
+
+	return sec
+}
+
+func (s *ServiceDescriptionSection) IsActual() bool {
+	return s.tableId == ServiceDescriptionActualTable
+}
+
+func (s *ServiceDescriptionSection) TransportStreamId() uint16 {
+	return s.transportStreamId
+}
+
+func (s *ServiceDescriptionSection) OriginalNetworkId() uint16 {
+	return s.originalNetworkId
+}
+
+func (s *ServiceDescriptionSection) VersionNumber() uint8 {
+	return s.version
+}
+
+func (s *ServiceDescriptionSection) SetVersionNumber(version uint8) {
+	s.version = version & 0x1f
+}
+
+func (s *ServiceDescriptionSection) SectionNumber() uint8 {
+	return s.sectionNumber
+}
+
+func (s *ServiceDescriptionSection) LastSectionNumber() uint8 {
+	return s.lastSectionNumber
+}
+
+func (s *ServiceDescriptionSection) SetSectionNumber(number, last uint8) {
+	s.sectionNumber = number
+	s.lastSectionNumber = last
+}
+
+func (s *ServiceDescriptionSection) Services() []*Service {
+	return s.services
+}
+
+func (s *ServiceDescriptionSection) Service(serviceId uint16) *Service {
+	for _, service := range s.services {
+		if service.serviceId == serviceId {
+			return service
+		}
+	}
+
+	return nil
+}
+
+func (s *ServiceDescriptionSection) AddService(service *Service) {
+	s.RemoveService(service.serviceId)
+	s.services = append(s.services, service)
+}
+
+func (s *ServiceDescriptionSection) RemoveService(serviceId uint16) {
+	services := s.services[:0]
+	for _, service := range s.services {
+		if service.serviceId != serviceId {
+			services = append(services, service)
+		}
+	}
+	s.services = services
+}
+
+func (s *ServiceDescriptionSection) Marshal() (tsparser.Table, error) {
+	data := []byte{byte(s.originalNetworkId >> 8), byte(s.originalNetworkId), 0xff}
+	for _, service := range s.services {
+		flags := 0xe0 | service.eitUserDefinedFlags<<2&0x1c
+		if service.eitScheduleFlag {
+			flags |= 0x02
+		}
+		if service.eitPresentFollowingFlag {
+			flags |= 0x01
+		}
+
+		info := tsparser.MarshalDescriptors(service.descriptors)
+		status := service.runningStatus<<5 | byte(len(info)>>8)&0x0f
+		if service.freeCAMode {
+			status |= 0x10
+		}
+
+		data = append(data, byte(service.serviceId>>8), byte(service.serviceId), flags, status, byte(len(info)))
+		data = append(data, info...)
+	}
+
+	header := &tsparser.TableHeader{
+		TableId:              s.tableId,
+		PrivateIndicator:     true,
+		TableIdExtension:     s.transportStreamId,
+		VersionNumber:        s.version,
+		CurrentNextIndicator: true,
+		SectionNumber:        s.sectionNumber,
+		LastSectionNumber:    s.lastSectionNumber,
+	}
+	return header.Build(data)
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+// Package si implements the SDT and the EIT of EN 300 468, which DVB and
+// ARIB STD-B10 share except for the time zone of the EIT.
+package si
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+const (
+	ServiceDescriptionActualTable  tsparser.TableId = 0x42
+	ServiceDescriptionOtherTable   tsparser.TableId = 0x46
+	EventInformationActualPFTable  tsparser.TableId = 0x4E
+	EventInformationOtherPFTable   tsparser.TableId = 0x4F
+	EventInformationActualSchTable tsparser.TableId = 0x50
+	EventInformationOtherSchTable  tsparser.TableId = 0x60
+	EventInformationScheduleEnd    tsparser.TableId = 0x70
+)
@@ -0,0 +1,137 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package si
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+func TestServiceDescriptionSectionMarshal(t *testing.T) {
+	sdt := NewServiceDescriptionSection(0x7fe0, 0x7fe0)
+	sdt.SetVersionNumber(9)
+	sdt.SetSectionNumber(0, 1)
+
+	service := NewService(0x0400, tsparser.NewDescriptor(0x48, []byte{0x01, 0x00, 0x03, 'A', 'B', 'C'}))
+	service.SetEITScheduleFlag(true)
+	service.SetEITPresentFollowingFlag(true)
+	sdt.AddService(service)
+	sdt.AddService(NewService(0x0408))
+
+	table, err := sdt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.TableId() != ServiceDescriptionActualTable || !table.CheckCRC() {
+		t.Fatalf("table_id = %#x, CRC %v", table.TableId(), table.CheckCRC())
+	}
+
+	parsed := ParseServiceDescriptionSection(table)
+	if !parsed.IsActual() || parsed.TransportStreamId() != 0x7fe0 || parsed.OriginalNetworkId() != 0x7fe0 {
+		t.Errorf("actual = %v, transport_stream_id = %#x, original_network_id = %#x",
+			parsed.IsActual(), parsed.TransportStreamId(), parsed.OriginalNetworkId())
+	}
+	if parsed.VersionNumber() != 9 || parsed.SectionNumber() != 0 || parsed.LastSectionNumber() != 1 {
+		t.Errorf("version_number = %d, section_number = %d/%d",
+			parsed.VersionNumber(), parsed.SectionNumber(), parsed.LastSectionNumber())
+	}
+	if len(parsed.Services()) != 2 {
+		t.Fatalf("%d services, want 2", len(parsed.Services()))
+	}
+
+	got := parsed.Service(0x0400)
+	if got == nil {
+		t.Fatal("service 0x400 is not found")
+	}
+	if !got.EITScheduleFlag() || !got.EITPresentFollowingFlag() {
+		t.Errorf("EIT_schedule_flag = %v, EIT_present_following_flag = %v", got.EITScheduleFlag(), got.EITPresentFollowingFlag())
+	}
+	if !bytes.Equal(tsparser.MarshalDescriptors(got.Descriptors()), tsparser.MarshalDescriptors(service.Descriptors())) {
+		t.Errorf("descriptors = %x, want %x", got.Descriptors(), service.Descriptors())
+	}
+
+	sdt.RemoveService(0x0408)
+	if table, err = sdt.Marshal(); err != nil {
+		t.Fatal(err)
+	}
+	if parsed = ParseServiceDescriptionSection(table); len(parsed.Services()) != 1 || parsed.Service(0x0408) != nil {
+		t.Error("service 0x408 is not removed")
+	}
+}
+
+func TestEventInformationSectionMarshal(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	start := time.Date(2014, time.October, 19, 21, 54, 30, 0, jst)
+
+	for _, location := range []*time.Location{time.UTC, jst} {
+		eit := NewEventInformationSection(EventInformationActualPFTable, 0x0400, 0x7fe0, 0x7fe1, location)
+		eit.SetVersionNumber(3)
+		eit.SetSectionNumber(1, 1, 1, EventInformationActualPFTable)
+		eit.AddEvent(NewEvent(0x1234, start, 54*time.Minute+30*time.Second,
+			tsparser.NewDescriptor(0x4d, []byte("jpn\x03abc\x00"))))
+		undefined := NewEvent(0x1235, time.Time{}, 0)
+		undefined.SetDurationUndefined(true)
+		eit.AddEvent(undefined)
+		eit.AddEvent(NewEvent(0x1236, start.Add(54*time.Minute+30*time.Second), 0))
+
+		table, err := eit.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !table.CheckCRC() {
+			t.Fatal("CRC mismatch")
+		}
+
+		parsed := ParseEventInformationSection(table, location)
+		if !parsed.IsPresentFollowing() || !parsed.IsActual() || parsed.IsSchedule() {
+			t.Errorf("%v: p/f = %v, actual = %v, schedule = %v",
+				location, parsed.IsPresentFollowing(), parsed.IsActual(), parsed.IsSchedule())
+		}
+		if parsed.ServiceId() != 0x0400 || parsed.TransportStreamId() != 0x7fe0 || parsed.OriginalNetworkId() != 0x7fe1 {
+			t.Errorf("%v: service_id = %#x, transport_stream_id = %#x, original_network_id = %#x",
+				location, parsed.ServiceId(), parsed.TransportStreamId(), parsed.OriginalNetworkId())
+		}
+		if parsed.VersionNumber() != 3 || parsed.SectionNumber() != 1 || parsed.LastTableId() != EventInformationActualPFTable {
+			t.Errorf("%v: version_number = %d, section_number = %d, last_table_id = %#x",
+				location, parsed.VersionNumber(), parsed.SectionNumber(), parsed.LastTableId())
+		}
+
+		events := parsed.Events()
+		if len(events) != 3 {
+			t.Fatalf("%v: %d events, want 3", location, len(events))
+		}
+		if e := events[0]; e.EventId() != 0x1234 || !e.StartTime().Equal(start) || e.Duration() != 54*time.Minute+30*time.Second {
+			t.Errorf("%v: event = %#x %v %v", location, e.EventId(), e.StartTime(), e.Duration())
+		}
+		if e := events[0]; !bytes.Equal(e.Descriptors()[0], []byte("\x4d\x08jpn\x03abc\x00")) {
+			t.Errorf("%v: descriptors = %x", location, e.Descriptors())
+		}
+		if e := events[1]; e.EventId() != 0x1235 || !e.StartTime().IsZero() || e.Duration() != 0 || !e.DurationUndefined() {
+			t.Errorf("%v: undefined event = %#x %v %v", location, e.EventId(), e.StartTime(), e.Duration())
+		}
+		// A duration of 0 is not taken for undefined.
+		if e := events[2]; e.EventId() != 0x1236 || e.Duration() != 0 || e.DurationUndefined() {
+			t.Errorf("%v: event = %#x %v %v (%v)", location, e.EventId(), e.StartTime(), e.Duration(), e.DurationUndefined())
+		}
+		if data := table.Data(); !bytes.Equal(data[len(data)-5:len(data)-2], []byte{0x00, 0x00, 0x00}) {
+			t.Errorf("%v: duration = %x", location, data[len(data)-5:len(data)-2])
+		}
+	}
+}
+
+func TestEncodeTime(t *testing.T) {
+	// The example of annex C of EN 300 468.
+	want := []byte{0xc0, 0x79, 0x12, 0x45, 0x00}
+	at := time.Date(1993, time.October, 13, 12, 45, 0, 0, time.UTC)
+	if got := EncodeTime(at, time.UTC); !bytes.Equal(got, want) {
+		t.Errorf("EncodeTime = %x, want %x", got, want)
+	}
+	if got := ParseTime(want, time.UTC); !got.Equal(at) {
+		t.Errorf("ParseTime = %v, want %v", got, at)
+	}
+}
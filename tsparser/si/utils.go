@@ -0,0 +1,62 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package si
+
+import (
+	"time"
+)
+
+var mjdEpoch = time.Date(1858, time.November, 17, 0, 0, 0, 0, time.UTC)
+
+// DecodeBCD decodes 2 digits of BCD.
+func DecodeBCD(b byte) int {
+	return int(b&0xf0)>>4*10 + int(b&0x0f)
+}
+
+func int2bcd(n int) byte {
+	return byte(n/10%10)<<4 | byte(n%10)
+}
+
+func isUndefined(data []byte) bool {
+	for _, b := range data {
+		if b != 0xff {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseTime decodes 16 bits of MJD followed by 6 digits of BCD, which are
+// of the time zone.
+func ParseTime(payload []byte, location *time.Location) time.Time {
+	mjd := int(payload[0])<<8 | int(payload[1])
+	date := mjdEpoch.AddDate(0, 0, mjd)
+
+	return time.Date(date.Year(), date.Month(), date.Day(),
+		DecodeBCD(payload[2]), DecodeBCD(payload[3]), DecodeBCD(payload[4]), 0, location)
+}
+
+func EncodeTime(t time.Time, location *time.Location) []byte {
+	t = t.In(location)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	mjd := int(date.Sub(mjdEpoch).Hours() / 24)
+
+	return []byte{
+		byte(mjd >> 8), byte(mjd),
+		int2bcd(t.Hour()), int2bcd(t.Minute()), int2bcd(t.Second()),
+	}
+}
+
+func parseDuration(payload []byte) time.Duration {
+	return time.Duration(DecodeBCD(payload[0]))*time.Hour +
+		time.Duration(DecodeBCD(payload[1]))*time.Minute +
+		time.Duration(DecodeBCD(payload[2]))*time.Second
+}
+
+func encodeDuration(d time.Duration) []byte {
+	seconds := int(d / time.Second)
+	return []byte{int2bcd(seconds / 3600), int2bcd(seconds / 60 % 60), int2bcd(seconds % 60)}
+}
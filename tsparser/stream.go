@@ -9,6 +9,13 @@ type PacketStream interface {
 	Packet() Packet
 }
 
+// OffsetStream is a PacketStream which knows the byte offset of the
+// current packet in the input.
+type OffsetStream interface {
+	PacketStream
+	Offset() int64
+}
+
 type TableStream interface {
 	Scan() bool
 	Table() Table
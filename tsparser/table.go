@@ -16,7 +16,15 @@ var (
 type TableId uint8
 
 const (
-	ProgramAssociationTable TableId = 0x00
+	ProgramAssociationTable    TableId = 0x00
+	ConditionalAccessTable     TableId = 0x01
+	ProgramMapTable            TableId = 0x02
+	TransportStreamDescription TableId = 0x03
+)
+
+const (
+	MaxSectionLength        = 1021
+	MaxPrivateSectionLength = 4093
 )
 
 type Table []byte
@@ -74,7 +82,7 @@ func (t Table) VersionNumber() uint8 {
 
 func (t Table) CurrentNextIndicator() bool {
 	if t.SectionSyntaxIndicator() {
-		return t[5]&0x01 > 1
+		return t[5]&0x01 > 0
 	}
 
 	return false
@@ -82,18 +90,18 @@ func (t Table) CurrentNextIndicator() bool {
 
 func (t Table) SectionNumber() uint8 {
 	if t.SectionSyntaxIndicator() {
-		return 0
+		return uint8(t[6])
 	}
 
-	return uint8(t[6])
+	return 0
 }
 
 func (t Table) LastSectionNumber() uint8 {
 	if t.SectionSyntaxIndicator() {
-		return 0
+		return uint8(t[7])
 	}
 
-	return uint8(t[7])
+	return 0
 }
 
 func (t Table) CRC32() uint32 {
@@ -130,3 +138,80 @@ func (t Table) validate() error {
 
 	return nil
 }
+
+func (t Table) SetVersionNumber(version uint8) {
+	if !t.SectionSyntaxIndicator() {
+		return
+	}
+
+	t[5] = t[5]&0xc1 | (version&0x1f)<<1
+	t.updateCRC()
+}
+
+func (t Table) updateCRC() {
+	end := t.dataEndsAt()
+	crc := updateCRC32(0xffffffff, &crc32Table, t[:end])
+	t[end], t[end+1], t[end+2], t[end+3] = byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc)
+}
+
+type TableHeader struct {
+	TableId              TableId
+	PrivateIndicator     bool
+	TableIdExtension     uint16
+	VersionNumber        uint8
+	CurrentNextIndicator bool
+	SectionNumber        uint8
+	LastSectionNumber    uint8
+}
+
+func (h *TableHeader) maxSectionLength() int {
+	if h.PrivateIndicator {
+		return MaxPrivateSectionLength
+	}
+
+	return MaxSectionLength
+}
+
+func (h *TableHeader) Build(data []byte) (Table, error) {
+	length := 5 + len(data) + 4
+	if length > h.maxSectionLength() {
+		return nil, ErrInvalidSectionLength
+	}
+
+	t := make(Table, 3+length)
+	t[0] = byte(h.TableId)
+	t[1] = 0x80 | 0x30 | byte(length>>8)&0x0f
+	if h.PrivateIndicator {
+		t[1] |= 0x40
+	}
+	t[2] = byte(length)
+	t[3] = byte(h.TableIdExtension >> 8)
+	t[4] = byte(h.TableIdExtension)
+	t[5] = 0xc0 | (h.VersionNumber&0x1f)<<1
+	if h.CurrentNextIndicator {
+		t[5] |= 0x01
+	}
+	t[6] = h.SectionNumber
+	t[7] = h.LastSectionNumber
+	copy(t[8:], data)
+	t.updateCRC()
+
+	return t, nil
+}
+
+func NewShortTable(id TableId, private bool, data []byte) (Table, error) {
+	if len(data) > MaxPrivateSectionLength {
+		return nil, ErrInvalidSectionLength
+	}
+
+	t := make(Table, 3+len(data))
+	t[0] = byte(id)
+	t[1] = 0x30 | byte(len(data)>>8)&0x0f
+	if private {
+		t[1] |= 0x40
+	}
+	t[2] = byte(len(data))
+	copy(t[3:], data)
+
+	return t, nil
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTableHeaderBuild(t *testing.T) {
+	h := &TableHeader{
+		TableId:              ProgramMapTable,
+		TableIdExtension:     0x0400,
+		VersionNumber:        21,
+		CurrentNextIndicator: true,
+		SectionNumber:        1,
+		LastSectionNumber:    2,
+	}
+	data := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	table, err := h.Build(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.TableId() != h.TableId {
+		t.Errorf("table_id = %#x, want %#x", table.TableId(), h.TableId)
+	}
+	if !table.SectionSyntaxIndicator() || table.PrivateIndicator() {
+		t.Errorf("section_syntax_indicator = %v, private_indicator = %v", table.SectionSyntaxIndicator(), table.PrivateIndicator())
+	}
+	if table.SectionLength() != 5+len(data)+4 {
+		t.Errorf("section_length = %d, want %d", table.SectionLength(), 5+len(data)+4)
+	}
+	if table.TableIdExtension() != h.TableIdExtension {
+		t.Errorf("table_id_extension = %#x, want %#x", table.TableIdExtension(), h.TableIdExtension)
+	}
+	if table.VersionNumber() != h.VersionNumber {
+		t.Errorf("version_number = %d, want %d", table.VersionNumber(), h.VersionNumber)
+	}
+	if !table.CurrentNextIndicator() {
+		t.Error("current_next_indicator is not set")
+	}
+	if table.SectionNumber() != 1 || table.LastSectionNumber() != 2 {
+		t.Errorf("section_number = %d/%d, want 1/2", table.SectionNumber(), table.LastSectionNumber())
+	}
+	if !bytes.Equal(table.Data(), data) {
+		t.Errorf("data = %x, want %x", table.Data(), data)
+	}
+	if !table.CheckCRC() {
+		t.Error("CRC mismatch")
+	}
+
+	table.SetVersionNumber(3)
+	if table.VersionNumber() != 3 || !table.CheckCRC() {
+		t.Errorf("version_number = %d after SetVersionNumber, CRC %v", table.VersionNumber(), table.CheckCRC())
+	}
+}
+
+func TestTableAccessors(t *testing.T) {
+	tests := []struct {
+		table             Table
+		currentNext       bool
+		sectionNumber     uint8
+		lastSectionNumber uint8
+	}{
+		{Table{0x02, 0xb0, 0x09, 0x04, 0x00, 0xc3, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00}, true, 1, 2},
+		{Table{0x02, 0xb0, 0x09, 0x04, 0x00, 0xc2, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00}, false, 0, 5},
+		// A short section has none of them, whatever its bytes are.
+		{Table{0x70, 0x70, 0x05, 0xde, 0x75, 0x21, 0x54, 0x30}, false, 0, 0},
+	}
+
+	for i, test := range tests {
+		if got := test.table.CurrentNextIndicator(); got != test.currentNext {
+			t.Errorf("%d: current_next_indicator = %v, want %v", i, got, test.currentNext)
+		}
+		if got := test.table.SectionNumber(); got != test.sectionNumber {
+			t.Errorf("%d: section_number = %d, want %d", i, got, test.sectionNumber)
+		}
+		if got := test.table.LastSectionNumber(); got != test.lastSectionNumber {
+			t.Errorf("%d: last_section_number = %d, want %d", i, got, test.lastSectionNumber)
+		}
+	}
+}
+
+func TestTableHeaderBuildTooLong(t *testing.T) {
+	h := &TableHeader{TableId: ProgramMapTable}
+	if _, err := h.Build(make([]byte, MaxSectionLength)); err != ErrInvalidSectionLength {
+		t.Errorf("err = %v, want %v", err, ErrInvalidSectionLength)
+	}
+
+	h.PrivateIndicator = true
+	if _, err := h.Build(make([]byte, MaxSectionLength)); err != nil {
+		t.Errorf("private section: %v", err)
+	}
+}
+
+func TestProgramAssociationSectionMarshal(t *testing.T) {
+	pat := NewProgramAssociationSection(0x7fe0)
+	pat.SetVersionNumber(5)
+	pat.SetNetworkPID(0x0010)
+	pat.SetProgram(0x0408, 0x01f0)
+	pat.SetProgram(0x0400, 0x0101)
+
+	table, err := pat.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !table.CheckCRC() {
+		t.Fatal("CRC mismatch")
+	}
+
+	parsed := ParseProgramAssociationSection(table)
+	if parsed.TransportStreamId() != 0x7fe0 {
+		t.Errorf("transport_stream_id = %#x, want 0x7fe0", parsed.TransportStreamId())
+	}
+	if parsed.VersionNumber() != 5 {
+		t.Errorf("version_number = %d, want 5", parsed.VersionNumber())
+	}
+	if parsed.NetworkPID() != 0x0010 {
+		t.Errorf("network_PID = %#x, want 0x10", parsed.NetworkPID())
+	}
+
+	programs := parsed.ProgramMap()
+	if len(programs) != 2 || programs[0x0400] != 0x0101 || programs[0x0408] != 0x01f0 {
+		t.Errorf("programs = %v", programs)
+	}
+}
+
+func TestProgramMapSectionMarshal(t *testing.T) {
+	pmt := NewProgramMapSection(0x0400, 0x0100)
+	pmt.SetVersionNumber(7)
+	pmt.SetDescriptors(NewDescriptor(0xc1, []byte{0x84}))
+	pmt.AddStream(NewElementaryStream(H264VideoStream, 0x0100, NewDescriptor(0x52, []byte{0x00})))
+	pmt.AddStream(NewElementaryStream(0x0f, 0x0110, NewDescriptor(0x52, []byte{0x10})))
+	pmt.AddStream(NewElementaryStream(0x06, 0x0130))
+
+	table, err := pmt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !table.CheckCRC() {
+		t.Fatal("CRC mismatch")
+	}
+
+	parsed := ParseProgramMapSection(table)
+	if parsed.ProgramNumber() != 0x0400 || parsed.PCRPID() != 0x0100 || parsed.VersionNumber() != 7 {
+		t.Errorf("program_number = %#x, PCR_PID = %#x, version_number = %d",
+			parsed.ProgramNumber(), parsed.PCRPID(), parsed.VersionNumber())
+	}
+	if ds := parsed.Descriptors(); len(ds) != 1 || !bytes.Equal(ds[0], pmt.Descriptors()[0]) {
+		t.Errorf("descriptors = %x", ds)
+	}
+
+	streams := parsed.Streams()
+	if len(streams) != len(pmt.Streams()) {
+		t.Fatalf("%d streams, want %d", len(streams), len(pmt.Streams()))
+	}
+	for i, es := range pmt.Streams() {
+		got := streams[i]
+		if got.StreamType() != es.StreamType() || got.PID() != es.PID() {
+			t.Errorf("stream %d = %#x/%#x, want %#x/%#x", i, got.StreamType(), got.PID(), es.StreamType(), es.PID())
+		}
+		if !bytes.Equal(MarshalDescriptors(got.Descriptors()), MarshalDescriptors(es.Descriptors())) {
+			t.Errorf("stream %d descriptors = %x, want %x", i, got.Descriptors(), es.Descriptors())
+		}
+	}
+
+	pmt.RemoveStream(0x0110)
+	if table, err = pmt.Marshal(); err != nil {
+		t.Fatal(err)
+	}
+	if parsed = ParseProgramMapSection(table); parsed.Stream(0x0110) != nil || len(parsed.Streams()) != 2 {
+		t.Errorf("stream 0x110 is not removed")
+	}
+}
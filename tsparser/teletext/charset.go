@@ -0,0 +1,75 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+// nationalPositions are the characters of the Latin G0 set replaced by a
+// national option sub-set.
+var nationalPositions = [13]byte{
+	0x23, 0x24, 0x40, 0x5B, 0x5C, 0x5D, 0x5E, 0x5F, 0x60, 0x7B, 0x7C, 0x7D, 0x7E,
+}
+
+const (
+	czechSlovak = iota
+	english
+	estonian
+	french
+	german
+	italian
+	lettishLithuanian
+	polish
+	portugueseSpanish
+	rumanian
+	serbianCroatianSlovenian
+	swedishFinnishHungarian
+	turkish
+)
+
+var nationalSubsets = [...][13]rune{
+	czechSlovak:              {'#', 'ů', 'č', 'ť', 'ž', 'ý', 'í', 'ř', 'é', 'á', 'ě', 'ú', 'š'},
+	english:                  {'£', '$', '@', '←', '½', '→', '↑', '#', '―', '¼', '‖', '¾', '÷'},
+	estonian:                 {'#', 'õ', 'Š', 'Ä', 'Ö', 'Ž', 'Ü', 'Õ', 'š', 'ä', 'ö', 'ž', 'ü'},
+	french:                   {'é', 'ï', 'à', 'ë', 'ê', 'ù', 'î', '#', 'è', 'â', 'ô', 'û', 'ç'},
+	german:                   {'#', '$', '§', 'Ä', 'Ö', 'Ü', '^', '_', '°', 'ä', 'ö', 'ü', 'ß'},
+	italian:                  {'£', '$', 'é', '°', 'ç', '→', '↑', '#', 'ù', 'à', 'ò', 'è', 'ì'},
+	lettishLithuanian:        {'#', '$', 'Š', 'ė', 'ę', 'Ž', 'č', 'ū', 'š', 'ą', 'ų', 'ž', 'į'},
+	polish:                   {'#', 'ń', 'ą', 'Ƶ', 'Ś', 'Ł', 'ć', 'ó', 'ę', 'ż', 'ś', 'ł', 'ź'},
+	portugueseSpanish:        {'ç', '$', '¡', 'á', 'é', 'í', 'ó', 'ú', '¿', 'ü', 'ñ', 'è', 'à'},
+	rumanian:                 {'#', '¤', 'Ţ', 'Â', 'Ş', 'Ă', 'Î', 'ı', 'ţ', 'â', 'ş', 'ă', 'î'},
+	serbianCroatianSlovenian: {'#', 'Ë', 'Č', 'Ć', 'Ž', 'Đ', 'Š', 'ë', 'č', 'ć', 'ž', 'đ', 'š'},
+	swedishFinnishHungarian:  {'#', '¤', 'É', 'Ä', 'Ö', 'Å', 'Ü', '_', 'é', 'ä', 'ö', 'å', 'ü'},
+	turkish:                  {'₺', 'ğ', 'İ', 'Ş', 'Ö', 'Ç', 'Ü', 'Ğ', 'ı', 'ş', 'ö', 'ç', 'ü'},
+}
+
+// designations maps the default G0 designation of the Latin sets, whose
+// three LSBs are C12 to C14 of the page header, into a national option
+// sub-set.  The codes are decoded as the basic Latin set under -1 and
+// the non-Latin designations.
+var designations = [40]int{
+	english, german, swedishFinnishHungarian, italian, french, portugueseSpanish, czechSlovak, -1,
+	polish, german, swedishFinnishHungarian, italian, french, -1, czechSlovak, -1,
+	english, german, swedishFinnishHungarian, italian, french, portugueseSpanish, turkish, -1,
+	-1, -1, -1, -1, -1, serbianCroatianSlovenian, -1, rumanian,
+	-1, german, estonian, lettishLithuanian, -1, -1, czechSlovak, -1,
+}
+
+// decodeChar returns the character of a Latin G0 code under a default G0
+// designation.
+func decodeChar(code byte, designation uint8) rune {
+	if code == 0x7F {
+		return '■'
+	}
+
+	if int(designation) < len(designations) {
+		if subset := designations[designation]; subset >= 0 {
+			for i, position := range nationalPositions {
+				if code == position {
+					return nationalSubsets[subset][i]
+				}
+			}
+		}
+	}
+
+	return rune(code)
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+import (
+	"errors"
+)
+
+var ErrInvalidDataField = errors.New("Invalid teletext PES data field")
+
+const (
+	dataUnitNonSubtitle = 0x02
+	dataUnitSubtitle    = 0x03
+	dataUnitLength      = 0x2C
+	framingCode         = 0xE4
+)
+
+type magazine struct {
+	page        *Page
+	designation uint8
+}
+
+// Decoder reassembles the teletext pages of EN 300 472 PES packets.
+type Decoder struct {
+	magazines [8]magazine
+	last      map[uint16]*Page
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{
+		last: make(map[uint16]*Page),
+	}
+}
+
+// Decode decodes PES_data_field of a PES packet with its PTS, and returns
+// the pages completed by it.
+func (d *Decoder) Decode(pts uint64, payload []byte) ([]*Page, error) {
+	if len(payload) < 1 || payload[0] < 0x10 || payload[0] > 0x1F {
+		return nil, ErrInvalidDataField
+	}
+
+	var pages []*Page
+	for i := 1; i+2 <= len(payload); {
+		id, length := payload[i], int(payload[i+1])
+		if i+2+length > len(payload) {
+			return pages, ErrInvalidDataField
+		}
+		unit := payload[i+2 : i+2+length]
+		i += 2 + length
+
+		if (id != dataUnitNonSubtitle && id != dataUnitSubtitle) || length != dataUnitLength || unit[1] != framingCode {
+			continue
+		}
+
+		var packet [42]byte
+		for j := range packet {
+			packet[j] = reverse(unit[2+j])
+		}
+		pages = append(pages, d.decodePacket(pts, packet[:])...)
+	}
+
+	return pages, nil
+}
+
+// Flush returns the pages in progress.
+func (d *Decoder) Flush() []*Page {
+	var pages []*Page
+	for i := range d.magazines {
+		if page := d.complete(i); page != nil {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages
+}
+
+func (d *Decoder) complete(i int) *Page {
+	page := d.magazines[i].page
+	d.magazines[i].page = nil
+	if page != nil {
+		d.last[page.Number] = page
+	}
+
+	return page
+}
+
+func (d *Decoder) decodePacket(pts uint64, packet []byte) []*Page {
+	address1, ok1 := DecodeHamming84(packet[0])
+	address2, ok2 := DecodeHamming84(packet[1])
+	if !ok1 || !ok2 {
+		return nil
+	}
+	number := int(address1 & 0x07)
+	if number == 0 {
+		number = 8
+	}
+	mag := &d.magazines[number-1]
+	row := int(address1>>3 | address2<<1)
+	data := packet[2:]
+
+	switch {
+	case row == 0:
+		return d.decodeHeader(pts, number, data)
+	case row < rows:
+		if mag.page == nil {
+			break
+		}
+		mag.page.rows[row] = decodeRow(data)
+	case row == 28:
+		if mag.page == nil {
+			break
+		}
+		if designation, ok := decodeDesignation(data); ok {
+			mag.page.Designation = designation&^0x07 | mag.page.Designation&0x07
+		}
+	case row == 29:
+		if designation, ok := decodeDesignation(data); ok {
+			mag.designation = designation
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) decodeHeader(pts uint64, number int, data []byte) []*Page {
+	var nibbles [8]uint8
+	for i := range nibbles {
+		var ok bool
+		if nibbles[i], ok = DecodeHamming84(data[i]); !ok {
+			return nil
+		}
+	}
+
+	// C11 of a header in the serial mode terminates the pages of all the
+	// magazines.
+	var pages []*Page
+	if nibbles[7]&0x01 > 0 {
+		pages = d.Flush()
+	} else if page := d.complete(number - 1); page != nil {
+		pages = append(pages, page)
+	}
+
+	// Page FF is a time filling header which only terminates pages.
+	if nibbles[0] == 0x0F && nibbles[1] == 0x0F {
+		return pages
+	}
+
+	mag := &d.magazines[number-1]
+	page := &Page{
+		PTS:            pts,
+		Number:         uint16(number)<<8 | uint16(nibbles[1])<<4 | uint16(nibbles[0]),
+		Subcode:        uint16(nibbles[2]) | uint16(nibbles[3]&0x07)<<4 | uint16(nibbles[4])<<8 | uint16(nibbles[5]&0x03)<<12,
+		Erase:          nibbles[3]&0x08 > 0,
+		Newsflash:      nibbles[5]&0x04 > 0,
+		Subtitle:       nibbles[5]&0x08 > 0,
+		InhibitDisplay: nibbles[6]&0x08 > 0,
+		Designation:    mag.designation&^0x07 | nibbles[7]>>3&0x01 | nibbles[7]>>1&0x02 | nibbles[7]<<1&0x04,
+	}
+	if last, ok := d.last[page.Number]; ok && !page.Erase {
+		// A page without C4 updates the rows transmitted.
+		page.rows = last.rows
+	}
+	page.rows[0] = decodeRow(data)[8:]
+	mag.page = page
+
+	return pages
+}
+
+// decodeRow strips the odd parity of the characters, where a character
+// with a parity error is replaced with a space.
+func decodeRow(data []byte) []byte {
+	row := make([]byte, columns)
+	for i := range row {
+		if b := data[i]; oddParity(b) {
+			row[i] = b & 0x7F
+		} else {
+			row[i] = ' '
+		}
+	}
+
+	return row
+}
+
+// decodeDesignation returns the default G0 designation of packet X/28 or
+// M/29 of designation code 0 or 4.
+func decodeDesignation(data []byte) (uint8, bool) {
+	code, ok := DecodeHamming84(data[0])
+	if !ok || (code != 0 && code != 4) {
+		return 0, false
+	}
+
+	triplet, ok := DecodeHamming2418(data[1:4])
+	if !ok {
+		return 0, false
+	}
+
+	return uint8(triplet >> 7 & 0x7F), true
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+import (
+	"log"
+
+	"github.com/yosida95/tsparser/tsparser"
+)
+
+// Subtitle is a text of a page shown from the PTS Start until End.
+type Subtitle struct {
+	Page  uint16
+	Start uint64
+	End   uint64
+	Text  string
+}
+
+// Extractor decodes a teletext stream into the subtitles of the pages
+// added.  A page is shown until the page is transmitted with another text.
+type Extractor struct {
+	s       *tsparser.PESScanner
+	pid     tsparser.PID
+	logger  *log.Logger
+	decoder *Decoder
+	handler func(*Subtitle) error
+
+	pages   map[uint16]*Subtitle
+	origin  uint64
+	started bool
+	lastPTS uint64
+}
+
+func NewExtractor(s *tsparser.PESScanner, pid tsparser.PID, logger *log.Logger) *Extractor {
+	return &Extractor{
+		s:       s,
+		pid:     pid,
+		logger:  logger,
+		decoder: NewDecoder(),
+		pages:   make(map[uint16]*Subtitle),
+	}
+}
+
+func (e *Extractor) log(v ...interface{}) {
+	if e.logger != nil {
+		e.logger.Print(v...)
+	}
+}
+
+// AddPage adds a page to extract by its number in hexadecimal such as
+// 0x888.
+func (e *Extractor) AddPage(number uint16) {
+	e.pages[number] = nil
+}
+
+// SetHandler sets the function which is called with every subtitle when
+// it is cleared from the page.
+func (e *Extractor) SetHandler(fn func(*Subtitle) error) {
+	e.handler = fn
+}
+
+// Origin returns the PTS of the first PES packet of the stream.
+func (e *Extractor) Origin() (uint64, bool) {
+	return e.origin, e.started
+}
+
+func (e *Extractor) Run() error {
+	for e.s.Scan() {
+		if e.s.PID() != e.pid {
+			continue
+		}
+
+		pes := e.s.PES()
+		if !pes.HasPTS() {
+			continue
+		}
+		if !e.started {
+			e.origin, e.started = pes.PTS(), true
+		}
+		e.lastPTS = pes.PTS()
+
+		pages, err := e.decoder.Decode(pes.PTS(), pes.Payload())
+		if err != nil {
+			e.log(err)
+		}
+		for _, page := range pages {
+			if err := e.show(page); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, page := range e.decoder.Flush() {
+		if err := e.show(page); err != nil {
+			return err
+		}
+	}
+	for number := range e.pages {
+		if err := e.close(number, e.lastPTS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Extractor) show(page *Page) error {
+	current, ok := e.pages[page.Number]
+	if !ok || page.InhibitDisplay {
+		return nil
+	}
+
+	text := page.Text()
+	if current != nil && current.Text == text {
+		return nil
+	}
+	if err := e.close(page.Number, page.PTS); err != nil {
+		return err
+	}
+
+	if text != "" {
+		e.pages[page.Number] = &Subtitle{
+			Page:  page.Number,
+			Start: page.PTS,
+			Text:  text,
+		}
+	}
+	return nil
+}
+
+func (e *Extractor) close(number uint16, pts uint64) error {
+	s := e.pages[number]
+	if s == nil {
+		return nil
+	}
+	e.pages[number] = nil
+
+	s.End = pts
+	if e.handler == nil || tsparser.TimestampDiff(s.End, s.Start) <= 0 {
+		return nil
+	}
+	return e.handler(s)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+// The bits of a teletext byte are numbered from b1 at the LSB, in the
+// order they are transmitted.
+
+var hamming84Table [256]uint8
+
+func init() {
+	for i := range hamming84Table {
+		hamming84Table[i] = 0xFF
+	}
+
+	for d := uint8(0); d < 16; d++ {
+		code := encodeHamming84(d)
+		hamming84Table[code] = d
+		for i := uint(0); i < 8; i++ {
+			hamming84Table[code^1<<i] = d
+		}
+	}
+}
+
+func encodeHamming84(d uint8) uint8 {
+	d1, d2, d3, d4 := d&0x01, d>>1&0x01, d>>2&0x01, d>>3&0x01
+	p1 := 1 ^ d1 ^ d3 ^ d4
+	p2 := 1 ^ d1 ^ d2 ^ d4
+	p3 := 1 ^ d1 ^ d2 ^ d3
+	p4 := 1 ^ p1 ^ d1 ^ p2 ^ d2 ^ p3 ^ d3 ^ d4
+
+	return p1 | d1<<1 | p2<<2 | d2<<3 | p3<<4 | d3<<5 | p4<<6 | d4<<7
+}
+
+// DecodeHamming84 decodes a Hamming 8/4 coded byte, correcting a single
+// bit error.
+func DecodeHamming84(b byte) (uint8, bool) {
+	d := hamming84Table[b]
+	return d, d != 0xFF
+}
+
+// DecodeHamming2418 decodes a Hamming 24/18 coded triplet into its 18
+// data bits, correcting a single bit error.
+func DecodeHamming2418(b []byte) (uint32, bool) {
+	if len(b) < 3 {
+		return 0, false
+	}
+	v := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+
+	// Each of P1 to P5 gives odd parity over the bits whose position has
+	// its bit set, and P6 gives odd parity over all the bits.
+	syndrome := 0
+	for k := uint(0); k < 5; k++ {
+		parity := uint32(0)
+		for i := uint(1); i <= 23; i++ {
+			if i&(1<<k) > 0 {
+				parity ^= v >> (i - 1) & 0x01
+			}
+		}
+		if parity == 0 {
+			syndrome |= 1 << k
+		}
+	}
+
+	parity := uint32(0)
+	for i := uint(0); i < 24; i++ {
+		parity ^= v >> i & 0x01
+	}
+
+	switch {
+	case parity == 0 && syndrome == 0:
+		v ^= 1 << 23
+	case parity == 0:
+		v ^= 1 << uint(syndrome-1)
+	case syndrome != 0:
+		return 0, false
+	}
+
+	return v>>2&0x01 | v>>3&0x0E | v>>4&0x07F0 | v>>5&0x3F800, true
+}
+
+func reverse(b byte) byte {
+	b = b>>4 | b<<4
+	b = b>>2&0x33 | b<<2&0xCC
+	b = b>>1&0x55 | b<<1&0xAA
+	return b
+}
+
+func oddParity(b byte) bool {
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	return b&0x01 > 0
+}
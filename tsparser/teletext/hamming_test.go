@@ -0,0 +1,95 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+import (
+	"testing"
+)
+
+// Hamming 8/4 codes of 0 to 15 in EN 300 706 8.2
+var hamming84Codes = []byte{
+	0x15, 0x02, 0x49, 0x5e, 0x64, 0x73, 0x38, 0x2f, 0xd0, 0xc7, 0x8c, 0x9b, 0xa1, 0xb6, 0xfd, 0xea,
+}
+
+func TestDecodeHamming84(t *testing.T) {
+	valid := make(map[byte]bool)
+	for d, code := range hamming84Codes {
+		if got, ok := DecodeHamming84(code); !ok || got != uint8(d) {
+			t.Errorf("%#02x: %d (%v), want %d", code, got, ok, d)
+		}
+
+		valid[code] = true
+		for i := uint(0); i < 8; i++ {
+			valid[code^1<<i] = true
+			if got, ok := DecodeHamming84(code ^ 1<<i); !ok || got != uint8(d) {
+				t.Errorf("%#02x with b%d flipped: %d (%v), want %d", code, i+1, got, ok, d)
+			}
+		}
+	}
+
+	// The other bytes have double bit errors.
+	for b := 0; b < 256; b++ {
+		if _, ok := DecodeHamming84(byte(b)); ok != valid[byte(b)] {
+			t.Errorf("%#02x: ok = %v", b, ok)
+		}
+	}
+}
+
+func TestDecodeHamming2418(t *testing.T) {
+	tests := []struct {
+		triplet  []byte
+		expected uint32
+	}{
+		{[]byte{0x8b, 0x80, 0x00}, 0x00000},
+		{[]byte{0x8c, 0x80, 0x80}, 0x00001},
+		{[]byte{0x2f, 0xb4, 0x24}, 0x12345},
+		{[]byte{0x59, 0xaa, 0xd5}, 0x2aaaa},
+		{[]byte{0xa6, 0x55, 0x2a}, 0x15555},
+		{[]byte{0x74, 0x7f, 0xff}, 0x3ffff},
+	}
+
+	for _, test := range tests {
+		if got, ok := DecodeHamming2418(test.triplet); !ok || got != test.expected {
+			t.Errorf("% x: %#05x (%v), want %#05x", test.triplet, got, ok, test.expected)
+		}
+
+		for i := uint(0); i < 24; i++ {
+			b := append([]byte(nil), test.triplet...)
+			b[i/8] ^= 1 << (i % 8)
+			if got, ok := DecodeHamming2418(b); !ok || got != test.expected {
+				t.Errorf("% x with bit %d flipped: %#05x (%v), want %#05x", test.triplet, i+1, got, ok, test.expected)
+			}
+
+			b[(i+5)%24/8] ^= 1 << ((i + 5) % 24 % 8)
+			if _, ok := DecodeHamming2418(b); ok {
+				t.Errorf("% x with bits %d and %d flipped is decoded", test.triplet, i+1, (i+5)%24+1)
+			}
+		}
+	}
+
+	if _, ok := DecodeHamming2418([]byte{0x8b, 0x80}); ok {
+		t.Error("a short triplet is decoded")
+	}
+}
+
+func TestParity(t *testing.T) {
+	tests := []struct {
+		b        byte
+		reversed byte
+		odd      bool
+	}{
+		{0x00, 0x00, false},
+		{0x01, 0x80, true},
+		{0x80, 0x01, true},
+		{0x83, 0xc1, true},
+		{0xf0, 0x0f, false},
+	}
+
+	for _, test := range tests {
+		if reverse(test.b) != test.reversed || oddParity(test.b) != test.odd {
+			t.Errorf("%#02x: reversed = %#02x, odd parity = %v", test.b, reverse(test.b), oddParity(test.b))
+		}
+	}
+}
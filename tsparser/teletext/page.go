@@ -0,0 +1,94 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package teletext
+
+import (
+	"strings"
+)
+
+const (
+	rows    = 25
+	columns = 40
+)
+
+const (
+	endBox   = 0x0A
+	startBox = 0x0B
+)
+
+// Page is a teletext page received from its header packet until the next
+// header packet of the magazine.
+type Page struct {
+	PTS     uint64
+	Number  uint16 // in hexadecimal such as 0x888
+	Subcode uint16
+
+	Erase          bool // C4
+	Newsflash      bool // C5
+	Subtitle       bool // C6
+	InhibitDisplay bool // C10
+	Designation    uint8
+
+	rows [rows][]byte
+}
+
+// Magazine returns the magazine number from 1 to 8.
+func (p *Page) Magazine() int {
+	return int(p.Number >> 8)
+}
+
+// Row returns the characters of row n, whose spacing attributes are
+// replaced with spaces.  The header row 0 starts at column 8.
+func (p *Page) Row(n int) string {
+	if n < 0 || n >= rows || p.rows[n] == nil {
+		return ""
+	}
+
+	line := make([]rune, 0, columns)
+	for _, code := range p.rows[n] {
+		if code < 0x20 {
+			line = append(line, ' ')
+		} else {
+			line = append(line, decodeChar(code, p.Designation))
+		}
+	}
+
+	return string(line)
+}
+
+// Text returns the text shown on rows 1 to 23.  Only the boxed text is
+// taken from a subtitle page as decoders show it over the picture.
+func (p *Page) Text() string {
+	var lines []string
+	for n := 1; n < 24; n++ {
+		if p.rows[n] == nil {
+			continue
+		}
+
+		line := []rune(p.Row(n))
+		if p.Subtitle {
+			inBox := false
+			for i, code := range p.rows[n] {
+				// Both of Start Box and End Box are set-after codes.
+				visible := inBox
+				switch code {
+				case startBox:
+					inBox = true
+				case endBox:
+					inBox = false
+				}
+				if !visible {
+					line[i] = ' '
+				}
+			}
+		}
+
+		if text := strings.TrimSpace(string(line)); text != "" {
+			lines = append(lines, strings.Join(strings.Fields(text), " "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"time"
+)
+
+const (
+	PCRClockRate = 27000000
+
+	pcrWrap = (TimestampMask + 1) * 300
+
+	// A PCR must be sent at least every 100 ms, so a larger step over a
+	// discontinuity_indicator, or any step longer than maxPCRGap, is taken
+	// as a change of the time base.
+	maxPCRInterval   = PCRClockRate / 10
+	maxPCRGap        = 10 * PCRClockRate
+	maxTimestampLead = 10 * ClockRate
+)
+
+// TimestampDiff returns a - b of 33-bit timestamps, allowing either of them
+// to have wrapped around.
+func TimestampDiff(a, b uint64) int64 {
+	d := int64((a - b) & TimestampMask)
+	if d >= 1<<(TimestampBits-1) {
+		d -= 1 << TimestampBits
+	}
+
+	return d
+}
+
+type timeSample struct {
+	clock int64
+	time  time.Time
+}
+
+// Timeline turns the 33-bit PTS/DTS and the PCR of a program into a
+// monotonic clock in 90 kHz units.  Time base discontinuities are bridged
+// with the last PCR interval, so the clock keeps running across them.
+type Timeline struct {
+	pcrPID PID
+
+	hasPCR   bool
+	lastPCR  uint64
+	clock    int64
+	interval int64
+
+	hasPrev   bool
+	prevPCR   uint64
+	prevClock int64
+
+	hasTimestamp  bool
+	lastTimestamp int64
+
+	discontinuities int
+	samples         []timeSample
+}
+
+func NewTimeline(pcrPID PID) *Timeline {
+	return &Timeline{
+		pcrPID: pcrPID,
+	}
+}
+
+func (t *Timeline) PCRPID() PID {
+	return t.pcrPID
+}
+
+func (t *Timeline) SetPCRPID(pid PID) {
+	t.pcrPID = pid
+}
+
+func (t *Timeline) HasPCR() bool {
+	return t.hasPCR
+}
+
+// Discontinuities returns the number of time base discontinuities bridged
+// so far.
+func (t *Timeline) Discontinuities() int {
+	return t.discontinuities
+}
+
+// Feed takes the PCR out of a packet of the PCR PID and reports whether it
+// starts a new time base.
+func (t *Timeline) Feed(p Packet) bool {
+	if p.PID() != t.pcrPID || !p.HasAdaptationField() {
+		return false
+	}
+
+	af := p.AdaptationField()
+	if !af.HasPCR() {
+		return false
+	}
+	return t.AddPCR(af.PCR(), af.DiscontinuityIndicator())
+}
+
+func (t *Timeline) AddPCR(pcr uint64, discontinuity bool) bool {
+	pcr %= pcrWrap
+	if !t.hasPCR {
+		t.hasPCR, t.lastPCR, t.clock = true, pcr, int64(pcr)
+		return false
+	}
+
+	delta := int64((pcr + pcrWrap - t.lastPCR) % pcrWrap)
+	jumped := delta > maxPCRGap || discontinuity && delta > maxPCRInterval
+	if jumped {
+		t.hasPrev, t.prevPCR, t.prevClock = true, t.lastPCR, t.clock
+		t.clock += t.interval
+		t.discontinuities++
+	} else {
+		t.clock += delta
+		if delta > 0 {
+			t.interval = delta
+		}
+	}
+
+	t.lastPCR = pcr
+	return jumped
+}
+
+// Clock returns the program clock at the last PCR.
+func (t *Timeline) Clock() int64 {
+	return t.clock / 300
+}
+
+// Timestamp converts a PTS or DTS into the program clock.  A timestamp of
+// the time base before the last discontinuity, which may still arrive
+// shortly after it, is converted with that time base.  Without PCR, the
+// timestamps are only unwrapped.
+func (t *Timeline) Timestamp(ts uint64) int64 {
+	ts &= TimestampMask
+	if !t.hasPCR {
+		if !t.hasTimestamp {
+			t.hasTimestamp, t.lastTimestamp = true, int64(ts)
+		} else {
+			t.lastTimestamp += TimestampDiff(ts, uint64(t.lastTimestamp))
+		}
+		return t.lastTimestamp
+	}
+
+	d := TimestampDiff(ts, t.lastPCR/300)
+	if t.hasPrev && abs(d) > maxTimestampLead {
+		if pd := TimestampDiff(ts, t.prevPCR/300); abs(pd) < abs(d) {
+			return t.prevClock/300 + pd
+		}
+	}
+
+	return t.clock/300 + d
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+// AddTimeSample associates the current program clock with the wall clock,
+// e.g. the JST_time of a TOT.
+func (t *Timeline) AddTimeSample(wall time.Time) {
+	var clock int64
+	switch {
+	case t.hasPCR:
+		clock = t.Clock()
+	case t.hasTimestamp:
+		clock = t.lastTimestamp
+	default:
+		return
+	}
+
+	t.AddTimeSampleAt(clock, wall)
+}
+
+// AddTimeSampleAt associates a program clock with the wall clock, e.g. the
+// clock of the access unit which a TOT is sent along with.
+func (t *Timeline) AddTimeSampleAt(clock int64, wall time.Time) {
+	t.samples = append(t.samples, timeSample{clock, wall})
+}
+
+// WallClock converts the program clock into the wall clock by the latest
+// time sample not later than clock.  Its accuracy is bound by the samples;
+// the TOT has a resolution of a second.
+func (t *Timeline) WallClock(clock int64) (time.Time, bool) {
+	if len(t.samples) == 0 {
+		return time.Time{}, false
+	}
+
+	sample := t.samples[0]
+	for _, s := range t.samples[1:] {
+		if s.clock > clock {
+			break
+		}
+		sample = s
+	}
+
+	return sample.time.Add(time.Duration(clock-sample.clock) * time.Second / ClockRate), true
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampDiff(t *testing.T) {
+	tests := []struct {
+		a, b     uint64
+		expected int64
+	}{
+		{93003, 90000, 3003},
+		{90000, 93003, -3003},
+		{1, TimestampMask, 2},
+		{TimestampMask, 1, -2},
+	}
+
+	for i, test := range tests {
+		if d := TimestampDiff(test.a, test.b); d != test.expected {
+			t.Errorf("%d: TimestampDiff = %d, want %d", i, d, test.expected)
+		}
+	}
+}
+
+func TestTimelineWithoutPCR(t *testing.T) {
+	tl := NewTimeline(NullPID)
+	base := tl.Timestamp(TimestampMask - 1000)
+	if clock := tl.Timestamp(2003); clock != base+3004 {
+		t.Errorf("clock = %d, want %d", clock, base+3004)
+	}
+	if clock := tl.Timestamp(TimestampMask - 1000); clock != base {
+		t.Errorf("clock = %d, want %d", clock, base)
+	}
+}
+
+func TestTimelineDiscontinuity(t *testing.T) {
+	tl := NewTimeline(0x0100)
+	if tl.AddPCR(90000*300, false) || tl.AddPCR(93000*300, false) {
+		t.Fatal("discontinuity is detected")
+	}
+	if clock := tl.Clock(); clock != 93000 {
+		t.Errorf("clock = %d, want 93000", clock)
+	}
+
+	// A new time base 110 seconds later continues the clock by the last
+	// PCR interval.
+	if !tl.AddPCR(10000000*300, false) {
+		t.Fatal("discontinuity is not detected")
+	}
+	if clock := tl.Clock(); clock != 96000 || tl.Discontinuities() != 1 {
+		t.Errorf("clock = %d, %d discontinuities", clock, tl.Discontinuities())
+	}
+	if clock := tl.Timestamp(10000900); clock != 96900 {
+		t.Errorf("clock of the new time base = %d, want 96900", clock)
+	}
+	if clock := tl.Timestamp(93500); clock != 93500 {
+		t.Errorf("clock of the former time base = %d, want 93500", clock)
+	}
+
+	// A step over 100 ms is a discontinuity only with
+	// discontinuity_indicator.
+	if tl.AddPCR(10010000*300, false) || !tl.AddPCR(10020000*300, true) {
+		t.Error("discontinuity_indicator is not followed")
+	}
+}
+
+func TestTimelineWallClock(t *testing.T) {
+	tl := NewTimeline(0x0100)
+	if _, ok := tl.WallClock(0); ok {
+		t.Error("wall clock without time sample")
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	t0 := time.Date(2014, 10, 19, 21, 54, 30, 0, jst)
+	tl.AddTimeSample(t0)
+	if _, ok := tl.WallClock(0); ok {
+		t.Error("time sample is taken without clock")
+	}
+
+	tl.AddPCR(90000*300, false)
+	tl.AddTimeSample(t0)
+	tl.AddPCR(180000*300, false)
+	tl.AddTimeSample(t0.Add(time.Second))
+
+	tests := []struct {
+		clock    int64
+		expected time.Time
+	}{
+		{90000, t0},
+		{135000, t0.Add(500 * time.Millisecond)},
+		{270000, t0.Add(2 * time.Second)},
+		{45000, t0.Add(-500 * time.Millisecond)},
+	}
+	for i, test := range tests {
+		if wall, ok := tl.WallClock(test.clock); !ok || !wall.Equal(test.expected) {
+			t.Errorf("%d: wall clock = %v (%v), want %v", i, wall, ok, test.expected)
+		}
+	}
+}
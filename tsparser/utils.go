@@ -16,6 +16,11 @@ func AsUint32(data []byte) (n uint32) {
 	return
 }
 
+// CRC32 returns the CRC_32 of MPEG-2 Systems over payload.
+func CRC32(payload []byte) uint32 {
+	return updateCRC32(0xffffffff, &crc32Table, payload)
+}
+
 func CheckCRC32(payload []byte) bool {
 	return updateCRC32(0xffffffff, &crc32Table, payload) == 0
 }
@@ -0,0 +1,44 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package video
+
+import (
+	"github.com/yosida95/tsparser/tsparser"
+	"github.com/yosida95/tsparser/tsparser/h264"
+	"github.com/yosida95/tsparser/tsparser/hevc"
+	"github.com/yosida95/tsparser/tsparser/mpeg2video"
+)
+
+func IsSupported(t tsparser.StreamType) bool {
+	switch t {
+	case tsparser.MPEG1VideoStream, tsparser.MPEG2VideoStream, tsparser.H264VideoStream, tsparser.HEVCVideoStream:
+		return true
+	}
+
+	return false
+}
+
+func IsRandomAccess(t tsparser.StreamType, payload []byte) bool {
+	switch t {
+	case tsparser.MPEG1VideoStream, tsparser.MPEG2VideoStream:
+		return mpeg2video.IsRandomAccess(payload)
+	case tsparser.H264VideoStream:
+		return h264.IsRandomAccess(payload)
+	case tsparser.HEVCVideoStream:
+		return hevc.IsRandomAccess(payload)
+	}
+
+	return false
+}
+
+func FindStream(pmt *tsparser.ProgramMapSection) *tsparser.ElementaryStream {
+	for _, es := range pmt.Streams() {
+		if IsSupported(es.StreamType()) {
+			return es
+		}
+	}
+
+	return nil
+}
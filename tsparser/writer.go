@@ -0,0 +1,211 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrInvalidAdaptationField = errors.New("Invalid adaptation field")
+	ErrInvalidPacketSize      = errors.New("Invalid packet size")
+)
+
+const (
+	PacketHeaderSize  = 4
+	PacketPayloadSize = PacketSize - PacketHeaderSize
+
+	NullPID PID = 0x1fff
+)
+
+type PacketWriter struct {
+	w      io.Writer
+	ccs    map[PID]uint8
+	buffer [PacketSize]byte
+}
+
+func NewPacketWriter(w io.Writer) *PacketWriter {
+	return &PacketWriter{
+		w:   w,
+		ccs: make(map[PID]uint8),
+	}
+}
+
+func (w *PacketWriter) ContinuityCounter(pid PID) uint8 {
+	return w.ccs[pid]
+}
+
+func (w *PacketWriter) SetContinuityCounter(pid PID, cc uint8) {
+	w.ccs[pid] = cc & 0x0f
+}
+
+func (w *PacketWriter) nextContinuityCounter(pid PID, hasPayload bool) uint8 {
+	cc, ok := w.ccs[pid]
+	if !ok {
+		cc = 0
+	} else if hasPayload {
+		cc = (cc + 1) & 0x0f
+	}
+
+	w.ccs[pid] = cc
+	return cc
+}
+
+func (w *PacketWriter) WritePacket(p Packet) error {
+	if len(p) != PacketSize {
+		return ErrInvalidPacketSize
+	}
+
+	copy(w.buffer[:], p)
+	if p.PID() != NullPID {
+		cc := w.nextContinuityCounter(p.PID(), p.HasPayload())
+		w.buffer[3] = w.buffer[3]&0xf0 | cc
+	}
+
+	_, err := w.w.Write(w.buffer[:])
+	return err
+}
+
+func (w *PacketWriter) WritePayload(pid PID, pusi bool, af AdaptationField, payload []byte) (n int, err error) {
+	if af != nil && (len(af) == 0 || len(af) != af.Length()+1 || len(af) > PacketPayloadSize) {
+		return 0, ErrInvalidAdaptationField
+	}
+
+	room := PacketPayloadSize - len(af)
+	n = len(payload)
+	if n > room {
+		n = room
+	}
+
+	stuffing := room - n
+	if af == nil && stuffing > 0 {
+		af = AdaptationField{0}
+		stuffing -= 1
+		if stuffing > 0 {
+			af = append(af, 0x00)
+			af[0] += 1
+			stuffing -= 1
+		}
+	} else if af != nil && af.Length() == 0 && stuffing > 0 {
+		af = AdaptationField{1, 0x00}
+		stuffing -= 1
+	}
+
+	b := w.buffer[:]
+	b[0] = SyncByte
+	b[1] = byte(pid>>8) & 0x1f
+	if pusi {
+		b[1] |= 0x40
+	}
+	b[2] = byte(pid)
+
+	afc := byte(0)
+	if n > 0 || af == nil {
+		afc |= 0x01
+	}
+	if af != nil {
+		afc |= 0x02
+	}
+
+	cc := uint8(0)
+	if pid != NullPID {
+		cc = w.nextContinuityCounter(pid, afc&0x01 > 0)
+	}
+	b[3] = afc<<4 | cc
+
+	offset := PacketHeaderSize
+	if af != nil {
+		offset += copy(b[offset:], af)
+		b[PacketHeaderSize] = byte(len(af) - 1 + stuffing)
+		for i := 0; i < stuffing; i++ {
+			b[offset] = 0xff
+			offset++
+		}
+	}
+	copy(b[offset:], payload[:n])
+
+	_, err = w.w.Write(b)
+	return
+}
+
+func (w *PacketWriter) WriteUnit(pid PID, af AdaptationField, payload []byte) error {
+	pusi := true
+	for {
+		n, err := w.WritePayload(pid, pusi, af, payload)
+		if err != nil {
+			return err
+		}
+
+		payload = payload[n:]
+		if len(payload) == 0 {
+			return nil
+		}
+
+		pusi = false
+		af = nil
+	}
+}
+
+func (w *PacketWriter) WriteNullPackets(count int) error {
+	b := w.buffer[:]
+	b[0] = SyncByte
+	b[1] = 0x1f
+	b[2] = 0xff
+	b[3] = 0x10
+	for i := PacketHeaderSize; i < PacketSize; i++ {
+		b[i] = 0xff
+	}
+
+	for i := 0; i < count; i++ {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *PacketWriter) WriteTables(pid PID, tables ...Table) error {
+	data := make([]byte, 0, PacketPayloadSize)
+	starts := make([]int, 0, len(tables))
+	for _, t := range tables {
+		starts = append(starts, len(data))
+		data = append(data, t...)
+	}
+
+	payload := make([]byte, 0, PacketPayloadSize)
+	for pos, next := 0, 0; pos < len(data); {
+		payload = payload[:0]
+
+		pusi := next < len(starts) && starts[next]-pos < PacketPayloadSize-1
+		if pusi {
+			payload = append(payload, byte(starts[next]-pos))
+		}
+
+		n := PacketPayloadSize - len(payload)
+		if rest := len(data) - pos; n > rest {
+			n = rest
+		}
+		if !pusi && next < len(starts) && starts[next]-pos < n {
+			n = starts[next] - pos
+		}
+
+		payload = append(payload, data[pos:pos+n]...)
+		pos += n
+		for next < len(starts) && starts[next] < pos {
+			next++
+		}
+
+		for len(payload) < PacketPayloadSize {
+			payload = append(payload, 0xff)
+		}
+		if _, err := w.WritePayload(pid, pusi, nil, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
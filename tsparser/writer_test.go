@@ -0,0 +1,127 @@
+// Copyright (c) 2014 Kohei YOSHIDA. All rights reserved.
+// This software is licensed under the 3-Clause BSD License
+// that can be found in LICENSE file.
+
+package tsparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func readPackets(t *testing.T, b []byte) []Packet {
+	if len(b)%PacketSize != 0 {
+		t.Fatalf("%d bytes are not a multiple of the packet size", len(b))
+	}
+
+	var packets []Packet
+	for i := 0; i < len(b); i += PacketSize {
+		packets = append(packets, Packet(b[i:i+PacketSize]))
+	}
+	return packets
+}
+
+func TestPacketWriterWriteTables(t *testing.T) {
+	var tables []Table
+	for i, size := range []int{16, 300, 1000, 1} {
+		h := &TableHeader{TableId: ProgramMapTable, TableIdExtension: uint16(i), CurrentNextIndicator: true}
+		table, err := h.Build(bytes.Repeat([]byte{byte(i)}, size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	for i := 0; i < 2; i++ {
+		if err := w.WriteTables(0x01f0, tables...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collector := NewTableCollector()
+	var got []Table
+	for i, p := range readPackets(t, buf.Bytes()) {
+		if p.PID() != 0x01f0 {
+			t.Fatalf("packet %d: PID = %#x", i, p.PID())
+		}
+		if cc := p.ContinuityCounter(); cc != uint8(i)&0x0f {
+			t.Errorf("packet %d: continuity_counter = %d", i, cc)
+		}
+
+		collected, err := collector.Feed(p)
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		got = append(got, collected...)
+	}
+
+	if len(got) != 2*len(tables) {
+		t.Fatalf("%d tables are collected, want %d", len(got), 2*len(tables))
+	}
+	for i, table := range got {
+		if !bytes.Equal(table, tables[i%len(tables)]) {
+			t.Errorf("table %d = %x, want %x", i, table, tables[i%len(tables)])
+		}
+	}
+}
+
+func TestPacketWriterWriteUnit(t *testing.T) {
+	payload := make([]byte, 1000)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	w.SetContinuityCounter(0x0100, 14)
+	if err := w.WriteUnit(0x0100, nil, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	for i, p := range readPackets(t, buf.Bytes()) {
+		if p.PayloadUnitStartIndicator() != (i == 0) {
+			t.Errorf("packet %d: payload_unit_start_indicator = %v", i, p.PayloadUnitStartIndicator())
+		}
+		if cc := p.ContinuityCounter(); cc != uint8(15+i)&0x0f {
+			t.Errorf("packet %d: continuity_counter = %d, want %d", i, cc, (15+i)&0x0f)
+		}
+		got = append(got, p.Payload()...)
+	}
+
+	if !bytes.Equal(got, payload) {
+		t.Errorf("payload = %x, want %x", got, payload)
+	}
+}
+
+func TestPacketWriterWritePacket(t *testing.T) {
+	var src bytes.Buffer
+	if _, err := NewPacketWriter(&src).WritePayload(0x0100, true, nil, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	packet := readPackets(t, src.Bytes())[0]
+
+	var buf bytes.Buffer
+	w := NewPacketWriter(&buf)
+	w.SetContinuityCounter(0x0100, 7)
+	for i := 0; i < 2; i++ {
+		if err := w.WritePacket(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, p := range readPackets(t, buf.Bytes()) {
+		if cc := p.ContinuityCounter(); cc != uint8(8+i) {
+			t.Errorf("packet %d: continuity_counter = %d, want %d", i, cc, 8+i)
+		}
+		if !bytes.Equal(p.Payload(), []byte{1, 2, 3}) {
+			t.Errorf("packet %d: payload = %x", i, p.Payload())
+		}
+	}
+
+	if err := w.WritePacket(packet[:100]); err != ErrInvalidPacketSize {
+		t.Errorf("err = %v, want %v", err, ErrInvalidPacketSize)
+	}
+}